@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+
+	// maxRecordSize bounds one JSON line on import; values are up to the
+	// manager's max_value_size, which base64 grows by a third.
+	maxRecordSize = 64 << 20
+
+	// maxBatchBytes bounds the keys and values of one BatchSet on import,
+	// keeping the request inside gRPC's default 4 MB message limit.
+	maxBatchBytes = 3 << 20
+)
+
+var csvHeader = []string{"key", "value", "encoding", "ttl_seconds", "version"}
+
+// record is one key of a dump. Values that are not valid UTF-8 are written
+// in base64 with encoding set to "base64", so text stays readable and
+// diffable. ttl_seconds is the key's remaining lifetime when it was exported
+// and version its version on its primary, for reference only: versions are
+// local to each server and are not restored by import.
+type record struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Encoding   string `json:"encoding,omitempty"`
+	TTLSeconds uint64 `json:"ttl_seconds,omitempty"`
+	Version    uint64 `json:"version,omitempty"`
+}
+
+func newRecord(p *db_manager.KeyValuePair, forceBase64 bool) record {
+	r := record{Key: p.Key, TTLSeconds: p.TtlSeconds, Version: p.Version}
+	if forceBase64 || !utf8.Valid(p.ValueBytes) {
+		r.Value = base64.StdEncoding.EncodeToString(p.ValueBytes)
+		r.Encoding = "base64"
+	} else {
+		r.Value = string(p.ValueBytes)
+	}
+	return r
+}
+
+func (r record) value() ([]byte, error) {
+	switch r.Encoding {
+	case "":
+		return []byte(r.Value), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(r.Value)
+	default:
+		return nil, fmt.Errorf("key %q has unknown encoding %q", r.Key, r.Encoding)
+	}
+}
+
+// dumpFormat returns format, or guesses it from the file name.
+func dumpFormat(format, file string) (string, error) {
+	if format == "" {
+		format = formatJSONL
+		if strings.HasSuffix(file, ".csv") {
+			format = formatCSV
+		}
+	}
+	if format != formatJSONL && format != formatCSV {
+		return "", fmt.Errorf("unknown format %q, want %s or %s", format, formatJSONL, formatCSV)
+	}
+	return format, nil
+}
+
+// export writes every key starting with prefix to out, or stdout, page by
+// page in key order.
+func export(client db_manager.DBManagerClient, prefix, out, format string, pageSize uint, forceBase64 bool) error {
+	format, err := dumpFormat(format, out)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if out != "" && out != "-" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var write func(record) error
+	var flush func() error
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(bw)
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+		write = func(r record) error {
+			return cw.Write([]string{r.Key, r.Value, r.Encoding, strconv.FormatUint(r.TTLSeconds, 10), strconv.FormatUint(r.Version, 10)})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		write = func(r record) error { return enc.Encode(r) }
+		flush = func() error { return nil }
+	}
+
+	count := 0
+	cursor := ""
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := client.Scan(ctx, &db_manager.ScanRequest{Prefix: prefix, Limit: uint32(pageSize), Cursor: cursor})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("scan failed after %d keys: %v", count, err)
+		}
+
+		for _, p := range resp.Pairs {
+			if err := write(newRecord(p, forceBase64)); err != nil {
+				return err
+			}
+		}
+		count += len(resp.Pairs)
+		if resp.NextCursor == "" {
+			break
+		}
+		cursor = resp.NextCursor
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if w != os.Stdout {
+		if err := w.Sync(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d keys\n", count)
+	return nil
+}
+
+// recordReader reads the records of a dump one by one, returning io.EOF at
+// the end.
+type recordReader func() (record, error)
+
+func newRecordReader(r io.Reader, format string) (recordReader, error) {
+	if format == formatCSV {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(csvHeader)
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %v", err)
+		}
+		if strings.Join(header, ",") != strings.Join(csvHeader, ",") {
+			return nil, fmt.Errorf("unexpected CSV header %v, want %v", header, csvHeader)
+		}
+		return func() (record, error) {
+			row, err := cr.Read()
+			if err != nil {
+				return record{}, err
+			}
+			r := record{Key: row[0], Value: row[1], Encoding: row[2]}
+			if r.TTLSeconds, err = strconv.ParseUint(row[3], 10, 64); err != nil {
+				return record{}, fmt.Errorf("key %q has a bad ttl_seconds: %v", r.Key, err)
+			}
+			if row[4] != "" {
+				if r.Version, err = strconv.ParseUint(row[4], 10, 64); err != nil {
+					return record{}, fmt.Errorf("key %q has a bad version: %v", r.Key, err)
+				}
+			}
+			return r, nil
+		}, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxRecordSize)
+	return func() (record, error) {
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(strings.TrimSpace(string(line))) == 0 {
+				continue
+			}
+			var r record
+			if err := json.Unmarshal(line, &r); err != nil {
+				return record{}, fmt.Errorf("malformed record: %v", err)
+			}
+			return r, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return record{}, err
+		}
+		return record{}, io.EOF
+	}, nil
+}
+
+// importDump writes the records of in to the cluster batchSize at a time, or
+// fewer once a batch holds maxBatchBytes of keys and values.
+// After every batch the number of records done is saved to progressFile, so
+// an interrupted import run again with the same arguments picks up after
+// the last finished batch. The progress file is removed once the import
+// completes. Keys the cluster rejects are reported and not retried.
+func importDump(client db_manager.DBManagerClient, in, format string, batchSize int, progressFile string) error {
+	format, err := dumpFormat(format, in)
+	if err != nil {
+		return err
+	}
+	if progressFile == "" {
+		progressFile = in + ".progress"
+	}
+	batchSize = max(batchSize, 1)
+
+	f, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	next, err := newRecordReader(bufio.NewReader(f), format)
+	if err != nil {
+		return err
+	}
+
+	done, err := readProgress(progressFile)
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming after %d records\n", done)
+	}
+
+	var read, imported, failed uint64
+	var batch []*db_manager.KeyValuePair
+	var batchBytes int
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := client.BatchSet(ctx, &db_manager.BatchSetRequest{Pairs: batch})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("batch write failed after %d records; run the import again to resume: %v", done, err)
+		}
+		for _, r := range resp.Results {
+			if r.Success {
+				imported++
+				continue
+			}
+			failed++
+			fmt.Fprintf(os.Stderr, "failed to import %s: %s\n", r.Key, r.Error)
+		}
+
+		done += uint64(len(batch))
+		batch, batchBytes = batch[:0], 0
+		if err := writeProgress(progressFile, done); err != nil {
+			return fmt.Errorf("failed to save progress: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d records done\n", done)
+		return nil
+	}
+
+	for {
+		r, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		read++
+		if err != nil {
+			return fmt.Errorf("record %d: %v", read, err)
+		}
+		if read <= done {
+			continue
+		}
+
+		value, err := r.value()
+		if err != nil {
+			return fmt.Errorf("record %d: %v", read, err)
+		}
+		batch = append(batch, &db_manager.KeyValuePair{Key: r.Key, ValueBytes: value, TtlSeconds: r.TTLSeconds})
+		batchBytes += len(r.Key) + len(value)
+		if len(batch) >= batchSize || batchBytes >= maxBatchBytes {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := send(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(progressFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Imported %d keys, %d failed\n", imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d keys failed to import", failed)
+	}
+	return nil
+}
+
+func readProgress(path string) (uint64, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	done, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("corrupt progress file %s: %v", path, err)
+	}
+	return done, nil
+}
+
+// writeProgress replaces the progress file atomically.
+func writeProgress(path string, done uint64) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(done, 10)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
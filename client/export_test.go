@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeManager is a DBManagerClient holding keys in memory. Scan pages by key
+// with the last key as the cursor, and BatchSet fails its call number failAt
+// with UNAVAILABLE.
+type fakeManager struct {
+	db_manager.DBManagerClient
+
+	data   map[string][]byte
+	ttls   map[string]uint64
+	failAt int
+
+	// batches holds the keys of every BatchSet call, failed ones included.
+	batches [][]string
+}
+
+func newFakeManager() *fakeManager {
+	return &fakeManager{data: make(map[string][]byte), ttls: make(map[string]uint64)}
+}
+
+func (m *fakeManager) Scan(ctx context.Context, req *db_manager.ScanRequest, opts ...grpc.CallOption) (*db_manager.ScanResponse, error) {
+	var keys []string
+	for k := range m.data {
+		if strings.HasPrefix(k, req.Prefix) && k > req.Cursor {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	resp := &db_manager.ScanResponse{}
+	if req.Limit > 0 && len(keys) > int(req.Limit) {
+		keys = keys[:req.Limit]
+		resp.NextCursor = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		resp.Pairs = append(resp.Pairs, &db_manager.KeyValuePair{Key: k, ValueBytes: m.data[k], TtlSeconds: m.ttls[k], Version: 7})
+	}
+	return resp, nil
+}
+
+func (m *fakeManager) BatchSet(ctx context.Context, req *db_manager.BatchSetRequest, opts ...grpc.CallOption) (*db_manager.BatchSetResponse, error) {
+	keys := make([]string, len(req.Pairs))
+	for i, p := range req.Pairs {
+		keys[i] = p.Key
+	}
+	m.batches = append(m.batches, keys)
+	if len(m.batches) == m.failAt {
+		return nil, status.Error(codes.Unavailable, "manager down")
+	}
+
+	resp := &db_manager.BatchSetResponse{}
+	for _, p := range req.Pairs {
+		m.data[p.Key] = p.ValueBytes
+		m.ttls[p.Key] = p.TtlSeconds
+		resp.Results = append(resp.Results, &db_manager.KeyResult{Key: p.Key, Success: true})
+	}
+	return resp, nil
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	source := newFakeManager()
+	source.data = map[string][]byte{
+		"user:1":  []byte("Alice"),
+		"user:2":  {0xff, 0x00, 0xfe},
+		"user:3":  []byte("a, \"quoted\"\nvalue"),
+		"user:4":  {},
+		"other:1": []byte("not exported"),
+	}
+	source.ttls["user:1"] = 60
+
+	tests := []struct {
+		name        string
+		file        string
+		format      string
+		forceBase64 bool
+	}{
+		{name: "jsonl", file: "dump.jsonl"},
+		{name: "jsonl base64", file: "dump.jsonl", forceBase64: true},
+		{name: "csv", file: "dump.csv"},
+		{name: "csv by flag", file: "dump.txt", format: formatCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dump := filepath.Join(t.TempDir(), tt.file)
+			if err := export(source, "user:", dump, tt.format, 2, tt.forceBase64); err != nil {
+				t.Fatalf("export failed: %v", err)
+			}
+
+			target := newFakeManager()
+			if err := importDump(target, dump, tt.format, 2, ""); err != nil {
+				t.Fatalf("import failed: %v", err)
+			}
+			if len(target.data) != 4 {
+				t.Fatalf("expected the 4 user keys, got %d", len(target.data))
+			}
+			for k, want := range source.data {
+				if !strings.HasPrefix(k, "user:") {
+					continue
+				}
+				if got, ok := target.data[k]; !ok || string(got) != string(want) {
+					t.Fatalf("expected %q=%q, got %q", k, want, got)
+				}
+			}
+			if target.ttls["user:1"] != 60 {
+				t.Fatalf("expected user:1 to keep its TTL, got %d", target.ttls["user:1"])
+			}
+			if _, err := os.Stat(dump + ".progress"); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected the progress file to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestImport_ResumesAfterFailedBatch(t *testing.T) {
+	dump := filepath.Join(t.TempDir(), "dump.jsonl")
+	lines := []string{
+		`{"key":"a","value":"1"}`,
+		`{"key":"b","value":"2"}`,
+		`{"key":"c","value":"3"}`,
+		`{"key":"d","value":"4"}`,
+		`{"key":"e","value":"5"}`,
+	}
+	if err := os.WriteFile(dump, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to write dump: %v", err)
+	}
+
+	target := newFakeManager()
+	target.failAt = 2
+	if err := importDump(target, dump, "", 2, ""); err == nil {
+		t.Fatal("expected the failed batch to stop the import")
+	}
+	if done, err := readProgress(dump + ".progress"); err != nil || done != 2 {
+		t.Fatalf("expected progress after the first batch, got %d (%v)", done, err)
+	}
+
+	target.batches, target.failAt = nil, 0
+	if err := importDump(target, dump, "", 2, ""); err != nil {
+		t.Fatalf("resumed import failed: %v", err)
+	}
+	if len(target.batches) != 2 || target.batches[0][0] != "c" {
+		t.Fatalf("expected the import to resume at c, got batches %v", target.batches)
+	}
+	if len(target.data) != 5 {
+		t.Fatalf("expected all 5 keys, got %v", target.data)
+	}
+}
+
+func TestImport_SplitsLargeBatches(t *testing.T) {
+	source := newFakeManager()
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		source.data[k] = make([]byte, 1<<20)
+	}
+	dump := filepath.Join(t.TempDir(), "dump.jsonl")
+	if err := export(source, "", dump, "", 100, false); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	target := newFakeManager()
+	if err := importDump(target, dump, "", 500, ""); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(target.batches) < 3 {
+		t.Fatalf("expected batches of at most %d bytes, got %d batches", maxBatchBytes, len(target.batches))
+	}
+	if len(target.data) != 8 {
+		t.Fatalf("expected all 8 keys, got %d", len(target.data))
+	}
+}
+
+func TestImport_MalformedRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		dump string
+		want string
+	}{
+		{name: "bad json", file: "dump.jsonl", dump: "{\"key\":\"a\",\"value\":\"1\"}\n{\"key\":\n", want: "record 2: malformed record"},
+		{name: "bad base64", file: "dump.jsonl", dump: "{\"key\":\"a\",\"value\":\"!!\",\"encoding\":\"base64\"}\n", want: "record 1"},
+		{name: "unknown encoding", file: "dump.jsonl", dump: "{\"key\":\"a\",\"value\":\"1\",\"encoding\":\"hex\"}\n", want: "unknown encoding"},
+		{name: "bad csv ttl", file: "dump.csv", dump: "key,value,encoding,ttl_seconds,version\na,1,,soon,\n", want: "bad ttl_seconds"},
+		{name: "short csv row", file: "dump.csv", dump: "key,value,encoding,ttl_seconds,version\na,1\n", want: "record 1"},
+		{name: "bad csv header", file: "dump.csv", dump: "k,v,e,t,ver\n", want: "unexpected CSV header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dump := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(dump, []byte(tt.dump), 0o644); err != nil {
+				t.Fatalf("failed to write dump: %v", err)
+			}
+
+			target := newFakeManager()
+			err := importDump(target, dump, "", 500, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+			if len(target.data) != 0 {
+				t.Fatalf("expected nothing imported past a malformed record, got %v", target.data)
+			}
+		})
+	}
+}
@@ -2,34 +2,64 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"time"
+	"unicode/utf8"
 
 	"github.com/arbhalerao/meerkat/pb/db_manager"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func main() {
 	var (
 		managerAddr = flag.String("addr", "127.0.0.1:9090", "DB Manager address")
-		operation   = flag.String("op", "", "Operation: get, set, delete")
+		operation   = flag.String("op", "", "Operation: get, set, delete, ttl, incr, scan, watch, backup, restore, export, import")
 		key         = flag.String("key", "", "Key")
 		value       = flag.String("value", "", "Value (for set operation)")
+		valueFile   = flag.String("value-file", "", "Read the value from this file, or stdin for - (for set operation)")
+		outFile     = flag.String("out", "", "Write the raw value to this file (for get operation), or the dump (for export operation)")
+		useBase64   = flag.Bool("base64", false, "Values given with -value and printed values are base64")
+		ttl         = flag.Duration("ttl", 0, "Expire the key after this long (for set operation)")
+		delta       = flag.Int64("delta", 1, "Amount to add (for incr operation)")
+		prefix      = flag.String("prefix", "", "Key prefix (for scan and export operations)")
+		limit       = flag.Uint("limit", 100, "Keys per page (for scan and export operations)")
+		fromVersion = flag.Uint64("from-version", 0, "Report changes after this version of the key (for watch operation)")
+		backupDir   = flag.String("dir", "", "Backup directory on the manager's host, inside its configured one, which is the default (for backup and restore operations)")
+		incremental = flag.Bool("incremental", false, "Back up only the changes since the latest backup (for backup operation)")
+		backupID    = flag.String("backup", "", "Backup to restore, or the latest one (for restore operation)")
+		format      = flag.String("format", "", "Dump format, jsonl or csv, guessed from the file name when empty (for export and import operations)")
+		inFile      = flag.String("in", "", "Dump to read (for import operation)")
+		batchSize   = flag.Int("batch", 500, "Keys per write (for import operation)")
+		progress    = flag.String("progress", "", "Progress file for resuming, default <in>.progress (for import operation)")
 	)
 	flag.Parse()
 
-	if *operation == "" || *key == "" {
+	if *operation == "" || (*key == "" && *operation != "scan" && *operation != "watch" && *operation != "backup" && *operation != "restore" && *operation != "export" && *operation != "import") {
 		fmt.Println("Usage:")
-		fmt.Println("  Set: ./client -op=set -key=mykey -value=myvalue")
-		fmt.Println("  Get: ./client -op=get -key=mykey")
+		fmt.Println("  Set: ./client -op=set -key=mykey -value=myvalue [-ttl=10m]")
+		fmt.Println("       ./client -op=set -key=mykey -value-file=image.png")
+		fmt.Println("  Get: ./client -op=get -key=mykey [-out=image.png] [-base64]")
 		fmt.Println("  Delete: ./client -op=delete -key=mykey")
+		fmt.Println("  TTL: ./client -op=ttl -key=mykey")
+		fmt.Println("  Incr: ./client -op=incr -key=mykey [-delta=1]")
+		fmt.Println("  Scan: ./client -op=scan [-prefix=user:] [-limit=100]")
+		fmt.Println("  Watch: ./client -op=watch -key=mykey [-from-version=12] | -prefix=config:")
+		fmt.Println("  Backup: ./client -op=backup [-dir=nightly] [-incremental]")
+		fmt.Println("  Restore: ./client -op=restore [-dir=nightly] [-backup=20250101T000000.000000Z]")
+		fmt.Println("  Export: ./client -op=export [-prefix=user:] [-out=dump.jsonl] [-format=jsonl|csv]")
+		fmt.Println("  Import: ./client -op=import -in=dump.jsonl [-format=jsonl|csv] [-batch=500]")
 		os.Exit(1)
 	}
 
-	conn, err := grpc.NewClient(*managerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(*managerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithUnaryInterceptor(valueBytesOnly))
 	if err != nil {
 		fmt.Printf("Failed to connect to DB Manager: %v\n", err)
 		os.Exit(1)
@@ -37,19 +67,57 @@ func main() {
 	defer conn.Close()
 
 	client := db_manager.NewDBManagerClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if *operation == "watch" {
+		watch(client, *key, *prefix, *fromVersion, *useBase64)
+		return
+	}
+	if *operation == "restore" {
+		restore(client, *backupDir, *backupID)
+		return
+	}
+	if *operation == "export" {
+		if err := export(client, *prefix, *outFile, *format, *limit, *useBase64); err != nil {
+			fmt.Fprintf(os.Stderr, "Export operation failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *operation == "import" {
+		if *inFile == "" {
+			fmt.Println("Import needs -in")
+			os.Exit(1)
+		}
+		if err := importDump(client, *inFile, *format, *batchSize, *progress); err != nil {
+			fmt.Fprintf(os.Stderr, "Import operation failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	timeout := 10 * time.Second
+	if *operation == "backup" {
+		timeout = time.Hour
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	switch *operation {
 	case "set":
-		if *value == "" {
+		if *value == "" && *valueFile == "" {
 			fmt.Println("Value is required for set operation")
 			os.Exit(1)
 		}
+
+		data, err := readValue(*value, *valueFile, *useBase64)
+		if err != nil {
+			fmt.Printf("Failed to read value: %v\n", err)
+			os.Exit(1)
+		}
 		
 		resp, err := client.Set(ctx, &db_manager.SetRequest{
-			Key:   *key,
-			Value: *value,
+			Key:        *key,
+			ValueBytes: data,
+			TtlSeconds: uint64((*ttl + time.Second - 1) / time.Second),
 		})
 		if err != nil {
 			fmt.Printf("Set operation failed: %v\n", err)
@@ -57,7 +125,7 @@ func main() {
 		}
 		
 		if resp.Success {
-			fmt.Printf("Successfully set key '%s' = '%s'\n", *key, *value)
+			fmt.Printf("Successfully set key '%s' (%d bytes)\n", *key, len(data))
 		} else {
 			fmt.Printf("Failed to set key '%s'\n", *key)
 		}
@@ -71,7 +139,15 @@ func main() {
 			os.Exit(1)
 		}
 		
-		fmt.Printf("Key '%s' = '%s'\n", *key, resp.Value)
+		if *outFile != "" {
+			if err := os.WriteFile(*outFile, resp.ValueBytes, 0o644); err != nil {
+				fmt.Printf("Failed to write value: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Wrote %d bytes of key '%s' to %s\n", len(resp.ValueBytes), *key, *outFile)
+			break
+		}
+		fmt.Printf("Key '%s' = '%s'\n", *key, formatValue(resp.ValueBytes, *useBase64))
 
 	case "delete":
 		resp, err := client.Delete(ctx, &db_manager.DeleteRequest{
@@ -88,9 +164,167 @@ func main() {
 			fmt.Printf("Failed to delete key '%s'\n", *key)
 		}
 
+	case "ttl":
+		resp, err := client.TTL(ctx, &db_manager.TTLRequest{
+			Key: *key,
+		})
+		if err != nil {
+			fmt.Printf("TTL operation failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.HasTtl {
+			fmt.Printf("Key '%s' expires in %s\n", *key, time.Duration(resp.TtlSeconds)*time.Second)
+		} else {
+			fmt.Printf("Key '%s' does not expire\n", *key)
+		}
+
+	case "incr":
+		resp, err := client.Increment(ctx, &db_manager.IncrementRequest{
+			Key:   *key,
+			Delta: *delta,
+		})
+		if err != nil {
+			fmt.Printf("Incr operation failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Key '%s' = %d\n", *key, resp.Value)
+
+	case "scan":
+		cursor := ""
+		for {
+			resp, err := client.Scan(ctx, &db_manager.ScanRequest{
+				Prefix: *prefix,
+				Limit:  uint32(*limit),
+				Cursor: cursor,
+			})
+			if err != nil {
+				fmt.Printf("Scan operation failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, pair := range resp.Pairs {
+				fmt.Printf("%s = %s\n", pair.Key, formatValue(pair.ValueBytes, *useBase64))
+			}
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+
+	case "backup":
+		resp, err := client.Backup(ctx, &db_manager.BackupRequest{Dir: *backupDir, Incremental: *incremental})
+		if err != nil {
+			fmt.Printf("Backup operation failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		if resp.Incremental {
+			fmt.Printf("Incremental backup %s on %s written to %s\n", resp.Id, resp.Base, resp.Path)
+		} else {
+			fmt.Printf("Full backup %s written to %s\n", resp.Id, resp.Path)
+		}
+		fmt.Printf("%d servers, %d bytes\n", resp.Servers, resp.Bytes)
+
 	default:
 		fmt.Printf("Unknown operation: %s\n", *operation)
-		fmt.Println("Supported operations: get, set, delete")
+		fmt.Println("Supported operations: get, set, delete, ttl, incr, scan, watch, backup, restore, export, import")
 		os.Exit(1)
 	}
 }
+
+// watch prints changes until interrupted, resuming from the last event's
+// token whenever the manager ends the stream because the topology changed.
+func watch(client db_manager.DBManagerClient, key, prefix string, fromVersion uint64, isBase64 bool) {
+	req := &db_manager.WatchRequest{Key: key, Prefix: prefix, FromVersion: fromVersion}
+	for {
+		stream, err := client.Watch(context.Background(), req)
+		if err != nil {
+			fmt.Printf("Watch operation failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				if status.Code(err) == codes.Unavailable {
+					break
+				}
+				fmt.Printf("Watch operation failed: %v\n", err)
+				os.Exit(1)
+			}
+
+			req.ResumeToken = event.ResumeToken
+			if event.Gap {
+				fmt.Printf("[%d] gap: changes on server %s after this version may be missing\n", event.Version, event.Server)
+				continue
+			}
+			if event.Deleted {
+				fmt.Printf("[%d] deleted %s\n", event.Version, event.Key)
+				continue
+			}
+			fmt.Printf("[%d] %s = %s\n", event.Version, event.Key, formatValue(event.Value, isBase64))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// restore replays a backup into the cluster, printing progress after every
+// batch and each key that failed.
+func restore(client db_manager.DBManagerClient, dir, backupID string) {
+	stream, err := client.Restore(context.Background(), &db_manager.RestoreRequest{Dir: dir, BackupId: backupID})
+	if err != nil {
+		fmt.Printf("Restore operation failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	for {
+		p, err := stream.Recv()
+		if err != nil {
+			fmt.Printf("Restore operation failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, f := range p.Failures {
+			fmt.Printf("failed to restore %s: %s\n", f.Key, f.Error)
+		}
+		fmt.Printf("[%s %s] %d restored, %d deleted, %d failed, %d from replicas\n", p.BackupId, p.Server, p.Restored, p.Deleted, p.Failed, p.FromReplicas)
+		if p.Done {
+			if p.Failed > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+	}
+}
+
+// readValue returns the value to set: the contents of file ("-" for stdin)
+// if given, otherwise value, decoded from base64 if asked to.
+func readValue(value, file string, isBase64 bool) ([]byte, error) {
+	switch {
+	case file == "-":
+		return io.ReadAll(os.Stdin)
+	case file != "":
+		return os.ReadFile(file)
+	case isBase64:
+		return base64.StdEncoding.DecodeString(value)
+	default:
+		return []byte(value), nil
+	}
+}
+
+// formatValue prints text values as they are and anything that is not valid
+// UTF-8, or everything when asked to, as base64.
+func formatValue(value []byte, isBase64 bool) string {
+	if isBase64 || !utf8.Valid(value) {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return string(value)
+}
+
+// valueBytesOnly tells the manager that this client reads values from the
+// bytes fields only, so responses do not carry each value twice.
+func valueBytesOnly(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, "x-value-bytes", "1"), method, req, reply, cc, opts...)
+}
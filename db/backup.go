@@ -0,0 +1,145 @@
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dgraph-io/badger/v4/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// bitDelete is Badger's meta bit for a delete marker, which backups carry
+// for keys deleted since the version they start after.
+const bitDelete byte = 1 << 0
+
+// maxBackupListSize bounds a single list of entries read from a backup, to
+// fail on a corrupt length rather than allocate it.
+const maxBackupListSize = 1 << 30
+
+// Backup writes every entry written after version since to w, in Badger's
+// backup format whatever the engine, and returns the highest version it
+// wrote, or zero if there was none. A full backup passes zero; passing the version returned by the
+// previous backup takes an incremental backup, which also carries the deletes
+// made since so it can be replayed over the earlier ones.
+//
+// Internal records, such as prepared transactions, are included, so loading
+// a backup into an empty database restores it exactly.
+func (d *Database) Backup(w io.Writer, since uint64) (uint64, error) {
+	return d.engine.Snapshot(w, since)
+}
+
+// Load writes the entries of backups taken by Backup into the database,
+// keeping their versions. Backups are loaded oldest first.
+func (d *Database) Load(r io.Reader) error {
+	if err := d.engine.Load(r); err != nil {
+		return fmt.Errorf("failed to load backup: %v", err)
+	}
+	return nil
+}
+
+// BackupEntry is the latest state of one key in a backup: a value, with the
+// time it expires if it has a TTL, or a delete.
+type BackupEntry struct {
+	Key       string
+	Value     []byte
+	Version   uint64
+	ExpiresAt time.Time
+	Deleted   bool
+}
+
+// ReadBackup calls fn with the latest entry of every key in a backup written
+// by Backup, skipping internal records. It reads the backup as a stream, so
+// backups larger than memory can be replayed.
+func ReadBackup(r io.Reader, fn func(BackupEntry) error) error {
+	return readBackupKVs(r, func(kv *pb.KV) error {
+		if isInternalKey(kv.Key) || bytes.HasPrefix(kv.Key, []byte("!badger!")) {
+			return nil
+		}
+
+		e := BackupEntry{Key: string(kv.Key), Version: kv.Version}
+		if isBackupDelete(kv) {
+			e.Deleted = true
+		} else {
+			e.Value = kv.Value
+			if kv.ExpiresAt > 0 {
+				e.ExpiresAt = time.Unix(int64(kv.ExpiresAt), 0)
+			}
+		}
+		return fn(e)
+	})
+}
+
+// readBackupKVs calls fn with the latest version of every key in a backup,
+// internal records included.
+func readBackupKVs(r io.Reader, fn func(*pb.KV) error) error {
+	br := bufio.NewReader(r)
+	var last []byte
+	for {
+		var size uint64
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read backup: %v", err)
+		}
+		if size > maxBackupListSize {
+			return fmt.Errorf("corrupt backup: list of %d bytes", size)
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return fmt.Errorf("failed to read backup: %v", err)
+		}
+		var list pb.KVList
+		if err := proto.Unmarshal(buf, &list); err != nil {
+			return fmt.Errorf("corrupt backup: %v", err)
+		}
+
+		for _, kv := range list.Kv {
+			// Older versions of a key follow its latest one.
+			if last != nil && bytes.Equal(kv.Key, last) {
+				continue
+			}
+			last = kv.Key
+			if err := fn(kv); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func isBackupDelete(kv *pb.KV) bool {
+	return len(kv.Meta) > 0 && kv.Meta[0]&bitDelete != 0
+}
+
+// WriteBackup writes entries to w in the format Backup produces, as a single
+// list. It is meant for tools and tests that build backups by hand.
+func WriteBackup(w io.Writer, entries []BackupEntry) error {
+	list := &pb.KVList{}
+	for _, e := range entries {
+		kv := &pb.KV{Key: []byte(e.Key), Version: e.Version, Meta: []byte{0}, UserMeta: []byte{userMetaValue}}
+		if e.Deleted {
+			kv.Meta[0] = bitDelete
+		} else {
+			kv.Value = e.Value
+			if !e.ExpiresAt.IsZero() {
+				kv.ExpiresAt = uint64(e.ExpiresAt.Unix())
+			}
+		}
+		list.Kv = append(list.Kv, kv)
+	}
+
+	buf, err := proto.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(buf))); err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
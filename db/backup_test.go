@@ -0,0 +1,160 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// restoreInto loads backups, oldest first, into a new database.
+func restoreInto(t *testing.T, backups ...*bytes.Buffer) *Database {
+	t.Helper()
+	restored := setupTestDB(t)
+	for _, b := range backups {
+		if err := restored.Load(b); err != nil {
+			t.Fatalf("failed to load backup: %v", err)
+		}
+	}
+	return restored
+}
+
+func TestBackup_Full(t *testing.T) {
+	db := setupTestDB(t)
+	db.SetKey("a", []byte("1"), 0)
+	db.SetKey("b", []byte("2"), 0)
+	db.DeleteKey("b")
+
+	var full bytes.Buffer
+	version, err := db.Backup(&full, 0)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if version == 0 {
+		t.Fatal("expected a non-zero backup version")
+	}
+
+	restored := restoreInto(t, &full)
+	if val, err := restored.GetKey("a"); err != nil || string(val) != "1" {
+		t.Fatalf("expected a=1, got %q, %v", val, err)
+	}
+	if _, err := restored.GetKey("b"); err == nil {
+		t.Fatal("expected b to stay deleted")
+	}
+}
+
+func TestBackup_Incremental(t *testing.T) {
+	db := setupTestDB(t)
+	db.SetKey("a", []byte("1"), 0)
+	db.SetKey("b", []byte("1"), 0)
+
+	var full bytes.Buffer
+	version, err := db.Backup(&full, 0)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	db.SetKey("a", []byte("2"), 0)
+	db.DeleteKey("b")
+	db.SetKey("c", []byte("1"), 0)
+
+	var incremental bytes.Buffer
+	next, err := db.Backup(&incremental, version)
+	if err != nil {
+		t.Fatalf("incremental Backup failed: %v", err)
+	}
+	if next <= version {
+		t.Fatalf("expected the incremental version to pass %d, got %d", version, next)
+	}
+	if incremental.Len() >= full.Len()+64 {
+		t.Fatalf("expected the incremental backup to hold only the changes, got %d bytes after %d", incremental.Len(), full.Len())
+	}
+
+	restored := restoreInto(t, &full, &incremental)
+	for key, want := range map[string]string{"a": "2", "c": "1"} {
+		if val, err := restored.GetKey(key); err != nil || string(val) != want {
+			t.Fatalf("expected %s=%s, got %q, %v", key, want, val, err)
+		}
+	}
+	if _, err := restored.GetKey("b"); err == nil {
+		t.Fatal("expected the incremental backup to replay the delete of b")
+	}
+
+	// Nothing changed since, so the next incremental backup is empty.
+	var empty bytes.Buffer
+	if v, err := db.Backup(&empty, next); err != nil || v != 0 {
+		t.Fatalf("expected an empty backup, got version %d, %v", v, err)
+	}
+}
+
+// readBackup returns the entries ReadBackup finds in b, by key.
+func readBackup(t *testing.T, b []byte) map[string]BackupEntry {
+	t.Helper()
+	entries := make(map[string]BackupEntry)
+	err := ReadBackup(bytes.NewReader(b), func(e BackupEntry) error {
+		if _, dup := entries[e.Key]; dup {
+			t.Fatalf("key %s reported twice", e.Key)
+		}
+		entries[e.Key] = e
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadBackup failed: %v", err)
+	}
+	return entries
+}
+
+func TestReadBackup(t *testing.T) {
+	db := setupTestDB(t)
+	db.SetKey("a", []byte("1"), 0)
+	db.SetKey("a", []byte("2"), 0)
+	db.SetKey("b", []byte("1"), 0)
+	db.SetKey("session", []byte("s"), time.Hour)
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "c", Value: []byte("1")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+
+	var full bytes.Buffer
+	version, err := db.Backup(&full, 0)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	entries := readBackup(t, full.Bytes())
+	if len(entries) != 3 || string(entries["a"].Value) != "2" || entries["a"].Version == 0 {
+		t.Fatalf("expected the latest a, b and session only, got %+v", entries)
+	}
+	if until := time.Until(entries["session"].ExpiresAt); until <= 0 || until > time.Hour {
+		t.Fatalf("expected session to expire within the hour, got %v", entries["session"].ExpiresAt)
+	}
+
+	db.DeleteKey("b")
+	var incremental bytes.Buffer
+	if _, err := db.Backup(&incremental, version); err != nil {
+		t.Fatalf("incremental Backup failed: %v", err)
+	}
+	entries = readBackup(t, incremental.Bytes())
+	if len(entries) != 1 || !entries["b"].Deleted {
+		t.Fatalf("expected only the delete of b, got %+v", entries)
+	}
+}
+
+func TestWriteBackup_LoadsIntoBadger(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteBackup(&b, []BackupEntry{
+		{Key: "a", Value: []byte("1"), Version: 5},
+		{Key: "session", Value: []byte("s"), Version: 6, ExpiresAt: time.Now().Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("WriteBackup failed: %v", err)
+	}
+
+	restored := restoreInto(t, bytes.NewBuffer(b.Bytes()))
+	if val, err := restored.GetKey("a"); err != nil || string(val) != "1" {
+		t.Fatalf("expected a=1, got %q, %v", val, err)
+	}
+	if ttl, err := restored.GetTTL("session"); err != nil || ttl <= 0 {
+		t.Fatalf("expected session to keep its TTL, got %v, %v", ttl, err)
+	}
+	if got := readBackup(t, b.Bytes()); len(got) != 2 || got["a"].Version != 5 {
+		t.Fatalf("expected ReadBackup to return what was written, got %+v", got)
+	}
+}
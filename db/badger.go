@@ -0,0 +1,470 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+	"github.com/dgraph-io/badger/v4/pb"
+)
+
+// userMetaValue marks entries that set a value. Badger hands subscribers
+// only an entry's user meta, so a delete is an entry without it.
+const userMetaValue byte = 1
+
+// Compression algorithms the Badger engine can apply to its tables.
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionZSTD   = "zstd"
+)
+
+// Badger's own bounds on its settings.
+const (
+	minValueLogFileSize = 1 << 20
+	maxValueLogFileSize = 2<<30 - 1
+	maxValueThreshold   = 1 << 20
+)
+
+const (
+	// defaultGCInterval is how often the value log is garbage collected
+	// unless configured otherwise.
+	defaultGCInterval = 10 * time.Minute
+	// defaultGCDiscardRatio is how much of a value log file must be stale
+	// before GC rewrites it, the ratio Badger's docs suggest.
+	defaultGCDiscardRatio = 0.5
+)
+
+var (
+	// ErrNotSupported is returned by the Badger maintenance methods of a
+	// Database on another engine.
+	ErrNotSupported = errors.New("not supported by the storage engine")
+	// ErrGCRunning is returned by RunValueLogGC while another value log GC
+	// is in progress.
+	ErrGCRunning = errors.New("value log GC already running")
+)
+
+// BadgerOptions tunes the Badger engine. Zero values keep Badger's
+// defaults.
+type BadgerOptions struct {
+	// SyncWrites syncs every write to disk before it is acknowledged.
+	SyncWrites bool
+	// ValueLogFileSize is the size of each value log file in bytes,
+	// between 1 MB and 2 GB.
+	ValueLogFileSize int64
+	// Compression is CompressionNone, CompressionSnappy or
+	// CompressionZSTD.
+	Compression string
+	// BlockCacheSize and IndexCacheSize are the bytes kept in memory for
+	// table blocks and indexes.
+	BlockCacheSize int64
+	IndexCacheSize int64
+	// ValueThreshold is the largest value in bytes kept in the LSM tree
+	// rather than the value log, at most 1 MB.
+	ValueThreshold int64
+
+	// GCInterval is how often stale data is collected from the value log,
+	// every 10 minutes when zero; a negative interval turns collection
+	// off. GCDiscardRatio is the share of a value log file that must be
+	// stale for it to be rewritten, 0.5 when zero.
+	GCInterval     time.Duration
+	GCDiscardRatio float64
+}
+
+func (o BadgerOptions) validate() error {
+	switch o.Compression {
+	case "", CompressionNone, CompressionSnappy, CompressionZSTD:
+	default:
+		return fmt.Errorf("unknown compression %q, want %s, %s or %s", o.Compression, CompressionNone, CompressionSnappy, CompressionZSTD)
+	}
+	if o.ValueLogFileSize != 0 && (o.ValueLogFileSize < minValueLogFileSize || o.ValueLogFileSize > maxValueLogFileSize) {
+		return fmt.Errorf("value log file size must be between %d and %d bytes, got %d", minValueLogFileSize, maxValueLogFileSize, o.ValueLogFileSize)
+	}
+	if o.ValueThreshold < 0 || o.ValueThreshold > maxValueThreshold {
+		return fmt.Errorf("value threshold must be between 0 and %d bytes, got %d", maxValueThreshold, o.ValueThreshold)
+	}
+	if o.BlockCacheSize < 0 || o.IndexCacheSize < 0 {
+		return fmt.Errorf("cache sizes must not be negative, got %d and %d", o.BlockCacheSize, o.IndexCacheSize)
+	}
+	if o.GCDiscardRatio < 0 || o.GCDiscardRatio >= 1 {
+		return fmt.Errorf("GC discard ratio must be at least 0 and below 1, got %g", o.GCDiscardRatio)
+	}
+	return nil
+}
+
+// apply sets the options that are not zero on opts.
+func (o BadgerOptions) apply(opts badger.Options) badger.Options {
+	opts = opts.WithSyncWrites(o.SyncWrites)
+	if o.ValueLogFileSize != 0 {
+		opts = opts.WithValueLogFileSize(o.ValueLogFileSize)
+	}
+	switch o.Compression {
+	case CompressionNone:
+		opts = opts.WithCompression(options.None)
+	case CompressionSnappy:
+		opts = opts.WithCompression(options.Snappy)
+	case CompressionZSTD:
+		opts = opts.WithCompression(options.ZSTD)
+	}
+	if o.BlockCacheSize != 0 {
+		opts = opts.WithBlockCacheSize(o.BlockCacheSize)
+	}
+	if o.IndexCacheSize != 0 {
+		opts = opts.WithIndexCacheSize(o.IndexCacheSize)
+	}
+	if o.ValueThreshold != 0 {
+		opts = opts.WithValueThreshold(o.ValueThreshold)
+	}
+	return opts
+}
+
+// badgerEngine is the default engine. Versions are Badger's commit
+// timestamps. Badger never reclaims value log space by itself, so the
+// engine runs value log GC in the background.
+type badgerEngine struct {
+	db           *badger.DB
+	discardRatio float64
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func openBadger(path string, o BadgerOptions) (*badgerEngine, error) {
+	db, err := badger.Open(o.apply(badger.DefaultOptions(path)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Badger database at %s: %v", path, err)
+	}
+
+	e := &badgerEngine{
+		db:           db,
+		discardRatio: o.GCDiscardRatio,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if e.discardRatio == 0 {
+		e.discardRatio = defaultGCDiscardRatio
+	}
+	interval := o.GCInterval
+	if interval == 0 {
+		interval = defaultGCInterval
+	}
+	if interval > 0 {
+		go e.gcLoop(interval)
+	} else {
+		close(e.done)
+	}
+	return e, nil
+}
+
+func (e *badgerEngine) gcLoop(interval time.Duration) {
+	defer close(e.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			// A GC that fails, or finds one already running, is tried
+			// again on the next tick.
+			e.runGC(e.discardRatio)
+		}
+	}
+}
+
+// runGC rewrites value log files until none has discardRatio of stale
+// data left, and returns how many it rewrote.
+func (e *badgerEngine) runGC(discardRatio float64) (int, error) {
+	for rewritten := 0; ; rewritten++ {
+		select {
+		case <-e.stop:
+			return rewritten, nil
+		default:
+		}
+		err := e.db.RunValueLogGC(discardRatio)
+		switch {
+		case errors.Is(err, badger.ErrNoRewrite):
+			return rewritten, nil
+		case errors.Is(err, badger.ErrRejected):
+			return rewritten, ErrGCRunning
+		case err != nil:
+			return rewritten, fmt.Errorf("value log GC failed: %v", err)
+		}
+	}
+}
+
+func (e *badgerEngine) View(fn func(tx Tx) error) error {
+	return e.db.View(func(txn *badger.Txn) error {
+		return fn(badgerTx{txn})
+	})
+}
+
+func (e *badgerEngine) Update(fn func(tx Tx) error) error {
+	err := e.db.Update(func(txn *badger.Txn) error {
+		return fn(badgerTx{txn})
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return ErrConflict
+	}
+	return err
+}
+
+// Batch uses a WriteBatch, which commits a new transaction whenever the
+// current one is full.
+func (e *badgerEngine) Batch(writes []Write) []error {
+	errs := make([]error, len(writes))
+	wb := e.db.NewWriteBatch()
+	for i, w := range writes {
+		var err error
+		if w.Delete {
+			err = wb.Delete(w.Key)
+		} else {
+			err = wb.SetEntry(newEntry(w.Key, w.Value, w.ExpiresAt))
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to add key '%s' to batch: %v", w.Key, err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = fmt.Errorf("failed to write batch: %v", err)
+			}
+		}
+	}
+	return errs
+}
+
+func (e *badgerEngine) Snapshot(w io.Writer, since uint64) (uint64, error) {
+	return e.db.Backup(w, since)
+}
+
+func (e *badgerEngine) Load(r io.Reader) error {
+	return e.db.Load(r, 16)
+}
+
+func (e *badgerEngine) Subscribe(ctx context.Context, fn func([]Event)) error {
+	// An empty prefix matches every key.
+	return e.db.Subscribe(ctx, func(kvs *badger.KVList) error {
+		events := make([]Event, 0, len(kvs.Kv))
+		for _, kv := range kvs.Kv {
+			// Badger publishes its own transaction markers under "!badger!".
+			if bytes.HasPrefix(kv.Key, []byte("!badger!")) {
+				continue
+			}
+			deleted := len(kv.Meta) == 0 || kv.Meta[0]&userMetaValue == 0
+			events = append(events, changeEvent(kv.Key, kv.Value, kv.Version, kv.ExpiresAt, deleted))
+		}
+		fn(events)
+		return nil
+	}, []pb.Match{{Prefix: nil}})
+}
+
+func (e *badgerEngine) Changes(prefix []byte, since uint64, fn func(Event) error) (uint64, error) {
+	var readTs uint64
+	err := e.db.View(func(txn *badger.Txn) error {
+		readTs = txn.ReadTs()
+
+		opts := badger.DefaultIteratorOptions
+		opts.AllVersions = true
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var prev []byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			// Versions of a key come newest first; only the newest counts.
+			if prev != nil && bytes.Equal(item.Key(), prev) {
+				continue
+			}
+			prev = item.KeyCopy(nil)
+			if item.Version() <= since {
+				continue
+			}
+
+			var val []byte
+			deleted := item.IsDeletedOrExpired()
+			if !deleted {
+				var err error
+				if val, err = item.ValueCopy(nil); err != nil {
+					return fmt.Errorf("failed to copy value for key '%s': %v", prev, err)
+				}
+			}
+			if err := fn(changeEvent(prev, val, item.Version(), item.ExpiresAt(), deleted)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return readTs, err
+}
+
+func (e *badgerEngine) Healthy() bool {
+	if e.db.IsClosed() {
+		return false
+	}
+	return e.db.View(func(txn *badger.Txn) error { return nil }) == nil
+}
+
+func (e *badgerEngine) Close() error {
+	select {
+	case <-e.stop:
+	default:
+		close(e.stop)
+	}
+	<-e.done
+	return e.db.Close()
+}
+
+// badgerEngine returns the engine if the database runs on Badger.
+func (d *Database) badgerEngine() (*badgerEngine, error) {
+	e, ok := d.engine.(*badgerEngine)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return e, nil
+}
+
+// RunValueLogGC collects stale data from Badger's value log, rewriting
+// files in which at least discardRatio of the data is stale until none is
+// left, and returns how many files it rewrote. A zero discardRatio uses the
+// configured one.
+func (d *Database) RunValueLogGC(discardRatio float64) (int, error) {
+	e, err := d.badgerEngine()
+	if err != nil {
+		return 0, err
+	}
+	if discardRatio < 0 || discardRatio >= 1 {
+		return 0, fmt.Errorf("GC discard ratio must be at least 0 and below 1, got %g", discardRatio)
+	}
+	if discardRatio == 0 {
+		discardRatio = e.discardRatio
+	}
+	return e.runGC(discardRatio)
+}
+
+// Flatten compacts every level of Badger's LSM tree into one, using workers
+// concurrent compactions, or one per CPU when workers is zero. Background
+// compaction is paused until it finishes.
+func (d *Database) Flatten(workers int) error {
+	e, err := d.badgerEngine()
+	if err != nil {
+		return err
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if err := e.db.Flatten(workers); err != nil {
+		return fmt.Errorf("failed to flatten the LSM tree: %v", err)
+	}
+	return nil
+}
+
+// DiskUsage reports the bytes Badger's LSM tree and value log take on disk,
+// as Badger last measured them; ok is false when the database runs on
+// another engine.
+func (d *Database) DiskUsage() (lsm, vlog int64, ok bool) {
+	e, err := d.badgerEngine()
+	if err != nil {
+		return 0, 0, false
+	}
+	lsm, vlog = e.db.Size()
+	return lsm, vlog, true
+}
+
+// newEntry builds the Badger entry for a write of value. Every write is
+// marked with userMetaValue so the change feed can tell it from a delete.
+func newEntry(key, value []byte, expiresAt uint64) *badger.Entry {
+	e := badger.NewEntry(key, value).WithMeta(userMetaValue)
+	e.ExpiresAt = expiresAt
+	return e
+}
+
+type badgerTx struct {
+	txn *badger.Txn
+}
+
+func (t badgerTx) Get(key []byte) (Item, error) {
+	item, err := t.txn.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return Item{}, ErrKeyNotFound
+	}
+	if err != nil {
+		return Item{}, err
+	}
+	return badgerItem(item, true)
+}
+
+func (t badgerTx) NewIterator(opts IterOptions) Iterator {
+	bopts := badger.DefaultIteratorOptions
+	bopts.Prefix = opts.Prefix
+	bopts.PrefetchValues = !opts.KeysOnly
+	return &badgerIterator{it: t.txn.NewIterator(bopts), prefix: opts.Prefix, values: !opts.KeysOnly}
+}
+
+func (t badgerTx) Set(key, value []byte, expiresAt uint64) error {
+	return txnError(t.txn.SetEntry(newEntry(key, value, expiresAt)))
+}
+
+func (t badgerTx) Delete(key []byte) error {
+	return txnError(t.txn.Delete(key))
+}
+
+// txnError reports a write that does not fit in the transaction as
+// ErrTxnTooBig, which callers can tell apart from a failing engine.
+func txnError(err error) error {
+	if errors.Is(err, badger.ErrTxnTooBig) {
+		return ErrTxnTooBig
+	}
+	return err
+}
+
+func (t badgerTx) Version() uint64 {
+	return t.txn.ReadTs()
+}
+
+type badgerIterator struct {
+	it     *badger.Iterator
+	prefix []byte
+	values bool
+}
+
+// Seek clamps key to the prefix; Badger would otherwise stop at the first
+// key before it.
+func (i *badgerIterator) Seek(key []byte) {
+	if bytes.Compare(key, i.prefix) < 0 {
+		key = i.prefix
+	}
+	i.it.Seek(key)
+}
+
+func (i *badgerIterator) Valid() bool { return i.it.Valid() }
+func (i *badgerIterator) Next()       { i.it.Next() }
+func (i *badgerIterator) Close()      { i.it.Close() }
+
+func (i *badgerIterator) Item() (Item, error) {
+	return badgerItem(i.it.Item(), i.values)
+}
+
+func badgerItem(item *badger.Item, withValue bool) (Item, error) {
+	it := Item{
+		Key:       item.KeyCopy(nil),
+		Version:   item.Version(),
+		ExpiresAt: item.ExpiresAt(),
+		Size:      item.EstimatedSize(),
+	}
+	if withValue {
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return Item{}, fmt.Errorf("failed to copy value for key '%s': %v", it.Key, err)
+		}
+		it.Value = val
+	}
+	return it, nil
+}
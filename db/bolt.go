@@ -0,0 +1,393 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4/pb"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltDataBucket = []byte("data")
+	boltMetaBucket = []byte("meta")
+	boltVersionKey = []byte("version")
+)
+
+// boltEngine keeps keys in a single bbolt file, a B+tree that is cheap to
+// read and, unlike Badger, needs no background compaction. bbolt allows one
+// writer at a time, so Update never conflicts. The latest committed version
+// is stored alongside the data.
+type boltEngine struct {
+	db *bolt.DB
+	// mu orders commits with their publication to subscribers.
+	mu   sync.Mutex
+	subs subscribers
+	stop chan struct{}
+	done chan struct{}
+}
+
+func openBolt(path string) (*boltEngine, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create bolt directory %s: %v", path, err)
+	}
+	file := filepath.Join(path, "meerkat.bolt")
+	db, err := bolt.Open(file, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt database at %s: %v", file, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltDataBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize bolt database at %s: %v", file, err)
+	}
+
+	e := &boltEngine{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+	go e.purgeLoop()
+	return e, nil
+}
+
+func (e *boltEngine) View(fn func(tx Tx) error) error {
+	return e.db.View(func(btx *bolt.Tx) error {
+		return fn(newBoltTx(btx))
+	})
+}
+
+func (e *boltEngine) Update(fn func(tx Tx) error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var tx *boltTx
+	err := e.db.Update(func(btx *bolt.Tx) error {
+		tx = newBoltTx(btx)
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if len(tx.events) == 0 {
+			return nil
+		}
+		return btx.Bucket(boltMetaBucket).Put(boltVersionKey, binary.BigEndian.AppendUint64(nil, tx.version+1))
+	})
+	if err != nil {
+		return err
+	}
+	e.subs.publish(tx.events)
+	return nil
+}
+
+func (e *boltEngine) Batch(writes []Write) []error {
+	return batchWrites(e.Update, writes)
+}
+
+func (e *boltEngine) Snapshot(w io.Writer, since uint64) (uint64, error) {
+	sw := newSnapshotWriter(w, since)
+	err := e.db.View(func(btx *bolt.Tx) error {
+		c := btx.Bucket(boltDataBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			r, err := decodeRecord(v)
+			if err != nil {
+				return fmt.Errorf("corrupt record for key '%s': %v", k, err)
+			}
+			if err := sw.add(bytes.Clone(k), r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sw.version, sw.flush()
+}
+
+func (e *boltEngine) Load(r io.Reader) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var kvs []*pb.KV
+	write := func() error {
+		if len(kvs) == 0 {
+			return nil
+		}
+		err := e.db.Update(func(btx *bolt.Tx) error {
+			data, meta := btx.Bucket(boltDataBucket), btx.Bucket(boltMetaBucket)
+			version := readBoltVersion(meta)
+			for _, kv := range kvs {
+				rec := record{value: kv.Value, version: kv.Version, expiresAt: kv.ExpiresAt}
+				if isBackupDelete(kv) {
+					rec = tombstone(kv.Version)
+				}
+				if err := data.Put(kv.Key, rec.encode()); err != nil {
+					return err
+				}
+				version = max(version, kv.Version)
+			}
+			return meta.Put(boltVersionKey, binary.BigEndian.AppendUint64(nil, version))
+		})
+		kvs = kvs[:0]
+		return err
+	}
+
+	err := readBackupKVs(r, func(kv *pb.KV) error {
+		kvs = append(kvs, kv)
+		if len(kvs) == snapshotListSize {
+			return write()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return write()
+}
+
+func (e *boltEngine) Subscribe(ctx context.Context, fn func([]Event)) error {
+	return e.subs.subscribe(ctx, fn)
+}
+
+func (e *boltEngine) Changes(prefix []byte, since uint64, fn func(Event) error) (uint64, error) {
+	var version uint64
+	err := e.db.View(func(btx *bolt.Tx) error {
+		version = readBoltVersion(btx.Bucket(boltMetaBucket))
+
+		now := time.Now()
+		c := btx.Bucket(boltDataBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			r, err := decodeRecord(v)
+			if err != nil {
+				return fmt.Errorf("corrupt record for key '%s': %v", k, err)
+			}
+			if r.version <= since {
+				continue
+			}
+			if err := fn(r.event(bytes.Clone(k), now)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return version, err
+}
+
+func (e *boltEngine) Healthy() bool {
+	return e.db.View(func(*bolt.Tx) error { return nil }) == nil
+}
+
+func (e *boltEngine) Close() error {
+	select {
+	case <-e.stop:
+		return nil
+	default:
+	}
+	close(e.stop)
+	<-e.done
+	return e.db.Close()
+}
+
+func (e *boltEngine) purgeLoop() {
+	defer close(e.done)
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			// A purge that fails is tried again on the next tick.
+			e.purge(time.Now())
+		}
+	}
+}
+
+// purge drops expired keys and tombstones, a bounded number per write
+// transaction so writers are not held up.
+func (e *boltEngine) purge(now time.Time) error {
+	for {
+		var keys [][]byte
+		err := e.db.View(func(btx *bolt.Tx) error {
+			c := btx.Bucket(boltDataBucket).Cursor()
+			for k, v := c.First(); k != nil && len(keys) < snapshotListSize; k, v = c.Next() {
+				if r, err := decodeRecord(v); err == nil && expired(r.expiresAt, now) {
+					keys = append(keys, bytes.Clone(k))
+				}
+			}
+			return nil
+		})
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+
+		e.mu.Lock()
+		err = e.db.Update(func(btx *bolt.Tx) error {
+			data := btx.Bucket(boltDataBucket)
+			for _, k := range keys {
+				// The key may have been written again since.
+				if r, err := decodeRecord(data.Get(k)); err == nil && expired(r.expiresAt, now) {
+					if err := data.Delete(k); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		e.mu.Unlock()
+		if err != nil || len(keys) < snapshotListSize {
+			return err
+		}
+	}
+}
+
+func readBoltVersion(meta *bolt.Bucket) uint64 {
+	if v := meta.Get(boltVersionKey); len(v) == 8 {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+// encode lays a record out as its version, expiry and flags followed by the
+// value.
+func (r record) encode() []byte {
+	buf := make([]byte, 17, 17+len(r.value))
+	binary.BigEndian.PutUint64(buf, r.version)
+	binary.BigEndian.PutUint64(buf[8:], r.expiresAt)
+	if r.deleted {
+		buf[16] = 1
+	}
+	return append(buf, r.value...)
+}
+
+// decodeRecord reads a record written by encode, copying its value.
+func decodeRecord(b []byte) (record, error) {
+	if len(b) < 17 {
+		return record{}, fmt.Errorf("record of %d bytes", len(b))
+	}
+	return record{
+		version:   binary.BigEndian.Uint64(b),
+		expiresAt: binary.BigEndian.Uint64(b[8:]),
+		deleted:   b[16] == 1,
+		value:     bytes.Clone(b[17:]),
+	}, nil
+}
+
+type boltTx struct {
+	tx      *bolt.Tx
+	data    *bolt.Bucket
+	version uint64
+	now     time.Time
+	// events are the writes of an Update, published once it commits.
+	events []Event
+}
+
+func newBoltTx(btx *bolt.Tx) *boltTx {
+	return &boltTx{
+		tx:      btx,
+		data:    btx.Bucket(boltDataBucket),
+		version: readBoltVersion(btx.Bucket(boltMetaBucket)),
+		now:     time.Now(),
+	}
+}
+
+func (t *boltTx) Get(key []byte) (Item, error) {
+	v := t.data.Get(key)
+	if v == nil {
+		return Item{}, ErrKeyNotFound
+	}
+	r, err := decodeRecord(v)
+	if err != nil {
+		return Item{}, fmt.Errorf("corrupt record for key '%s': %v", key, err)
+	}
+	if r.hidden(t.now) {
+		return Item{}, ErrKeyNotFound
+	}
+	return r.item(bytes.Clone(key), true), nil
+}
+
+func (t *boltTx) NewIterator(opts IterOptions) Iterator {
+	return &boltIterator{tx: t, c: t.data.Cursor(), prefix: opts.Prefix, values: !opts.KeysOnly}
+}
+
+func (t *boltTx) Set(key, value []byte, expiresAt uint64) error {
+	return t.put(key, record{value: value, expiresAt: expiresAt})
+}
+
+func (t *boltTx) Delete(key []byte) error {
+	return t.put(key, tombstone(0))
+}
+
+// put writes r under the version this transaction commits at.
+func (t *boltTx) put(key []byte, r record) error {
+	r.version = t.version + 1
+	if err := t.data.Put(key, r.encode()); err != nil {
+		return err
+	}
+	t.events = append(t.events, r.event(bytes.Clone(key), t.now))
+	return nil
+}
+
+func (t *boltTx) Version() uint64 {
+	return t.version
+}
+
+type boltIterator struct {
+	tx     *boltTx
+	c      *bolt.Cursor
+	prefix []byte
+	values bool
+
+	key []byte
+	rec record
+	err error
+}
+
+func (i *boltIterator) Seek(key []byte) {
+	if bytes.Compare(key, i.prefix) < 0 {
+		key = i.prefix
+	}
+	i.load(i.c.Seek(key))
+}
+
+func (i *boltIterator) Next() {
+	i.load(i.c.Next())
+}
+
+// load moves to the first visible key at or after k.
+func (i *boltIterator) load(k, v []byte) {
+	for ; k != nil && bytes.HasPrefix(k, i.prefix); k, v = i.c.Next() {
+		r, err := decodeRecord(v)
+		if err != nil {
+			i.key, i.err = k, fmt.Errorf("corrupt record for key '%s': %v", k, err)
+			return
+		}
+		if !r.hidden(i.tx.now) {
+			i.key, i.rec, i.err = k, r, nil
+			return
+		}
+	}
+	i.key = nil
+}
+
+func (i *boltIterator) Valid() bool {
+	return i.key != nil
+}
+
+func (i *boltIterator) Item() (Item, error) {
+	if i.err != nil {
+		return Item{}, i.err
+	}
+	return i.rec.item(bytes.Clone(i.key), i.values), nil
+}
+
+func (i *boltIterator) Close() {}
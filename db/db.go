@@ -1,34 +1,70 @@
 package db
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrConditionFailed is returned by the conditional writes when the key's
+// current state does not match the condition, including when a concurrent
+// write changed it while the condition was being checked.
+var ErrConditionFailed = errors.New("condition failed")
 
-	badger "github.com/dgraph-io/badger/v4"
+var (
+	// ErrNotInteger is returned by IncrementKey when the key holds something
+	// other than a base-10 64-bit integer.
+	ErrNotInteger = errors.New("value is not an integer")
+	// ErrOverflow is returned by IncrementKey when the result would not fit
+	// in 64 bits.
+	ErrOverflow = errors.New("increment would overflow")
 )
 
+// maxIncrementAttempts bounds how often IncrementKey retries after losing a
+// race with a concurrent write to the same key.
+const maxIncrementAttempts = 16
+
 type Database struct {
-	db     *badger.DB
+	engine Engine
 	dbPath string
+	feed   *feed
+	// batchMu is held for reading by batches and for writing by PrepareTxn.
+	batchMu sync.RWMutex
 }
 
+// NewDatabase opens a Badger database at path.
 func NewDatabase(path string) (*Database, error) {
-	badgerDb, err := badger.Open(badger.DefaultOptions(path))
+	return Open(path, Options{})
+}
+
+// Open opens a database at path on the engine opts selects. The memory
+// engine keeps nothing at path.
+func Open(path string, opts Options) (*Database, error) {
+	engine, err := OpenEngine(path, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open Badger database at %s: %v", path, err)
+		return nil, err
 	}
 
 	db := &Database{
-		db:     badgerDb,
+		engine: engine,
 		dbPath: path,
 	}
+	db.feed = startFeed(engine)
 
 	return db, nil
 }
 
 func (d *Database) Close() error {
-	if d.db != nil {
-		return d.db.Close()
+	if d.feed != nil {
+		d.feed.stop()
+	}
+	if d.engine != nil {
+		return d.engine.Close()
 	}
 	return nil
 }
@@ -46,105 +82,495 @@ func (d *Database) Cleanup() error {
 }
 
 func (d *Database) GetKey(key string) ([]byte, error) {
-	var valCopy []byte
-	err := d.db.View(func(txn *badger.Txn) error {
-		val, err := txn.Get([]byte(key))
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	val, _, err := d.getItem(key)
+	if err != nil {
+		return nil, fmt.Errorf("transaction failed while getting key '%s': %v", key, err)
+	}
+	return val, nil
+}
+
+// getItem reads the value and version of key.
+func (d *Database) getItem(key string) ([]byte, uint64, error) {
+	var item Item
+	err := d.engine.View(func(tx Tx) error {
+		var err error
+		item, err = getKey(tx, key)
+		return err
+	})
+	return item.Value, item.Version, err
+}
+
+// getKey reads key in tx, naming it in the error if it is missing.
+func getKey(tx Tx, key string) (Item, error) {
+	item, err := tx.Get([]byte(key))
+	if err == ErrKeyNotFound {
+		return Item{}, fmt.Errorf("key '%s' not found", key)
+	}
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to get key '%s': %v", key, err)
+	}
+	return item, nil
+}
+
+// GetKeyVersion returns the value of key together with its version, which
+// changes on every write. Versions are local to this database.
+func (d *Database) GetKeyVersion(key string) ([]byte, uint64, error) {
+	if err := checkKey(key); err != nil {
+		return nil, 0, err
+	}
+	val, version, err := d.getItem(key)
+	if err != nil {
+		return nil, 0, fmt.Errorf("transaction failed while getting key '%s': %v", key, err)
+	}
+	return val, version, nil
+}
+
+// SetKey stores value under key. A positive ttl makes the key expire after
+// that long; Badger tracks expiry with one-second granularity.
+func (d *Database) SetKey(key string, value []byte, ttl time.Duration) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	err := d.engine.Update(func(tx Tx) error {
+		if err := checkUnlocked(tx, key, ""); err != nil {
+			return err
+		}
+		if err := tx.Set([]byte(key), value, expiresAt(ttl)); err != nil {
+			return fmt.Errorf("failed to set key '%s' with a %d-byte value: %v", key, len(value), err)
+		}
+		return nil
+	})
+	if err = lockConflict(err); err != nil {
+		return fmt.Errorf("transaction failed while setting key '%s': %w", key, err)
+	}
+
+	return nil
+}
+
+// GetTTL returns the remaining lifetime of key, or zero if it never expires.
+func (d *Database) GetTTL(key string) (time.Duration, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	var ttl time.Duration
+	err := d.engine.View(func(tx Tx) error {
+		item, err := getKey(tx, key)
 		if err != nil {
-			if err == badger.ErrKeyNotFound {
-				return fmt.Errorf("key '%s' not found", key)
-			}
+			return err
+		}
+		ttl = remainingTTL(item.ExpiresAt)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("transaction failed while reading TTL of key '%s': %v", key, err)
+	}
+
+	return ttl, nil
+}
+
+// remainingTTL converts an expiry timestamp, in Unix seconds, into the time
+// left. Engines hide items once their expiry second has passed, so any
+// visible item with an expiry has a positive remainder.
+func remainingTTL(expiresAt uint64) time.Duration {
+	if expiresAt == 0 {
+		return 0
+	}
+	return time.Until(time.Unix(int64(expiresAt), 0))
+}
+
+// SetKeyIfAbsent stores value under key only if the key does not exist. Like
+// every conditional write it returns the write's order, for copying it to
+// other replicas with SetKeyFromPrimary.
+func (d *Database) SetKeyIfAbsent(key string, value []byte, ttl time.Duration) (uint64, error) {
+	return d.conditionalUpdate(key, func(tx Tx) error {
+		_, err := tx.Get([]byte(key))
+		if err == nil {
+			return fmt.Errorf("key '%s' already exists: %w", key, ErrConditionFailed)
+		}
+		if err != ErrKeyNotFound {
 			return fmt.Errorf("failed to get key '%s': %v", key, err)
 		}
-		valCopy, err = val.ValueCopy(nil)
+		return setEntry(tx, key, value, ttl)
+	})
+}
+
+// SetKeyIfVersion overwrites key only if its current version, as returned by
+// GetKeyVersion, is version.
+func (d *Database) SetKeyIfVersion(key string, value []byte, version uint64, ttl time.Duration) (uint64, error) {
+	return d.conditionalUpdate(key, func(tx Tx) error {
+		item, err := tx.Get([]byte(key))
+		if err == ErrKeyNotFound {
+			return fmt.Errorf("key '%s' not found: %w", key, ErrConditionFailed)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to copy value for key '%s': %v", key, err)
+			return fmt.Errorf("failed to get key '%s': %v", key, err)
+		}
+		if item.Version != version {
+			return fmt.Errorf("key '%s' is at version %d, not %d: %w", key, item.Version, version, ErrConditionFailed)
+		}
+		return setEntry(tx, key, value, ttl)
+	})
+}
+
+// DeleteKeyIfValue deletes key only if its current value is value.
+func (d *Database) DeleteKeyIfValue(key string, value []byte) (uint64, error) {
+	return d.conditionalUpdate(key, func(tx Tx) error {
+		item, err := tx.Get([]byte(key))
+		if err == ErrKeyNotFound {
+			return fmt.Errorf("key '%s' not found: %w", key, ErrConditionFailed)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get key '%s': %v", key, err)
+		}
+		if !bytes.Equal(item.Value, value) {
+			return fmt.Errorf("key '%s' holds a different value: %w", key, ErrConditionFailed)
+		}
+		if err := tx.Delete([]byte(key)); err != nil {
+			return fmt.Errorf("failed to delete key '%s': %v", key, err)
 		}
 		return nil
 	})
+}
+
+// IncrementKey adds delta to the integer stored under key, treating a missing
+// key as zero, and returns the new value with the key's remaining TTL and the
+// write's order. The read-modify-write runs in one transaction and is retried
+// when a concurrent write to the key causes a conflict. An existing expiry is
+// kept.
+func (d *Database) IncrementKey(key string, delta int64) (int64, time.Duration, uint64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, 0, 0, err
+	}
+
+	var result int64
+	var expiresAt, order uint64
+
+	increment := func(tx Tx) error {
+		var current int64
+		expiresAt = 0
+
+		if err := checkUnlocked(tx, key, ""); err != nil {
+			return err
+		}
+
+		item, err := tx.Get([]byte(key))
+		switch {
+		case err == ErrKeyNotFound:
+		case err != nil:
+			return fmt.Errorf("failed to get key '%s': %v", key, err)
+		default:
+			current, err = strconv.ParseInt(string(item.Value), 10, 64)
+			if err != nil {
+				return fmt.Errorf("key '%s' holds %q: %w", key, item.Value, ErrNotInteger)
+			}
+			expiresAt = item.ExpiresAt
+		}
+
+		if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+			return fmt.Errorf("adding %d to key '%s' at %d: %w", delta, key, current, ErrOverflow)
+		}
+		result = current + delta
+
+		if err := tx.Set([]byte(key), []byte(strconv.FormatInt(result, 10)), expiresAt); err != nil {
+			return fmt.Errorf("failed to set key '%s': %v", key, err)
+		}
+		order = tx.Version() + 1
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt < maxIncrementAttempts; attempt++ {
+		err = d.engine.Update(increment)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("transaction failed while getting key '%s': %v", key, err)
+		return 0, 0, 0, fmt.Errorf("transaction failed while incrementing key '%s': %w", key, err)
 	}
 
-	return valCopy, nil
+	return result, remainingTTL(expiresAt), order, nil
 }
 
-func (d *Database) SetKey(key string, value string) error {
-	err := d.db.Update(func(txn *badger.Txn) error {
-		e := badger.NewEntry([]byte(key), []byte(value))
-		if err := txn.SetEntry(e); err != nil {
-			return fmt.Errorf("failed to set key '%s' with value '%s': %v", key, value, err)
+// conditionalUpdate runs fn in a read-write transaction, unless a prepared
+// transaction holds key, and returns the write's order. The engine detects
+// when another transaction wrote the key after fn read it; that write may
+// have changed the outcome of the check, so the conflict is reported as a
+// failed condition.
+func (d *Database) conditionalUpdate(key string, fn func(tx Tx) error) (uint64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	var order uint64
+	err := d.engine.Update(func(tx Tx) error {
+		if err := checkUnlocked(tx, key, ""); err != nil {
+			return err
 		}
-		return nil
+		order = tx.Version() + 1
+		return fn(tx)
 	})
+	if errors.Is(err, ErrConflict) {
+		return 0, fmt.Errorf("key '%s' changed concurrently: %w", key, ErrConditionFailed)
+	}
 	if err != nil {
-		return fmt.Errorf("transaction failed while setting key '%s': %v", key, err)
+		return 0, fmt.Errorf("transaction failed while updating key '%s': %w", key, err)
 	}
+	return order, nil
+}
 
+func setEntry(tx Tx, key string, value []byte, ttl time.Duration) error {
+	if err := tx.Set([]byte(key), value, expiresAt(ttl)); err != nil {
+		return fmt.Errorf("failed to set key '%s': %v", key, err)
+	}
 	return nil
 }
 
-func (d *Database) IsHealthy() bool {
-	if d.db == nil || d.db.IsClosed() {
-		return false
+// SetKeys stores all pairs, each with its own TTL, and returns one error
+// per pair, nil for each pair that was stored. Pairs succeed or fail on their
+// own: one fails with ErrLocked if a prepared transaction holds its key.
+// The batch is not atomic, and a large one is committed in parts.
+func (d *Database) SetKeys(pairs []KeyValuePair) []error {
+	writes := make([]Write, len(pairs))
+	for i, p := range pairs {
+		writes[i] = Write{Key: []byte(p.Key), Value: p.Value, ExpiresAt: expiresAt(p.TTL)}
 	}
-	err := d.db.View(func(txn *badger.Txn) error {
+	return d.writeBatch(writes)
+}
+
+// DeleteKeys deletes all keys and returns one error per key, like SetKeys.
+// Unlike DeleteKey, missing keys are not an error.
+func (d *Database) DeleteKeys(keys []string) []error {
+	writes := make([]Write, len(keys))
+	for i, key := range keys {
+		writes[i] = Write{Key: []byte(key), Delete: true}
+	}
+	return d.writeBatch(writes)
+}
+
+// writeBatch applies writes with the engine's Batch. Batch reads nothing, so
+// the locks of the keys are checked beforehand, holding batchMu so that
+// PrepareTxn cannot lock one of them until the batch is written.
+func (d *Database) writeBatch(writes []Write) []error {
+	errs := make([]error, len(writes))
+	for i, w := range writes {
+		errs[i] = checkKey(string(w.Key))
+	}
+
+	d.batchMu.RLock()
+	defer d.batchMu.RUnlock()
+
+	err := d.engine.View(func(tx Tx) error {
+		for i, w := range writes {
+			if errs[i] != nil {
+				continue
+			}
+			if err := checkUnlocked(tx, string(w.Key), ""); errors.Is(err, ErrLocked) {
+				errs[i] = err
+			} else if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
-	return err == nil
+	if err != nil {
+		err = fmt.Errorf("failed to check the locks of a batch of %d keys: %v", len(writes), err)
+	}
+
+	var accepted []Write
+	var indexes []int
+	for i, w := range writes {
+		switch {
+		case errs[i] != nil:
+		case err != nil:
+			errs[i] = err
+		default:
+			accepted = append(accepted, w)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(accepted) == 0 {
+		return errs
+	}
+	for j, err := range d.engine.Batch(accepted) {
+		errs[indexes[j]] = err
+	}
+	return errs
 }
 
+// GetKeys reads keys in a single transaction and returns the values of those
+// that exist. Missing keys are left out of the result.
+func (d *Database) GetKeys(keys []string) (map[string][]byte, error) {
+	for _, key := range keys {
+		if err := checkKey(key); err != nil {
+			return nil, err
+		}
+	}
+	values := make(map[string][]byte, len(keys))
+	err := d.engine.View(func(tx Tx) error {
+		for _, key := range keys {
+			item, err := tx.Get([]byte(key))
+			if err == ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get key '%s': %v", key, err)
+			}
+			values[key] = item.Value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transaction failed while getting %d keys: %v", len(keys), err)
+	}
+
+	return values, nil
+}
+
+func (d *Database) IsHealthy() bool {
+	return d.engine != nil && d.engine.Healthy()
+}
+
+// KeyValuePair is a stored key with its value and remaining lifetime. TTL is
+// zero for keys that never expire. Version is the key's version as read;
+// writes ignore it.
 type KeyValuePair struct {
-	Key   string
-	Value string
+	Key     string
+	Value   []byte
+	TTL     time.Duration
+	Version uint64
 }
 
 func (d *Database) GetAllKeys() ([]KeyValuePair, error) {
-	var pairs []KeyValuePair
+	pairs, _, err := d.ScanKeys("", "", "", 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over keys: %v", err)
+	}
+	return pairs, nil
+}
 
-	err := d.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		it := txn.NewIterator(opts)
+// ScanKeys returns, in key order, up to limit keys that are >= start, below
+// end and begin with prefix. The page also stops before the key that would
+// take its keys and values past maxBytes, though it always holds at least one
+// key. An empty end means no upper bound, and a limit or maxBytes of zero
+// means no limit. more reports whether further matching keys exist.
+func (d *Database) ScanKeys(prefix, start, end string, limit, maxBytes int) (pairs []KeyValuePair, more bool, err error) {
+	size := 0
+	err = d.engine.View(func(tx Tx) error {
+		it := tx.NewIterator(IterOptions{Prefix: []byte(prefix)})
 		defer it.Close()
 
-		for it.Rewind(); it.Valid(); it.Next() {
-			item := it.Item()
-			key := string(item.Key())
-
-			val, err := item.ValueCopy(nil)
+		for it.Seek([]byte(start)); it.Valid(); it.Next() {
+			item, err := it.Item()
 			if err != nil {
-				return fmt.Errorf("failed to copy value for key '%s': %v", key, err)
+				return err
+			}
+			if isInternalKey(item.Key) {
+				continue
+			}
+			key := string(item.Key)
+			if end != "" && key >= end {
+				break
+			}
+			if limit > 0 && len(pairs) == limit {
+				more = true
+				break
+			}
+			size += len(item.Key) + len(item.Value)
+			if maxBytes > 0 && len(pairs) > 0 && size > maxBytes {
+				more = true
+				break
 			}
 
 			pairs = append(pairs, KeyValuePair{
-				Key:   key,
-				Value: string(val),
+				Key:     key,
+				Value:   item.Value,
+				TTL:     remainingTTL(item.ExpiresAt),
+				Version: item.Version,
 			})
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to iterate over keys: %v", err)
+		return nil, false, fmt.Errorf("failed to scan keys: %v", err)
 	}
 
-	return pairs, nil
+	return pairs, more, nil
+}
+
+// RangeStats reports how many keys lie in [start, end), roughly how many
+// bytes they take, and the key at the middle of the range. An empty end means
+// no upper bound. Values are not read, so the scan stays cheap.
+func (d *Database) RangeStats(start, end string) (keys int, bytes int64, median string, err error) {
+	err = d.engine.View(func(tx Tx) error {
+		it := tx.NewIterator(IterOptions{KeysOnly: true})
+		defer it.Close()
+
+		// walk calls fn with the keys in the range, in order, until fn
+		// returns false.
+		walk := func(fn func(Item) bool) error {
+			for it.Seek([]byte(start)); it.Valid(); it.Next() {
+				item, err := it.Item()
+				if err != nil {
+					return err
+				}
+				if end != "" && string(item.Key) >= end {
+					return nil
+				}
+				if isInternalKey(item.Key) {
+					continue
+				}
+				if !fn(item) {
+					return nil
+				}
+			}
+			return nil
+		}
+
+		err := walk(func(item Item) bool {
+			keys++
+			bytes += item.Size
+			return true
+		})
+		if err != nil || keys == 0 {
+			return err
+		}
+
+		i := 0
+		return walk(func(item Item) bool {
+			if i == keys/2 {
+				median = string(item.Key)
+				return false
+			}
+			i++
+			return true
+		})
+	})
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("failed to read range stats: %v", err)
+	}
+
+	return keys, bytes, median, nil
 }
 
 func (d *Database) DeleteKey(key string) error {
-	_, err := d.GetKey(key)
-	if err == badger.ErrKeyNotFound {
-		return fmt.Errorf("key '%s' not found, cannot delete", key)
-	} else if err != nil {
-		return fmt.Errorf("failed to check existence of key '%s': %v", key, err)
+	if _, err := d.GetKey(key); err != nil {
+		return fmt.Errorf("failed to check existence of key '%s': %w", key, err)
 	}
 
-	err = d.db.Update(func(txn *badger.Txn) error {
-		if err := txn.Delete([]byte(key)); err != nil {
+	err := d.engine.Update(func(tx Tx) error {
+		if err := checkUnlocked(tx, key, ""); err != nil {
+			return err
+		}
+		if err := tx.Delete([]byte(key)); err != nil {
 			return fmt.Errorf("failed to delete key '%s': %v", key, err)
 		}
 		return nil
 	})
-	if err != nil {
-		return fmt.Errorf("transaction failed while deleting key '%s': %v", key, err)
+	if err = lockConflict(err); err != nil {
+		return fmt.Errorf("transaction failed while deleting key '%s': %w", key, err)
 	}
 
 	return nil
@@ -13,7 +13,7 @@ func setupBenchDB(b *testing.B) *Database {
 		b.Fatalf("failed to create temp dir: %v", err)
 	}
 
-	db, err := NewDatabase(dir)
+	db, err := Open(dir, Options{Engine: testEngine})
 	if err != nil {
 		os.RemoveAll(dir)
 		b.Fatalf("failed to create database: %v", err)
@@ -31,7 +31,7 @@ func BenchmarkSetKey(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		db.SetKey(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i))
+		db.SetKey(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i)), 0)
 	}
 }
 
@@ -39,7 +39,7 @@ func BenchmarkGetKey(b *testing.B) {
 	db := setupBenchDB(b)
 
 	for i := 0; i < 10000; i++ {
-		db.SetKey(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i))
+		db.SetKey(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i)), 0)
 	}
 
 	b.ResetTimer()
@@ -52,7 +52,7 @@ func BenchmarkDeleteKey(b *testing.B) {
 	db := setupBenchDB(b)
 
 	for i := 0; i < b.N; i++ {
-		db.SetKey(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i))
+		db.SetKey(fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("value-%d", i)), 0)
 	}
 
 	b.ResetTimer()
@@ -1,10 +1,40 @@
 package db
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
+	"time"
 )
 
+var engineFlag = flag.String("engine", "", "run the tests against only this storage engine")
+
+// testEngine is the engine setupTestDB opens. TestMain runs the whole
+// suite once per engine.
+var testEngine string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	engines := []string{EngineBadger, EngineMemory, EngineBolt}
+	if *engineFlag != "" {
+		engines = []string{*engineFlag}
+	}
+
+	code := 0
+	for _, engine := range engines {
+		testEngine = engine
+		if c := m.Run(); c != 0 {
+			fmt.Fprintf(os.Stderr, "tests failed on the %s engine\n", engine)
+			code = c
+		}
+	}
+	os.Exit(code)
+}
+
 func setupTestDB(t *testing.T) *Database {
 	t.Helper()
 	dir, err := os.MkdirTemp("", "meerkat-test-*")
@@ -12,10 +42,10 @@ func setupTestDB(t *testing.T) *Database {
 		t.Fatalf("failed to create temp dir: %v", err)
 	}
 
-	db, err := NewDatabase(dir)
+	db, err := Open(dir, Options{Engine: testEngine})
 	if err != nil {
 		os.RemoveAll(dir)
-		t.Fatalf("failed to create database: %v", err)
+		t.Fatalf("failed to create %s database: %v", testEngine, err)
 	}
 
 	t.Cleanup(func() {
@@ -39,10 +69,102 @@ func TestNewDatabase_InvalidPath(t *testing.T) {
 	}
 }
 
+func TestOpen_BadgerOptions(t *testing.T) {
+	dir := t.TempDir()
+	db, err := Open(dir, Options{Badger: BadgerOptions{
+		SyncWrites:       true,
+		ValueLogFileSize: 16 << 20,
+		Compression:      CompressionZSTD,
+		BlockCacheSize:   8 << 20,
+		IndexCacheSize:   4 << 20,
+		ValueThreshold:   1024,
+	}})
+	if err != nil {
+		t.Fatalf("failed to open tuned Badger database: %v", err)
+	}
+	defer db.Close()
+
+	value := make([]byte, 4096)
+	if err := db.SetKey("big", value, 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if got, err := db.GetKey("big"); err != nil || len(got) != len(value) {
+		t.Fatalf("expected %d bytes back, got %d (%v)", len(value), len(got), err)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	bad := map[string]Options{
+		"engine":         {Engine: "leveldb"},
+		"eviction":       {Eviction: "random"},
+		"max memory":     {MaxMemory: -1},
+		"compression":    {Badger: BadgerOptions{Compression: "lz4"}},
+		"value log size": {Badger: BadgerOptions{ValueLogFileSize: 1024}},
+		"threshold":      {Badger: BadgerOptions{ValueThreshold: 2 << 20}},
+		"cache size":     {Badger: BadgerOptions{BlockCacheSize: -1}},
+	}
+	for name, opts := range bad {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("expected a bad %s to be rejected", name)
+		}
+	}
+	if err := (Options{}).Validate(); err != nil {
+		t.Fatalf("expected the default options to be valid, got %v", err)
+	}
+}
+
+func TestBadgerMaintenance(t *testing.T) {
+	db, err := Open(t.TempDir(), Options{Badger: BadgerOptions{GCInterval: -1, ValueThreshold: 64}})
+	if err != nil {
+		t.Fatalf("failed to open Badger database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i%10)
+		if err := db.SetKey(key, make([]byte, 1024), 0); err != nil {
+			t.Fatalf("SetKey failed: %v", err)
+		}
+	}
+	if _, err := db.RunValueLogGC(0); err != nil {
+		t.Fatalf("RunValueLogGC failed: %v", err)
+	}
+	if _, err := db.RunValueLogGC(1); err == nil {
+		t.Fatal("expected a discard ratio of 1 to be rejected")
+	}
+	if err := db.Flatten(1); err != nil {
+		t.Fatalf("Flatten failed: %v", err)
+	}
+	if _, _, ok := db.DiskUsage(); !ok {
+		t.Fatal("expected disk usage on Badger")
+	}
+	if got, err := db.GetKey("key-3"); err != nil || len(got) != 1024 {
+		t.Fatalf("expected key-3 to survive maintenance, got %d bytes (%v)", len(got), err)
+	}
+}
+
+func TestBadgerMaintenance_OtherEngines(t *testing.T) {
+	db, err := Open("", Options{Engine: EngineMemory})
+	if err != nil {
+		t.Fatalf("failed to open memory database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.RunValueLogGC(0); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported from RunValueLogGC, got %v", err)
+	}
+	if err := db.Flatten(0); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported from Flatten, got %v", err)
+	}
+	if _, _, ok := db.DiskUsage(); ok {
+		t.Fatal("expected no disk usage for the memory engine")
+	}
+}
+
 func TestSetAndGetKey(t *testing.T) {
 	db := setupTestDB(t)
 
-	err := db.SetKey("user:1", "Alice")
+	err := db.SetKey("user:1", []byte("Alice"), 0)
 	if err != nil {
 		t.Fatalf("SetKey failed: %v", err)
 	}
@@ -68,8 +190,8 @@ func TestGetKey_NotFound(t *testing.T) {
 func TestSetKey_Overwrite(t *testing.T) {
 	db := setupTestDB(t)
 
-	db.SetKey("key", "value1")
-	db.SetKey("key", "value2")
+	db.SetKey("key", []byte("value1"), 0)
+	db.SetKey("key", []byte("value2"), 0)
 
 	val, err := db.GetKey("key")
 	if err != nil {
@@ -83,7 +205,7 @@ func TestSetKey_Overwrite(t *testing.T) {
 func TestDeleteKey(t *testing.T) {
 	db := setupTestDB(t)
 
-	db.SetKey("key", "value")
+	db.SetKey("key", []byte("value"), 0)
 
 	err := db.DeleteKey("key")
 	if err != nil {
@@ -120,7 +242,7 @@ func TestIsHealthy(t *testing.T) {
 
 func TestCleanup(t *testing.T) {
 	dir, _ := os.MkdirTemp("", "meerkat-cleanup-*")
-	db, _ := NewDatabase(dir)
+	db, _ := Open(dir, Options{Engine: testEngine})
 
 	err := db.Cleanup()
 	if err != nil {
@@ -144,7 +266,7 @@ func TestMultipleKeys(t *testing.T) {
 	}
 
 	for k, v := range keys {
-		if err := db.SetKey(k, v); err != nil {
+		if err := db.SetKey(k, []byte(v), 0); err != nil {
 			t.Fatalf("SetKey(%s) failed: %v", k, err)
 		}
 	}
@@ -163,7 +285,7 @@ func TestMultipleKeys(t *testing.T) {
 func TestEmptyKeyAndValue(t *testing.T) {
 	db := setupTestDB(t)
 
-	err := db.SetKey("empty-val", "")
+	err := db.SetKey("empty-val", []byte(""), 0)
 	if err != nil {
 		t.Fatalf("SetKey with empty value failed: %v", err)
 	}
@@ -199,7 +321,7 @@ func TestGetAllKeys(t *testing.T) {
 	}
 
 	for k, v := range expected {
-		db.SetKey(k, v)
+		db.SetKey(k, []byte(v), 0)
 	}
 
 	pairs, err := db.GetAllKeys()
@@ -215,7 +337,7 @@ func TestGetAllKeys(t *testing.T) {
 		if !ok {
 			t.Fatalf("unexpected key %q in GetAllKeys result", p.Key)
 		}
-		if p.Value != expectedVal {
+		if string(p.Value) != expectedVal {
 			t.Fatalf("GetAllKeys: key %q: expected %q, got %q", p.Key, expectedVal, p.Value)
 		}
 	}
@@ -224,9 +346,9 @@ func TestGetAllKeys(t *testing.T) {
 func TestGetAllKeys_AfterDelete(t *testing.T) {
 	db := setupTestDB(t)
 
-	db.SetKey("a", "1")
-	db.SetKey("b", "2")
-	db.SetKey("c", "3")
+	db.SetKey("a", []byte("1"), 0)
+	db.SetKey("b", []byte("2"), 0)
+	db.SetKey("c", []byte("3"), 0)
 	db.DeleteKey("b")
 
 	pairs, err := db.GetAllKeys()
@@ -252,7 +374,7 @@ func TestLargeValue(t *testing.T) {
 		largeVal[i] = byte(i % 256)
 	}
 
-	err := db.SetKey("large", string(largeVal))
+	err := db.SetKey("large", largeVal, 0)
 	if err != nil {
 		t.Fatalf("SetKey with large value failed: %v", err)
 	}
@@ -265,3 +387,413 @@ func TestLargeValue(t *testing.T) {
 		t.Fatalf("expected %d bytes, got %d", len(largeVal), len(val))
 	}
 }
+
+func TestBinaryValue(t *testing.T) {
+	db := setupTestDB(t)
+
+	binary := []byte{0x00, 0xff, 0xfe, '\n', 0x00, 0x80}
+	if err := db.SetKey("bin", binary, 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+
+	val, err := db.GetKey("bin")
+	if err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	if !bytes.Equal(val, binary) {
+		t.Fatalf("expected %x, got %x", binary, val)
+	}
+
+	if _, err := db.DeleteKeyIfValue("bin", binary); err != nil {
+		t.Fatalf("DeleteKeyIfValue with binary value failed: %v", err)
+	}
+}
+
+func TestSetKey_WithTTL(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.SetKey("session", []byte("token"), time.Hour); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+
+	ttl, err := db.GetTTL("session")
+	if err != nil {
+		t.Fatalf("GetTTL failed: %v", err)
+	}
+	if ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("expected TTL close to 1h, got %v", ttl)
+	}
+}
+
+func TestGetTTL_NoExpiry(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("key", []byte("value"), 0)
+
+	ttl, err := db.GetTTL("key")
+	if err != nil {
+		t.Fatalf("GetTTL failed: %v", err)
+	}
+	if ttl != 0 {
+		t.Fatalf("expected no TTL, got %v", ttl)
+	}
+}
+
+func TestGetTTL_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.GetTTL("nonexistent"); err == nil {
+		t.Fatal("expected error for non-existent key")
+	}
+}
+
+func TestSetKey_Expires(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for a key to expire")
+	}
+	db := setupTestDB(t)
+
+	db.SetKey("short-lived", []byte("value"), time.Second)
+	time.Sleep(2 * time.Second)
+
+	if _, err := db.GetKey("short-lived"); err == nil {
+		t.Fatal("expected key to have expired")
+	}
+}
+
+func TestGetAllKeys_CarriesTTL(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("expiring", []byte("1"), time.Hour)
+	db.SetKey("permanent", []byte("2"), 0)
+
+	pairs, err := db.GetAllKeys()
+	if err != nil {
+		t.Fatalf("GetAllKeys failed: %v", err)
+	}
+
+	for _, p := range pairs {
+		switch p.Key {
+		case "expiring":
+			if p.TTL <= 59*time.Minute {
+				t.Fatalf("expected remaining TTL close to 1h, got %v", p.TTL)
+			}
+		case "permanent":
+			if p.TTL != 0 {
+				t.Fatalf("expected no TTL, got %v", p.TTL)
+			}
+		}
+	}
+}
+
+func TestSetKeyIfAbsent(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := db.SetKeyIfAbsent("lock", []byte("owner-1"), 0); err != nil {
+		t.Fatalf("SetKeyIfAbsent on missing key failed: %v", err)
+	}
+
+	_, err := db.SetKeyIfAbsent("lock", []byte("owner-2"), 0)
+	if !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("expected ErrConditionFailed, got %v", err)
+	}
+
+	val, _ := db.GetKey("lock")
+	if string(val) != "owner-1" {
+		t.Fatalf("expected 'owner-1', got '%s'", string(val))
+	}
+}
+
+func TestSetKeyIfVersion(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("counter", []byte("1"), 0)
+	_, version, err := db.GetKeyVersion("counter")
+	if err != nil {
+		t.Fatalf("GetKeyVersion failed: %v", err)
+	}
+
+	if _, err := db.SetKeyIfVersion("counter", []byte("2"), version, 0); err != nil {
+		t.Fatalf("SetKeyIfVersion with current version failed: %v", err)
+	}
+
+	_, err = db.SetKeyIfVersion("counter", []byte("3"), version, 0)
+	if !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("expected ErrConditionFailed for stale version, got %v", err)
+	}
+
+	val, _ := db.GetKey("counter")
+	if string(val) != "2" {
+		t.Fatalf("expected '2', got '%s'", string(val))
+	}
+}
+
+func TestSetKeyIfVersion_Missing(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := db.SetKeyIfVersion("missing", []byte("value"), 1, 0)
+	if !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("expected ErrConditionFailed, got %v", err)
+	}
+}
+
+func TestDeleteKeyIfValue(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("lock", []byte("owner-1"), 0)
+
+	_, err := db.DeleteKeyIfValue("lock", []byte("owner-2"))
+	if !errors.Is(err, ErrConditionFailed) {
+		t.Fatalf("expected ErrConditionFailed, got %v", err)
+	}
+
+	if _, err := db.DeleteKeyIfValue("lock", []byte("owner-1")); err != nil {
+		t.Fatalf("DeleteKeyIfValue with matching value failed: %v", err)
+	}
+	if _, err := db.GetKey("lock"); err == nil {
+		t.Fatal("expected key to be deleted")
+	}
+}
+
+func TestIncrementKey(t *testing.T) {
+	db := setupTestDB(t)
+
+	val, _, _, err := db.IncrementKey("hits", 5)
+	if err != nil {
+		t.Fatalf("IncrementKey on missing key failed: %v", err)
+	}
+	if val != 5 {
+		t.Fatalf("expected 5, got %d", val)
+	}
+
+	val, _, _, err = db.IncrementKey("hits", -7)
+	if err != nil {
+		t.Fatalf("IncrementKey failed: %v", err)
+	}
+	if val != -2 {
+		t.Fatalf("expected -2, got %d", val)
+	}
+
+	stored, _ := db.GetKey("hits")
+	if string(stored) != "-2" {
+		t.Fatalf("expected stored '-2', got '%s'", string(stored))
+	}
+}
+
+func TestIncrementKey_NotInteger(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("name", []byte("Alice"), 0)
+
+	if _, _, _, err := db.IncrementKey("name", 1); !errors.Is(err, ErrNotInteger) {
+		t.Fatalf("expected ErrNotInteger, got %v", err)
+	}
+}
+
+func TestIncrementKey_Overflow(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("big", []byte("9223372036854775807"), 0)
+
+	if _, _, _, err := db.IncrementKey("big", 1); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestIncrementKey_KeepsTTL(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("rate", []byte("1"), time.Hour)
+
+	_, ttl, _, err := db.IncrementKey("rate", 1)
+	if err != nil {
+		t.Fatalf("IncrementKey failed: %v", err)
+	}
+	if ttl <= 59*time.Minute {
+		t.Fatalf("expected TTL close to 1h to be kept, got %v", ttl)
+	}
+}
+
+func TestIncrementKey_Concurrent(t *testing.T) {
+	db := setupTestDB(t)
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if _, _, _, err := db.IncrementKey("counter", 1); err != nil {
+					t.Errorf("IncrementKey failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, _ := db.GetKey("counter")
+	if string(val) != "400" {
+		t.Fatalf("expected '400', got '%s'", string(val))
+	}
+}
+
+func TestSetKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	errs := db.SetKeys([]KeyValuePair{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2"), TTL: time.Hour},
+	})
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("SetKeys failed: %v", err)
+	}
+
+	values, err := db.GetKeys([]string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatalf("GetKeys failed: %v", err)
+	}
+	if len(values) != 2 || string(values["a"]) != "1" || string(values["b"]) != "2" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	if _, ok := values["missing"]; ok {
+		t.Fatal("expected missing key to be left out")
+	}
+
+	ttl, _ := db.GetTTL("b")
+	if ttl <= 59*time.Minute {
+		t.Fatalf("expected TTL close to 1h, got %v", ttl)
+	}
+}
+
+func TestSetKeys_Large(t *testing.T) {
+	db := setupTestDB(t)
+
+	// More than Badger commits in one transaction.
+	pairs := make([]KeyValuePair, 200000)
+	for i := range pairs {
+		pairs[i] = KeyValuePair{Key: fmt.Sprintf("key%06d", i), Value: []byte("v")}
+	}
+	if err := errors.Join(db.SetKeys(pairs)...); err != nil {
+		t.Fatalf("SetKeys failed: %v", err)
+	}
+	for _, i := range []int{0, len(pairs) / 2, len(pairs) - 1} {
+		if _, err := db.GetKey(pairs[i].Key); err != nil {
+			t.Fatalf("expected %s to be written, got %v", pairs[i].Key, err)
+		}
+	}
+}
+
+func TestSetKeys_PerKeyErrors(t *testing.T) {
+	db := setupTestDB(t)
+
+	errs := db.SetKeys([]KeyValuePair{{Key: "a", Value: []byte("1")}, {Key: lockPrefix + "b", Value: []byte("2")}, {Key: "c", Value: []byte("3")}})
+	if len(errs) != 3 || errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Fatalf("expected only the reserved key to fail, got %v", errs)
+	}
+	values, _ := db.GetKeys([]string{"a", "c"})
+	if len(values) != 2 {
+		t.Fatalf("expected the other keys to be written, got %v", values)
+	}
+}
+
+func TestDeleteKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("a", []byte("1"), 0)
+	db.SetKey("b", []byte("2"), 0)
+	db.SetKey("c", []byte("3"), 0)
+
+	if err := errors.Join(db.DeleteKeys([]string{"a", "b", "missing"})...); err != nil {
+		t.Fatalf("DeleteKeys failed: %v", err)
+	}
+
+	values, _ := db.GetKeys([]string{"a", "b", "c"})
+	if len(values) != 1 || string(values["c"]) != "3" {
+		t.Fatalf("expected only 'c' to remain, got %v", values)
+	}
+}
+
+func TestScanKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, k := range []string{"user:3", "user:1", "order:1", "user:2", "user:4"} {
+		db.SetKey(k, []byte("v"), 0)
+	}
+
+	pairs, more, err := db.ScanKeys("user:", "", "", 2, 0)
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+	if len(pairs) != 2 || pairs[0].Key != "user:1" || pairs[1].Key != "user:2" || !more {
+		t.Fatalf("unexpected first page: %v (more %v)", pairs, more)
+	}
+
+	pairs, more, err = db.ScanKeys("user:", "user:3", "", 2, 0)
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+	if len(pairs) != 2 || pairs[0].Key != "user:3" || pairs[1].Key != "user:4" || more {
+		t.Fatalf("unexpected second page: %v (more %v)", pairs, more)
+	}
+}
+
+func TestScanKeys_Range(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		db.SetKey(k, []byte("v"), 0)
+	}
+
+	pairs, _, err := db.ScanKeys("", "b", "d", 0, 0)
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+	if len(pairs) != 2 || pairs[0].Key != "b" || pairs[1].Key != "c" {
+		t.Fatalf("expected [b c], got %v", pairs)
+	}
+}
+
+func TestScanKeys_MaxBytes(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, k := range []string{"a", "b", "c"} {
+		db.SetKey(k, make([]byte, 100), 0)
+	}
+
+	pairs, more, err := db.ScanKeys("", "", "", 0, 250)
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+	if len(pairs) != 2 || pairs[1].Key != "b" || !more {
+		t.Fatalf("expected [a b] and more, got %v (more %v)", pairs, more)
+	}
+
+	// A key bigger than the budget still comes back on its own.
+	pairs, more, err = db.ScanKeys("", "c", "", 0, 50)
+	if err != nil || len(pairs) != 1 || pairs[0].Key != "c" || more {
+		t.Fatalf("expected [c] alone, got %v (more %v, %v)", pairs, more, err)
+	}
+}
+
+func TestRangeStats(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		db.SetKey(k, []byte("value"), 0)
+	}
+
+	keys, bytes, median, err := db.RangeStats("b", "e")
+	if err != nil {
+		t.Fatalf("RangeStats failed: %v", err)
+	}
+	if keys != 3 || median != "c" || bytes <= 0 {
+		t.Fatalf("expected 3 keys with median c, got %d keys, %d bytes, median %q", keys, bytes, median)
+	}
+
+	keys, _, median, _ = db.RangeStats("x", "")
+	if keys != 0 || median != "" {
+		t.Fatalf("expected empty range, got %d keys, median %q", keys, median)
+	}
+}
@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Storage engines a Database can run on, by the name db_server's engine
+// setting uses.
+const (
+	EngineBadger = "badger"
+	EngineMemory = "memory"
+	EngineBolt   = "bolt"
+)
+
+var (
+	// ErrKeyNotFound is returned by Tx.Get for keys that do not exist,
+	// have been deleted or have expired.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrConflict is returned by Engine.Update when a key the transaction
+	// read was written by another transaction that committed first.
+	ErrConflict = errors.New("transaction conflict")
+	// ErrTxnTooBig is returned by Engine.Update when a transaction writes
+	// more than the engine can commit at once.
+	ErrTxnTooBig = errors.New("transaction too big")
+)
+
+// Engine is the key-value store under a Database. Every write made by one
+// Update or one commit of a Batch is applied atomically and gets the same
+// version, a number that grows with every commit and is local to the engine.
+// Keys with an expiry are hidden once their expiry second has passed.
+type Engine interface {
+	// View runs fn against a consistent snapshot.
+	View(fn func(tx Tx) error) error
+	// Update runs fn in a read-write transaction whose writes are applied
+	// when fn returns nil and dropped when it fails.
+	Update(fn func(tx Tx) error) error
+	// Batch applies writes without reading anything, so it cannot
+	// conflict, in as many commits as the engine needs; a batch is not
+	// atomic. It returns one error per write, nil for each write that was
+	// applied. A write whose commit failed is reported failed even if an
+	// earlier part of the commit reached the disk.
+	Batch(writes []Write) []error
+
+	// Snapshot writes every entry written after version since to w, in
+	// the format ReadBackup reads, and returns the highest version written,
+	// or zero if there was none. Entries deleted since are written as
+	// deletes unless since is zero.
+	Snapshot(w io.Writer, since uint64) (uint64, error)
+	// Load writes the entries of a snapshot, keeping their versions.
+	Load(r io.Reader) error
+
+	// Subscribe calls fn with every change committed from now on, in
+	// version order, until ctx is done.
+	Subscribe(ctx context.Context, fn func([]Event)) error
+	// Changes calls fn with the latest change of every key starting with
+	// prefix made after version since, in key order, and returns the
+	// version of the snapshot it read. Deletes may be reported only for a
+	// while after they happen.
+	Changes(prefix []byte, since uint64, fn func(Event) error) (uint64, error)
+
+	Healthy() bool
+	Close() error
+}
+
+// Tx is an engine transaction. Reads through Get see the transaction's own
+// writes; iterators see the snapshot it started from.
+type Tx interface {
+	// Get returns the item stored under key, or ErrKeyNotFound.
+	Get(key []byte) (Item, error)
+	NewIterator(opts IterOptions) Iterator
+	// Set stores value under key. A non-zero expiresAt, in Unix seconds,
+	// is when the key expires.
+	Set(key, value []byte, expiresAt uint64) error
+	Delete(key []byte) error
+	// Version is the version of the snapshot the transaction reads.
+	Version() uint64
+}
+
+// Item is one key as read from an engine. Its slices stay valid after the
+// transaction ends.
+type Item struct {
+	Key       []byte
+	Value     []byte
+	Version   uint64
+	ExpiresAt uint64
+	// Size is roughly how many bytes the key takes in the engine.
+	Size int64
+}
+
+// IterOptions selects the keys an iterator visits. With KeysOnly set,
+// items are returned without their values.
+type IterOptions struct {
+	Prefix   []byte
+	KeysOnly bool
+}
+
+// Iterator walks keys in order, skipping deleted and expired ones. It must
+// be closed before its transaction ends.
+type Iterator interface {
+	// Seek moves to the first key at or after key.
+	Seek(key []byte)
+	Valid() bool
+	Next()
+	Item() (Item, error)
+	Close()
+}
+
+// Write is one write of a batch: a set of Value, or a delete.
+type Write struct {
+	Key       []byte
+	Value     []byte
+	ExpiresAt uint64
+	Delete    bool
+}
+
+// Eviction policies of the memory engine.
+const (
+	EvictNone = "none"
+	EvictLRU  = "lru"
+	EvictLFU  = "lfu"
+)
+
+// Options configures a Database.
+type Options struct {
+	// Engine is one of EngineBadger, EngineMemory or EngineBolt; empty
+	// selects Badger.
+	Engine string
+
+	// MaxMemory bounds the bytes the memory engine holds; zero means no
+	// limit. Eviction decides what happens when a write would pass it:
+	// EvictLRU drops the least recently used keys, EvictLFU the least
+	// frequently used, and EvictNone, the default, fails the write with
+	// ErrMemoryFull.
+	MaxMemory int64
+	Eviction  string
+
+	// Badger tunes the Badger engine.
+	Badger BadgerOptions
+}
+
+// Validate reports the first setting of o that an engine would reject.
+func (o Options) Validate() error {
+	switch o.Engine {
+	case "", EngineBadger, EngineMemory, EngineBolt:
+	default:
+		return fmt.Errorf("unknown storage engine %q, want %s, %s or %s", o.Engine, EngineBadger, EngineMemory, EngineBolt)
+	}
+	switch o.Eviction {
+	case "", EvictNone, EvictLRU, EvictLFU:
+	default:
+		return fmt.Errorf("unknown eviction policy %q, want %s, %s or %s", o.Eviction, EvictNone, EvictLRU, EvictLFU)
+	}
+	if o.MaxMemory < 0 {
+		return fmt.Errorf("memory limit must not be negative, got %d", o.MaxMemory)
+	}
+	return o.Badger.validate()
+}
+
+// OpenEngine opens the engine opts selects, keeping its data under path.
+func OpenEngine(path string, opts Options) (Engine, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	switch opts.Engine {
+	case EngineMemory:
+		return openMemory(opts)
+	case EngineBolt:
+		return openBolt(path)
+	default:
+		return openBadger(path, opts.Badger)
+	}
+}
+
+// expiresAt converts a TTL into the Unix second a key written now expires
+// at, the way Badger does.
+func expiresAt(ttl time.Duration) uint64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return uint64(time.Now().Add(ttl).Unix())
+}
+
+func expired(expiresAt uint64, now time.Time) bool {
+	return expiresAt != 0 && expiresAt <= uint64(now.Unix())
+}
+
+// subscribers fans committed changes out to Subscribe callers, for engines
+// that publish their own commits.
+type subscribers struct {
+	mu  sync.Mutex
+	fns map[*func([]Event)]struct{}
+}
+
+func (s *subscribers) subscribe(ctx context.Context, fn func([]Event)) error {
+	s.mu.Lock()
+	if s.fns == nil {
+		s.fns = make(map[*func([]Event)]struct{})
+	}
+	s.fns[&fn] = struct{}{}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	s.mu.Lock()
+	delete(s.fns, &fn)
+	s.mu.Unlock()
+	return nil
+}
+
+// publish must be called in commit order.
+func (s *subscribers) publish(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for fn := range s.fns {
+		(*fn)(events)
+	}
+}
+
+// changeEvent builds the event for a committed write, or for the latest
+// change of a key read back by Changes.
+func changeEvent(key, value []byte, version, expiresAt uint64, deleted bool) Event {
+	e := Event{Key: string(key), Version: version}
+	if deleted {
+		e.Deleted = true
+	} else {
+		e.Value = value
+		e.TTL = remainingTTL(expiresAt)
+	}
+	return e
+}
+
+const (
+	// tombstoneTTL is how long the memory and bolt engines remember a
+	// delete, so Changes and incremental snapshots can report it.
+	tombstoneTTL = 24 * time.Hour
+	// purgeInterval is how often those engines drop expired keys and
+	// tombstones.
+	purgeInterval = time.Minute
+	// snapshotListSize is how many entries they write per list of a
+	// snapshot.
+	snapshotListSize = 1000
+	// batchTxnWrites is how many writes of a Batch they commit together.
+	batchTxnWrites = 1000
+)
+
+// batchWrites is Batch for the memory and bolt engines. It applies writes in
+// transactions of batchTxnWrites, and retries the writes of a transaction
+// that fails one at a time, so each gets its own outcome.
+func batchWrites(update func(fn func(tx Tx) error) error, writes []Write) []error {
+	errs := make([]error, len(writes))
+	for start := 0; start < len(writes); start += batchTxnWrites {
+		part := writes[start:min(start+batchTxnWrites, len(writes))]
+		if err := update(func(tx Tx) error { return applyWrites(tx, part) }); err == nil {
+			continue
+		}
+		for i := range part {
+			errs[start+i] = update(func(tx Tx) error { return applyWrites(tx, part[i:i+1]) })
+		}
+	}
+	return errs
+}
+
+func applyWrites(tx Tx, writes []Write) error {
+	for _, w := range writes {
+		var err error
+		if w.Delete {
+			err = tx.Delete(w.Key)
+		} else {
+			err = tx.Set(w.Key, w.Value, w.ExpiresAt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write key '%s': %w", w.Key, err)
+		}
+	}
+	return nil
+}
+
+// record is how the memory and bolt engines keep a key: its latest value,
+// or a tombstone for a delete. A tombstone expires after tombstoneTTL.
+type record struct {
+	value     []byte
+	version   uint64
+	expiresAt uint64
+	deleted   bool
+}
+
+func tombstone(version uint64) record {
+	return record{version: version, expiresAt: expiresAt(tombstoneTTL), deleted: true}
+}
+
+// hidden reports whether reads skip the record.
+func (r record) hidden(now time.Time) bool {
+	return r.deleted || expired(r.expiresAt, now)
+}
+
+func (r record) item(key []byte, withValue bool) Item {
+	it := Item{Key: key, Version: r.version, ExpiresAt: r.expiresAt, Size: int64(len(key) + len(r.value))}
+	if withValue {
+		it.Value = r.value
+	}
+	return it
+}
+
+func (r record) event(key []byte, now time.Time) Event {
+	return changeEvent(key, r.value, r.version, r.expiresAt, r.hidden(now))
+}
+
+// snapshotWriter writes the records of the memory and bolt engines in the
+// format Badger's backups use, following Badger's rules for what to leave
+// out.
+type snapshotWriter struct {
+	w       io.Writer
+	since   uint64
+	now     time.Time
+	entries []BackupEntry
+	version uint64
+}
+
+func newSnapshotWriter(w io.Writer, since uint64) *snapshotWriter {
+	return &snapshotWriter{w: w, since: since, now: time.Now()}
+}
+
+func (s *snapshotWriter) add(key []byte, r record) error {
+	if r.version <= s.since {
+		return nil
+	}
+	// A full snapshot has nothing to delete.
+	if s.since == 0 && r.hidden(s.now) {
+		return nil
+	}
+
+	e := BackupEntry{Key: string(key), Version: r.version, Deleted: r.hidden(s.now)}
+	if !e.Deleted {
+		e.Value = r.value
+		if r.expiresAt != 0 {
+			e.ExpiresAt = time.Unix(int64(r.expiresAt), 0)
+		}
+	}
+	s.entries = append(s.entries, e)
+	s.version = max(s.version, r.version)
+	if len(s.entries) == snapshotListSize {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *snapshotWriter) flush() error {
+	if len(s.entries) == 0 {
+		return nil
+	}
+	err := WriteBackup(s.w, s.entries)
+	s.entries = s.entries[:0]
+	return err
+}
@@ -2,7 +2,12 @@ module github.com/arbhalerao/meerkat/db
 
 go 1.22.0
 
-require github.com/dgraph-io/badger/v4 v4.5.1
+require (
+	github.com/dgraph-io/badger/v4 v4.5.1
+	github.com/google/btree v1.1.3
+	go.etcd.io/bbolt v1.3.11
+	google.golang.org/protobuf v1.36.3
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -15,5 +20,4 @@ require (
 	go.opencensus.io v0.24.0 // indirect
 	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	google.golang.org/protobuf v1.36.3 // indirect
 )
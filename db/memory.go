@@ -0,0 +1,477 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4/pb"
+	"github.com/google/btree"
+)
+
+var errClosed = errors.New("engine is closed")
+
+// ErrMemoryFull is returned by writes to the memory engine that would take
+// it past its memory limit when it is not allowed to evict.
+var ErrMemoryFull = errors.New("memory limit reached")
+
+const (
+	// entryOverhead is roughly what the memory engine spends on a key
+	// besides its key and value bytes, counted against the limit.
+	entryOverhead = 64
+	// evictionSamples is how many keys are compared to pick each one to
+	// evict. Like Redis, the engine approximates LRU and LFU by sampling
+	// rather than keeping every key in order.
+	evictionSamples = 8
+	// lfuDecay is how long a key must go unused for its use count to
+	// halve, so keys that were popular once do not stay forever.
+	lfuDecay = time.Minute
+	// keysDegree is the degree of the B-tree holding the keys in order.
+	keysDegree = 32
+)
+
+// MemoryStats describes what the memory engine holds. Keys counts
+// tombstones of recent deletes too.
+type MemoryStats struct {
+	Keys      int
+	Bytes     int64
+	MaxBytes  int64
+	Evictions uint64
+}
+
+// memoryEngine keeps every key in memory and nothing on disk. Writers take
+// the lock for the whole transaction, so Update never conflicts, and a View
+// holds off writers until it returns.
+type memoryEngine struct {
+	mu      sync.RWMutex
+	records map[string]*memoryEntry
+	// keys holds the keys of records in order, tombstones included.
+	keys    *btree.BTreeG[string]
+	version uint64
+	closed  bool
+
+	// used is the size of all records, limit the most it may reach.
+	used      int64
+	limit     int64
+	eviction  string
+	evictions atomic.Uint64
+
+	subs subscribers
+	stop chan struct{}
+	done chan struct{}
+}
+
+// memoryEntry is a record with how it has been used, for eviction. Reads
+// update the use under the read lock, so it is kept in atomics.
+type memoryEntry struct {
+	record
+	lastUsed atomic.Int64
+	uses     atomic.Uint32
+}
+
+func (m *memoryEntry) touch(now time.Time) {
+	m.lastUsed.Store(now.UnixNano())
+	if m.uses.Load() < math.MaxUint32 {
+		m.uses.Add(1)
+	}
+}
+
+func entrySize(key string, r record) int64 {
+	return int64(len(key) + len(r.value) + entryOverhead)
+}
+
+func openMemory(opts Options) (*memoryEngine, error) {
+	eviction := opts.Eviction
+	if eviction == "" {
+		eviction = EvictNone
+	}
+
+	e := &memoryEngine{
+		records:  make(map[string]*memoryEntry),
+		keys:     btree.NewOrderedG[string](keysDegree),
+		limit:    opts.MaxMemory,
+		eviction: eviction,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go e.purgeLoop()
+	return e, nil
+}
+
+func (e *memoryEngine) View(fn func(tx Tx) error) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return errClosed
+	}
+	return fn(&memoryTx{e: e, now: time.Now()})
+}
+
+func (e *memoryEngine) Update(fn func(tx Tx) error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errClosed
+	}
+	tx := &memoryTx{e: e, now: time.Now(), pending: make(map[string]record)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := e.checkLimit(tx); err != nil {
+		return err
+	}
+	e.commit(tx)
+	e.evict(tx.pending, tx.now)
+	return nil
+}
+
+// checkLimit fails tx with ErrMemoryFull if its writes cannot fit: with no
+// eviction, if they would take the engine past its limit, and otherwise if
+// a single one is larger than the limit.
+func (e *memoryEngine) checkLimit(tx *memoryTx) error {
+	if e.limit == 0 {
+		return nil
+	}
+	var growth int64
+	for key, r := range tx.pending {
+		size := entrySize(key, r)
+		if size > e.limit {
+			return fmt.Errorf("%w: key '%s' takes %d bytes, more than the %d byte limit", ErrMemoryFull, key, size, e.limit)
+		}
+		growth += size
+		if old, ok := e.records[key]; ok {
+			growth -= entrySize(key, old.record)
+		}
+	}
+	if e.eviction == EvictNone && growth > 0 && e.used+growth > e.limit {
+		return fmt.Errorf("%w: %d of %d bytes in use", ErrMemoryFull, e.used, e.limit)
+	}
+	return nil
+}
+
+func (e *memoryEngine) Batch(writes []Write) []error {
+	return batchWrites(e.Update, writes)
+}
+
+// commit applies the writes of tx under one new version and publishes them.
+// The caller holds the write lock.
+func (e *memoryEngine) commit(tx *memoryTx) {
+	if len(tx.order) == 0 {
+		return
+	}
+	e.version++
+	events := make([]Event, 0, len(tx.order))
+	for _, key := range tx.order {
+		r := tx.pending[key]
+		r.version = e.version
+		e.put(key, r)
+		events = append(events, r.event([]byte(key), tx.now))
+	}
+	e.subs.publish(events)
+}
+
+func (e *memoryEngine) put(key string, r record) {
+	ent, ok := e.records[key]
+	if !ok {
+		e.keys.ReplaceOrInsert(key)
+		ent = &memoryEntry{}
+		e.records[key] = ent
+	} else {
+		e.used -= entrySize(key, ent.record)
+	}
+	ent.record = r
+	ent.touch(time.Now())
+	e.used += entrySize(key, r)
+}
+
+func (e *memoryEngine) remove(key string) {
+	ent, ok := e.records[key]
+	if !ok {
+		return
+	}
+	e.used -= entrySize(key, ent.record)
+	delete(e.records, key)
+	e.keys.Delete(key)
+}
+
+// evict drops keys until the engine is back within its limit, sparing the
+// keys just written. Tombstones and expired keys go first. Like expiry,
+// eviction is not reported to the change feed. The caller holds the write
+// lock.
+func (e *memoryEngine) evict(spare map[string]record, now time.Time) {
+	if e.limit == 0 || e.eviction == EvictNone {
+		return
+	}
+	for e.used > e.limit {
+		var victim string
+		var lowest int64
+		sampled := 0
+		for key, ent := range e.records {
+			if _, ok := spare[key]; ok || isInternalKey([]byte(key)) {
+				continue
+			}
+			if score := e.score(ent, now); victim == "" || score < lowest {
+				victim, lowest = key, score
+			}
+			if sampled++; sampled == evictionSamples {
+				break
+			}
+		}
+		if victim == "" {
+			return
+		}
+		e.remove(victim)
+		e.evictions.Add(1)
+	}
+}
+
+// score ranks a key for eviction; the lowest goes first.
+func (e *memoryEngine) score(ent *memoryEntry, now time.Time) int64 {
+	if ent.hidden(now) {
+		return math.MinInt64
+	}
+	if e.eviction == EvictLRU {
+		return ent.lastUsed.Load()
+	}
+	idle := now.Sub(time.Unix(0, ent.lastUsed.Load())) / lfuDecay
+	return int64(ent.uses.Load() >> min(uint64(idle), 32))
+}
+
+func (e *memoryEngine) stats() MemoryStats {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return MemoryStats{Keys: len(e.records), Bytes: e.used, MaxBytes: e.limit, Evictions: e.evictions.Load()}
+}
+
+// MemoryStats reports the usage of the memory engine; ok is false when the
+// database runs on another engine.
+func (d *Database) MemoryStats() (stats MemoryStats, ok bool) {
+	m, ok := d.engine.(*memoryEngine)
+	if !ok {
+		return MemoryStats{}, false
+	}
+	return m.stats(), true
+}
+
+func (e *memoryEngine) Snapshot(w io.Writer, since uint64) (uint64, error) {
+	// Copy the entries out so writers are not held up by a slow reader.
+	e.mu.RLock()
+	if e.closed {
+		e.mu.RUnlock()
+		return 0, errClosed
+	}
+	var keys []string
+	var records []record
+	e.keys.Ascend(func(key string) bool {
+		if r := e.records[key].record; r.version > since {
+			keys = append(keys, key)
+			records = append(records, r)
+		}
+		return true
+	})
+	e.mu.RUnlock()
+
+	sw := newSnapshotWriter(w, since)
+	for i, key := range keys {
+		if err := sw.add([]byte(key), records[i]); err != nil {
+			return 0, err
+		}
+	}
+	return sw.version, sw.flush()
+}
+
+func (e *memoryEngine) Load(r io.Reader) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errClosed
+	}
+	err := readBackupKVs(r, func(kv *pb.KV) error {
+		rec := record{value: kv.Value, version: kv.Version, expiresAt: kv.ExpiresAt}
+		if isBackupDelete(kv) {
+			rec = tombstone(kv.Version)
+		}
+		e.put(string(kv.Key), rec)
+		e.version = max(e.version, kv.Version)
+		return nil
+	})
+	e.evict(nil, time.Now())
+	return err
+}
+
+func (e *memoryEngine) Subscribe(ctx context.Context, fn func([]Event)) error {
+	return e.subs.subscribe(ctx, fn)
+}
+
+func (e *memoryEngine) Changes(prefix []byte, since uint64, fn func(Event) error) (uint64, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return 0, errClosed
+	}
+
+	now := time.Now()
+	var err error
+	e.keys.AscendGreaterOrEqual(string(prefix), func(key string) bool {
+		if !strings.HasPrefix(key, string(prefix)) {
+			return false
+		}
+		if r := e.records[key].record; r.version > since {
+			err = fn(r.event([]byte(key), now))
+		}
+		return err == nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return e.version, nil
+}
+
+func (e *memoryEngine) Healthy() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return !e.closed
+}
+
+func (e *memoryEngine) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	e.mu.Unlock()
+
+	close(e.stop)
+	<-e.done
+	return nil
+}
+
+func (e *memoryEngine) purgeLoop() {
+	defer close(e.done)
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.purge(time.Now())
+		}
+	}
+}
+
+// purge drops expired keys and tombstones.
+func (e *memoryEngine) purge(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, ent := range e.records {
+		if expired(ent.expiresAt, now) {
+			e.used -= entrySize(key, ent.record)
+			delete(e.records, key)
+			e.keys.Delete(key)
+		}
+	}
+}
+
+type memoryTx struct {
+	e   *memoryEngine
+	now time.Time
+	// pending holds the writes of an Update, applied in order on commit.
+	pending map[string]record
+	order   []string
+}
+
+func (t *memoryTx) Get(key []byte) (Item, error) {
+	if r, ok := t.pending[string(key)]; ok {
+		if r.hidden(t.now) {
+			return Item{}, ErrKeyNotFound
+		}
+		return r.item(bytes.Clone(key), true), nil
+	}
+
+	ent, ok := t.e.records[string(key)]
+	if !ok || ent.hidden(t.now) {
+		return Item{}, ErrKeyNotFound
+	}
+	ent.touch(t.now)
+	return ent.item(bytes.Clone(key), true), nil
+}
+
+func (t *memoryTx) NewIterator(opts IterOptions) Iterator {
+	return &memoryIterator{tx: t, prefix: string(opts.Prefix), values: !opts.KeysOnly}
+}
+
+func (t *memoryTx) Set(key, value []byte, expiresAt uint64) error {
+	return t.write(key, record{value: bytes.Clone(value), expiresAt: expiresAt})
+}
+
+func (t *memoryTx) Delete(key []byte) error {
+	return t.write(key, tombstone(0))
+}
+
+func (t *memoryTx) write(key []byte, r record) error {
+	if t.pending == nil {
+		return errors.New("write in a read-only transaction")
+	}
+	if _, ok := t.pending[string(key)]; !ok {
+		t.order = append(t.order, string(key))
+	}
+	t.pending[string(key)] = r
+	return nil
+}
+
+func (t *memoryTx) Version() uint64 {
+	return t.e.version
+}
+
+// memoryIterator walks the keys of a transaction's engine, which no writer
+// changes while the transaction holds its lock.
+type memoryIterator struct {
+	tx     *memoryTx
+	prefix string
+	values bool
+	key    string
+	valid  bool
+}
+
+func (i *memoryIterator) Seek(key []byte) {
+	i.seek(max(string(key), i.prefix))
+}
+
+func (i *memoryIterator) Valid() bool {
+	return i.valid
+}
+
+func (i *memoryIterator) Next() {
+	i.seek(i.key + "\x00")
+}
+
+// seek moves to the first visible key from on that has the prefix.
+func (i *memoryIterator) seek(from string) {
+	i.valid = false
+	i.tx.e.keys.AscendGreaterOrEqual(from, func(key string) bool {
+		if !strings.HasPrefix(key, i.prefix) {
+			return false
+		}
+		if i.tx.e.records[key].hidden(i.tx.now) {
+			return true
+		}
+		i.key, i.valid = key, true
+		return false
+	})
+}
+
+func (i *memoryIterator) Item() (Item, error) {
+	return i.tx.e.records[i.key].item([]byte(i.key), i.values), nil
+}
+
+func (i *memoryIterator) Close() {}
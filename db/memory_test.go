@@ -0,0 +1,104 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func setupMemoryDB(t *testing.T, maxMemory int64, eviction string) *Database {
+	t.Helper()
+	db, err := Open("", Options{Engine: EngineMemory, MaxMemory: maxMemory, Eviction: eviction})
+	if err != nil {
+		t.Fatalf("failed to open memory database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fill writes n keys of about 100 bytes each, key-0 first.
+func fill(t *testing.T, db *Database, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := db.SetKey(fmt.Sprintf("key-%d", i), make([]byte, 100-entryOverhead), 0); err != nil {
+			t.Fatalf("SetKey failed: %v", err)
+		}
+	}
+}
+
+func TestMemory_NoEvictionRejectsWrites(t *testing.T) {
+	db := setupMemoryDB(t, 1000, EvictNone)
+	fill(t, db, 9)
+
+	err := db.SetKey("overflow", make([]byte, 200), 0)
+	if !errors.Is(err, ErrMemoryFull) {
+		t.Fatalf("expected ErrMemoryFull, got %v", err)
+	}
+	// Overwriting in place does not grow the engine.
+	if err := db.SetKey("key-0", []byte("small"), 0); err != nil {
+		t.Fatalf("expected an overwrite to fit, got %v", err)
+	}
+	if stats, _ := db.MemoryStats(); stats.Evictions != 0 || stats.Bytes > 1000 {
+		t.Fatalf("expected no evictions within the limit, got %+v", stats)
+	}
+}
+
+func TestMemory_LRUEvictsLeastRecentlyUsed(t *testing.T) {
+	db := setupMemoryDB(t, 1000, EvictLRU)
+	// With fewer keys than evictionSamples the choice is exact.
+	fill(t, db, 5)
+	for _, key := range []string{"key-0", "key-1", "key-3", "key-4"} {
+		db.GetKey(key)
+	}
+
+	if err := db.SetKey("new", make([]byte, 600), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if _, err := db.GetKey("key-2"); err == nil {
+		t.Fatal("expected the least recently used key-2 to be evicted")
+	}
+	if _, err := db.GetKey("new"); err != nil {
+		t.Fatalf("expected the new key to stay, got %v", err)
+	}
+	stats, ok := db.MemoryStats()
+	if !ok || stats.Evictions == 0 || stats.Bytes > stats.MaxBytes {
+		t.Fatalf("expected evictions to bring the engine within its limit, got %+v", stats)
+	}
+}
+
+func TestMemory_LFUEvictsLeastFrequentlyUsed(t *testing.T) {
+	db := setupMemoryDB(t, 500, EvictLFU)
+	fill(t, db, 4)
+	for i := 0; i < 5; i++ {
+		for _, key := range []string{"key-0", "key-2", "key-3"} {
+			db.GetKey(key)
+		}
+	}
+	// key-1 is now the most recently used but the least often.
+	db.GetKey("key-1")
+
+	if err := db.SetKey("new", make([]byte, 100), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if _, err := db.GetKey("key-1"); err == nil {
+		t.Fatal("expected the least frequently used key-1 to be evicted")
+	}
+	for _, key := range []string{"key-0", "key-2", "key-3"} {
+		if _, err := db.GetKey(key); err != nil {
+			t.Fatalf("expected %s to stay, got %v", key, err)
+		}
+	}
+}
+
+func TestMemory_RejectsKeysLargerThanLimit(t *testing.T) {
+	db := setupMemoryDB(t, 100, EvictLRU)
+	if err := db.SetKey("huge", make([]byte, 200), 0); !errors.Is(err, ErrMemoryFull) {
+		t.Fatalf("expected ErrMemoryFull, got %v", err)
+	}
+}
+
+func TestOpen_BadEvictionPolicy(t *testing.T) {
+	if _, err := Open("", Options{Engine: EngineMemory, Eviction: "random"}); err == nil {
+		t.Fatal("expected an unknown eviction policy to be rejected")
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Writes that check the key before changing it, the conditional writes and
+// IncrementKey, run on the key's primary only, and their outcome is copied
+// to the other replicas afterwards, so copies of two such writes can arrive
+// in either order. Each of them returns its order: one more than the version
+// of the snapshot it read, which is higher for every later one of these
+// writes on the same key and never zero. A replica applies a copy only if it
+// is newer than the last copy it applied to the key, whose PrimaryVersion it
+// records under the reserved prefix.
+
+const primaryPrefix = internalPrefix + "primary/"
+
+// deleteRecordTTL is how long the record of a copied delete is kept: far
+// longer than a copy can be in flight, after which a late copy could bring
+// the key back.
+const deleteRecordTTL = time.Hour
+
+// PrimaryVersion places a copy of a write among the writes the key's primary
+// made: by the ring epoch it was routed at, which changes whenever the
+// primary does, then by the order the primary returned.
+type PrimaryVersion struct {
+	Epoch uint64
+	Order uint64
+}
+
+func (v PrimaryVersion) newerThan(o PrimaryVersion) bool {
+	if v.Epoch != o.Epoch {
+		return v.Epoch > o.Epoch
+	}
+	return v.Order > o.Order
+}
+
+// SetKeyFromPrimary stores a copy of the value the key's primary wrote at v.
+// A copy older than one already applied is dropped without an error.
+func (d *Database) SetKeyFromPrimary(key string, value []byte, ttl time.Duration, v PrimaryVersion) error {
+	return d.applyFromPrimary(key, v, expiresAt(ttl), func(tx Tx) error {
+		return setEntry(tx, key, value, ttl)
+	})
+}
+
+// DeleteKeyFromPrimary deletes key as a copy of a delete the key's primary
+// made at v, like SetKeyFromPrimary.
+func (d *Database) DeleteKeyFromPrimary(key string, v PrimaryVersion) error {
+	return d.applyFromPrimary(key, v, expiresAt(deleteRecordTTL), func(tx Tx) error {
+		if err := tx.Delete([]byte(key)); err != nil {
+			return fmt.Errorf("failed to delete key '%s': %v", key, err)
+		}
+		return nil
+	})
+}
+
+// applyFromPrimary runs write and records v, expiring at recordExpiresAt,
+// unless the key holds a copy newer than v. Copies of the same key that
+// race are retried like IncrementKey.
+func (d *Database) applyFromPrimary(key string, v PrimaryVersion, recordExpiresAt uint64, write func(tx Tx) error) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	record := []byte(primaryPrefix + key)
+	apply := func(tx Tx) error {
+		if err := checkUnlocked(tx, key, ""); err != nil {
+			return err
+		}
+
+		item, err := tx.Get(record)
+		switch {
+		case err == ErrKeyNotFound:
+		case err != nil:
+			return fmt.Errorf("failed to get the primary version of key '%s': %v", key, err)
+		case len(item.Value) != 16:
+			return fmt.Errorf("corrupt primary version of key '%s'", key)
+		default:
+			applied := PrimaryVersion{Epoch: binary.BigEndian.Uint64(item.Value), Order: binary.BigEndian.Uint64(item.Value[8:])}
+			if !v.newerThan(applied) {
+				return nil
+			}
+		}
+
+		if err := write(tx); err != nil {
+			return err
+		}
+		encoded := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, v.Epoch), v.Order)
+		return tx.Set(record, encoded, recordExpiresAt)
+	}
+
+	var err error
+	for attempt := 0; attempt < maxIncrementAttempts; attempt++ {
+		err = d.engine.Update(apply)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+	}
+	if err = lockConflict(err); err != nil {
+		return fmt.Errorf("transaction failed while copying a write to key '%s': %w", key, err)
+	}
+	return nil
+}
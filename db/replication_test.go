@@ -0,0 +1,60 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestIncrementKey_OrderGrows(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, _, first, err := db.IncrementKey("hits", 1)
+	if err != nil {
+		t.Fatalf("IncrementKey failed: %v", err)
+	}
+	_, version, _ := db.GetKeyVersion("hits")
+	second, err := db.SetKeyIfVersion("hits", []byte("5"), version, 0)
+	if err != nil {
+		t.Fatalf("SetKeyIfVersion failed: %v", err)
+	}
+	if second <= first {
+		t.Fatalf("expected the later write to have a higher order, got %d after %d", second, first)
+	}
+}
+
+func TestFromPrimary_DropsStaleCopies(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.SetKeyFromPrimary("hits", []byte("2"), 0, PrimaryVersion{Epoch: 1, Order: 20}); err != nil {
+		t.Fatalf("SetKeyFromPrimary failed: %v", err)
+	}
+	// The copy of the earlier increment arrives late.
+	if err := db.SetKeyFromPrimary("hits", []byte("1"), 0, PrimaryVersion{Epoch: 1, Order: 10}); err != nil {
+		t.Fatalf("expected a stale copy to be dropped quietly, got %v", err)
+	}
+	if v, _ := db.GetKey("hits"); string(v) != "2" {
+		t.Fatalf("expected the newer copy to stay, got %q", v)
+	}
+
+	// A new primary's orders start over, but its epoch is later.
+	if err := db.SetKeyFromPrimary("hits", []byte("3"), 0, PrimaryVersion{Epoch: 2, Order: 5}); err != nil {
+		t.Fatalf("SetKeyFromPrimary failed: %v", err)
+	}
+	if v, _ := db.GetKey("hits"); string(v) != "3" {
+		t.Fatalf("expected the copy from the new epoch to apply, got %q", v)
+	}
+
+	if err := db.DeleteKeyFromPrimary("hits", PrimaryVersion{Epoch: 2, Order: 6}); err != nil {
+		t.Fatalf("DeleteKeyFromPrimary failed: %v", err)
+	}
+	if err := db.SetKeyFromPrimary("hits", []byte("3"), 0, PrimaryVersion{Epoch: 2, Order: 5}); err != nil {
+		t.Fatalf("SetKeyFromPrimary failed: %v", err)
+	}
+	if _, err := db.GetKey("hits"); err == nil {
+		t.Fatal("expected a late copy not to bring a deleted key back")
+	}
+
+	pairs, _ := db.GetAllKeys()
+	if len(pairs) != 0 {
+		t.Fatalf("expected the primary versions to stay hidden, got %+v", pairs)
+	}
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when a write touches a key that a prepared
+	// transaction holds a lock on.
+	ErrLocked = errors.New("key is locked by another transaction")
+	// ErrReservedKey is returned for keys under the prefix that holds
+	// transaction records, which callers may not read or write.
+	ErrReservedKey = errors.New("key is reserved")
+)
+
+// Transaction records live in the same engine as the data, under a reserved
+// prefix, so that preparing, committing and aborting are each a single engine
+// transaction. For every prepared transaction there is one
+// intent record holding its operations and one lock record per key naming
+// the transaction. Keys under the prefix are hidden from listings and scans.
+const (
+	internalPrefix = "\x00meerkat/"
+	intentPrefix   = internalPrefix + "txn/intent/"
+	lockPrefix     = internalPrefix + "txn/lock/"
+)
+
+func isInternalKey(key []byte) bool {
+	return strings.HasPrefix(string(key), internalPrefix)
+}
+
+// IsReservedKey reports whether key lies under the prefix the database keeps
+// its transaction records in.
+func IsReservedKey(key string) bool {
+	return isInternalKey([]byte(key))
+}
+
+// checkKey rejects reserved keys, so callers cannot forge, read or drop
+// transaction records.
+func checkKey(key string) error {
+	if IsReservedKey(key) {
+		return fmt.Errorf("key %q: %w", key, ErrReservedKey)
+	}
+	return nil
+}
+
+// TxnOp is one write of a transaction: a set of Value, expiring after TTL if
+// positive, or a delete when Delete is true.
+type TxnOp struct {
+	Key    string        `json:"key"`
+	Value  []byte        `json:"value,omitempty"`
+	TTL    time.Duration `json:"ttl,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// ApplyTxn applies ops atomically in one engine transaction. It fails with
+// ErrLocked if a prepared transaction holds any of the keys, including one
+// prepared concurrently.
+func (d *Database) ApplyTxn(ops []TxnOp) error {
+	if err := checkOpKeys(ops); err != nil {
+		return err
+	}
+	err := d.engine.Update(func(tx Tx) error {
+		for _, op := range ops {
+			if err := checkUnlocked(tx, op.Key, ""); err != nil {
+				return err
+			}
+		}
+		return applyOps(tx, ops)
+	})
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("concurrent transaction on the same keys: %w", ErrLocked)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply transaction of %d ops: %w", len(ops), err)
+	}
+	return nil
+}
+
+// PrepareTxn durably records ops as the intent of transaction id and locks
+// their keys, so that a later CommitTxn cannot fail on them. Preparing the
+// same id again is a no-op.
+func (d *Database) PrepareTxn(id string, ops []TxnOp) error {
+	if err := checkOpKeys(ops); err != nil {
+		return err
+	}
+	intent, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction %s: %v", id, err)
+	}
+
+	d.batchMu.Lock()
+	defer d.batchMu.Unlock()
+	err = d.engine.Update(func(tx Tx) error {
+		if _, err := tx.Get([]byte(intentPrefix + id)); err == nil {
+			return nil
+		} else if err != ErrKeyNotFound {
+			return err
+		}
+
+		for _, op := range ops {
+			if err := checkUnlocked(tx, op.Key, id); err != nil {
+				return err
+			}
+			if err := tx.Set([]byte(lockPrefix+op.Key), []byte(id), 0); err != nil {
+				return err
+			}
+		}
+		return tx.Set([]byte(intentPrefix+id), intent, 0)
+	})
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("concurrent transaction on the same keys: %w", ErrLocked)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prepare transaction %s: %w", id, err)
+	}
+	return nil
+}
+
+// CommitTxn applies the intent of transaction id and releases its locks. A
+// transaction that is not prepared, because it was already committed, is
+// left alone.
+func (d *Database) CommitTxn(id string) error {
+	return d.finishTxn(id, true)
+}
+
+// AbortTxn drops the intent of transaction id and releases its locks.
+func (d *Database) AbortTxn(id string) error {
+	return d.finishTxn(id, false)
+}
+
+func (d *Database) finishTxn(id string, commit bool) error {
+	err := d.engine.Update(func(tx Tx) error {
+		item, err := tx.Get([]byte(intentPrefix + id))
+		if err == ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var ops []TxnOp
+		if err := json.Unmarshal(item.Value, &ops); err != nil {
+			return fmt.Errorf("corrupt intent: %v", err)
+		}
+
+		if commit {
+			if err := applyOps(tx, ops); err != nil {
+				return err
+			}
+		}
+		for _, op := range ops {
+			if err := tx.Delete([]byte(lockPrefix + op.Key)); err != nil {
+				return err
+			}
+		}
+		return tx.Delete([]byte(intentPrefix + id))
+	})
+	if err != nil {
+		verb := "abort"
+		if commit {
+			verb = "commit"
+		}
+		return fmt.Errorf("failed to %s transaction %s: %v", verb, id, err)
+	}
+	return nil
+}
+
+// PreparedTxns returns the ids of transactions that are prepared but not yet
+// committed or aborted.
+func (d *Database) PreparedTxns() ([]string, error) {
+	var ids []string
+	err := d.engine.View(func(tx Tx) error {
+		it := tx.NewIterator(IterOptions{Prefix: []byte(intentPrefix), KeysOnly: true})
+		defer it.Close()
+
+		for it.Seek(nil); it.Valid(); it.Next() {
+			item, err := it.Item()
+			if err != nil {
+				return err
+			}
+			ids = append(ids, strings.TrimPrefix(string(item.Key), intentPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prepared transactions: %v", err)
+	}
+	return ids, nil
+}
+
+func checkOpKeys(ops []TxnOp) error {
+	for _, op := range ops {
+		if err := checkKey(op.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUnlocked fails with ErrLocked if a transaction other than owner holds
+// key; plain writes pass no owner. Every write path calls it, so nothing but
+// the commit can change a key between prepare and commit. Reading the lock
+// record also makes the engine abort this transaction if another one takes
+// the lock before it commits.
+func checkUnlocked(tx Tx, key, owner string) error {
+	item, err := tx.Get([]byte(lockPrefix + key))
+	if err == ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	holder := item.Value
+	if string(holder) == owner {
+		return nil
+	}
+	return fmt.Errorf("key '%s' is locked by transaction %s: %w", key, holder, ErrLocked)
+}
+
+// lockConflict reports a conflict on a plain write as ErrLocked: the only
+// record such a write reads is the lock of its key, so a transaction must
+// have been prepared on it meanwhile.
+func lockConflict(err error) error {
+	if errors.Is(err, ErrConflict) {
+		return fmt.Errorf("concurrent transaction on the same keys: %w", ErrLocked)
+	}
+	return err
+}
+
+func applyOps(tx Tx, ops []TxnOp) error {
+	for _, op := range ops {
+		if op.Delete {
+			if err := tx.Delete([]byte(op.Key)); err != nil {
+				return fmt.Errorf("failed to delete key '%s': %v", op.Key, err)
+			}
+			continue
+		}
+		if err := setEntry(tx, op.Key, op.Value, op.TTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyTxn(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("old", []byte("value"), 0)
+	err := db.ApplyTxn([]TxnOp{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2")},
+		{Key: "old", Delete: true},
+	})
+	if err != nil {
+		t.Fatalf("ApplyTxn failed: %v", err)
+	}
+
+	for k, want := range map[string]string{"a": "1", "b": "2"} {
+		val, err := db.GetKey(k)
+		if err != nil || string(val) != want {
+			t.Fatalf("expected %s=%s, got %q (%v)", k, want, val, err)
+		}
+	}
+	if _, err := db.GetKey("old"); err == nil {
+		t.Fatal("expected 'old' to be deleted")
+	}
+}
+
+func TestPrepareAndCommitTxn(t *testing.T) {
+	db := setupTestDB(t)
+
+	ops := []TxnOp{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}}
+	if err := db.PrepareTxn("txn-1", ops); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+	if _, err := db.GetKey("a"); err == nil {
+		t.Fatal("expected prepared write to be invisible before commit")
+	}
+
+	ids, err := db.PreparedTxns()
+	if err != nil || len(ids) != 1 || ids[0] != "txn-1" {
+		t.Fatalf("expected [txn-1] prepared, got %v (%v)", ids, err)
+	}
+
+	if err := db.CommitTxn("txn-1"); err != nil {
+		t.Fatalf("CommitTxn failed: %v", err)
+	}
+	if val, _ := db.GetKey("b"); string(val) != "2" {
+		t.Fatalf("expected b=2 after commit, got %q", val)
+	}
+
+	// Committing again, as a coordinator retrying after a crash would, is a no-op.
+	db.SetKey("a", []byte("later"), 0)
+	if err := db.CommitTxn("txn-1"); err != nil {
+		t.Fatalf("second CommitTxn failed: %v", err)
+	}
+	if val, _ := db.GetKey("a"); string(val) != "later" {
+		t.Fatalf("expected repeated commit to leave a alone, got %q", val)
+	}
+	if ids, _ := db.PreparedTxns(); len(ids) != 0 {
+		t.Fatalf("expected no prepared transactions, got %v", ids)
+	}
+}
+
+func TestPrepareTxn_Locked(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "a", Value: []byte("1")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+
+	err := db.PrepareTxn("txn-2", []TxnOp{{Key: "b", Value: []byte("2")}, {Key: "a", Value: []byte("2")}})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	err = db.ApplyTxn([]TxnOp{{Key: "a", Delete: true}})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked from ApplyTxn, got %v", err)
+	}
+
+	// The failed prepare must not leave a lock on b behind.
+	if err := db.ApplyTxn([]TxnOp{{Key: "b", Value: []byte("3")}}); err != nil {
+		t.Fatalf("ApplyTxn on b failed: %v", err)
+	}
+
+	if err := db.AbortTxn("txn-1"); err != nil {
+		t.Fatalf("AbortTxn failed: %v", err)
+	}
+	if _, err := db.GetKey("a"); err == nil {
+		t.Fatal("expected aborted write to be dropped")
+	}
+	if err := db.PrepareTxn("txn-2", []TxnOp{{Key: "a", Value: []byte("2")}}); err != nil {
+		t.Fatalf("PrepareTxn after abort failed: %v", err)
+	}
+}
+
+func TestTxnRecordsHidden(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("a", []byte("1"), 0)
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "b", Value: []byte("2")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+
+	pairs, err := db.GetAllKeys()
+	if err != nil || len(pairs) != 1 || pairs[0].Key != "a" {
+		t.Fatalf("expected only [a] from GetAllKeys, got %v (%v)", pairs, err)
+	}
+	pairs, _, err = db.ScanKeys("", "", "", 0, 0)
+	if err != nil || len(pairs) != 1 || pairs[0].Key != "a" {
+		t.Fatalf("expected only [a] from ScanKeys, got %v (%v)", pairs, err)
+	}
+	keys, _, median, _ := db.RangeStats("", "")
+	if keys != 1 || median != "a" {
+		t.Fatalf("expected 1 key with median a, got %d keys, median %q", keys, median)
+	}
+}
+
+func TestPlainWrites_Locked(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("a", []byte("1"), 0)
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "a", Value: []byte("txn")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+
+	writes := map[string]func() error{
+		"SetKey":           func() error { return db.SetKey("a", []byte("plain"), 0) },
+		"DeleteKey":        func() error { return db.DeleteKey("a") },
+		"IncrementKey":     func() error { _, _, _, err := db.IncrementKey("a", 1); return err },
+		"SetKeyIfAbsent":   func() error { _, err := db.SetKeyIfAbsent("a", []byte("plain"), 0); return err },
+		"SetKeyIfVersion":  func() error { _, err := db.SetKeyIfVersion("a", []byte("plain"), 0, 0); return err },
+		"DeleteKeyIfValue": func() error { _, err := db.DeleteKeyIfValue("a", []byte("1")); return err },
+		"SetKeys":          func() error { return db.SetKeys([]KeyValuePair{{Key: "a"}})[0] },
+		"DeleteKeys":       func() error { return db.DeleteKeys([]string{"a"})[0] },
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrLocked) {
+			t.Errorf("expected ErrLocked from %s, got %v", name, err)
+		}
+	}
+	// The rest of a batch does not fail with the locked key.
+	errs := db.SetKeys([]KeyValuePair{{Key: "b", Value: []byte("plain")}, {Key: "a"}})
+	if errs[0] != nil || !errors.Is(errs[1], ErrLocked) {
+		t.Fatalf("expected only the locked key of the batch to fail, got %v", errs)
+	}
+
+	if err := db.CommitTxn("txn-1"); err != nil {
+		t.Fatalf("CommitTxn failed: %v", err)
+	}
+	if val, _ := db.GetKey("a"); string(val) != "txn" {
+		t.Fatalf("expected the committed value, got %q", val)
+	}
+	if err := db.SetKey("a", []byte("plain"), 0); err != nil {
+		t.Fatalf("expected writes after commit to succeed, got %v", err)
+	}
+}
+
+func TestReservedKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "a", Value: []byte("txn")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+	lock := lockPrefix + "a"
+
+	if err := db.SetKey(lockPrefix+"b", []byte("txn-2"), 0); !errors.Is(err, ErrReservedKey) {
+		t.Fatalf("expected ErrReservedKey from SetKey, got %v", err)
+	}
+	if _, err := db.GetKey(lock); !errors.Is(err, ErrReservedKey) {
+		t.Fatalf("expected ErrReservedKey from GetKey, got %v", err)
+	}
+	if err := db.DeleteKey(lock); !errors.Is(err, ErrReservedKey) {
+		t.Fatalf("expected ErrReservedKey from DeleteKey, got %v", err)
+	}
+	if err := db.DeleteKeys([]string{lock})[0]; !errors.Is(err, ErrReservedKey) {
+		t.Fatalf("expected ErrReservedKey from DeleteKeys, got %v", err)
+	}
+	if err := db.ApplyTxn([]TxnOp{{Key: intentPrefix + "txn-1", Delete: true}}); !errors.Is(err, ErrReservedKey) {
+		t.Fatalf("expected ErrReservedKey from ApplyTxn, got %v", err)
+	}
+
+	// The lock survived, so a plain write is still refused.
+	if err := db.SetKey("a", []byte("plain"), 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWatchLagging is returned by Watch when the watcher stopped keeping up
+// with the change feed. Watching again from the last version seen picks up
+// where it left off.
+var ErrWatchLagging = errors.New("watcher fell behind the change feed")
+
+const (
+	// feedBacklog is how many recent changes the feed keeps for watchers
+	// that register while those changes are being published, and for
+	// watches resuming from a version they still reach back to.
+	feedBacklog = 1024
+	// watchBuffer is how many changes a watcher may fall behind before it
+	// is dropped with ErrWatchLagging.
+	watchBuffer = 1024
+)
+
+// Event is one change to a key: a set of Value, or a delete. Version is the
+// commit version of the change, the same version GetKeyVersion returns.
+// Keys that expire do not produce an event. Time is when the change was
+// committed, as seen by the change feed; it is zero for changes reported by
+// a catch-up, since engines do not keep commit times. A Progress event
+// carries no change; its Version is the point the watch has reached. Nor does
+// a Gap event, which comes before a catch-up that may leave out changes made
+// after its Version.
+type Event struct {
+	Key      string
+	Value    []byte
+	Deleted  bool
+	Version  uint64
+	TTL      time.Duration
+	Time     time.Time
+	Progress bool
+	Gap      bool
+}
+
+// feed is the database's change feed: a single engine subscription for the
+// lifetime of the database, fanned out to watchers.
+type feed struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+	recent   []Event
+	// floor is the version at or below which changes may be missing from
+	// recent: the one before the first change published, since commits made
+	// while the subscription starts are not, or the last change dropped
+	// from recent since. It is math.MaxUint64 until a change is published.
+	floor uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type watcher struct {
+	prefix string
+	events chan Event
+	// lagging is closed when the watcher is dropped for falling behind.
+	lagging chan struct{}
+}
+
+func startFeed(engine Engine) *feed {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &feed{
+		watchers: make(map[*watcher]struct{}),
+		floor:    math.MaxUint64,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		defer close(f.done)
+		engine.Subscribe(ctx, f.publish)
+	}()
+	return f
+}
+
+func (f *feed) stop() {
+	f.cancel()
+	<-f.done
+}
+
+func (f *feed) publish(events []Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Engines publish a commit as soon as it is made.
+	now := time.Now()
+	if f.floor == math.MaxUint64 && len(events) > 0 {
+		f.floor = events[0].Version - 1
+	}
+	for _, e := range events {
+		if isInternalKey([]byte(e.Key)) {
+			continue
+		}
+		e.Time = now
+
+		if len(f.recent) == feedBacklog {
+			f.floor = f.recent[0].Version
+			f.recent = f.recent[1:]
+		}
+		f.recent = append(f.recent, e)
+
+		for w := range f.watchers {
+			if !strings.HasPrefix(e.Key, w.prefix) {
+				continue
+			}
+			select {
+			case w.events <- e:
+			default:
+				delete(f.watchers, w)
+				close(w.lagging)
+			}
+		}
+	}
+}
+
+// register adds a watcher and returns the recent changes it may have missed
+// while registering, and the feed's floor.
+func (f *feed) register(w *watcher) ([]Event, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.watchers[w] = struct{}{}
+
+	var backlog []Event
+	for _, e := range f.recent {
+		if strings.HasPrefix(e.Key, w.prefix) {
+			backlog = append(backlog, e)
+		}
+	}
+	return backlog, f.floor
+}
+
+func (f *feed) unregister(w *watcher) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.watchers, w)
+}
+
+// Watch calls fn, in version order, for every change to a key starting with
+// prefix until ctx is done or fn fails. With catchUp set, the changes made
+// after fromVersion are reported first. While the feed's recent changes
+// reach back to fromVersion they are replayed exactly; otherwise the latest
+// change of each key is read from the engine, leaving out intermediate
+// versions of a key and deletes the engine no longer remembers, and a Gap
+// event comes first when there is any. Without catchUp only changes made
+// from now on are reported. Either way a Progress event then marks the
+// version the live feed continues from.
+func (d *Database) Watch(ctx context.Context, prefix string, fromVersion uint64, catchUp bool, fn func(Event) error) error {
+	w := &watcher{
+		prefix:  prefix,
+		events:  make(chan Event, watchBuffer),
+		lagging: make(chan struct{}),
+	}
+	backlog, floor := d.feed.register(w)
+	defer d.feed.unregister(w)
+
+	// Everything committed up to the snapshot is covered by the catch-up
+	// read; the feed supplies what comes after it, or everything after
+	// fromVersion when it still holds all of that.
+	var changes []Event
+	readTs := fromVersion
+	if !catchUp || fromVersion < floor {
+		var err error
+		if changes, readTs, err = d.changesSince(prefix, fromVersion, catchUp); err != nil {
+			return err
+		}
+	}
+	if len(changes) > 0 {
+		if err := fn(Event{Version: fromVersion, Gap: true}); err != nil {
+			return err
+		}
+	}
+	for _, e := range changes {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	last := readTs
+	if err := fn(Event{Version: readTs, Progress: true}); err != nil {
+		return err
+	}
+	deliver := func(e Event) error {
+		if e.Version <= last {
+			return nil
+		}
+		last = e.Version
+		return fn(e)
+	}
+
+	for _, e := range backlog {
+		if err := deliver(e); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e := <-w.events:
+			if err := deliver(e); err != nil {
+				return err
+			}
+		case <-w.lagging:
+			return fmt.Errorf("watch on prefix '%s' at version %d: %w", prefix, last, ErrWatchLagging)
+		}
+	}
+}
+
+// changesSince returns the latest change of every key under prefix made
+// after fromVersion, in version order, and the version of the snapshot it
+// read. Without catchUp it only takes the snapshot.
+func (d *Database) changesSince(prefix string, fromVersion uint64, catchUp bool) ([]Event, uint64, error) {
+	var changes []Event
+	var readTs uint64
+	var err error
+
+	if catchUp {
+		readTs, err = d.engine.Changes([]byte(prefix), fromVersion, func(e Event) error {
+			if !isInternalKey([]byte(e.Key)) {
+				changes = append(changes, e)
+			}
+			return nil
+		})
+	} else {
+		err = d.engine.View(func(tx Tx) error {
+			readTs = tx.Version()
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read changes since version %d: %v", fromVersion, err)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Version < changes[j].Version })
+	return changes, readTs, nil
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// watchEvents runs Watch in the background and returns its events.
+func watchEvents(t *testing.T, db *Database, prefix string, fromVersion uint64) <-chan Event {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan Event, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		db.Watch(ctx, prefix, fromVersion, fromVersion > 0, func(e Event) error {
+			if !e.Progress {
+				events <- e
+			}
+			return nil
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Give the watcher a moment to register before the test writes.
+	time.Sleep(50 * time.Millisecond)
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestWatch_SetAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	events := watchEvents(t, db, "config:", 0)
+
+	db.SetKey("other", []byte("ignored"), 0)
+	db.SetKey("config:a", []byte("1"), time.Hour)
+	db.DeleteKey("config:a")
+
+	set := nextEvent(t, events)
+	if set.Key != "config:a" || string(set.Value) != "1" || set.Deleted || set.TTL <= 0 || set.Time.IsZero() {
+		t.Fatalf("expected a set of config:a=1 with a TTL and commit time, got %+v", set)
+	}
+	del := nextEvent(t, events)
+	if del.Key != "config:a" || !del.Deleted || del.Version <= set.Version {
+		t.Fatalf("expected a later delete of config:a, got %+v", del)
+	}
+}
+
+func TestWatch_ResumeFromVersion(t *testing.T) {
+	db := setupTestDB(t)
+	// Give the change feed a moment to subscribe, so that it sees every
+	// change below.
+	time.Sleep(50 * time.Millisecond)
+
+	db.SetKey("config:a", []byte("1"), 0)
+	_, from, _ := db.GetKeyVersion("config:a")
+	db.SetKey("config:a", []byte("2"), 0)
+	db.SetKey("config:b", []byte("1"), 0)
+	db.SetKey("config:c", []byte("1"), 0)
+	db.DeleteKey("config:c")
+
+	events := watchEvents(t, db, "config:", from)
+
+	// The feed still holds every change since from, so the catch-up replays
+	// them all, in version order, and nothing at or before from.
+	want := []struct {
+		key     string
+		value   string
+		deleted bool
+	}{{"config:a", "2", false}, {"config:b", "1", false}, {"config:c", "1", false}, {"config:c", "", true}}
+	for _, w := range want {
+		e := nextEvent(t, events)
+		if e.Key != w.key || string(e.Value) != w.value || e.Deleted != w.deleted {
+			t.Fatalf("expected %+v, got %+v", w, e)
+		}
+	}
+
+	db.SetKey("config:d", []byte("1"), 0)
+	if e := nextEvent(t, events); e.Key != "config:d" {
+		t.Fatalf("expected the live change to config:d, got %+v", e)
+	}
+}
+
+func TestWatch_GapPastBacklog(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.SetKey("config:a", []byte("0"), 0)
+	_, from, _ := db.GetKeyVersion("config:a")
+	for i := 1; i <= feedBacklog+10; i++ {
+		db.SetKey("config:a", []byte(fmt.Sprint(i)), 0)
+	}
+	db.SetKey("config:b", []byte("1"), 0)
+
+	events := watchEvents(t, db, "config:", from)
+
+	// The feed has dropped changes made after from, so the catch-up comes
+	// from the engine, collapsed, and says so first.
+	if e := nextEvent(t, events); !e.Gap || e.Version != from {
+		t.Fatalf("expected a gap after version %d, got %+v", from, e)
+	}
+	if e := nextEvent(t, events); e.Key != "config:a" || string(e.Value) != fmt.Sprint(feedBacklog+10) {
+		t.Fatalf("expected the latest change to config:a, got %+v", e)
+	}
+	if e := nextEvent(t, events); e.Key != "config:b" {
+		t.Fatalf("expected the change to config:b, got %+v", e)
+	}
+
+	db.SetKey("config:c", []byte("1"), 0)
+	if e := nextEvent(t, events); e.Key != "config:c" || e.Gap {
+		t.Fatalf("expected the live change to config:c, got %+v", e)
+	}
+}
+
+func TestWatch_HidesTxnRecords(t *testing.T) {
+	db := setupTestDB(t)
+	events := watchEvents(t, db, "", 0)
+
+	if err := db.PrepareTxn("txn-1", []TxnOp{{Key: "a", Value: []byte("1")}}); err != nil {
+		t.Fatalf("PrepareTxn failed: %v", err)
+	}
+	if err := db.CommitTxn("txn-1"); err != nil {
+		t.Fatalf("CommitTxn failed: %v", err)
+	}
+
+	if e := nextEvent(t, events); e.Key != "a" || string(e.Value) != "1" {
+		t.Fatalf("expected only the committed write to a, got %+v", e)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("expected no further events, got %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -22,6 +22,7 @@ type Config struct {
 		GRPC_Addr string `toml:"grpc_addr"`
 		HTTP_Addr string `toml:"http_addr"`
 	} `toml:"manager"`
+	internal.Options
 }
 
 type RegisterRequest struct {
@@ -98,10 +99,11 @@ func (ms *ManagerServer) healthHandler(w http.ResponseWriter, r *http.Request) {
 
 func (ms *ManagerServer) clusterHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	servers := ms.manager.GetClusterStatus()
+	servers, epoch := ms.manager.GetClusterStatus()
 	response := map[string]interface{}{
 		"status":             "healthy",
 		"server_count":       len(servers),
+		"epoch":              epoch,
 		"replication_factor": internal.ReplicationFactor,
 		"servers":            servers,
 		"time":               time.Now().Format(time.RFC3339),
@@ -126,19 +128,47 @@ func main() {
 	utils.NewLogger()
 	utils.Logger.Info().Msg("meerkat manager starting...")
 
-	var config Config
+	config := Config{Options: internal.DefaultOptions()}
 	err := utils.LoadTomlConfig(&config, *configPath)
 	if err != nil {
 		utils.Logger.Fatal().Err(err).Msg("Failed to load config file")
 		return
 	}
+	if err := config.Options.Validate(); err != nil {
+		utils.Logger.Fatal().Err(err).Msg("Invalid config")
+		return
+	}
 
 	grpcAddr := config.Manager.GRPC_Addr
 	httpAddr := config.Manager.HTTP_Addr
 
-	dbManager := internal.NewDBManager()
+	dbManager := internal.NewDBManager(config.Options)
+	if config.Transactions.LogPath != "" {
+		if err := dbManager.OpenTxnLog(config.Transactions.LogPath); err != nil {
+			utils.Logger.Fatal().Err(err).Msg("Failed to open transaction log")
+			return
+		}
+	}
 
-	grpcService := grpc_server.NewServer(grpcAddr, dbManager)
+	var cdc *internal.CDC
+	if config.CDC.Enabled {
+		cdc, err = dbManager.StartCDC(config.CDC)
+		if err != nil {
+			utils.Logger.Fatal().Err(err).Msg("Failed to start CDC")
+			return
+		}
+	}
+
+	var limiter *internal.RateLimiter
+	if config.RateLimit.Enabled {
+		limiter, err = internal.NewRateLimiter(config.RateLimit)
+		if err != nil {
+			utils.Logger.Fatal().Err(err).Msg("Failed to configure rate limiting")
+			return
+		}
+	}
+
+	grpcService := grpc_server.NewServer(grpcAddr, dbManager, limiter)
 
 	httpService := NewManagerServer(dbManager, httpAddr)
 
@@ -149,6 +179,8 @@ func main() {
 		for range ticker.C {
 			utils.Logger.Debug().Msg("Running health check on registered servers")
 			dbManager.HealthCheckServers()
+			dbManager.RebalanceRanges()
+			dbManager.ResolveTransactions()
 		}
 	}()
 
@@ -180,6 +212,10 @@ func main() {
 	httpService.Stop()
 
 	wg.Wait()
+	dbManager.WaitForBackgroundWrites()
+	if cdc != nil {
+		cdc.Stop()
+	}
 
 	utils.Logger.Info().Msg("Servers stopped successfully.")
 }
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serverLoad counts the data-path calls the manager has outstanding against
+// one db_server, a proxy for that server's request queue.
+type serverLoad struct {
+	uuid     string
+	inFlight atomic.Int64
+}
+
+func (l *serverLoad) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, exempt := controlPlaneMethods[method]; exempt {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	ServerInFlight.WithLabelValues(l.uuid).Set(float64(l.inFlight.Add(1)))
+	defer func() {
+		ServerInFlight.WithLabelValues(l.uuid).Set(float64(l.inFlight.Add(-1)))
+	}()
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// admit sheds a request when every replica it would go to already has
+// MaxInFlightPerServer calls outstanding, rather than queueing more work on
+// servers that are falling behind.
+func (m *DBManager) admit(servers []dbServer) error {
+	limit := int64(m.opts.Admission.MaxInFlightPerServer)
+	if limit <= 0 {
+		return nil
+	}
+
+	for _, server := range servers {
+		if server.load == nil || server.load.inFlight.Load() < limit {
+			return nil
+		}
+	}
+
+	RequestsShed.Inc()
+	return status.Errorf(codes.ResourceExhausted, "all %d replicas have %d requests in flight", len(servers), limit)
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmission_ShedsWhenAllReplicasSaturated(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Admission.MaxInFlightPerServer = 2
+	m, _ := newTestManager(t, opts, 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "key", []byte("value"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	replicas, _, err := m.getReplicaServers("key")
+	if err != nil {
+		t.Fatalf("getReplicaServers failed: %v", err)
+	}
+
+	for _, r := range replicas[1:] {
+		r.load.inFlight.Store(2)
+	}
+	if _, err := m.GetKey(context.Background(), "key"); err != nil {
+		t.Fatalf("expected request to be admitted while one replica has room, got %v", err)
+	}
+
+	replicas[0].load.inFlight.Store(2)
+	defer func() {
+		for _, r := range replicas {
+			r.load.inFlight.Store(0)
+		}
+	}()
+
+	_, err = m.GetKey(context.Background(), "key")
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected RESOURCE_EXHAUSTED, got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	backupManifestFile = "manifest.json"
+	// backupIDFormat names backups by their start time, so that they sort in
+	// the order they were taken.
+	backupIDFormat = "20060102T150405.000000Z"
+
+	BackupFull        = "full"
+	BackupIncremental = "incremental"
+)
+
+// BackupManifest describes one backup: the ring it was taken from and, for
+// every server, the file holding its snapshot in Badger's backup format.
+//
+// An incremental backup holds only what changed on each server after the
+// version recorded for it in Base, so it is restored by replaying the chain
+// of backups it builds on, oldest first.
+type BackupManifest struct {
+	ID                string         `json:"id"`
+	Kind              string         `json:"kind"`
+	Base              string         `json:"base,omitempty"`
+	Started           time.Time      `json:"started"`
+	Finished          time.Time      `json:"finished"`
+	Epoch             uint64         `json:"epoch"`
+	Partitioning      string         `json:"partitioning"`
+	ReplicationFactor int            `json:"replication_factor"`
+	Servers           []BackupServer `json:"servers"`
+	// Ranges are the key ranges of a range-partitioned ring, each with its
+	// replicas, primary first.
+	Ranges []BackupRange `json:"ranges,omitempty"`
+
+	// Path is the backup's directory. It is not stored in the manifest, so
+	// that backups can be moved.
+	Path string `json:"-"`
+}
+
+// BackupServer is one server's part of a backup. The snapshot holds the
+// entries the server wrote after Since, up to Version.
+type BackupServer struct {
+	UUID     string `json:"uuid"`
+	Region   string `json:"region"`
+	Addr     string `json:"addr"`
+	Position uint32 `json:"position"`
+	File     string `json:"file"`
+	Since    uint64 `json:"since"`
+	Version  uint64 `json:"version"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+type BackupRange struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end,omitempty"`
+	Replicas []string `json:"replicas"`
+}
+
+// Server returns the part of the backup taken from uuid.
+func (b *BackupManifest) Server(uuid string) (BackupServer, bool) {
+	for _, s := range b.Servers {
+		if s.UUID == uuid {
+			return s, true
+		}
+	}
+	return BackupServer{}, false
+}
+
+// Backup snapshots every server into a new backup under dir, or the
+// configured backup directory when dir is empty; a dir from a client goes
+// through BackupDir first. An incremental backup
+// builds on the latest backup in the directory and falls back to a full one
+// when there is none.
+//
+// All servers are snapshotted at once, each at a single point in its own
+// history. Two-phase commits wait while the snapshots start, so a
+// transaction is in every participant's snapshot or in none, unless a
+// participant missed the commit and ResolveTransactions had not resent it
+// yet. Single-primary transactions are atomic on the primary, whose snapshot
+// restore prefers. A membership change while the backup runs can move keys
+// between servers behind the snapshots, so the backup then fails with
+// UNAVAILABLE and should be taken again.
+func (m *DBManager) Backup(ctx context.Context, dir string, incremental bool) (*BackupManifest, error) {
+	if dir == "" {
+		dir = m.opts.Backup.Dir
+	}
+	manifest, err := m.backup(ctx, dir, incremental)
+	kind := BackupFull
+	if manifest != nil {
+		kind = manifest.Kind
+	}
+	if err != nil {
+		Backups.WithLabelValues(kind, "error").Inc()
+		return nil, err
+	}
+	Backups.WithLabelValues(kind, "success").Inc()
+	return manifest, nil
+}
+
+// BackupDir resolves a directory named by a client to one under the
+// configured backup directory, which is returned for an empty dir. A
+// relative dir is taken from the configured directory; anything that
+// resolves outside it fails with INVALID_ARGUMENT, so that clients cannot
+// read or write other paths on the manager's host.
+func (m *DBManager) BackupDir(dir string) (string, error) {
+	root, err := filepath.Abs(m.opts.Backup.Dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve backup directory: %v", err)
+	}
+	if dir == "" {
+		return root, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	dir = filepath.Clean(dir)
+	if rel, err := filepath.Rel(root, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", status.Errorf(codes.InvalidArgument, "backup directory %s is outside %s", dir, root)
+	}
+	return dir, nil
+}
+
+func (m *DBManager) backup(ctx context.Context, dir string, incremental bool) (*BackupManifest, error) {
+	var base *BackupManifest
+	if incremental {
+		backups, err := ListBackups(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) > 0 {
+			base = &backups[len(backups)-1]
+		} else {
+			log.Info().Msgf("No backup in %s to build on; taking a full backup", dir)
+		}
+	}
+
+	manifest, servers := m.backupLayout()
+	if len(servers) == 0 {
+		return nil, status.Errorf(codes.Unavailable, "no servers to back up")
+	}
+	if base != nil {
+		manifest.Kind = BackupIncremental
+		manifest.Base = base.ID
+		for i := range manifest.Servers {
+			// A server missing from the base has nothing backed up yet
+			// and starts from zero.
+			if prev, ok := base.Server(manifest.Servers[i].UUID); ok {
+				manifest.Servers[i].Since = prev.Version
+			}
+		}
+	}
+
+	final := filepath.Join(dir, manifest.ID)
+	if _, err := os.Stat(final); err == nil {
+		return manifest, fmt.Errorf("backup %s already exists", final)
+	}
+	tmp := filepath.Join(dir, "."+manifest.ID+".tmp")
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		return manifest, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// Each server's snapshot point is fixed by the time it sends its first
+	// chunk.
+	var started sync.WaitGroup
+	started.Add(len(servers))
+	m.commits.Lock()
+	errs := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(part *BackupServer, server dbServer) {
+			errs <- backupServer(ctx, server, tmp, part, sync.OnceFunc(started.Done))
+		}(&manifest.Servers[i], server)
+	}
+	started.Wait()
+	m.commits.Unlock()
+	var failed error
+	for range servers {
+		if err := <-errs; err != nil && failed == nil {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return manifest, failed
+	}
+
+	if epoch := m.Epoch(); epoch != manifest.Epoch {
+		return manifest, status.Errorf(codes.Unavailable, "ring changed from epoch %d to %d during the backup", manifest.Epoch, epoch)
+	}
+
+	manifest.Finished = time.Now().UTC()
+	if err := writeBackupManifest(tmp, manifest); err != nil {
+		return manifest, err
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return manifest, fmt.Errorf("failed to publish backup %s: %v", final, err)
+	}
+	manifest.Path = final
+
+	for _, s := range manifest.Servers {
+		BackupBytes.Add(float64(s.Bytes))
+	}
+	log.Info().Msgf("Took %s backup %s of %d servers", manifest.Kind, final, len(manifest.Servers))
+	return manifest, nil
+}
+
+// backupLayout starts a full backup's manifest from the current ring and
+// returns the servers to snapshot in the manifest's order.
+func (m *DBManager) backupLayout() (*BackupManifest, []dbServer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	manifest := &BackupManifest{
+		ID:                now.Format(backupIDFormat),
+		Kind:              BackupFull,
+		Started:           now,
+		Epoch:             m.epoch,
+		Partitioning:      PartitioningHash,
+		ReplicationFactor: ReplicationFactor,
+	}
+
+	servers := make([]dbServer, 0, len(m.servers))
+	for _, s := range m.servers {
+		servers = append(servers, s)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].uuid < servers[j].uuid })
+	for _, s := range servers {
+		manifest.Servers = append(manifest.Servers, BackupServer{
+			UUID:     s.uuid,
+			Region:   s.region,
+			Addr:     s.addr,
+			Position: m.partitioner.Position(s.uuid),
+			File:     s.uuid + ".backup",
+		})
+	}
+
+	if m.ranges != nil {
+		manifest.Partitioning = PartitioningRange
+		for _, r := range m.ranges.Ranges(ReplicationFactor) {
+			manifest.Ranges = append(manifest.Ranges, BackupRange{Start: r.Start, End: r.End, Replicas: r.Replicas})
+		}
+	}
+	return manifest, servers
+}
+
+// backupServer streams server's snapshot after part.Since into dir and fills
+// in the version and size it reached. It calls started once the snapshot has
+// begun, or failed to.
+func backupServer(ctx context.Context, server dbServer, dir string, part *BackupServer, started func()) error {
+	defer started()
+	f, err := os.Create(filepath.Join(dir, part.File))
+	if err != nil {
+		return fmt.Errorf("failed to create backup file for server %s: %v", server.uuid, err)
+	}
+	defer f.Close()
+
+	stream, err := server.client.Backup(ctx, &db_server.BackupRequest{Since: part.Since})
+	if err != nil {
+		return fmt.Errorf("failed to start backup of server %s: %w", server.uuid, err)
+	}
+
+	w := bufio.NewWriter(f)
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("backup of server %s failed: %w", server.uuid, err)
+		}
+		started()
+		if _, err := w.Write(chunk.Data); err != nil {
+			return fmt.Errorf("failed to write backup of server %s: %v", server.uuid, err)
+		}
+		part.Bytes += uint64(len(chunk.Data))
+		if chunk.Last {
+			// A server with nothing new stays at the version it had.
+			part.Version = max(chunk.Version, part.Since)
+			break
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write backup of server %s: %v", server.uuid, err)
+	}
+	return f.Sync()
+}
+
+func writeBackupManifest(dir string, manifest *BackupManifest) error {
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(dir, backupManifestFile))
+	if err != nil {
+		return fmt.Errorf("failed to write backup manifest: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(raw); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %v", err)
+	}
+	return f.Sync()
+}
+
+// ReadBackupManifest reads the manifest of the backup in dir.
+func ReadBackupManifest(dir string) (*BackupManifest, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, backupManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("corrupt backup manifest in %s: %v", dir, err)
+	}
+	manifest.Path = dir
+	return &manifest, nil
+}
+
+// ListBackups returns the completed backups in dir, oldest first. A missing
+// directory holds no backups.
+func ListBackups(dir string) ([]BackupManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups in %s: %v", dir, err)
+	}
+
+	var backups []BackupManifest
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		manifest, err := ReadBackupManifest(filepath.Join(dir, e.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			// Not a backup, or one that never finished.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, *manifest)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ID < backups[j].ID })
+	return backups, nil
+}
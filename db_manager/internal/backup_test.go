@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arbhalerao/meerkat/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readBackupFile returns the entries in the snapshot of uuid, by key.
+func readBackupFile(t *testing.T, manifest *BackupManifest, uuid string) map[string]db.BackupEntry {
+	t.Helper()
+	part, ok := manifest.Server(uuid)
+	if !ok {
+		t.Fatalf("backup %s has no part for %s", manifest.ID, uuid)
+	}
+	f, err := os.Open(filepath.Join(manifest.Path, part.File))
+	if err != nil {
+		t.Fatalf("failed to open backup of %s: %v", uuid, err)
+	}
+	defer f.Close()
+
+	entries := make(map[string]db.BackupEntry)
+	err = db.ReadBackup(f, func(e db.BackupEntry) error {
+		entries[e.Key] = e
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read backup of %s: %v", uuid, err)
+	}
+	return entries
+}
+
+func TestBackup_Full(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+
+	manifest, err := m.Backup(context.Background(), dir, false)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if manifest.Kind != BackupFull || manifest.Base != "" || manifest.Epoch != m.Epoch() {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if len(manifest.Servers) != 3 || manifest.ReplicationFactor != ReplicationFactor {
+		t.Fatalf("expected the manifest to record the 3-server ring, got %+v", manifest)
+	}
+
+	// Every replica of a holds it in its snapshot.
+	replicas, _, _ := m.getReplicaServers("a")
+	for _, r := range replicas {
+		if got := readBackupFile(t, manifest, r.uuid); string(got["a"].Value) != "1" {
+			t.Fatalf("expected the backup of %s to hold a, got %+v", r.uuid, got)
+		}
+		if part, _ := manifest.Server(r.uuid); part.Version == 0 || part.Bytes == 0 {
+			t.Fatalf("expected a version and size for %s, got %+v", r.uuid, part)
+		}
+	}
+
+	read, err := ReadBackupManifest(filepath.Join(dir, manifest.ID))
+	if err != nil || read.ID != manifest.ID || len(read.Servers) != 3 {
+		t.Fatalf("expected the manifest on disk, got %+v, %v", read, err)
+	}
+}
+
+func TestBackup_Incremental(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+	full, err := m.Backup(context.Background(), dir, true)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if full.Kind != BackupFull {
+		t.Fatalf("expected the first backup to be full, got %s", full.Kind)
+	}
+
+	m.SetKey(context.Background(), "b", []byte("2"), 0)
+	m.WaitForBackgroundWrites()
+	incr, err := m.Backup(context.Background(), dir, true)
+	if err != nil {
+		t.Fatalf("incremental Backup failed: %v", err)
+	}
+	if incr.Kind != BackupIncremental || incr.Base != full.ID {
+		t.Fatalf("expected an incremental backup on %s, got %+v", full.ID, incr)
+	}
+
+	for _, part := range incr.Servers {
+		prev, _ := full.Server(part.UUID)
+		if part.Since != prev.Version || part.Version < prev.Version {
+			t.Fatalf("expected %s to continue from version %d, got %+v", part.UUID, prev.Version, part)
+		}
+		if got := readBackupFile(t, incr, part.UUID); got["a"].Key != "" {
+			t.Fatalf("expected the incremental backup of %s to skip a, got %+v", part.UUID, got)
+		}
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil || len(backups) != 2 || backups[0].ID != full.ID || backups[1].ID != incr.ID {
+		t.Fatalf("expected both backups oldest first, got %+v, %v", backups, err)
+	}
+}
+
+func TestBackup_WaitsForCommits(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	a, b := txnKeys(t, m, false)
+	primaryA, primaryB := replicasFor(t, m, servers, a)[0], replicasFor(t, m, servers, b)[0]
+	primaryB.mu.Lock()
+	primaryB.commitDelay = 300 * time.Millisecond
+	primaryB.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Transact(context.Background(), []TxnOp{{Key: a, Value: []byte("1")}, {Key: b, Value: []byte("2")}})
+		done <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for _, ok := primaryA.value(a); !ok; _, ok = primaryA.value(a) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the commit to reach a's primary")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The commit has reached a's primary but not yet b's; the backup waits
+	// for it rather than snapshot the transaction half applied.
+	manifest, err := m.Backup(context.Background(), t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Transact failed: %v", err)
+	}
+	for key, want := range map[string]string{a: "1", b: "2"} {
+		replicas, _, _ := m.getReplicaServers(key)
+		if got := readBackupFile(t, manifest, replicas[0].uuid)[key]; string(got.Value) != want {
+			t.Fatalf("expected the backup of %s's primary to hold %s=%s, got %+v", key, key, want, got)
+		}
+	}
+}
+
+func TestBackup_ServerFailure(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0)
+	dir := t.TempDir()
+	servers[1].grpc.Stop()
+
+	if _, err := m.Backup(context.Background(), dir, false); err == nil {
+		t.Fatal("expected the backup to fail with a server down")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("expected no partial backup to be left, got %v", entries)
+	}
+	if backups, _ := ListBackups(dir); len(backups) != 0 {
+		t.Fatalf("expected no backups, got %+v", backups)
+	}
+}
+
+func TestBackupDir(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Backup.Dir = t.TempDir()
+	m := NewDBManager(opts)
+
+	for dir, want := range map[string]string{
+		"":                                  opts.Backup.Dir,
+		"nightly":                           filepath.Join(opts.Backup.Dir, "nightly"),
+		"a/../b":                            filepath.Join(opts.Backup.Dir, "b"),
+		filepath.Join(opts.Backup.Dir, "x"): filepath.Join(opts.Backup.Dir, "x"),
+	} {
+		if got, err := m.BackupDir(dir); err != nil || got != want {
+			t.Fatalf("expected %q to resolve to %s, got %q, %v", dir, want, got, err)
+		}
+	}
+	for _, dir := range []string{"..", "../other", "/etc", opts.Backup.Dir + "-other"} {
+		if _, err := m.BackupDir(dir); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected INVALID_ARGUMENT for %q, got %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// KeyValue is one entry of a BatchSet. A positive TTL makes the key expire
+// that long after the write. Version is only set by Scan, to the key's
+// version on its primary; writes ignore it.
+type KeyValue struct {
+	Key     string
+	Value   []byte
+	TTL     time.Duration
+	Version uint64
+}
+
+type BatchGetResult struct {
+	Key   string
+	Value []byte
+	Found bool
+	Err   error
+}
+
+type BatchWriteResult struct {
+	Key string
+	Err error
+}
+
+// maxBatchBytes bounds the keys and values sent to a db_server in one batched
+// call, to stay under gRPC's default 4 MB message limit. A server's share of
+// a larger batch is sent in several calls.
+const maxBatchBytes = 3 << 20
+
+// serverBatch is the part of a batch bound for one db_server. indexes point
+// into the caller's request; the per-key results from the server come back in
+// the same order.
+type serverBatch struct {
+	server  dbServer
+	indexes []int
+
+	getResults   []*db_server.BatchGetResult
+	writeResults []*db_server.KeyResult
+	err          error
+}
+
+// resolveBatch looks up the replicas of every key under a single lock, so the
+// whole batch is routed on one epoch.
+func (m *DBManager) resolveBatch(keys []string) ([]replicaSet, []error, uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sets := make([]replicaSet, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		sets[i], errs[i] = m.replicaSetLocked(key)
+	}
+	return sets, errs, m.epoch
+}
+
+// splitBatch splits a server's share of a batch into calls whose entries,
+// sized by size, take at most maxBatchBytes together. An entry larger than
+// that is sent on its own.
+func splitBatch(b *serverBatch, size func(int) int) []*serverBatch {
+	var parts []*serverBatch
+	part := &serverBatch{server: b.server}
+	bytes := 0
+	for _, i := range b.indexes {
+		n := size(i)
+		if len(part.indexes) > 0 && bytes+n > maxBatchBytes {
+			parts = append(parts, part)
+			part, bytes = &serverBatch{server: b.server}, 0
+		}
+		part.indexes = append(part.indexes, i)
+		bytes += n
+	}
+	if len(part.indexes) > 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// runBatches sends every server its part of the batch concurrently and waits
+// for all of them to answer.
+func (m *DBManager) runBatches(ctx context.Context, batches []*serverBatch, call func(context.Context, *serverBatch)) {
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		wg.Add(1)
+		go func(b *serverBatch) {
+			defer wg.Done()
+			callCtx, cancel := m.callContext(ctx)
+			defer cancel()
+			call(callCtx, b)
+		}(b)
+	}
+	wg.Wait()
+}
+
+// BatchGet reads keys with one BatchGet call per server. Each key is first
+// asked of its primary; keys that fail or are missing there move on to their
+// next replica in the following round, like GetKey failing over.
+func (m *DBManager) BatchGet(ctx context.Context, keys []string) []BatchGetResult {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("batch_get").Observe(time.Since(start).Seconds())
+	}()
+
+	sets, errs, epoch := m.resolveBatch(keys)
+	results := make([]BatchGetResult, len(keys))
+	next := make([]int, len(keys))
+	answered := make([]bool, len(keys))
+
+	var pending []int
+	for i, key := range keys {
+		results[i].Key = key
+		if errs[i] != nil {
+			results[i].Err = errs[i]
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for len(pending) > 0 {
+		byServer := make(map[string]*serverBatch)
+		var batches []*serverBatch
+		for _, i := range pending {
+			server := sets[i].servers[next[i]]
+			b, ok := byServer[server.uuid]
+			if !ok {
+				b = &serverBatch{server: server}
+				byServer[server.uuid] = b
+				batches = append(batches, b)
+			}
+			b.indexes = append(b.indexes, i)
+		}
+
+		m.runBatches(ctx, batches, func(ctx context.Context, b *serverBatch) {
+			req := &db_server.BatchGetRequest{Keys: make([]string, len(b.indexes)), Epoch: epoch}
+			for j, i := range b.indexes {
+				req.Keys[j] = keys[i]
+			}
+			resp, err := b.server.client.BatchGet(ctx, req)
+			if err != nil {
+				b.err = err
+				return
+			}
+			b.getResults = resp.Results
+		})
+
+		pending = pending[:0]
+		for _, b := range batches {
+			for j, i := range b.indexes {
+				switch {
+				case b.err != nil:
+					results[i].Err = b.err
+				case j >= len(b.getResults):
+					results[i].Err = fmt.Errorf("server %s returned no result", b.server.uuid)
+				case b.getResults[j].Error != "":
+					results[i].Err = errors.New(b.getResults[j].Error)
+				case b.getResults[j].Found:
+					results[i].Value = storedValue(b.getResults[j].ValueBytes, b.getResults[j].Value)
+					results[i].Found = true
+					results[i].Err = nil
+					continue
+				default:
+					answered[i] = true
+				}
+
+				next[i]++
+				if next[i] < len(sets[i].servers) {
+					pending = append(pending, i)
+				}
+			}
+		}
+	}
+
+	failed := false
+	for i := range results {
+		if !results[i].Found && answered[i] {
+			results[i].Err = nil
+		}
+		if results[i].Err != nil {
+			failed = true
+		}
+	}
+	recordBatch("batch_get", failed)
+	return results
+}
+
+// BatchSet writes pairs with one BatchSet call per server, each server
+// receiving every pair it replicates. A pair succeeds once the configured
+// number of its replicas have accepted it.
+func (m *DBManager) BatchSet(ctx context.Context, pairs []KeyValue) []BatchWriteResult {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("batch_set").Observe(time.Since(start).Seconds())
+	}()
+
+	keys := make([]string, len(pairs))
+	rejected := make([]error, len(pairs))
+	for i, p := range pairs {
+		keys[i] = p.Key
+		rejected[i] = m.checkValueSize(p.Key, p.Value)
+	}
+
+	size := func(i int) int { return len(pairs[i].Key) + len(pairs[i].Value) }
+	return m.batchWrite(ctx, "batch_set", keys, rejected, size, func(ctx context.Context, b *serverBatch, epoch uint64) ([]*db_server.KeyResult, error) {
+		req := &db_server.BatchSetRequest{Pairs: make([]*db_server.KeyValuePair, len(b.indexes)), Epoch: epoch}
+		for j, i := range b.indexes {
+			req.Pairs[j] = &db_server.KeyValuePair{
+				Key:        pairs[i].Key,
+				ValueBytes: pairs[i].Value,
+				TtlSeconds: uint64((pairs[i].TTL + time.Second - 1) / time.Second),
+			}
+		}
+		resp, err := b.server.client.BatchSet(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	})
+}
+
+// BatchDelete deletes keys with one BatchDelete call per server. Deleting a
+// key that does not exist succeeds.
+func (m *DBManager) BatchDelete(ctx context.Context, keys []string) []BatchWriteResult {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("batch_delete").Observe(time.Since(start).Seconds())
+	}()
+
+	size := func(i int) int { return len(keys[i]) }
+	return m.batchWrite(ctx, "batch_delete", keys, nil, size, func(ctx context.Context, b *serverBatch, epoch uint64) ([]*db_server.KeyResult, error) {
+		req := &db_server.BatchDeleteRequest{Keys: make([]string, len(b.indexes)), Epoch: epoch}
+		for j, i := range b.indexes {
+			req.Keys[j] = keys[i]
+		}
+		resp, err := b.server.client.BatchDelete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	})
+}
+
+// batchWrite groups keys by the servers that replicate them, sends each
+// server its share in calls of at most maxBatchBytes, as sized by size, and
+// counts per key how many replicas accepted it. Keys with a non-nil rejected
+// error fail with it without being sent.
+func (m *DBManager) batchWrite(ctx context.Context, op string, keys []string, rejected []error, size func(int) int, call func(context.Context, *serverBatch, uint64) ([]*db_server.KeyResult, error)) []BatchWriteResult {
+	sets, errs, epoch := m.resolveBatch(keys)
+	results := make([]BatchWriteResult, len(keys))
+
+	byServer := make(map[string]*serverBatch)
+	var shares []*serverBatch
+	for i, key := range keys {
+		results[i].Key = key
+		if rejected != nil && rejected[i] != nil {
+			errs[i] = rejected[i]
+		}
+		if errs[i] == nil && len(sets[i].servers) < m.writeAcks(sets[i]) {
+			errs[i] = status.Errorf(codes.Unavailable, "%d replicas of key %q reachable, %d must accept the write", len(sets[i].servers), key, m.writeAcks(sets[i]))
+		}
+		if errs[i] != nil {
+			results[i].Err = errs[i]
+			continue
+		}
+		for _, server := range sets[i].servers {
+			b, ok := byServer[server.uuid]
+			if !ok {
+				b = &serverBatch{server: server}
+				byServer[server.uuid] = b
+				shares = append(shares, b)
+			}
+			b.indexes = append(b.indexes, i)
+		}
+	}
+
+	var batches []*serverBatch
+	for _, b := range shares {
+		batches = append(batches, splitBatch(b, size)...)
+	}
+
+	m.runBatches(ctx, batches, func(ctx context.Context, b *serverBatch) {
+		b.writeResults, b.err = call(ctx, b, epoch)
+	})
+
+	acked := make([]int, len(keys))
+	lastErr := make([]error, len(keys))
+	for _, b := range batches {
+		for j, i := range b.indexes {
+			switch {
+			case b.err != nil:
+				lastErr[i] = b.err
+			case j >= len(b.writeResults):
+				lastErr[i] = fmt.Errorf("server %s returned no result", b.server.uuid)
+			case !b.writeResults[j].Success:
+				lastErr[i] = errors.New(b.writeResults[j].Error)
+			default:
+				acked[i]++
+				ReplicationWrites.WithLabelValues("success").Inc()
+				continue
+			}
+			ReplicationWrites.WithLabelValues("failure").Inc()
+		}
+	}
+
+	failed := false
+	for i := range results {
+		if errs[i] != nil {
+			failed = true
+			continue
+		}
+		if acked[i] < m.writeAcks(sets[i]) {
+			results[i].Err = fmt.Errorf("write for key %q acknowledged by %d of %d replicas: %v", keys[i], acked[i], len(sets[i].servers), lastErr[i])
+			failed = true
+		}
+	}
+	recordBatch(op, failed)
+	return results
+}
+
+func recordBatch(op string, failed bool) {
+	if failed {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return
+	}
+	RequestsTotal.WithLabelValues(op, "success").Inc()
+}
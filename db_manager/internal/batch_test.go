@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatchSet_OneCallPerServer(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0, 0)
+
+	pairs := make([]KeyValue, 40)
+	for i := range pairs {
+		pairs[i] = KeyValue{Key: fmt.Sprintf("key-%d", i), Value: []byte(fmt.Sprintf("value-%d", i)), TTL: time.Minute}
+	}
+
+	for _, r := range m.BatchSet(context.Background(), pairs) {
+		if r.Err != nil {
+			t.Fatalf("BatchSet failed for %s: %v", r.Key, r.Err)
+		}
+	}
+
+	for _, s := range servers {
+		if calls := s.batchCallCount(); calls > 1 {
+			t.Fatalf("expected at most one batch call to %s, got %d", s.addr, calls)
+		}
+	}
+
+	for _, p := range pairs {
+		for _, r := range replicasFor(t, m, servers, p.Key) {
+			if v, _ := r.value(p.Key); v != string(p.Value) {
+				t.Fatalf("expected replica %s to hold %q for %s, got %q", r.addr, p.Value, p.Key, v)
+			}
+			if ttl := r.ttl(p.Key); ttl != 60 {
+				t.Fatalf("expected replica %s to hold a 60s TTL for %s, got %d", r.addr, p.Key, ttl)
+			}
+		}
+	}
+}
+
+func TestBatchSet_SplitsLargeShares(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0)
+
+	// Together far more than one gRPC message holds.
+	pairs := make([]KeyValue, 8)
+	for i := range pairs {
+		pairs[i] = KeyValue{Key: fmt.Sprintf("key-%d", i), Value: make([]byte, 1<<20-100)}
+	}
+	for _, r := range m.BatchSet(context.Background(), pairs) {
+		if r.Err != nil {
+			t.Fatalf("BatchSet failed for %s: %v", r.Key, r.Err)
+		}
+	}
+	if calls := servers[0].batchCallCount(); calls < 3 {
+		t.Fatalf("expected the batch to be split into calls under the message limit, got %d", calls)
+	}
+}
+
+func TestBatchGet(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}})
+
+	results := m.BatchGet(context.Background(), []string{"a", "missing", "b"})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	want := []BatchGetResult{
+		{Key: "a", Value: []byte("1"), Found: true},
+		{Key: "missing"},
+		{Key: "b", Value: []byte("2"), Found: true},
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("unexpected error for %s: %v", r.Key, r.Err)
+		}
+		if !reflect.DeepEqual(r, want[i]) {
+			t.Fatalf("result %d: expected %+v, got %+v", i, want[i], r)
+		}
+	}
+}
+
+func TestBatchGet_FailsOverToReplica(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "user:1", Value: []byte("Alice")}})
+
+	replicas := replicasFor(t, m, servers, "user:1")
+	replicas[0].grpc.Stop()
+
+	results := m.BatchGet(context.Background(), []string{"user:1"})
+	if results[0].Err != nil || !results[0].Found || string(results[0].Value) != "Alice" {
+		t.Fatalf("expected Alice from a replica, got %+v", results[0])
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}})
+
+	for _, r := range m.BatchDelete(context.Background(), []string{"a", "b", "missing"}) {
+		if r.Err != nil {
+			t.Fatalf("BatchDelete failed for %s: %v", r.Key, r.Err)
+		}
+	}
+
+	for _, s := range servers {
+		for _, key := range []string{"a", "b"} {
+			if _, ok := s.value(key); ok {
+				t.Fatalf("expected %s to be deleted from %s", key, s.addr)
+			}
+		}
+	}
+}
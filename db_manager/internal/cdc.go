@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CDC mirrors every mutation into external sinks. Each sink runs its own
+// pipeline over a cluster-wide Watch, batches the changes, and after every
+// batch it acknowledges stores the batch's resume token as its checkpoint.
+// A restarted pipeline resumes from the checkpoint, so records delivered but
+// not yet checkpointed are delivered again: delivery is at-least-once.
+//
+// A pipeline resumes not only after a restart but whenever its watch ends,
+// which a db_server does once the pipeline falls more than 1024 changes
+// behind its live feed. A server replays the changes since the checkpoint
+// while its feed still holds them. Past that it catches up from storage,
+// which keeps only the latest change of each key, so intermediate values and
+// deletes it no longer remembers are lost; the sink then gets a gap record
+// first, telling it to resync the keys it mirrors from that server.
+type CDC struct {
+	cancel    context.CancelFunc
+	pipelines sync.WaitGroup
+}
+
+type cdcPipeline struct {
+	m          *DBManager
+	opts       CDCOptions
+	name       string
+	prefix     string
+	sink       Sink
+	checkpoint string
+}
+
+// cdcCheckpoint is the content of a sink's checkpoint file.
+type cdcCheckpoint struct {
+	ResumeToken string    `json:"resume_token"`
+	Records     uint64    `json:"records"`
+	Updated     time.Time `json:"updated"`
+}
+
+// StartCDC builds the configured sinks and starts a pipeline for each.
+func (m *DBManager) StartCDC(opts CDCOptions) (*CDC, error) {
+	if err := os.MkdirAll(opts.CheckpointDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create CDC checkpoint directory: %v", err)
+	}
+
+	var pipelines []*cdcPipeline
+	closeAll := func() {
+		for _, p := range pipelines {
+			p.sink.Close()
+		}
+	}
+	names := make(map[string]bool)
+	for _, sinkOpts := range opts.Sinks {
+		if sinkOpts.Name == "" || names[sinkOpts.Name] {
+			closeAll()
+			return nil, fmt.Errorf("CDC sink names must be set and unique, got %q", sinkOpts.Name)
+		}
+		names[sinkOpts.Name] = true
+
+		sink, err := NewSink(sinkOpts)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		pipelines = append(pipelines, m.newCDCPipeline(opts, sinkOpts.Name, sinkOpts.Prefix, sink))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CDC{cancel: cancel}
+	for _, p := range pipelines {
+		c.pipelines.Add(1)
+		go func(p *cdcPipeline) {
+			defer c.pipelines.Done()
+			defer p.sink.Close()
+			p.run(ctx)
+		}(p)
+	}
+	return c, nil
+}
+
+func (m *DBManager) newCDCPipeline(opts CDCOptions, name, prefix string, sink Sink) *cdcPipeline {
+	return &cdcPipeline{
+		m:          m,
+		opts:       opts,
+		name:       name,
+		prefix:     prefix,
+		sink:       sink,
+		checkpoint: filepath.Join(opts.CheckpointDir, name+".checkpoint"),
+	}
+}
+
+// Stop ends every pipeline and waits for them. A batch being delivered is
+// abandoned; it is delivered again from the checkpoint on the next start.
+func (c *CDC) Stop() {
+	c.cancel()
+	c.pipelines.Wait()
+}
+
+// run follows the change stream from the checkpoint until ctx is done,
+// watching again whenever the watch ends, e.g. on a topology change.
+func (p *cdcPipeline) run(ctx context.Context) {
+	cp, err := p.loadCheckpoint()
+	if err != nil {
+		log.Error().Err(err).Msgf("CDC sink %s cannot start", p.name)
+		return
+	}
+
+	backoff := p.opts.RetryBackoff
+	for ctx.Err() == nil {
+		err := p.follow(ctx, &cp)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warn().Err(err).Msgf("CDC stream for sink %s ended; resuming", p.name)
+		backoff = p.sleep(ctx, backoff)
+	}
+}
+
+// follow runs one watch from cp, delivering and checkpointing batches, and
+// returns once the watch ends and everything it produced is delivered.
+func (p *cdcPipeline) follow(ctx context.Context, cp *cdcCheckpoint) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan WatchEvent, p.opts.BatchSize)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- p.m.Watch(watchCtx, WatchOptions{Prefix: p.prefix, ResumeToken: cp.ResumeToken}, func(e WatchEvent) error {
+			select {
+			case events <- e:
+				return nil
+			case <-watchCtx.Done():
+				return watchCtx.Err()
+			}
+		})
+	}()
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []WatchEvent
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := p.deliver(ctx, batch, cp); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		select {
+		case e := <-events:
+			batch = append(batch, e)
+			if len(batch) >= p.opts.BatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case err := <-watchErr:
+			// Watch has returned, so every event it produced is queued.
+			for len(events) > 0 {
+				batch = append(batch, <-events)
+			}
+			if ferr := flush(); ferr != nil {
+				return ferr
+			}
+			return err
+		}
+	}
+}
+
+// deliver writes batch to the sink, retrying until it is accepted or ctx is
+// done, then checkpoints past it.
+func (p *cdcPipeline) deliver(ctx context.Context, batch []WatchEvent, cp *cdcCheckpoint) error {
+	records := make([]ChangeRecord, len(batch))
+	gaps := 0
+	for i, e := range batch {
+		records[i] = ChangeRecord{
+			Key:        e.Key,
+			Value:      e.Value,
+			Deleted:    e.Deleted,
+			Version:    e.Version,
+			TTLSeconds: uint64(e.TTL / time.Second),
+			Server:     e.Server,
+			Time:       e.Time,
+			Gap:        e.Gap,
+		}
+		if e.Gap {
+			gaps++
+			log.Warn().Msgf("CDC sink %s may have missed changes made on server %s after version %d", p.name, e.Server, e.Version)
+		}
+	}
+
+	backoff := p.opts.RetryBackoff
+	for {
+		err := p.sink.Write(ctx, records)
+		if err == nil {
+			break
+		}
+		CDCFailures.WithLabelValues(p.name).Inc()
+		log.Warn().Err(err).Msgf("CDC sink %s rejected a batch of %d records; retrying", p.name, len(records))
+		backoff = p.sleep(ctx, backoff)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	CDCRecords.WithLabelValues(p.name).Add(float64(len(records)))
+	CDCGaps.WithLabelValues(p.name).Add(float64(gaps))
+
+	cp.ResumeToken = batch[len(batch)-1].ResumeToken
+	cp.Records += uint64(len(records))
+	cp.Updated = time.Now().UTC()
+	if err := p.saveCheckpoint(*cp); err != nil {
+		// The batch is delivered; at worst it is delivered again after a
+		// restart.
+		log.Warn().Err(err).Msgf("Failed to checkpoint CDC sink %s", p.name)
+	}
+	return nil
+}
+
+// sleep waits for backoff or until ctx is done and returns the next backoff.
+func (p *cdcPipeline) sleep(ctx context.Context, backoff time.Duration) time.Duration {
+	select {
+	case <-time.After(backoff):
+	case <-ctx.Done():
+	}
+	if backoff *= 2; backoff > p.opts.MaxRetryBackoff {
+		backoff = p.opts.MaxRetryBackoff
+	}
+	return backoff
+}
+
+func (p *cdcPipeline) loadCheckpoint() (cdcCheckpoint, error) {
+	var cp cdcCheckpoint
+	raw, err := os.ReadFile(p.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("failed to read checkpoint %s: %v", p.checkpoint, err)
+	}
+	if err := json.Unmarshal(raw, &cp); err != nil {
+		return cp, fmt.Errorf("corrupt checkpoint %s: %v", p.checkpoint, err)
+	}
+	return cp, nil
+}
+
+// saveCheckpoint replaces the checkpoint file atomically.
+func (p *cdcPipeline) saveCheckpoint(cp cdcCheckpoint) error {
+	raw, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	tmp := p.checkpoint + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(raw); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.checkpoint)
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ChangeRecord is one mutation as delivered to a CDC sink. Version is the
+// change's version on Server, the primary of the key when it changed;
+// records from one server are delivered in version order, so every key range
+// a server owns is mirrored in order. Time is when Server committed the
+// change. Value is base64 in JSON.
+//
+// Records delivered after the pipeline resumed its watch, e.g. after falling
+// behind, are replayed from the servers' recent changes while they reach
+// back to the checkpoint, and otherwise come from a catch-up: each key's
+// latest change only, with a zero Time. Such a catch-up is preceded by a Gap
+// record, which carries no change: Server's changes after Version may be
+// missing, and the keys mirrored from it should be resynced.
+type ChangeRecord struct {
+	Key        string    `json:"key"`
+	Value      []byte    `json:"value,omitempty"`
+	Deleted    bool      `json:"deleted,omitempty"`
+	Version    uint64    `json:"version"`
+	TTLSeconds uint64    `json:"ttl_seconds,omitempty"`
+	Server     string    `json:"server"`
+	Time       time.Time `json:"time"`
+	Gap        bool      `json:"gap,omitempty"`
+}
+
+// Sink receives batches of change records. Write must only return nil once
+// the whole batch is durably accepted; a failed batch is retried, so a sink
+// sees every record at least once and should tolerate duplicates.
+type Sink interface {
+	Write(ctx context.Context, records []ChangeRecord) error
+	Close() error
+}
+
+// Sink types accepted in SinkOptions.Type.
+const (
+	SinkFile    = "file"
+	SinkWebhook = "webhook"
+)
+
+// NewSink builds the sink described by opts.
+func NewSink(opts SinkOptions) (Sink, error) {
+	switch opts.Type {
+	case SinkFile:
+		return NewFileSink(opts.Path)
+	case SinkWebhook:
+		return NewWebhookSink(opts.URL, opts.Headers, opts.Timeout)
+	default:
+		return nil, fmt.Errorf("unknown CDC sink type %q for sink %q", opts.Type, opts.Name)
+	}
+}
+
+// FileSink appends records to a file as JSON lines, syncing after every
+// batch.
+type FileSink struct {
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file sink needs a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	return &FileSink{file: f}, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, records []ChangeRecord) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %d records to %s: %v", len(records), s.file.Name(), err)
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// WebhookSink POSTs each batch to a URL as a JSON object with a "records"
+// array. Any 2xx answer acknowledges the batch.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) (*WebhookSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook sink needs a url")
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *WebhookSink) Write(ctx context.Context, records []ChangeRecord) error {
+	body, err := json.Marshal(struct {
+		Records []ChangeRecord `json:"records"`
+	}{records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post %d records to %s: %v", len(records), s.url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testCDCOptions(t *testing.T, sinks ...SinkOptions) CDCOptions {
+	t.Helper()
+	opts := DefaultOptions().CDC
+	opts.CheckpointDir = filepath.Join(t.TempDir(), "cdc")
+	opts.FlushInterval = 20 * time.Millisecond
+	opts.RetryBackoff = 10 * time.Millisecond
+	opts.Sinks = sinks
+	return opts
+}
+
+func startTestCDC(t *testing.T, m *DBManager, opts CDCOptions) *CDC {
+	t.Helper()
+	c, err := m.StartCDC(opts)
+	if err != nil {
+		t.Fatalf("StartCDC failed: %v", err)
+	}
+	t.Cleanup(c.Stop)
+
+	// Let the pipelines open their watches before the test writes.
+	time.Sleep(100 * time.Millisecond)
+	return c
+}
+
+// waitForRecords polls read until it returns at least n records.
+func waitForRecords(t *testing.T, n int, read func() []ChangeRecord) []ChangeRecord {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		records := read()
+		if len(records) >= n {
+			return records
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d records, got %d: %+v", n, len(records), records)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func readRecordFile(t *testing.T, path string) []ChangeRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var records []ChangeRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r ChangeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("malformed record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// waitForCheckpoint polls until the sink's checkpoint has counted at least n
+// records; a batch is checkpointed only after the sink accepted it.
+func waitForCheckpoint(t *testing.T, opts CDCOptions, sink string, n uint64) cdcCheckpoint {
+	t.Helper()
+	path := filepath.Join(opts.CheckpointDir, sink+".checkpoint")
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		var cp cdcCheckpoint
+		if raw, err := os.ReadFile(path); err == nil && json.Unmarshal(raw, &cp) == nil && cp.Records >= n {
+			return cp
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a checkpoint of %d records at %s", n, path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCDC_FileSink(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	path := filepath.Join(t.TempDir(), "changes.jsonl")
+	opts := testCDCOptions(t, SinkOptions{Name: "file", Type: SinkFile, Path: path, Prefix: "user:"})
+	startTestCDC(t, m, opts)
+
+	start := time.Now()
+	m.SetKey(context.Background(), "user:1", []byte("v1"), 0)
+	m.SetKey(context.Background(), "order:1", []byte("ignored"), 0)
+	m.SetKey(context.Background(), "user:1", []byte("v2"), 0)
+	m.DeleteKey(context.Background(), "user:1")
+	m.WaitForBackgroundWrites()
+
+	records := waitForRecords(t, 3, func() []ChangeRecord { return readRecordFile(t, path) })
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %+v", records)
+	}
+	if string(records[0].Value) != "v1" || string(records[1].Value) != "v2" || !records[2].Deleted {
+		t.Fatalf("expected v1, v2 and a delete in order, got %+v", records)
+	}
+	for i, r := range records {
+		if r.Key != "user:1" || r.Server == "" {
+			t.Fatalf("unexpected record %+v", r)
+		}
+		if r.Time.Before(start) || r.Time.After(time.Now()) {
+			t.Fatalf("expected the commit time of the change, got %+v", r)
+		}
+		if i > 0 && r.Version <= records[i-1].Version {
+			t.Fatalf("expected increasing versions, got %+v", records)
+		}
+	}
+
+	if cp := waitForCheckpoint(t, opts, "file", 3); cp.ResumeToken == "" {
+		t.Fatalf("expected a checkpoint with a resume token, got %+v", cp)
+	}
+}
+
+// webhookReceiver stands in for a webhook endpoint, rejecting the first
+// failures requests.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	records  []ChangeRecord
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var body struct {
+		Records []ChangeRecord `json:"records"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.records = append(r.records, body.Records...)
+}
+
+func (r *webhookReceiver) received() []ChangeRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ChangeRecord(nil), r.records...)
+}
+
+func TestCDC_WebhookSinkRetries(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	receiver := &webhookReceiver{failures: 1}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	startTestCDC(t, m, testCDCOptions(t, SinkOptions{Name: "hook", Type: SinkWebhook, URL: srv.URL}))
+
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+
+	records := waitForRecords(t, 1, receiver.received)
+	if records[0].Key != "a" || string(records[0].Value) != "1" {
+		t.Fatalf("expected a=1, got %+v", records)
+	}
+	receiver.mu.Lock()
+	requests := receiver.requests
+	receiver.mu.Unlock()
+	if requests < 2 {
+		t.Fatalf("expected the rejected batch to be retried, got %d requests", requests)
+	}
+}
+
+func TestCDC_ResumesFromCheckpoint(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	path := filepath.Join(t.TempDir(), "changes.jsonl")
+	opts := testCDCOptions(t, SinkOptions{Name: "file", Type: SinkFile, Path: path})
+
+	c := startTestCDC(t, m, opts)
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+	waitForRecords(t, 1, func() []ChangeRecord { return readRecordFile(t, path) })
+	c.Stop()
+
+	// Changes made while CDC is down are delivered after the restart, and
+	// the checkpointed ones are not delivered again.
+	m.SetKey(context.Background(), "b", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+	startTestCDC(t, m, opts)
+
+	waitForRecords(t, 2, func() []ChangeRecord { return readRecordFile(t, path) })
+	time.Sleep(100 * time.Millisecond)
+	records := readRecordFile(t, path)
+	if len(records) != 2 || records[0].Key != "a" || records[1].Key != "b" {
+		t.Fatalf("expected a then b exactly once, got %+v", records)
+	}
+}
+
+func TestCDC_ReportsGapPastBacklog(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	path := filepath.Join(t.TempDir(), "changes.jsonl")
+	opts := testCDCOptions(t, SinkOptions{Name: "file", Type: SinkFile, Path: path})
+
+	c := startTestCDC(t, m, opts)
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+	waitForRecords(t, 1, func() []ChangeRecord { return readRecordFile(t, path) })
+	c.Stop()
+
+	// The sink falls so far behind that the servers' feeds no longer hold
+	// the changes since its checkpoint.
+	m.SetKey(context.Background(), "a", []byte("2"), 0)
+	m.SetKey(context.Background(), "a", []byte("3"), 0)
+	m.WaitForBackgroundWrites()
+	for _, s := range servers {
+		s.mu.Lock()
+		s.feedFloor = s.clock
+		s.mu.Unlock()
+	}
+	startTestCDC(t, m, opts)
+
+	replicas := replicasFor(t, m, servers, "a")
+	waitForRecords(t, 2+len(replicas), func() []ChangeRecord { return readRecordFile(t, path) })
+	time.Sleep(100 * time.Millisecond)
+	records := readRecordFile(t, path)
+
+	// Each replica of a reports a gap before its catch-up, which collapses
+	// a's changes into the latest; a=2 is never delivered.
+	gaps := make(map[string]bool)
+	latest := 0
+	for _, r := range records[1:] {
+		switch {
+		case r.Gap:
+			if r.Key != "" || r.Server == "" {
+				t.Fatalf("expected a gap record without a change, got %+v", r)
+			}
+			gaps[r.Server] = true
+		case r.Key == "a" && string(r.Value) == "3":
+			if !gaps[r.Server] {
+				t.Fatalf("expected a gap from %s before its catch-up, got %+v", r.Server, records)
+			}
+			latest++
+		default:
+			t.Fatalf("expected only gaps and a=3 after the restart, got %+v", records)
+		}
+	}
+	if latest != 1 || len(gaps) != len(replicas) {
+		t.Fatalf("expected a gap from each replica of a and a=3 once, got %+v", records)
+	}
+	if cp := waitForCheckpoint(t, opts, "file", uint64(len(records))); cp.ResumeToken == "" {
+		t.Fatalf("expected the gap records to be checkpointed, got %+v", cp)
+	}
+}
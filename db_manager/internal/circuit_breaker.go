@@ -0,0 +1,225 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// controlPlaneMethods lists the DBServer methods that bypass the breaker and
+// load tracking. Health checks must keep flowing so that an open breaker does
+// not get its server evicted, and ownership pushes and key listing are not
+// client traffic.
+var controlPlaneMethods = map[string]struct{}{
+	"/db_server.DBServer/HealthCheck":     {},
+	"/db_server.DBServer/UpdateOwnership": {},
+	"/db_server.DBServer/ListKeys":        {},
+}
+
+// circuitBreaker guards the data-path calls to one db_server. It trips open
+// when the share of failed or slow calls in the recent window reaches the
+// configured rate, rejects calls while open, and after OpenDuration lets a few
+// probe calls through in the half-open state to decide whether to close again.
+type circuitBreaker struct {
+	mu   sync.Mutex
+	opts BreakerOptions
+	now  func() time.Time
+
+	state    breakerState
+	openedAt time.Time
+
+	outcomes []bool
+	next     int
+	count    int
+	failures int
+
+	probesInFlight int
+	probeSuccesses int
+
+	onStateChange func(breakerState)
+}
+
+func newCircuitBreaker(opts BreakerOptions, onStateChange func(breakerState)) *circuitBreaker {
+	window := opts.Window
+	if window < 1 {
+		window = 1
+	}
+
+	return &circuitBreaker{
+		opts:          opts,
+		now:           time.Now,
+		outcomes:      make([]bool, window),
+		onStateChange: onStateChange,
+	}
+}
+
+func (b *circuitBreaker) State() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// available reports whether requests may be routed to the server. An open
+// breaker whose cool-down has elapsed moves to half-open here, so routing
+// starts sending it probe traffic.
+func (b *circuitBreaker) available() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maybeHalfOpenLocked()
+	return b.state != breakerOpen
+}
+
+// allow admits a single call. In the half-open state only HalfOpenProbes calls
+// may be outstanding at once.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maybeHalfOpenLocked()
+	switch b.state {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		if b.probesInFlight >= b.opts.HalfOpenProbes {
+			return false
+		}
+		b.probesInFlight++
+	}
+	return true
+}
+
+// record reports the outcome of a call admitted by allow.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		if b.probesInFlight > 0 {
+			b.probesInFlight--
+		}
+		if failed {
+			b.transitionLocked(breakerOpen)
+			return
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.opts.HalfOpenProbes {
+			b.transitionLocked(breakerClosed)
+		}
+		return
+	}
+
+	if b.state != breakerClosed {
+		return
+	}
+
+	if b.count == len(b.outcomes) {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.outcomes[b.next] = failed
+	b.next = (b.next + 1) % len(b.outcomes)
+	if failed {
+		b.failures++
+	}
+
+	if b.count >= b.opts.MinCalls && float64(b.failures) >= b.opts.FailureRate*float64(b.count) {
+		b.transitionLocked(breakerOpen)
+	}
+}
+
+// release gives back a half-open probe slot for a call whose outcome says
+// nothing about the server's health, such as one cancelled by the caller.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen && b.probesInFlight > 0 {
+		b.probesInFlight--
+	}
+}
+
+func (b *circuitBreaker) maybeHalfOpenLocked() {
+	if b.state == breakerOpen && b.now().Sub(b.openedAt) >= b.opts.OpenDuration {
+		b.transitionLocked(breakerHalfOpen)
+	}
+}
+
+func (b *circuitBreaker) transitionLocked(state breakerState) {
+	b.state = state
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+
+	switch state {
+	case breakerOpen:
+		b.openedAt = b.now()
+	case breakerClosed:
+		b.count, b.next, b.failures = 0, 0, 0
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
+
+// unaryInterceptor gates data-path calls on the breaker and feeds their
+// outcome back into it. Calls taking longer than SlowCall count as failures
+// even when they succeed.
+func (b *circuitBreaker) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, exempt := controlPlaneMethods[method]; exempt {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	if !b.allow() {
+		return status.Errorf(codes.Unavailable, "circuit breaker open for %s", cc.Target())
+	}
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	elapsed := time.Since(start)
+
+	if status.Code(err) == codes.Canceled {
+		b.release()
+		return err
+	}
+
+	b.record(isServerFailure(err) || (b.opts.SlowCall > 0 && elapsed > b.opts.SlowCall))
+	return err
+}
+
+// isServerFailure tells server or transport faults apart from errors that are
+// part of normal operation, such as a missing key or a fenced request.
+func isServerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.ResourceExhausted, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testBreakerOptions() BreakerOptions {
+	return BreakerOptions{
+		Enabled:        true,
+		Window:         10,
+		MinCalls:       4,
+		FailureRate:    0.5,
+		SlowCall:       time.Second,
+		OpenDuration:   10 * time.Second,
+		HalfOpenProbes: 2,
+	}
+}
+
+func newTestBreaker() (*circuitBreaker, *time.Time) {
+	now := time.Unix(0, 0)
+	b := newCircuitBreaker(testBreakerOptions(), nil)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreaker_StaysClosedBelowMinCalls(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	for i := 0; i < 3; i++ {
+		b.record(true)
+	}
+	if b.State() != breakerClosed {
+		t.Fatalf("expected closed below min calls, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_OpensOnFailureRate(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	b.record(false)
+	b.record(false)
+	b.record(true)
+	if b.State() != breakerClosed {
+		t.Fatalf("expected closed, got %s", b.State())
+	}
+
+	b.record(true)
+	if b.State() != breakerOpen {
+		t.Fatalf("expected open at 50%% failures, got %s", b.State())
+	}
+	if b.allow() || b.available() {
+		t.Fatal("expected open breaker to reject calls")
+	}
+}
+
+func TestCircuitBreaker_WindowSlides(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	for i := 0; i < 4; i++ {
+		b.record(false)
+		b.record(false)
+		b.record(true)
+	}
+	if b.State() != breakerClosed {
+		t.Fatalf("expected closed with 1/3 failure rate, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenCloses(t *testing.T) {
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		b.record(true)
+	}
+
+	*now = now.Add(10 * time.Second)
+	if !b.available() {
+		t.Fatal("expected breaker to become available after open duration")
+	}
+	if b.State() != breakerHalfOpen {
+		t.Fatalf("expected half-open, got %s", b.State())
+	}
+
+	if !b.allow() || !b.allow() {
+		t.Fatal("expected half-open breaker to admit probes")
+	}
+	if b.allow() {
+		t.Fatal("expected half-open breaker to cap outstanding probes")
+	}
+
+	b.record(false)
+	b.record(false)
+	if b.State() != breakerClosed {
+		t.Fatalf("expected closed after successful probes, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenReopens(t *testing.T) {
+	b, now := newTestBreaker()
+	for i := 0; i < 4; i++ {
+		b.record(true)
+	}
+
+	*now = now.Add(10 * time.Second)
+	if !b.allow() {
+		t.Fatal("expected half-open breaker to admit a probe")
+	}
+	b.record(true)
+
+	if b.State() != breakerOpen {
+		t.Fatalf("expected failed probe to reopen breaker, got %s", b.State())
+	}
+}
+
+func TestGetReplicaServers_SkipsOpenBreaker(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0)
+
+	servers, _, err := m.getReplicaServers("user:1")
+	if err != nil || len(servers) != 2 {
+		t.Fatalf("expected 2 replicas, got %d (err=%v)", len(servers), err)
+	}
+
+	primary := servers[0]
+	for i := 0; i < m.opts.Breaker.MinCalls; i++ {
+		primary.breaker.record(true)
+	}
+
+	servers, _, err = m.getReplicaServers("user:1")
+	if err != nil {
+		t.Fatalf("getReplicaServers failed: %v", err)
+	}
+	if len(servers) != 1 || servers[0].uuid == primary.uuid {
+		t.Fatalf("expected only the healthy replica, got %d servers", len(servers))
+	}
+
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("expected writes to succeed on the healthy replica: %v", err)
+	}
+
+	status, _ := m.GetClusterStatus()
+	for _, s := range status {
+		want := "closed"
+		if s.UUID == primary.uuid {
+			want = "open"
+		}
+		if s.Breaker != want {
+			t.Fatalf("server %s: expected breaker %s, got %s", s.UUID, want, s.Breaker)
+		}
+	}
+}
+
+func TestPrimaryOnlyOps_FailWithPrimaryBreakerOpen(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0)
+	replicas := replicasFor(t, m, servers, "user:1")
+
+	reachable, _, _ := m.getReplicaServers("user:1")
+	for i := 0; i < m.opts.Breaker.MinCalls; i++ {
+		reachable[0].breaker.record(true)
+	}
+
+	if _, _, err := m.GetKeyVersion(context.Background(), "user:1"); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from GetKeyVersion, got %v", err)
+	}
+	if _, err := m.SetKeyIfAbsent(context.Background(), "user:1", []byte("Alice"), 0); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from SetKeyIfAbsent, got %v", err)
+	}
+	if _, err := m.Increment(context.Background(), "user:1", 1); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from Increment, got %v", err)
+	}
+	if _, err := m.Transact(context.Background(), []TxnOp{{Key: "user:1", Value: []byte("Alice")}}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from Transact, got %v", err)
+	}
+
+	m.WaitForBackgroundWrites()
+	if v, ok := replicas[1].value("user:1"); ok {
+		t.Fatalf("expected the replica not to be promoted, but it took %q", v)
+	}
+}
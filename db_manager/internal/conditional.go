@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Versions are assigned by each db_server's storage engine and differ between
+// replicas, so versioned reads and conditional writes all go to the key's
+// primary. If the primary changes, a version read from the old one no longer
+// matches and the conditional write fails safely.
+
+// GetKeyVersion reads key and its version from the key's primary. The read is
+// not hedged, and fails with UNAVAILABLE while the primary's breaker is open,
+// since a replica's version would be useless to SetKeyIfVersion.
+func (m *DBManager) GetKeyVersion(ctx context.Context, key string) ([]byte, uint64, error) {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("get").Observe(time.Since(start).Seconds())
+	}()
+
+	set, epoch, err := m.getReplicaSet(key)
+	if err != nil {
+		RequestsTotal.WithLabelValues("get", "error").Inc()
+		return nil, 0, err
+	}
+	primary, err := set.primaryServer(key)
+	if err != nil {
+		RequestsTotal.WithLabelValues("get", "error").Inc()
+		return nil, 0, err
+	}
+	if err := m.admit([]dbServer{primary}); err != nil {
+		RequestsTotal.WithLabelValues("get", "shed").Inc()
+		return nil, 0, err
+	}
+
+	callCtx, cancel := m.callContext(ctx)
+	defer cancel()
+
+	resp, err := primary.client.Get(callCtx, &db_server.GetRequest{Key: key, Epoch: epoch})
+	if err != nil {
+		RequestsTotal.WithLabelValues("get", "error").Inc()
+		return nil, 0, fmt.Errorf("primary %s failed for key %q: %w", primary.uuid, key, err)
+	}
+
+	RequestsTotal.WithLabelValues("get", "success").Inc()
+	return storedValue(resp.ValueBytes, resp.Value), resp.Version, nil
+}
+
+// SetKeyIfAbsent writes key only if it does not exist yet.
+func (m *DBManager) SetKeyIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	req := &db_server.ConditionalSetRequest{Condition: &db_server.ConditionalSetRequest_IfAbsent{IfAbsent: true}}
+	return m.conditionalSet(ctx, "set_if_absent", key, value, ttl, req)
+}
+
+// SetKeyIfVersion overwrites key only if the primary holds it at version.
+func (m *DBManager) SetKeyIfVersion(ctx context.Context, key string, value []byte, version uint64, ttl time.Duration) (bool, error) {
+	req := &db_server.ConditionalSetRequest{Condition: &db_server.ConditionalSetRequest_IfVersion{IfVersion: version}}
+	return m.conditionalSet(ctx, "set_if_version", key, value, ttl, req)
+}
+
+// conditionalSet fills in req, which carries only the condition, and runs it
+// through writeThroughPrimary.
+func (m *DBManager) conditionalSet(ctx context.Context, op, key string, value []byte, ttl time.Duration, req *db_server.ConditionalSetRequest) (bool, error) {
+	if err := m.checkValueSize(key, value); err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, err
+	}
+
+	ttlSeconds := uint64((ttl + time.Second - 1) / time.Second)
+	req.Key, req.ValueBytes, req.TtlSeconds = key, value, ttlSeconds
+	return m.writeThroughPrimary(ctx, op, key,
+		func(ctx context.Context, primary dbServer, epoch uint64) (uint64, error) {
+			req.Epoch = epoch
+			resp, err := primary.client.ConditionalSet(ctx, req)
+			if err != nil {
+				return 0, err
+			}
+			return resp.Order, nil
+		},
+		func(ctx context.Context, server dbServer, epoch, order uint64) error {
+			_, err := server.client.Set(ctx, &db_server.SetRequest{Key: key, ValueBytes: value, Epoch: epoch, TtlSeconds: ttlSeconds, PrimaryOrder: order})
+			return err
+		})
+}
+
+// DeleteKeyIfValue deletes key only if the primary holds it with value.
+func (m *DBManager) DeleteKeyIfValue(ctx context.Context, key string, value []byte) (bool, error) {
+	return m.writeThroughPrimary(ctx, "delete_if_value", key,
+		func(ctx context.Context, primary dbServer, epoch uint64) (uint64, error) {
+			resp, err := primary.client.ConditionalDelete(ctx, &db_server.ConditionalDeleteRequest{Key: key, Epoch: epoch, IfValueBytes: value})
+			if err != nil {
+				return 0, err
+			}
+			return resp.Order, nil
+		},
+		func(ctx context.Context, server dbServer, epoch, order uint64) error {
+			_, err := server.client.Delete(ctx, &db_server.DeleteRequest{Key: key, Epoch: epoch, PrimaryOrder: order})
+			return err
+		})
+}
+
+// writeThroughPrimary applies a write on the key's primary, where conditions
+// are checked and read-modify-writes happen atomically, then copies the
+// outcome to the other replicas. primaryWrite returns the order the primary
+// gave the write, which replicaWrite sends with the copy: copies run in the
+// background and may arrive out of order, and a replica drops a copy older
+// than one it already applied. Errors from the primary, such as ABORTED for
+// a failed condition, are returned with their status intact. While the
+// primary's breaker is open the write fails with UNAVAILABLE.
+func (m *DBManager) writeThroughPrimary(ctx context.Context, op, key string, primaryWrite func(context.Context, dbServer, uint64) (uint64, error), replicaWrite func(context.Context, dbServer, uint64, uint64) error) (bool, error) {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	}()
+
+	set, epoch, err := m.getReplicaSet(key)
+	if err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, err
+	}
+	primary, err := set.primaryServer(key)
+	if err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, err
+	}
+	acks := m.writeAcks(set)
+	if len(set.servers) < acks {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, status.Errorf(codes.Unavailable, "%s for key %q needs %d replicas, %d reachable", op, key, acks, len(set.servers))
+	}
+	if err := m.admit([]dbServer{primary}); err != nil {
+		RequestsTotal.WithLabelValues(op, "shed").Inc()
+		return false, err
+	}
+
+	callCtx, cancel := m.callContext(ctx)
+	order, err := primaryWrite(callCtx, primary, epoch)
+	cancel()
+	if err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, fmt.Errorf("primary %s rejected %s for key %q: %w", primary.uuid, op, key, err)
+	}
+
+	acked, err := m.replicateFromPrimary(ctx, set.servers[1:], acks, func(ctx context.Context, server dbServer) error {
+		if err := replicaWrite(ctx, server, epoch, order); err != nil {
+			ReplicationWrites.WithLabelValues("failure").Inc()
+			return err
+		}
+		ReplicationWrites.WithLabelValues("success").Inc()
+		return nil
+	})
+	if err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		return false, fmt.Errorf("%s for key %q applied on the primary but acknowledged by only %d other replicas: %v", op, key, acked, err)
+	}
+
+	RequestsTotal.WithLabelValues(op, "success").Inc()
+	return true, nil
+}
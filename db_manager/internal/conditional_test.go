@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetKeyIfAbsent(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKeyIfAbsent(context.Background(), "lock", []byte("owner-1"), 0); err != nil {
+		t.Fatalf("SetKeyIfAbsent on missing key failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	for _, r := range replicasFor(t, m, servers, "lock") {
+		if v, _ := r.value("lock"); v != "owner-1" {
+			t.Fatalf("expected replica %s to hold 'owner-1', got %q", r.addr, v)
+		}
+	}
+
+	_, err := m.SetKeyIfAbsent(context.Background(), "lock", []byte("owner-2"), 0)
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("expected ABORTED for existing key, got %v", err)
+	}
+}
+
+func TestSetKeyIfVersion(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "counter", []byte("1"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	value, version, err := m.GetKeyVersion(context.Background(), "counter")
+	if err != nil {
+		t.Fatalf("GetKeyVersion failed: %v", err)
+	}
+	if string(value) != "1" {
+		t.Fatalf("expected '1', got %q", value)
+	}
+
+	if _, err := m.SetKeyIfVersion(context.Background(), "counter", []byte("2"), version, 0); err != nil {
+		t.Fatalf("SetKeyIfVersion with current version failed: %v", err)
+	}
+
+	_, err = m.SetKeyIfVersion(context.Background(), "counter", []byte("3"), version, 0)
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("expected ABORTED for stale version, got %v", err)
+	}
+}
+
+func TestDeleteKeyIfValue(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "lock", []byte("owner-1"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	_, err := m.DeleteKeyIfValue(context.Background(), "lock", []byte("owner-2"))
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("expected ABORTED for different value, got %v", err)
+	}
+
+	if _, err := m.DeleteKeyIfValue(context.Background(), "lock", []byte("owner-1")); err != nil {
+		t.Fatalf("DeleteKeyIfValue with matching value failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	for _, r := range replicasFor(t, m, servers, "lock") {
+		if _, ok := r.value("lock"); ok {
+			t.Fatalf("expected replica %s to have dropped the key", r.addr)
+		}
+	}
+}
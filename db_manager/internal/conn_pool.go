@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// connPool spreads the RPCs for one db_server over several connections in
+// round-robin order. It implements grpc.ClientConnInterface, so the generated
+// DBServerClient can be built on top of it.
+type connPool struct {
+	conns []*grpc.ClientConn
+	next  atomic.Uint32
+}
+
+func newConnPool(addr string, opts Options, extra ...grpc.DialOption) (*connPool, error) {
+	size := opts.Pool.Size
+	if size < 1 {
+		size = 1
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.Pool.KeepaliveTime,
+			Timeout:             opts.Pool.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+	}
+	if opts.RPC.MaxRetries > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(retryServiceConfig(opts.RPC)))
+	}
+	dialOpts = append(dialOpts, extra...)
+
+	p := &connPool{conns: make([]*grpc.ClientConn, 0, size)}
+	for i := 0; i < size; i++ {
+		conn, err := grpc.NewClient(addr, dialOpts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, conn)
+	}
+
+	return p, nil
+}
+
+// retryableMethods are the DBServer methods that are safe to send again when
+// the server may already have applied them. Increment, the conditional
+// writes and the transaction RPCs are not: a retry could increment twice or
+// fail the caller's own write. Neither is Delete, whose retry would find the
+// key already gone and report it not found.
+var retryableMethods = []string{
+	"HealthCheck", "Get", "Set", "TTL", "ListKeys", "Scan",
+	"RangeStats", "BatchGet", "BatchSet", "BatchDelete", "UpdateOwnership",
+	"ListPreparedTxns", "Watch", "Backup",
+}
+
+// retryServiceConfig builds a gRPC service config that retries the
+// retryableMethods when they fail with UNAVAILABLE. gRPC caps maxAttempts at
+// 5.
+func retryServiceConfig(opts RPCOptions) string {
+	names := make([]string, len(retryableMethods))
+	for i, method := range retryableMethods {
+		names[i] = fmt.Sprintf(`{"service": "db_server.DBServer", "method": %q}`, method)
+	}
+	return fmt.Sprintf(`{
+		"methodConfig": [{
+			"name": [%s],
+			"retryPolicy": {
+				"maxAttempts": %d,
+				"initialBackoff": "%.3fs",
+				"maxBackoff": "%.3fs",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE"]
+			}
+		}]
+	}`, strings.Join(names, ", "), opts.MaxRetries+1, opts.RetryBackoff.Seconds(), 10*opts.RetryBackoff.Seconds())
+}
+
+func (p *connPool) pick() *grpc.ClientConn {
+	return p.conns[p.next.Add(1)%uint32(len(p.conns))]
+}
+
+func (p *connPool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return p.pick().Invoke(ctx, method, args, reply, opts...)
+}
+
+func (p *connPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().NewStream(ctx, desc, method, opts...)
+}
+
+func (p *connPool) Close() error {
+	var firstErr error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestConnPool_Size(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Pool.Size = 3
+
+	p, err := newConnPool("127.0.0.1:1", opts)
+	if err != nil {
+		t.Fatalf("newConnPool failed: %v", err)
+	}
+	defer p.Close()
+
+	if len(p.conns) != 3 {
+		t.Fatalf("expected 3 connections, got %d", len(p.conns))
+	}
+}
+
+func TestConnPool_MinimumOneConnection(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Pool.Size = 0
+
+	p, err := newConnPool("127.0.0.1:1", opts)
+	if err != nil {
+		t.Fatalf("newConnPool failed: %v", err)
+	}
+	defer p.Close()
+
+	if len(p.conns) != 1 {
+		t.Fatalf("expected 1 connection, got %d", len(p.conns))
+	}
+}
+
+func TestConnPool_RoundRobin(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Pool.Size = 4
+
+	p, err := newConnPool("127.0.0.1:1", opts)
+	if err != nil {
+		t.Fatalf("newConnPool failed: %v", err)
+	}
+	defer p.Close()
+
+	counts := make(map[*grpc.ClientConn]int)
+	for i := 0; i < 100; i++ {
+		counts[p.pick()]++
+	}
+
+	if len(counts) != 4 {
+		t.Fatalf("expected picks across 4 connections, got %d", len(counts))
+	}
+	for _, c := range counts {
+		if c != 25 {
+			t.Fatalf("expected 25 picks per connection, got %v", counts)
+		}
+	}
+}
+
+func TestConnPool_RetryConfigAccepted(t *testing.T) {
+	opts := DefaultOptions()
+	opts.RPC.MaxRetries = 4
+
+	p, err := newConnPool("127.0.0.1:1", opts)
+	if err != nil {
+		t.Fatalf("expected retry service config to be accepted: %v", err)
+	}
+	p.Close()
+}
+
+func TestRetryServiceConfig_OnlyIdempotentMethods(t *testing.T) {
+	var config struct {
+		MethodConfig []struct {
+			Name []struct {
+				Service string `json:"service"`
+				Method  string `json:"method"`
+			} `json:"name"`
+		} `json:"methodConfig"`
+	}
+	if err := json.Unmarshal([]byte(retryServiceConfig(DefaultOptions().RPC)), &config); err != nil {
+		t.Fatalf("invalid service config: %v", err)
+	}
+
+	retried := make(map[string]bool)
+	for _, name := range config.MethodConfig[0].Name {
+		if name.Method == "" {
+			t.Fatalf("expected every name to list a method, got a whole service %q", name.Service)
+		}
+		retried[name.Method] = true
+	}
+	if !retried["Get"] || !retried["Set"] {
+		t.Fatalf("expected Get and Set to be retried, got %v", retried)
+	}
+	for _, method := range []string{"Delete", "Increment", "ConditionalSet", "ConditionalDelete", "ApplyTxn", "PrepareTxn", "CommitTxn", "AbortTxn"} {
+		if retried[method] {
+			t.Errorf("expected %s not to be retried", method)
+		}
+	}
+}
@@ -119,6 +119,60 @@ func (h *ConsistentHasher) GetReplicaNodes(key string, count int) []string {
 	return nodes
 }
 
+// HashRange is the arc of ring hashes in (Start, End], wrapping past zero when
+// Start > End. Start == End covers the whole ring.
+type HashRange struct {
+	Start uint32
+	End   uint32
+}
+
+func (r HashRange) Contains(hash uint32) bool {
+	switch {
+	case r.Start < r.End:
+		return hash > r.Start && hash <= r.End
+	case r.Start > r.End:
+		return hash > r.Start || hash <= r.End
+	default:
+		return true
+	}
+}
+
+// OwnedRange returns the arc of hashes for which node is one of the first
+// count replicas. Because replicas are the successors of a key's primary, this
+// is a single contiguous arc ending at the node's own position.
+func (h *ConsistentHasher) OwnedRange(node string, count int) (HashRange, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, exists := h.nodes[node]; !exists {
+		return HashRange{}, false
+	}
+
+	hash := h.hashKey(node)
+	if count >= len(h.ring) {
+		return HashRange{Start: hash, End: hash}, true
+	}
+
+	idx := sort.Search(len(h.ring), func(i int) bool {
+		return h.ring[i] >= hash
+	})
+	start := h.ring[(idx-count+len(h.ring))%len(h.ring)]
+
+	return HashRange{Start: start, End: hash}, true
+}
+
+func (h *ConsistentHasher) Position(node string) uint32 {
+	return h.hashKey(node)
+}
+
+func (h *ConsistentHasher) Ownership(node string, count int) (Ownership, bool) {
+	r, ok := h.OwnedRange(node, count)
+	if !ok {
+		return Ownership{}, false
+	}
+	return Ownership{HashRanges: []HashRange{r}}, true
+}
+
 func (h *ConsistentHasher) Reconcile(nodes []string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -299,3 +299,50 @@ func TestReconcile(t *testing.T) {
 		t.Fatalf("expected 3 nodes after reconcile, got %d", h.Size())
 	}
 }
+
+func TestOwnedRange_MatchesReplicaPlacement(t *testing.T) {
+	h := NewConsistentHasher()
+	for i := 0; i < 5; i++ {
+		h.AddNode(fmt.Sprintf("server-%d", i))
+	}
+
+	for _, node := range h.GetNodes() {
+		r, ok := h.OwnedRange(node, 2)
+		if !ok {
+			t.Fatalf("expected owned range for %s", node)
+		}
+
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			isReplica := false
+			for _, n := range h.GetReplicaNodes(key, 2) {
+				if n == node {
+					isReplica = true
+					break
+				}
+			}
+
+			if r.Contains(h.hashKey(key)) != isReplica {
+				t.Fatalf("%s: range %+v disagrees with replica placement for %s (replica=%v)", node, r, key, isReplica)
+			}
+		}
+	}
+}
+
+func TestOwnedRange_FullRingWhenReplicasCoverCluster(t *testing.T) {
+	h := NewConsistentHasher()
+	h.AddNode("server-1")
+	h.AddNode("server-2")
+
+	r, ok := h.OwnedRange("server-1", 2)
+	if !ok {
+		t.Fatal("expected owned range for server-1")
+	}
+	if r.Start != r.End {
+		t.Fatalf("expected full-ring range, got %+v", r)
+	}
+
+	if _, ok := h.OwnedRange("server-99", 2); ok {
+		t.Fatal("expected no range for unknown node")
+	}
+}
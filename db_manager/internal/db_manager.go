@@ -9,30 +9,86 @@ import (
 	"github.com/arbhalerao/meerkat/pb/db_server"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type dbServer struct {
-	uuid   string
-	region string
-	addr   string
-	conn   *grpc.ClientConn
-	client db_server.DBServerClient
+	uuid    string
+	region  string
+	addr    string
+	pool    *connPool
+	client  db_server.DBServerClient
+	breaker *circuitBreaker
+	load    *serverLoad
+}
+
+// available reports whether data-path requests may be routed to the server,
+// i.e. its circuit breaker is not open.
+func (s dbServer) available() bool {
+	return s.breaker == nil || s.breaker.available()
+}
+
+func (s dbServer) breakerState() string {
+	if s.breaker == nil {
+		return "disabled"
+	}
+	return s.breaker.State().String()
 }
 
 const ReplicationFactor = 2
 
+// DBManager tracks cluster membership and routes key operations to replicas.
+// Every membership change bumps epoch, so a request can carry the ring version
+// it was routed on and db_servers can detect stale topology.
 type DBManager struct {
-	mu      sync.Mutex
-	servers map[string]dbServer
-	hasher  *ConsistentHasher
+	mu          sync.Mutex
+	servers     map[string]dbServer
+	partitioner Partitioner
+	epoch       uint64
+	opts        Options
+
+	// ranges is the partitioner in range-partitioned mode and nil otherwise.
+	ranges *RangePartitioner
+
+	background  sync.WaitGroup
+	readLatency *latencyTracker
+
+	// txns is the coordinator log of two-phase commits.
+	txns *txnLog
+	// commits is held for reading while a two-phase commit is sent to its
+	// participants and for writing while a backup starts its snapshots, so
+	// that no snapshot starts halfway through a commit.
+	commits sync.RWMutex
 }
 
-func NewDBManager() *DBManager {
-	return &DBManager{
-		servers: make(map[string]dbServer),
-		hasher:  NewConsistentHasher(),
+func NewDBManager(opts Options) *DBManager {
+	m := &DBManager{
+		servers:     make(map[string]dbServer),
+		partitioner: NewConsistentHasher(),
+		opts:        opts,
+		readLatency: newLatencyTracker(1000),
+		txns:        newTxnLog(),
+	}
+	if opts.Partitioning.Mode == PartitioningRange {
+		m.ranges = NewRangePartitioner(opts.Partitioning.InitialSplits)
+		m.partitioner = m.ranges
 	}
+	return m
+}
+
+// Partitioning returns PartitioningHash or PartitioningRange.
+func (m *DBManager) Partitioning() string {
+	if m.ranges != nil {
+		return PartitioningRange
+	}
+	return PartitioningHash
+}
+
+// WriteAcks returns how many replicas must accept a write before it is
+// acknowledged, before capping at the replicas a key has.
+func (m *DBManager) WriteAcks() int {
+	return max(m.opts.Replication.WriteAcks, 1)
 }
 
 func (m *DBManager) AddServer(uuid, region, addr string) bool {
@@ -43,13 +99,28 @@ func (m *DBManager) AddServer(uuid, region, addr string) bool {
 		return false
 	}
 
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	load := &serverLoad{uuid: uuid}
+	dialOpts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(load.unaryInterceptor)}
+
+	var breaker *circuitBreaker
+	if m.opts.Breaker.Enabled {
+		breaker = newCircuitBreaker(m.opts.Breaker, func(state breakerState) {
+			CircuitBreakerState.WithLabelValues(uuid).Set(float64(state))
+			log.Warn().Msgf("Circuit breaker for server %s is now %s", uuid, state)
+		})
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(breaker.unaryInterceptor))
+	}
+
+	pool, err := newConnPool(addr, m.opts, dialOpts...)
 	if err != nil {
 		m.mu.Unlock()
 		return false
 	}
+	if breaker != nil {
+		CircuitBreakerState.WithLabelValues(uuid).Set(float64(breakerClosed))
+	}
 
-	client := db_server.NewDBServerClient(conn)
+	client := db_server.NewDBServerClient(pool)
 
 	existingServers := make([]dbServer, 0, len(m.servers))
 	for _, s := range m.servers {
@@ -57,19 +128,38 @@ func (m *DBManager) AddServer(uuid, region, addr string) bool {
 	}
 
 	m.servers[uuid] = dbServer{
-		uuid:   uuid,
-		region: region,
-		addr:   addr,
-		conn:   conn,
-		client: client,
+		uuid:    uuid,
+		region:  region,
+		addr:    addr,
+		pool:    pool,
+		client:  client,
+		breaker: breaker,
+		load:    load,
 	}
 
-	m.hasher.AddNode(uuid)
+	var moves []rangeMove
+	if m.ranges != nil {
+		before := m.ranges.Ranges(ReplicationFactor)
+		m.ranges.AddNode(uuid)
+		moves = rangeMoves(before, m.ranges.Ranges(ReplicationFactor))
+	} else {
+		m.partitioner.AddNode(uuid)
+	}
+	m.bumpEpochLocked()
 	ActiveServers.Inc()
 	m.mu.Unlock()
 
+	go m.pushOwnership()
 	if len(existingServers) > 0 {
-		go m.migrateKeysOnNodeAdd(uuid, existingServers)
+		m.background.Add(1)
+		go func() {
+			defer m.background.Done()
+			if m.ranges != nil {
+				m.dropRanges(m.migrateRanges(moves, "node_add"))
+				return
+			}
+			m.migrateKeysOnNodeAdd(uuid, existingServers)
+		}()
 	}
 
 	return true
@@ -84,18 +174,48 @@ func (m *DBManager) RemoveServer(uuid string) bool {
 	}
 	m.mu.Unlock()
 
-	m.migrateKeysOnNodeRemove(uuid, server)
+	release := m.drainNode(uuid, server)
 
 	m.mu.Lock()
-	if server.conn != nil {
-		server.conn.Close()
+	m.dropServerLocked(uuid)
+	m.mu.Unlock()
+	release()
+
+	go m.pushOwnership()
+
+	return true
+}
+
+// dropServerLocked closes the server's connection and removes it from the
+// membership and the ring. The caller must hold m.mu.
+func (m *DBManager) dropServerLocked(uuid string) {
+	server, exists := m.servers[uuid]
+	if !exists {
+		return
+	}
+	if server.pool != nil {
+		server.pool.Close()
 	}
 	delete(m.servers, uuid)
-	m.hasher.RemoveNode(uuid)
+	m.partitioner.RemoveNode(uuid)
+	m.bumpEpochLocked()
 	ActiveServers.Dec()
-	m.mu.Unlock()
+	CircuitBreakerState.DeleteLabelValues(uuid)
+	ServerInFlight.DeleteLabelValues(uuid)
+}
 
-	return true
+// bumpEpochLocked advances the ring epoch after a membership change. The
+// caller must hold m.mu.
+func (m *DBManager) bumpEpochLocked() {
+	m.epoch++
+	RingEpoch.Set(float64(m.epoch))
+}
+
+// Epoch returns the current ring epoch.
+func (m *DBManager) Epoch() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.epoch
 }
 
 func (m *DBManager) HealthCheckServers() {
@@ -109,7 +229,9 @@ func (m *DBManager) HealthCheckServers() {
 	var toRemove []string
 
 	for uuid, server := range servers {
-		_, err := server.client.HealthCheck(context.Background(), &db_server.HealthCheckRequest{})
+		ctx, cancel := m.callContext(context.Background())
+		_, err := server.client.HealthCheck(ctx, &db_server.HealthCheckRequest{})
+		cancel()
 		if err != nil {
 			toRemove = append(toRemove, uuid)
 		}
@@ -124,155 +246,293 @@ func (m *DBManager) HealthCheckServers() {
 		}
 		m.mu.Unlock()
 
-		m.migrateKeysOnNodeRemove(uuid, server)
+		release := m.drainNode(uuid, server)
 
 		m.mu.Lock()
-		if s, ok := m.servers[uuid]; ok {
-			if s.conn != nil {
-				s.conn.Close()
-			}
-			delete(m.servers, uuid)
-		}
+		m.dropServerLocked(uuid)
 		m.mu.Unlock()
+		release()
 	}
 
 	m.ReconcileServers()
+	m.pushOwnership()
 }
 
-func (m *DBManager) getReplicaServers(key string) ([]dbServer, error) {
+// pushOwnership sends every server the hash range it currently owns together
+// with the ring epoch, so servers can fence off traffic for keys they no
+// longer hold. It also runs on every health check to resync restarted servers.
+func (m *DBManager) pushOwnership() {
+	type assignment struct {
+		server dbServer
+		req    *db_server.UpdateOwnershipRequest
+	}
+
+	m.mu.Lock()
+	epoch := m.epoch
+	assignments := make([]assignment, 0, len(m.servers))
+	for uuid, server := range m.servers {
+		owned, ok := m.partitioner.Ownership(uuid, ReplicationFactor)
+		if !ok {
+			continue
+		}
+		req := &db_server.UpdateOwnershipRequest{Epoch: epoch}
+		for _, r := range owned.HashRanges {
+			req.Ranges = append(req.Ranges, &db_server.HashRange{Start: r.Start, End: r.End})
+		}
+		for _, r := range owned.KeyRanges {
+			req.KeyRanges = append(req.KeyRanges, &db_server.KeyRange{Start: r.Start, End: r.End})
+		}
+		assignments = append(assignments, assignment{server: server, req: req})
+	}
+	m.mu.Unlock()
+
+	for _, a := range assignments {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := a.server.client.UpdateOwnership(ctx, a.req)
+		cancel()
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to push ownership for epoch %d to server %s", epoch, a.server.uuid)
+		}
+	}
+}
+
+// replicaSet is where a key is stored: its primary on the ring, those of its
+// replicas that are reachable, in ring order, and how many replicas the ring
+// gives it. servers starts with the primary only while it is reachable.
+type replicaSet struct {
+	primary string
+	servers []dbServer
+	size    int
+}
+
+// primaryServer returns the key's primary. Operations that must run there
+// fail with UNAVAILABLE while its breaker is open instead of moving to a
+// replica, whose versions differ and which may miss the primary's latest
+// writes.
+func (r replicaSet) primaryServer(key string) (dbServer, error) {
+	if len(r.servers) == 0 || r.servers[0].uuid != r.primary {
+		return dbServer{}, status.Errorf(codes.Unavailable, "primary %s of key %q is unavailable", r.primary, key)
+	}
+	return r.servers[0], nil
+}
+
+// writeAcks is how many replicas of set must accept a write: the configured
+// count, at least one and at most the replicas the key has on the ring.
+// Replicas taken out by their breaker still count, so a write that cannot
+// reach enough of them fails instead of settling for fewer.
+func (m *DBManager) writeAcks(set replicaSet) int {
+	return min(m.WriteAcks(), set.size)
+}
+
+// getReplicaSet returns the replica set for key together with the ring
+// epoch the lookup was made at.
+func (m *DBManager) getReplicaSet(key string) (replicaSet, uint64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	uuids := m.hasher.GetReplicaNodes(key, ReplicationFactor)
+	set, err := m.replicaSetLocked(key)
+	if err != nil {
+		return replicaSet{}, 0, err
+	}
+	return set, m.epoch, nil
+}
+
+// getReplicaServers returns the reachable replicas for key, primary first,
+// together with the ring epoch the lookup was made at.
+func (m *DBManager) getReplicaServers(key string) ([]dbServer, uint64, error) {
+	set, epoch, err := m.getReplicaSet(key)
+	return set.servers, epoch, err
+}
+
+// replicaSetLocked looks up the replicas of key. The caller must hold m.mu.
+func (m *DBManager) replicaSetLocked(key string) (replicaSet, error) {
+	uuids := m.partitioner.GetReplicaNodes(key, ReplicationFactor)
 	if len(uuids) == 0 {
-		return nil, fmt.Errorf("no available database servers")
+		return replicaSet{}, fmt.Errorf("no available database servers")
 	}
 
-	var servers []dbServer
+	set := replicaSet{primary: uuids[0], size: len(uuids)}
 	for _, uuid := range uuids {
-		if server, exists := m.servers[uuid]; exists {
-			servers = append(servers, server)
+		if server, exists := m.servers[uuid]; exists && server.available() {
+			set.servers = append(set.servers, server)
 		}
 	}
 
-	if len(servers) == 0 {
-		return nil, fmt.Errorf("no reachable servers for key %q", key)
+	if len(set.servers) == 0 {
+		return replicaSet{}, fmt.Errorf("no reachable servers for key %q", key)
 	}
 
-	return servers, nil
+	return set, nil
 }
 
-func (m *DBManager) GetKey(key string) (string, error) {
+// callContext derives the context for a single db_server call from the
+// incoming request context, bounded by the configured RPC timeout.
+func (m *DBManager) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.opts.RPC.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.opts.RPC.Timeout)
+}
+
+func (m *DBManager) GetKey(ctx context.Context, key string) ([]byte, error) {
 	start := time.Now()
 	defer func() {
 		RequestDuration.WithLabelValues("get").Observe(time.Since(start).Seconds())
 	}()
 
-	servers, err := m.getReplicaServers(key)
+	servers, epoch, err := m.getReplicaServers(key)
 	if err != nil {
 		RequestsTotal.WithLabelValues("get", "error").Inc()
-		return "", err
+		return nil, err
+	}
+	if err := m.admit(servers); err != nil {
+		RequestsTotal.WithLabelValues("get", "shed").Inc()
+		return nil, err
 	}
 
-	var lastErr error
-	for _, server := range servers {
-		resp, err := server.client.Get(context.Background(), &db_server.GetRequest{Key: key})
-		if err == nil {
-			RequestsTotal.WithLabelValues("get", "success").Inc()
-			return resp.Value, nil
-		}
-		lastErr = err
+	value, err := m.readReplicas(ctx, servers, epoch, key)
+	if err == nil {
+		RequestsTotal.WithLabelValues("get", "success").Inc()
+		return value, nil
 	}
 
 	RequestsTotal.WithLabelValues("get", "error").Inc()
-	return "", fmt.Errorf("all replicas failed for key %q: %v", key, lastErr)
+	return nil, fmt.Errorf("all replicas failed for key %q: %v", key, err)
 }
 
-func (m *DBManager) SetKey(key, value string) (bool, error) {
+// SetKey writes key to its replicas. A positive ttl makes the key expire that
+// long after the write; it is rounded up to whole seconds.
+func (m *DBManager) SetKey(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
 	start := time.Now()
 	defer func() {
 		RequestDuration.WithLabelValues("set").Observe(time.Since(start).Seconds())
 	}()
 
-	servers, err := m.getReplicaServers(key)
+	if err := m.checkValueSize(key, value); err != nil {
+		RequestsTotal.WithLabelValues("set", "error").Inc()
+		return false, err
+	}
+
+	set, epoch, err := m.getReplicaSet(key)
 	if err != nil {
 		RequestsTotal.WithLabelValues("set", "error").Inc()
 		return false, err
 	}
+	servers := set.servers
+	if err := m.admit(servers); err != nil {
+		RequestsTotal.WithLabelValues("set", "shed").Inc()
+		return false, err
+	}
 
-	successCount := 0
-	var lastErr error
-	for _, server := range servers {
-		_, err := server.client.Set(context.Background(), &db_server.SetRequest{Key: key, Value: value})
+	ttlSeconds := uint64((ttl + time.Second - 1) / time.Second)
+	acked, err := m.fanOutWrite(ctx, servers, m.writeAcks(set), func(ctx context.Context, server dbServer) error {
+		_, err := server.client.Set(ctx, &db_server.SetRequest{Key: key, ValueBytes: value, Epoch: epoch, TtlSeconds: ttlSeconds})
 		if err != nil {
-			lastErr = err
 			ReplicationWrites.WithLabelValues("failure").Inc()
-			continue
+			return err
 		}
-		successCount++
 		ReplicationWrites.WithLabelValues("success").Inc()
-	}
-
-	if successCount == 0 {
+		return nil
+	})
+	if err != nil {
 		RequestsTotal.WithLabelValues("set", "error").Inc()
-		return false, fmt.Errorf("failed to write to any replica for key %q: %v", key, lastErr)
+		return false, fmt.Errorf("write for key %q acknowledged by %d of %d replicas: %w", key, acked, len(servers), err)
 	}
 
 	RequestsTotal.WithLabelValues("set", "success").Inc()
 	return true, nil
 }
 
-func (m *DBManager) DeleteKey(key string) (bool, error) {
+func (m *DBManager) DeleteKey(ctx context.Context, key string) (bool, error) {
 	start := time.Now()
 	defer func() {
 		RequestDuration.WithLabelValues("delete").Observe(time.Since(start).Seconds())
 	}()
 
-	servers, err := m.getReplicaServers(key)
+	set, epoch, err := m.getReplicaSet(key)
 	if err != nil {
 		RequestsTotal.WithLabelValues("delete", "error").Inc()
 		return false, err
 	}
+	servers := set.servers
+	if err := m.admit(servers); err != nil {
+		RequestsTotal.WithLabelValues("delete", "shed").Inc()
+		return false, err
+	}
+
+	acked, err := m.fanOutWrite(ctx, servers, m.writeAcks(set), func(ctx context.Context, server dbServer) error {
+		_, err := server.client.Delete(ctx, &db_server.DeleteRequest{Key: key, Epoch: epoch})
+		return err
+	})
+	if err != nil {
+		RequestsTotal.WithLabelValues("delete", "error").Inc()
+		return false, fmt.Errorf("delete for key %q acknowledged by %d of %d replicas: %w", key, acked, len(servers), err)
+	}
+
+	RequestsTotal.WithLabelValues("delete", "success").Inc()
+	return true, nil
+}
+
+// TTL returns the remaining lifetime of key, asking its replicas in order
+// until one answers. ok is false for keys that never expire.
+func (m *DBManager) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("ttl").Observe(time.Since(start).Seconds())
+	}()
+
+	servers, epoch, err := m.getReplicaServers(key)
+	if err != nil {
+		RequestsTotal.WithLabelValues("ttl", "error").Inc()
+		return 0, false, err
+	}
+	if err := m.admit(servers); err != nil {
+		RequestsTotal.WithLabelValues("ttl", "shed").Inc()
+		return 0, false, err
+	}
 
-	successCount := 0
 	var lastErr error
 	for _, server := range servers {
-		_, err := server.client.Delete(context.Background(), &db_server.DeleteRequest{Key: key})
+		callCtx, cancel := m.callContext(ctx)
+		resp, err := server.client.TTL(callCtx, &db_server.TTLRequest{Key: key, Epoch: epoch})
+		cancel()
 		if err != nil {
 			lastErr = err
 			continue
 		}
-		successCount++
-	}
 
-	if successCount == 0 {
-		RequestsTotal.WithLabelValues("delete", "error").Inc()
-		return false, fmt.Errorf("failed to delete from any replica for key %q: %v", key, lastErr)
+		RequestsTotal.WithLabelValues("ttl", "success").Inc()
+		return time.Duration(resp.TtlSeconds) * time.Second, resp.HasTtl, nil
 	}
 
-	RequestsTotal.WithLabelValues("delete", "success").Inc()
-	return true, nil
+	RequestsTotal.WithLabelValues("ttl", "error").Inc()
+	return 0, false, fmt.Errorf("all replicas failed for key %q: %v", key, lastErr)
 }
 
 type ServerInfo struct {
-	UUID   string `json:"uuid"`
-	Region string `json:"region"`
-	Addr   string `json:"addr"`
+	UUID     string `json:"uuid"`
+	Region   string `json:"region"`
+	Addr     string `json:"addr"`
+	Position uint32 `json:"position"`
+	Breaker  string `json:"breaker"`
 }
 
-func (m *DBManager) GetClusterStatus() []ServerInfo {
+// GetClusterStatus returns the current members and the ring epoch they form.
+func (m *DBManager) GetClusterStatus() ([]ServerInfo, uint64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	servers := make([]ServerInfo, 0, len(m.servers))
 	for _, s := range m.servers {
 		servers = append(servers, ServerInfo{
-			UUID:   s.uuid,
-			Region: s.region,
-			Addr:   s.addr,
+			UUID:     s.uuid,
+			Region:   s.region,
+			Addr:     s.addr,
+			Position: m.partitioner.Position(s.uuid),
+			Breaker:  s.breakerState(),
 		})
 	}
-	return servers
+	return servers, m.epoch
 }
 
 func (m *DBManager) ServerCount() int {
@@ -289,7 +549,7 @@ func (m *DBManager) ReconcileServers() {
 	}
 	m.mu.Unlock()
 
-	m.hasher.Reconcile(activeNodes)
+	m.partitioner.Reconcile(activeNodes)
 }
 
 func (m *DBManager) migrateKeysOnNodeAdd(newUUID string, existingServers []dbServer) {
@@ -306,9 +566,9 @@ func (m *DBManager) migrateKeysOnNodeAdd(newUUID string, existingServers []dbSer
 		}
 
 		for _, pair := range resp.Pairs {
-			primaryNode, ok := m.hasher.GetNode(pair.Key)
+			primaryNode, ok := m.partitioner.GetNode(pair.Key)
 			if !ok || primaryNode != newUUID {
-				replicas := m.hasher.GetReplicaNodes(pair.Key, ReplicationFactor)
+				replicas := m.partitioner.GetReplicaNodes(pair.Key, ReplicationFactor)
 				isReplica := false
 				for _, r := range replicas {
 					if r == newUUID {
@@ -330,14 +590,14 @@ func (m *DBManager) migrateKeysOnNodeAdd(newUUID string, existingServers []dbSer
 			}
 
 			ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
-			_, err := newServer.client.Set(ctx2, &db_server.SetRequest{Key: pair.Key, Value: pair.Value})
+			_, err := newServer.client.Set(ctx2, &db_server.SetRequest{Key: pair.Key, ValueBytes: storedValue(pair.ValueBytes, pair.Value), TtlSeconds: pair.TtlSeconds})
 			cancel2()
 			if err != nil {
 				log.Warn().Err(err).Msgf("Failed to migrate key %q to new node %s", pair.Key, newUUID)
 				continue
 			}
 
-			replicas := m.hasher.GetReplicaNodes(pair.Key, ReplicationFactor)
+			replicas := m.partitioner.GetReplicaNodes(pair.Key, ReplicationFactor)
 			oldIsReplica := false
 			for _, r := range replicas {
 				if r == oldServer.uuid {
@@ -359,6 +619,22 @@ func (m *DBManager) migrateKeysOnNodeAdd(newUUID string, existingServers []dbSer
 	log.Info().Msgf("Key migration for new node %s completed: %d keys migrated", newUUID, migrated)
 }
 
+// drainNode copies the keys of node uuid, about to leave the ring, to the
+// servers that take them over. It returns a function to call once the node
+// is off the ring, which in range-partitioned mode deletes the ranges that
+// moved from the other servers leaving their replica sets.
+func (m *DBManager) drainNode(uuid string, server dbServer) func() {
+	if m.ranges == nil {
+		m.migrateKeysOnNodeRemove(uuid, server)
+		return func() {}
+	}
+
+	log.Info().Msgf("Draining ranges from node %s before removal", uuid)
+	moves := rangeMoves(m.ranges.Ranges(ReplicationFactor), m.ranges.RangesWithout(uuid, ReplicationFactor))
+	done := m.migrateRanges(moves, "node_remove")
+	return func() { m.dropRanges(done) }
+}
+
 func (m *DBManager) migrateKeysOnNodeRemove(uuid string, server dbServer) {
 	log.Info().Msgf("Draining keys from node %s before removal", uuid)
 
@@ -377,7 +653,7 @@ func (m *DBManager) migrateKeysOnNodeRemove(uuid string, server dbServer) {
 
 	migrated := 0
 	for _, pair := range resp.Pairs {
-		replicas := m.hasher.GetReplicaNodes(pair.Key, ReplicationFactor+1)
+		replicas := m.partitioner.GetReplicaNodes(pair.Key, ReplicationFactor+1)
 
 		for _, replicaUUID := range replicas {
 			if replicaUUID == uuid {
@@ -392,7 +668,7 @@ func (m *DBManager) migrateKeysOnNodeRemove(uuid string, server dbServer) {
 			}
 
 			ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
-			_, err := target.client.Set(ctx2, &db_server.SetRequest{Key: pair.Key, Value: pair.Value})
+			_, err := target.client.Set(ctx2, &db_server.SetRequest{Key: pair.Key, ValueBytes: storedValue(pair.ValueBytes, pair.Value), TtlSeconds: pair.TtlSeconds})
 			cancel2()
 			if err != nil {
 				log.Warn().Err(err).Msgf("Failed to migrate key %q to server %s", pair.Key, replicaUUID)
@@ -0,0 +1,32 @@
+package internal
+
+import "testing"
+
+func TestEpoch_AdvancesOnMembershipChange(t *testing.T) {
+	m := NewDBManager(DefaultOptions())
+	if m.Epoch() != 0 {
+		t.Fatalf("expected epoch 0 for empty cluster, got %d", m.Epoch())
+	}
+
+	m.AddServer("server-1", "pune", "127.0.0.1:1")
+	m.AddServer("server-2", "mumbai", "127.0.0.1:2")
+	if m.Epoch() != 2 {
+		t.Fatalf("expected epoch 2 after two joins, got %d", m.Epoch())
+	}
+
+	if m.AddServer("server-1", "pune", "127.0.0.1:1") {
+		t.Fatal("expected duplicate registration to be rejected")
+	}
+	if m.Epoch() != 2 {
+		t.Fatalf("expected duplicate registration to leave epoch at 2, got %d", m.Epoch())
+	}
+
+	m.RemoveServer("server-1")
+	servers, epoch := m.GetClusterStatus()
+	if epoch != 3 {
+		t.Fatalf("expected epoch 3 after removal, got %d", epoch)
+	}
+	if len(servers) != 1 {
+		t.Fatalf("expected 1 server after removal, got %d", len(servers))
+	}
+}
@@ -0,0 +1,681 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeServer is an in-memory DBServer that sleeps for delay before answering
+// each data RPC, standing in for a db_server with a given round-trip time.
+type fakeServer struct {
+	db_server.UnimplementedDBServerServer
+
+	addr string
+	grpc *grpc.Server
+
+	mu    sync.Mutex
+	delay time.Duration
+	data  map[string]string
+	ttls  map[string]uint64
+
+	// versions mimics Badger's per-server commit timestamps.
+	versions map[string]uint64
+	clock    uint64
+
+	// fromPrimary holds the epoch and order of the last copy of a primary's
+	// write applied to each key, like db.SetKeyFromPrimary.
+	fromPrimary map[string][2]uint64
+
+	batchCalls int
+
+	// scanBytes, when set, ends a Scan page at that many bytes of keys and
+	// values, like db_server's page limit.
+	scanBytes int
+
+	// prepared holds the intents of prepared transactions and locks the
+	// owner of each locked key, like db.PrepareTxn.
+	prepared map[string][]*db_server.TxnOp
+	locks    map[string]string
+	txnCalls map[string]int
+	// commitDelay holds up CommitTxn before it applies anything.
+	commitDelay time.Duration
+
+	// changes is the change feed; changed is closed and replaced whenever a
+	// change is added.
+	changes []*db_server.WatchEvent
+	changed chan struct{}
+
+	// feedFloor, when set, is the version at or below which the feed no
+	// longer holds changes: a catch-up from before it sends a gap and then
+	// only the latest change of each key, like db.Watch.
+	feedFloor uint64
+}
+
+func startFakeServer(tb testing.TB, delay time.Duration) *fakeServer {
+	tb.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &fakeServer{
+		delay: delay,
+		addr:  listener.Addr().String(),
+		grpc:  grpc.NewServer(),
+		data:  make(map[string]string),
+		ttls:  make(map[string]uint64),
+
+		versions:    make(map[string]uint64),
+		fromPrimary: make(map[string][2]uint64),
+		prepared:    make(map[string][]*db_server.TxnOp),
+		locks:       make(map[string]string),
+		txnCalls:    make(map[string]int),
+		changed:     make(chan struct{}),
+	}
+	db_server.RegisterDBServerServer(s.grpc, s)
+
+	go s.grpc.Serve(listener)
+	tb.Cleanup(s.grpc.Stop)
+
+	return s
+}
+
+// newTestManager registers one fake server per delay with a fresh manager.
+func newTestManager(tb testing.TB, opts Options, delays ...time.Duration) (*DBManager, []*fakeServer) {
+	tb.Helper()
+
+	m := NewDBManager(opts)
+	servers := make([]*fakeServer, len(delays))
+	for i, delay := range delays {
+		servers[i] = startFakeServer(tb, delay)
+		m.AddServer(fmt.Sprintf("server-%d", i), "test", servers[i].addr)
+	}
+	m.WaitForBackgroundWrites()
+	tb.Cleanup(m.WaitForBackgroundWrites)
+
+	return m, servers
+}
+
+// replicasFor returns the fake servers holding key, primary first.
+func replicasFor(tb testing.TB, m *DBManager, servers []*fakeServer, key string) []*fakeServer {
+	tb.Helper()
+
+	replicas, _, err := m.getReplicaServers(key)
+	if err != nil {
+		tb.Fatalf("getReplicaServers failed: %v", err)
+	}
+
+	byAddr := make(map[string]*fakeServer, len(servers))
+	for _, s := range servers {
+		byAddr[s.addr] = s
+	}
+
+	result := make([]*fakeServer, len(replicas))
+	for i, r := range replicas {
+		result[i] = byAddr[r.addr]
+	}
+	return result
+}
+
+func (s *fakeServer) setDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+func (s *fakeServer) wait(ctx context.Context) error {
+	s.mu.Lock()
+	delay := s.delay
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *fakeServer) value(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+func (s *fakeServer) ttl(key string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ttls[key]
+}
+
+func (s *fakeServer) batchCallCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batchCalls
+}
+
+func (s *fakeServer) HealthCheck(ctx context.Context, req *db_server.HealthCheckRequest) (*db_server.HealthCheckResponse, error) {
+	return &db_server.HealthCheckResponse{Healthy: true}, nil
+}
+
+func (s *fakeServer) Get(ctx context.Context, req *db_server.GetRequest) (*db_server.GetResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[req.Key]
+	if !ok {
+		return nil, fmt.Errorf("key '%s' not found", req.Key)
+	}
+	return &db_server.GetResponse{ValueBytes: []byte(v), Version: s.versions[req.Key]}, nil
+}
+
+func (s *fakeServer) Set(ctx context.Context, req *db_server.SetRequest) (*db_server.SetResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.newCopyLocked(req.Key, req.Epoch, req.PrimaryOrder) {
+		s.setLocked(req.Key, string(req.ValueBytes), req.TtlSeconds)
+	}
+	s.mu.Unlock()
+	return &db_server.SetResponse{Success: true}, nil
+}
+
+// newCopyLocked reports whether a write should be applied: any plain write,
+// or a copy of a primary's write newer than the last copy applied to key.
+func (s *fakeServer) newCopyLocked(key string, epoch, order uint64) bool {
+	if order == 0 {
+		return true
+	}
+	last, ok := s.fromPrimary[key]
+	if ok && (epoch < last[0] || epoch == last[0] && order <= last[1]) {
+		return false
+	}
+	s.fromPrimary[key] = [2]uint64{epoch, order}
+	return true
+}
+
+func (s *fakeServer) setLocked(key, value string, ttlSeconds uint64) {
+	s.clock++
+	s.data[key] = value
+	s.versions[key] = s.clock
+	if ttlSeconds > 0 {
+		s.ttls[key] = ttlSeconds
+	} else {
+		delete(s.ttls, key)
+	}
+	s.publishLocked(&db_server.WatchEvent{Key: key, Value: []byte(value), Version: s.clock, TtlSeconds: ttlSeconds})
+}
+
+func (s *fakeServer) deleteLocked(key string) {
+	delete(s.data, key)
+	delete(s.ttls, key)
+	delete(s.versions, key)
+	s.clock++
+	s.publishLocked(&db_server.WatchEvent{Key: key, Deleted: true, Version: s.clock})
+}
+
+func (s *fakeServer) publishLocked(e *db_server.WatchEvent) {
+	e.CommitUnixNanos = time.Now().UnixNano()
+	s.changes = append(s.changes, e)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *fakeServer) ConditionalSet(ctx context.Context, req *db_server.ConditionalSetRequest) (*db_server.ConditionalSetResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.data[req.Key]
+	switch cond := req.Condition.(type) {
+	case *db_server.ConditionalSetRequest_IfAbsent:
+		if exists {
+			return nil, status.Errorf(codes.Aborted, "key '%s' already exists", req.Key)
+		}
+	case *db_server.ConditionalSetRequest_IfVersion:
+		if !exists || s.versions[req.Key] != cond.IfVersion {
+			return nil, status.Errorf(codes.Aborted, "key '%s' is not at version %d", req.Key, cond.IfVersion)
+		}
+	}
+
+	order := s.clock + 1
+	s.setLocked(req.Key, string(req.ValueBytes), req.TtlSeconds)
+	return &db_server.ConditionalSetResponse{Success: true, Order: order}, nil
+}
+
+func (s *fakeServer) Increment(ctx context.Context, req *db_server.IncrementRequest) (*db_server.IncrementResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current int64
+	if v, ok := s.data[req.Key]; ok {
+		var err error
+		if current, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "key '%s' is not an integer", req.Key)
+		}
+	}
+
+	current += req.Delta
+	ttl, order := s.ttls[req.Key], s.clock+1
+	s.setLocked(req.Key, strconv.FormatInt(current, 10), ttl)
+	return &db_server.IncrementResponse{Value: current, TtlSeconds: ttl, Order: order}, nil
+}
+
+func (s *fakeServer) BatchGet(ctx context.Context, req *db_server.BatchGetRequest) (*db_server.BatchGetResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchCalls++
+
+	results := make([]*db_server.BatchGetResult, len(req.Keys))
+	for i, key := range req.Keys {
+		v, ok := s.data[key]
+		results[i] = &db_server.BatchGetResult{Key: key, Found: ok, ValueBytes: []byte(v)}
+	}
+	return &db_server.BatchGetResponse{Results: results}, nil
+}
+
+func (s *fakeServer) BatchSet(ctx context.Context, req *db_server.BatchSetRequest) (*db_server.BatchSetResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchCalls++
+
+	results := make([]*db_server.KeyResult, len(req.Pairs))
+	for i, p := range req.Pairs {
+		s.setLocked(p.Key, string(p.ValueBytes), p.TtlSeconds)
+		results[i] = &db_server.KeyResult{Key: p.Key, Success: true}
+	}
+	return &db_server.BatchSetResponse{Results: results}, nil
+}
+
+func (s *fakeServer) BatchDelete(ctx context.Context, req *db_server.BatchDeleteRequest) (*db_server.BatchDeleteResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batchCalls++
+
+	results := make([]*db_server.KeyResult, len(req.Keys))
+	for i, key := range req.Keys {
+		s.deleteLocked(key)
+		results[i] = &db_server.KeyResult{Key: key, Success: true}
+	}
+	return &db_server.BatchDeleteResponse{Results: results}, nil
+}
+
+func (s *fakeServer) ConditionalDelete(ctx context.Context, req *db_server.ConditionalDeleteRequest) (*db_server.ConditionalDeleteResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.data[req.Key]; !ok || v != string(req.IfValueBytes) {
+		return nil, status.Errorf(codes.Aborted, "key '%s' does not hold the expected value", req.Key)
+	}
+
+	order := s.clock + 1
+	s.deleteLocked(req.Key)
+	return &db_server.ConditionalDeleteResponse{Success: true, Order: order}, nil
+}
+
+func (s *fakeServer) Delete(ctx context.Context, req *db_server.DeleteRequest) (*db_server.DeleteResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.newCopyLocked(req.Key, req.Epoch, req.PrimaryOrder) {
+		s.deleteLocked(req.Key)
+	}
+	s.mu.Unlock()
+	return &db_server.DeleteResponse{Success: true}, nil
+}
+
+func (s *fakeServer) ListKeys(ctx context.Context, req *db_server.ListKeysRequest) (*db_server.ListKeysResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pairs := make([]*db_server.KeyValuePair, 0, len(s.data))
+	for k, v := range s.data {
+		pairs = append(pairs, &db_server.KeyValuePair{Key: k, ValueBytes: []byte(v), TtlSeconds: s.ttls[k]})
+	}
+	return &db_server.ListKeysResponse{Pairs: pairs}, nil
+}
+
+func (s *fakeServer) TTL(ctx context.Context, req *db_server.TTLRequest) (*db_server.TTLResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[req.Key]; !ok {
+		return nil, fmt.Errorf("key '%s' not found", req.Key)
+	}
+	ttl := s.ttls[req.Key]
+	return &db_server.TTLResponse{HasTtl: ttl > 0, TtlSeconds: ttl}, nil
+}
+
+func (s *fakeServer) Scan(ctx context.Context, req *db_server.ScanRequest) (*db_server.ScanResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.data {
+		if strings.HasPrefix(k, req.Prefix) && k >= req.Start && (req.End == "" || k < req.End) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	resp := &db_server.ScanResponse{}
+	if req.Limit > 0 && len(keys) > int(req.Limit) {
+		keys = keys[:req.Limit]
+		resp.More = true
+	}
+	size := 0
+	for i, k := range keys {
+		size += len(k) + len(s.data[k])
+		if s.scanBytes > 0 && i > 0 && size > s.scanBytes {
+			resp.More = true
+			break
+		}
+		resp.Pairs = append(resp.Pairs, &db_server.KeyValuePair{Key: k, ValueBytes: []byte(s.data[k]), TtlSeconds: s.ttls[k], Version: s.versions[k]})
+	}
+	return resp, nil
+}
+
+func (s *fakeServer) RangeStats(ctx context.Context, req *db_server.RangeStatsRequest) (*db_server.RangeStatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	resp := &db_server.RangeStatsResponse{}
+	for k, v := range s.data {
+		if k >= req.Start && (req.End == "" || k < req.End) {
+			keys = append(keys, k)
+			resp.Bytes += uint64(len(k) + len(v))
+		}
+	}
+	sort.Strings(keys)
+
+	resp.Keys = uint64(len(keys))
+	if len(keys) > 0 {
+		resp.MedianKey = keys[len(keys)/2]
+	}
+	return resp, nil
+}
+
+func (s *fakeServer) UpdateOwnership(ctx context.Context, req *db_server.UpdateOwnershipRequest) (*db_server.UpdateOwnershipResponse, error) {
+	return &db_server.UpdateOwnershipResponse{Accepted: true}, nil
+}
+
+func (s *fakeServer) txnCallCount(rpc string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.txnCalls[rpc]
+}
+
+func (s *fakeServer) preparedTxns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.prepared)
+}
+
+// checkUnlockedLocked fails with ABORTED if a transaction other than owner
+// holds any of the keys. The caller must hold s.mu.
+func (s *fakeServer) checkUnlockedLocked(ops []*db_server.TxnOp, owner string) error {
+	for _, op := range ops {
+		if holder, ok := s.locks[op.Key]; ok && holder != owner {
+			return status.Errorf(codes.Aborted, "key '%s' is locked by transaction %s", op.Key, holder)
+		}
+	}
+	return nil
+}
+
+func (s *fakeServer) applyOpsLocked(ops []*db_server.TxnOp) {
+	for _, op := range ops {
+		if op.Delete {
+			s.deleteLocked(op.Key)
+			continue
+		}
+		s.setLocked(op.Key, string(op.Value), op.TtlSeconds)
+	}
+}
+
+func (s *fakeServer) ApplyTxn(ctx context.Context, req *db_server.ApplyTxnRequest) (*db_server.ApplyTxnResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txnCalls["apply"]++
+
+	if err := s.checkUnlockedLocked(req.Ops, ""); err != nil {
+		return nil, err
+	}
+	s.applyOpsLocked(req.Ops)
+	return &db_server.ApplyTxnResponse{}, nil
+}
+
+func (s *fakeServer) PrepareTxn(ctx context.Context, req *db_server.PrepareTxnRequest) (*db_server.PrepareTxnResponse, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txnCalls["prepare"]++
+
+	if _, ok := s.prepared[req.TxnId]; ok {
+		return &db_server.PrepareTxnResponse{}, nil
+	}
+	if err := s.checkUnlockedLocked(req.Ops, req.TxnId); err != nil {
+		return nil, err
+	}
+	for _, op := range req.Ops {
+		s.locks[op.Key] = req.TxnId
+	}
+	s.prepared[req.TxnId] = req.Ops
+	return &db_server.PrepareTxnResponse{}, nil
+}
+
+func (s *fakeServer) CommitTxn(ctx context.Context, req *db_server.CommitTxnRequest) (*db_server.CommitTxnResponse, error) {
+	s.mu.Lock()
+	delay := s.commitDelay
+	s.mu.Unlock()
+	time.Sleep(delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txnCalls["commit"]++
+
+	if ops, ok := s.prepared[req.TxnId]; ok {
+		s.applyOpsLocked(ops)
+		s.releaseLocked(req.TxnId)
+	}
+	return &db_server.CommitTxnResponse{}, nil
+}
+
+func (s *fakeServer) AbortTxn(ctx context.Context, req *db_server.AbortTxnRequest) (*db_server.AbortTxnResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txnCalls["abort"]++
+
+	s.releaseLocked(req.TxnId)
+	return &db_server.AbortTxnResponse{}, nil
+}
+
+func (s *fakeServer) releaseLocked(id string) {
+	for _, op := range s.prepared[id] {
+		delete(s.locks, op.Key)
+	}
+	delete(s.prepared, id)
+}
+
+func (s *fakeServer) ListPreparedTxns(ctx context.Context, req *db_server.ListPreparedTxnsRequest) (*db_server.ListPreparedTxnsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &db_server.ListPreparedTxnsResponse{}
+	for id := range s.prepared {
+		resp.TxnIds = append(resp.TxnIds, id)
+	}
+	return resp, nil
+}
+
+// Watch sends every change after from_version, or from now on, unlike
+// db.Watch, which coalesces the catch-up to the latest change per key.
+func (s *fakeServer) Watch(req *db_server.WatchRequest, stream grpc.ServerStreamingServer[db_server.WatchEvent]) error {
+	s.mu.Lock()
+	next := len(s.changes)
+	if req.CatchUp {
+		next = sort.Search(len(s.changes), func(i int) bool { return s.changes[i].Version > req.FromVersion })
+	}
+	var catchUp []*db_server.WatchEvent
+	if req.CatchUp && req.FromVersion < s.feedFloor {
+		latest := make(map[string]*db_server.WatchEvent)
+		for _, e := range s.changes[next:] {
+			latest[e.Key] = e
+		}
+		for _, e := range s.changes[next:] {
+			if latest[e.Key] == e && strings.HasPrefix(e.Key, req.Prefix) {
+				catchUp = append(catchUp, e)
+			}
+		}
+		next = len(s.changes)
+	}
+	progress := &db_server.WatchEvent{Version: s.clock, Progress: true}
+	s.mu.Unlock()
+
+	if len(catchUp) > 0 {
+		catchUp = append([]*db_server.WatchEvent{{Version: req.FromVersion, Gap: true}}, catchUp...)
+	}
+	for _, e := range catchUp {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+
+	for {
+		s.mu.Lock()
+		pending := s.changes[next:]
+		changed := s.changed
+		s.mu.Unlock()
+
+		for _, e := range pending {
+			if !strings.HasPrefix(e.Key, req.Prefix) {
+				continue
+			}
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+		next += len(pending)
+
+		if progress != nil {
+			if err := stream.Send(progress); err != nil {
+				return err
+			}
+			progress = nil
+		}
+
+		select {
+		case <-changed:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Backup sends the latest change of each key after since, in Badger's
+// backup format, split into small chunks to exercise reassembly.
+func (s *fakeServer) Backup(req *db_server.BackupRequest, stream grpc.ServerStreamingServer[db_server.BackupChunk]) error {
+	s.mu.Lock()
+	latest := make(map[string]*db_server.WatchEvent)
+	var keys []string
+	for _, e := range s.changes {
+		if e.Version <= req.Since {
+			continue
+		}
+		if _, ok := latest[e.Key]; !ok {
+			keys = append(keys, e.Key)
+		}
+		latest[e.Key] = e
+	}
+	var version uint64
+	if len(keys) > 0 {
+		version = s.clock
+	}
+	s.mu.Unlock()
+
+	sort.Strings(keys)
+	entries := make([]db.BackupEntry, len(keys))
+	for i, k := range keys {
+		e := latest[k]
+		entries[i] = db.BackupEntry{Key: e.Key, Value: e.Value, Version: e.Version, Deleted: e.Deleted}
+		if e.TtlSeconds > 0 {
+			entries[i].ExpiresAt = time.Now().Add(time.Duration(e.TtlSeconds) * time.Second)
+		}
+	}
+	var buf bytes.Buffer
+	if len(entries) > 0 {
+		if err := db.WriteBackup(&buf, entries); err != nil {
+			return err
+		}
+	}
+
+	data := buf.Bytes()
+	for len(data) > 0 {
+		n := min(len(data), 64)
+		if err := stream.Send(&db_server.BackupChunk{Data: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return stream.Send(&db_server.BackupChunk{Version: version, Last: true})
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fanOutWrite sends write to every replica at once and returns as soon as
+// acks replicas have accepted it, or once every replica has answered. With
+// fewer than acks replicas it fails with UNAVAILABLE without writing. Writes
+// still running at that point finish in the background; they are detached
+// from the caller's cancellation, bounded by the RPC timeout, and tracked in
+// m.background so shutdown can wait for them.
+func (m *DBManager) fanOutWrite(ctx context.Context, servers []dbServer, acks int, write func(context.Context, dbServer) error) (int, error) {
+	if acks < 1 {
+		acks = 1
+	}
+	if len(servers) < acks {
+		return 0, status.Errorf(codes.Unavailable, "%d replicas reachable, %d must accept the write", len(servers), acks)
+	}
+
+	results := make(chan error, len(servers))
+	detached := context.WithoutCancel(ctx)
+
+	m.background.Add(len(servers))
+	ReplicaWritesInFlight.Add(float64(len(servers)))
+	for _, server := range servers {
+		go func(server dbServer) {
+			defer m.background.Done()
+			defer ReplicaWritesInFlight.Dec()
+
+			callCtx, cancel := m.callContext(detached)
+			defer cancel()
+			results <- write(callCtx, server)
+		}(server)
+	}
+
+	acked := 0
+	var lastErr error
+	for answered := 0; answered < len(servers); answered++ {
+		select {
+		case err := <-results:
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			acked++
+			if acked >= acks {
+				return acked, nil
+			}
+		case <-ctx.Done():
+			return acked, ctx.Err()
+		}
+	}
+
+	return acked, lastErr
+}
+
+// replicateFromPrimary copies a write the primary has already applied to the
+// remaining replicas. The primary counts as one of the acks, so with a single
+// required ack the copies run entirely in the background.
+func (m *DBManager) replicateFromPrimary(ctx context.Context, secondaries []dbServer, acks int, write func(context.Context, dbServer) error) (int, error) {
+	if len(secondaries) == 0 {
+		return 0, nil
+	}
+
+	if acks-1 < 1 {
+		detached := context.WithoutCancel(ctx)
+		m.background.Add(1)
+		go func() {
+			defer m.background.Done()
+			m.fanOutWrite(detached, secondaries, len(secondaries), write)
+		}()
+		return 0, nil
+	}
+
+	return m.fanOutWrite(ctx, secondaries, acks-1, write)
+}
+
+// WaitForBackgroundWrites blocks until every replica write that outlived its
+// request, and every key migration started by a join, has finished.
+func (m *DBManager) WaitForBackgroundWrites() {
+	m.background.Wait()
+}
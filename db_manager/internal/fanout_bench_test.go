@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+)
+
+// The replicas answer after 2ms and 8ms, so a sequential write costs ~10ms,
+// a fan-out waiting for every ack ~8ms, and a fan-out acknowledged by one
+// replica ~2ms.
+var benchReplicaDelays = []time.Duration{2 * time.Millisecond, 8 * time.Millisecond}
+
+func BenchmarkSetKey_Sequential(b *testing.B) {
+	m, _ := newTestManager(b, DefaultOptions(), benchReplicaDelays...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		servers, epoch, err := m.getReplicaServers(key)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, server := range servers {
+			if _, err := server.client.Set(ctx, &db_server.SetRequest{Key: key, Value: "value", Epoch: epoch}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSetKey_FanOutAllAcks(b *testing.B) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+	m, _ := newTestManager(b, opts, benchReplicaDelays...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.SetKey(ctx, fmt.Sprintf("key-%d", i), []byte("value"), 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetKey_FanOutOneAck(b *testing.B) {
+	m, _ := newTestManager(b, DefaultOptions(), benchReplicaDelays...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.SetKey(ctx, fmt.Sprintf("key-%d", i), []byte("value"), 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	m.WaitForBackgroundWrites()
+}
+
+func BenchmarkDeleteKey_FanOutOneAck(b *testing.B) {
+	m, _ := newTestManager(b, DefaultOptions(), benchReplicaDelays...)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.DeleteKey(ctx, fmt.Sprintf("key-%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	m.WaitForBackgroundWrites()
+}
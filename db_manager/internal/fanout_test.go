@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetKey_ReturnsAfterRequiredAcks(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 300*time.Millisecond)
+
+	start := time.Now()
+	ok, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0)
+	if err != nil || !ok {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("expected SetKey to return after the fast replica, took %v", elapsed)
+	}
+
+	m.WaitForBackgroundWrites()
+	for i, s := range servers {
+		if v, ok := s.value("user:1"); !ok || v != "Alice" {
+			t.Fatalf("server %d: expected background write to land, got %q (ok=%v)", i, v, ok)
+		}
+	}
+}
+
+func TestSetKey_WaitsForAllAcks(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+
+	m, _ := newTestManager(t, opts, 0, 100*time.Millisecond)
+
+	start := time.Now()
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected SetKey to wait for the slow replica, took %v", elapsed)
+	}
+}
+
+func TestSetKey_TooFewReachableReplicas(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+	m, servers := newTestManager(t, opts, 0, 0, 0)
+
+	replicas, _, _ := m.getReplicaServers("user:1")
+	for i := 0; i < m.opts.Breaker.MinCalls; i++ {
+		replicas[1].breaker.record(true)
+	}
+
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE with one of two required replicas reachable, got %v", err)
+	}
+	if _, err := m.Increment(context.Background(), "user:1", 1); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from Increment, got %v", err)
+	}
+	results := m.BatchSet(context.Background(), []KeyValue{{Key: "user:1", Value: []byte("Alice")}})
+	if status.Code(results[0].Err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE from BatchSet, got %v", results[0].Err)
+	}
+
+	m.WaitForBackgroundWrites()
+	for i, s := range servers {
+		if _, ok := s.value("user:1"); ok {
+			t.Fatalf("server %d: expected nothing to be written", i)
+		}
+	}
+}
+
+func TestSetKey_AcksCappedAtRing(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+	m, _ := newTestManager(t, opts, 0)
+
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("expected a one-server ring to need one ack, got %v", err)
+	}
+}
+
+func TestDeleteKey_FansOut(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	if _, err := m.DeleteKey(context.Background(), "user:1"); err != nil {
+		t.Fatalf("DeleteKey failed: %v", err)
+	}
+
+	m.WaitForBackgroundWrites()
+	for i, s := range servers {
+		if _, ok := s.value("user:1"); ok {
+			t.Fatalf("server %d: expected key to be deleted", i)
+		}
+	}
+}
+
+func TestSetKey_NoServers(t *testing.T) {
+	m := NewDBManager(DefaultOptions())
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err == nil {
+		t.Fatal("expected error with no servers")
+	}
+}
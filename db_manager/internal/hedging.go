@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+)
+
+// minHedgeSamples is how many reads must be observed before the adaptive
+// hedge delay is trusted; until then FallbackHedgeDelay is used.
+const minHedgeSamples = 20
+
+type getResult struct {
+	value []byte
+	err   error
+	hedge bool
+}
+
+// hedgeDelay returns how long to wait on a replica before hedging the read to
+// the next one: the configured fixed delay, or the configured percentile of
+// recent read latency.
+func (m *DBManager) hedgeDelay() time.Duration {
+	if m.opts.Reads.HedgeDelay > 0 {
+		return m.opts.Reads.HedgeDelay
+	}
+	if d, ok := m.readLatency.percentile(m.opts.Reads.HedgePercentile, minHedgeSamples); ok {
+		return d
+	}
+	return m.opts.Reads.FallbackHedgeDelay
+}
+
+// readReplicas asks the replicas for key in order. A replica that fails is
+// replaced by the next one straight away; a replica that is merely slow gets
+// a hedge sent to the next replica after hedgeDelay. The first successful
+// answer wins and the calls still in flight are cancelled.
+func (m *DBManager) readReplicas(ctx context.Context, servers []dbServer, epoch uint64, key string) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan getResult, len(servers))
+	launch := func(server dbServer, hedge bool) {
+		go func() {
+			callCtx, callCancel := m.callContext(ctx)
+			defer callCancel()
+
+			start := time.Now()
+			resp, err := server.client.Get(callCtx, &db_server.GetRequest{Key: key, Epoch: epoch})
+			if err != nil {
+				results <- getResult{err: err, hedge: hedge}
+				return
+			}
+			m.readLatency.observe(time.Since(start))
+			results <- getResult{value: storedValue(resp.ValueBytes, resp.Value), hedge: hedge}
+		}()
+	}
+
+	launch(servers[0], false)
+	next, inFlight := 1, 1
+
+	var hedgeTimer <-chan time.Time
+	if m.opts.Reads.Hedge && len(servers) > 1 {
+		timer := time.NewTimer(m.hedgeDelay())
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	var lastErr error
+	for inFlight > 0 {
+		select {
+		case r := <-results:
+			inFlight--
+			if r.err == nil {
+				if r.hedge {
+					HedgedReads.WithLabelValues("won").Inc()
+				}
+				return r.value, nil
+			}
+			lastErr = r.err
+			if next < len(servers) {
+				launch(servers[next], false)
+				next++
+				inFlight++
+			}
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if next < len(servers) {
+				HedgedReads.WithLabelValues("fired").Inc()
+				launch(servers[next], true)
+				next++
+				inFlight++
+			}
+		}
+	}
+
+	return nil, lastErr
+}
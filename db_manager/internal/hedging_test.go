@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestGetKey_HedgeWinsOverSlowPrimary(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+	opts.Reads.HedgeDelay = 20 * time.Millisecond
+
+	m, servers := newTestManager(t, opts, 0, 0)
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+
+	replicas := replicasFor(t, m, servers, "user:1")
+	replicas[0].setDelay(time.Second)
+
+	fired := testutil.ToFloat64(HedgedReads.WithLabelValues("fired"))
+	won := testutil.ToFloat64(HedgedReads.WithLabelValues("won"))
+
+	start := time.Now()
+	val, err := m.GetKey(context.Background(), "user:1")
+	if err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	if string(val) != "Alice" {
+		t.Fatalf("expected 'Alice', got %q", val)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected hedge to answer before the slow primary, took %v", elapsed)
+	}
+
+	if got := testutil.ToFloat64(HedgedReads.WithLabelValues("fired")) - fired; got != 1 {
+		t.Fatalf("expected 1 hedge fired, got %v", got)
+	}
+	if got := testutil.ToFloat64(HedgedReads.WithLabelValues("won")) - won; got != 1 {
+		t.Fatalf("expected 1 hedge won, got %v", got)
+	}
+}
+
+func TestGetKey_NoHedgeForFastPrimary(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = ReplicationFactor
+	opts.Reads.HedgeDelay = 200 * time.Millisecond
+
+	m, _ := newTestManager(t, opts, 0, 0)
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+
+	fired := testutil.ToFloat64(HedgedReads.WithLabelValues("fired"))
+	if _, err := m.GetKey(context.Background(), "user:1"); err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	if got := testutil.ToFloat64(HedgedReads.WithLabelValues("fired")) - fired; got != 0 {
+		t.Fatalf("expected no hedge for a fast primary, got %v", got)
+	}
+}
+
+func TestGetKey_FailsOverOnError(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Reads.Hedge = false
+
+	m, servers := newTestManager(t, opts, 0, 0)
+	replicas := replicasFor(t, m, servers, "user:1")
+
+	// Only the secondary holds the key, so the primary answers with an error.
+	replicas[1].mu.Lock()
+	replicas[1].data["user:1"] = "Alice"
+	replicas[1].mu.Unlock()
+
+	val, err := m.GetKey(context.Background(), "user:1")
+	if err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	if string(val) != "Alice" {
+		t.Fatalf("expected 'Alice', got %q", val)
+	}
+}
+
+func TestLatencyTracker_Percentile(t *testing.T) {
+	tr := newLatencyTracker(100)
+	if _, ok := tr.percentile(0.95, 1); ok {
+		t.Fatal("expected no percentile for empty tracker")
+	}
+
+	for i := 1; i <= 100; i++ {
+		tr.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	p95, ok := tr.percentile(0.95, 20)
+	if !ok {
+		t.Fatal("expected percentile after 100 samples")
+	}
+	if p95 != 95*time.Millisecond {
+		t.Fatalf("expected p95 of 95ms, got %v", p95)
+	}
+
+	if _, ok := newLatencyTracker(100).percentile(0.95, 20); ok {
+		t.Fatal("expected no percentile below minimum sample count")
+	}
+}
+
+func TestLatencyTracker_SlidingWindow(t *testing.T) {
+	tr := newLatencyTracker(10)
+	for i := 0; i < 10; i++ {
+		tr.observe(time.Second)
+	}
+	for i := 0; i < 10; i++ {
+		tr.observe(time.Millisecond)
+	}
+
+	p, _ := tr.percentile(1, 1)
+	if p != time.Millisecond {
+		t.Fatalf("expected old samples to be evicted, got max %v", p)
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+)
+
+// Increment atomically adds delta to the integer stored under key and returns
+// the new value. The read-modify-write happens on the key's primary; the
+// result, with the key's remaining TTL, is then copied to the other replicas
+// as a plain value, where a copy older than one already applied is dropped.
+func (m *DBManager) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	var resp *db_server.IncrementResponse
+	_, err := m.writeThroughPrimary(ctx, "increment", key,
+		func(ctx context.Context, primary dbServer, epoch uint64) (uint64, error) {
+			var err error
+			resp, err = primary.client.Increment(ctx, &db_server.IncrementRequest{Key: key, Delta: delta, Epoch: epoch})
+			if err != nil {
+				return 0, err
+			}
+			return resp.Order, nil
+		},
+		func(ctx context.Context, server dbServer, epoch, order uint64) error {
+			_, err := server.client.Set(ctx, &db_server.SetRequest{
+				Key:          key,
+				ValueBytes:   []byte(strconv.FormatInt(resp.Value, 10)),
+				Epoch:        epoch,
+				TtlSeconds:   resp.TtlSeconds,
+				PrimaryOrder: order,
+			})
+			return err
+		})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Value, nil
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIncrement_ReplicatesResult(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "hits", []byte("10"), time.Hour); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	val, err := m.Increment(context.Background(), "hits", 5)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if val != 15 {
+		t.Fatalf("expected 15, got %d", val)
+	}
+	m.WaitForBackgroundWrites()
+
+	for _, r := range replicasFor(t, m, servers, "hits") {
+		if v, _ := r.value("hits"); v != "15" {
+			t.Fatalf("expected replica %s to hold '15', got %q", r.addr, v)
+		}
+		if ttl := r.ttl("hits"); ttl != 3600 {
+			t.Fatalf("expected replica %s to keep the 1h TTL, got %d", r.addr, ttl)
+		}
+	}
+}
+
+func TestIncrement_Concurrent(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Increment(context.Background(), "seq", 1); err != nil {
+				t.Errorf("Increment failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	m.WaitForBackgroundWrites()
+
+	// Copies race to the replicas; the last one they keep is the final value.
+	for _, r := range replicasFor(t, m, servers, "seq") {
+		if v, _ := r.value("seq"); v != "20" {
+			t.Fatalf("expected replica %s to hold '20', got %q", r.addr, v)
+		}
+	}
+
+	val, err := m.Increment(context.Background(), "seq", 0)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if val != 20 {
+		t.Fatalf("expected 20, got %d", val)
+	}
+}
+
+func TestIncrement_NotInteger(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "name", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	_, err := m.Increment(context.Background(), "name", 1)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyTracker keeps a sliding window of recent call latencies and answers
+// percentile queries over it. The sorted view is rebuilt lazily, at most once
+// every refreshEvery observations, so reads stay cheap on the hot path.
+type latencyTracker struct {
+	mu           sync.Mutex
+	samples      []time.Duration
+	next         int
+	full         bool
+	sorted       []time.Duration
+	sinceRefresh int
+	refreshEvery int
+}
+
+func newLatencyTracker(window int) *latencyTracker {
+	return &latencyTracker{
+		samples:      make([]time.Duration, window),
+		refreshEvery: window/10 + 1,
+	}
+}
+
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % len(t.samples)
+	if t.next == 0 {
+		t.full = true
+	}
+	t.sinceRefresh++
+}
+
+func (t *latencyTracker) count() int {
+	if t.full {
+		return len(t.samples)
+	}
+	return t.next
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of the window, or false
+// if there are not yet minSamples observations.
+func (t *latencyTracker) percentile(p float64, minSamples int) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.count()
+	if n == 0 || n < minSamples {
+		return 0, false
+	}
+
+	if t.sorted == nil || t.sinceRefresh >= t.refreshEvery {
+		t.sorted = append(t.sorted[:0], t.samples[:n]...)
+		sort.Slice(t.sorted, func(i, j int) bool { return t.sorted[i] < t.sorted[j] })
+		t.sinceRefresh = 0
+	}
+
+	idx := int(p*float64(len(t.sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(t.sorted) {
+		idx = len(t.sorted) - 1
+	}
+	return t.sorted[idx], true
+}
@@ -25,15 +25,111 @@ var (
 		Help:      "Number of active database servers in the cluster",
 	})
 
+	RingEpoch = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Name:      "ring_epoch",
+		Help:      "Current hash ring epoch, bumped on every membership change",
+	})
+
 	ReplicationWrites = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "meerkat",
 		Name:      "replication_writes_total",
 		Help:      "Total replication write attempts",
 	}, []string{"status"})
 
+	ReplicaWritesInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Name:      "replica_writes_in_flight",
+		Help:      "Replica writes still running, including those that outlived their request",
+	})
+
+	HedgedReads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "hedged_reads_total",
+		Help:      "Hedged reads fired to a second replica, and those whose answer arrived first",
+	}, []string{"outcome"})
+
+	CircuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Name:      "circuit_breaker_state",
+		Help:      "Circuit breaker state per server (0 = closed, 1 = open, 2 = half-open)",
+	}, []string{"server"})
+
+	ServerInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Name:      "server_in_flight_requests",
+		Help:      "Data-path calls outstanding against each server",
+	}, []string{"server"})
+
+	RequestsShed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "requests_shed_total",
+		Help:      "Requests rejected because every replica was saturated",
+	})
+
+	RateLimited = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter",
+	})
+
 	KeysMigrated = promauto.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "meerkat",
 		Name:      "keys_migrated_total",
-		Help:      "Total number of keys migrated during node add/remove",
+		Help:      "Total number of keys migrated during node add/remove and range moves",
 	}, []string{"event"})
+
+	RangeChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "range_changes_total",
+		Help:      "Ranges split, merged or moved in range-partitioned mode",
+	}, []string{"change"})
+
+	Transactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "transactions_total",
+		Help:      "Multi-key transactions by mode (single_shard or two_phase) and outcome",
+	}, []string{"mode", "outcome"})
+
+	TransactionsRecovered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "transactions_recovered_total",
+		Help:      "Two-phase commits finished by recovery rather than by their coordinator",
+	}, []string{"outcome"})
+
+	CDCRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "cdc_records_total",
+		Help:      "Change records acknowledged by each CDC sink",
+	}, []string{"sink"})
+
+	CDCFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "cdc_delivery_failures_total",
+		Help:      "Failed CDC batch deliveries, each retried, by sink",
+	}, []string{"sink"})
+
+	CDCGaps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "cdc_gaps_total",
+		Help:      "Gap records delivered to each CDC sink, each marking changes it may have missed",
+	}, []string{"sink"})
+
+	Backups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "backups_total",
+		Help:      "Cluster backups by kind (full/incremental) and outcome",
+	}, []string{"kind", "outcome"})
+
+	BackupBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "backup_bytes_total",
+		Help:      "Bytes of server snapshots written to backups",
+	})
+
+	RestoredKeys = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Name:      "restore_keys_total",
+		Help:      "Keys replayed from backups by outcome (restored/deleted/failed)",
+	}, []string{"outcome"})
 )
@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Options tunes how the manager admits client requests and talks to
+// db_servers. The TOML tags let the manager config decode straight into it.
+type Options struct {
+	Pool        PoolOptions        `toml:"pool"`
+	RPC         RPCOptions         `toml:"rpc"`
+	Replication ReplicationOptions `toml:"replication"`
+	Reads       ReadOptions        `toml:"reads"`
+	Breaker     BreakerOptions     `toml:"circuit_breaker"`
+	RateLimit   RateLimitOptions   `toml:"rate_limit"`
+	Admission   AdmissionOptions   `toml:"admission"`
+	Limits      LimitOptions       `toml:"limits"`
+
+	Partitioning PartitioningOptions `toml:"partitioning"`
+	Transactions TransactionOptions  `toml:"transactions"`
+	CDC          CDCOptions          `toml:"cdc"`
+	Backup       BackupOptions       `toml:"backup"`
+}
+
+type PoolOptions struct {
+	// Size is the number of gRPC connections kept open to each db_server.
+	Size int `toml:"size"`
+	// KeepaliveTime is how long a connection may stay idle before it is
+	// pinged, and KeepaliveTimeout how long to wait for the ping ack.
+	KeepaliveTime    time.Duration `toml:"keepalive_time"`
+	KeepaliveTimeout time.Duration `toml:"keepalive_timeout"`
+}
+
+type RPCOptions struct {
+	// Timeout bounds every call to a db_server. The caller's own deadline
+	// still applies when it is shorter.
+	Timeout time.Duration `toml:"timeout"`
+	// MaxRetries is how many times an idempotent call that failed with
+	// UNAVAILABLE is retried by gRPC, backing off from RetryBackoff.
+	MaxRetries   int           `toml:"max_retries"`
+	RetryBackoff time.Duration `toml:"retry_backoff"`
+}
+
+type ReplicationOptions struct {
+	// WriteAcks is how many replicas must accept a write before it is
+	// acknowledged to the client, capped at the replicas a key has on the
+	// ring. The remaining replicas are written in the background. A write
+	// that cannot reach this many replicas fails with UNAVAILABLE.
+	WriteAcks int `toml:"write_acks"`
+}
+
+type ReadOptions struct {
+	// Hedge sends a read to the next replica when the current one has not
+	// answered within the hedge delay, and takes whichever answers first.
+	Hedge bool `toml:"hedge"`
+	// HedgeDelay fixes the hedge delay. When zero, the delay tracks the
+	// HedgePercentile of recent read latency, falling back to
+	// FallbackHedgeDelay until enough reads have been observed.
+	HedgeDelay         time.Duration `toml:"hedge_delay"`
+	HedgePercentile    float64       `toml:"hedge_percentile"`
+	FallbackHedgeDelay time.Duration `toml:"fallback_hedge_delay"`
+}
+
+type BreakerOptions struct {
+	Enabled bool `toml:"enabled"`
+	// Window is the number of recent calls the failure rate is computed over,
+	// and MinCalls how many must have been seen before the breaker can trip.
+	Window   int `toml:"window"`
+	MinCalls int `toml:"min_calls"`
+	// FailureRate is the share of failed or slow calls that opens the
+	// breaker. Calls slower than SlowCall count as failures.
+	FailureRate float64       `toml:"failure_rate"`
+	SlowCall    time.Duration `toml:"slow_call"`
+	// OpenDuration is how long an open breaker rejects traffic before it
+	// lets HalfOpenProbes trial calls through.
+	OpenDuration   time.Duration `toml:"open_duration"`
+	HalfOpenProbes int           `toml:"half_open_probes"`
+}
+
+type RateLimitOptions struct {
+	Enabled bool `toml:"enabled"`
+	// KeyBy picks what a bucket is kept for: "client", identified by the
+	// peer address, or "prefix", the part of the request key before
+	// PrefixDelimiter. Requests without a key or whose key lacks the
+	// delimiter are charged to the client.
+	KeyBy           string `toml:"key_by"`
+	PrefixDelimiter string `toml:"prefix_delimiter"`
+	// TrustedProxies lists the addresses and CIDR ranges of peers, such as
+	// a gateway that authenticates clients, whose x-client-id metadata
+	// names the client instead. Any other peer's metadata is ignored.
+	TrustedProxies []string `toml:"trusted_proxies"`
+	// Rate is the sustained number of requests per second allowed for each
+	// identity, and Burst how many may be spent at once.
+	Rate  float64 `toml:"rate"`
+	Burst int     `toml:"burst"`
+	// Overrides sets a different limit for specific identities.
+	Overrides map[string]RateLimit `toml:"overrides"`
+}
+
+type RateLimit struct {
+	Rate  float64 `toml:"rate"`
+	Burst int     `toml:"burst"`
+}
+
+type AdmissionOptions struct {
+	// MaxInFlightPerServer sheds a request with RESOURCE_EXHAUSTED when all
+	// of its replicas already have this many calls outstanding. Zero disables
+	// shedding.
+	MaxInFlightPerServer int `toml:"max_in_flight_per_server"`
+}
+
+type LimitOptions struct {
+	// MaxValueSize is the largest value, in bytes, a write may carry. Zero
+	// disables the check.
+	MaxValueSize int `toml:"max_value_size"`
+}
+
+type PartitioningOptions struct {
+	// Mode is "hash" to spread keys over a consistent-hash ring, or "range" to
+	// keep them in key order between split points held by the manager.
+	Mode string `toml:"mode"`
+	// InitialSplits are the split points a range-partitioned keyspace starts
+	// with. They are never merged away.
+	InitialSplits []string `toml:"initial_splits"`
+	// A range is split at its median key once it holds more than SplitBytes,
+	// and two neighbouring ranges are merged once together they hold less
+	// than MergeBytes.
+	SplitBytes int64 `toml:"split_bytes"`
+	MergeBytes int64 `toml:"merge_bytes"`
+}
+
+type TransactionOptions struct {
+	// LogPath is where the coordinator log of two-phase commits is kept. When
+	// empty the log lives in memory only, and transactions in doubt when the
+	// manager stops are resolved by presumed abort.
+	LogPath string `toml:"log_path"`
+	// MaxKeys bounds the number of keys a single transaction may write.
+	MaxKeys int `toml:"max_keys"`
+}
+
+type CDCOptions struct {
+	Enabled bool `toml:"enabled"`
+	// CheckpointDir holds one checkpoint file per sink, recording how far
+	// the sink has acknowledged the change stream.
+	CheckpointDir string `toml:"checkpoint_dir"`
+	// A batch is delivered once it holds BatchSize records or its oldest
+	// record has waited FlushInterval.
+	BatchSize     int           `toml:"batch_size"`
+	FlushInterval time.Duration `toml:"flush_interval"`
+	// A failed delivery is retried, backing off from RetryBackoff up to
+	// MaxRetryBackoff, until it succeeds.
+	RetryBackoff    time.Duration `toml:"retry_backoff"`
+	MaxRetryBackoff time.Duration `toml:"max_retry_backoff"`
+	Sinks           []SinkOptions `toml:"sinks"`
+}
+
+type SinkOptions struct {
+	// Name identifies the sink's checkpoint, so it must be unique and stay
+	// the same across restarts.
+	Name string `toml:"name"`
+	// Type is "file", which appends JSON lines to Path, or "webhook", which
+	// POSTs batches to URL with Headers, each request bounded by Timeout.
+	Type    string            `toml:"type"`
+	Path    string            `toml:"path"`
+	URL     string            `toml:"url"`
+	Headers map[string]string `toml:"headers"`
+	Timeout time.Duration     `toml:"timeout"`
+	// Prefix limits the sink to keys starting with it.
+	Prefix string `toml:"prefix"`
+}
+
+type BackupOptions struct {
+	// Dir is where backups are written when a request does not name a
+	// directory, and the only place a request may name one. Each backup is a subdirectory holding a manifest and one
+	// file per server.
+	Dir string `toml:"dir"`
+	// RestoreBatchSize is how many keys a restore writes per batch. A batch
+	// is also written early once its keys and values reach 3 MB.
+	RestoreBatchSize int `toml:"restore_batch_size"`
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Pool: PoolOptions{
+			Size:             4,
+			KeepaliveTime:    30 * time.Second,
+			KeepaliveTimeout: 10 * time.Second,
+		},
+		RPC: RPCOptions{
+			Timeout:      5 * time.Second,
+			MaxRetries:   2,
+			RetryBackoff: 100 * time.Millisecond,
+		},
+		Replication: ReplicationOptions{
+			WriteAcks: 1,
+		},
+		Reads: ReadOptions{
+			Hedge:              true,
+			HedgePercentile:    0.95,
+			FallbackHedgeDelay: 10 * time.Millisecond,
+		},
+		Breaker: BreakerOptions{
+			Enabled:        true,
+			Window:         20,
+			MinCalls:       10,
+			FailureRate:    0.5,
+			SlowCall:       time.Second,
+			OpenDuration:   10 * time.Second,
+			HalfOpenProbes: 3,
+		},
+		RateLimit: RateLimitOptions{
+			Enabled:         false,
+			KeyBy:           "client",
+			PrefixDelimiter: ":",
+			Rate:            1000,
+			Burst:           2000,
+		},
+		Admission: AdmissionOptions{
+			MaxInFlightPerServer: 256,
+		},
+		Limits: LimitOptions{
+			MaxValueSize: 1 << 20,
+		},
+		Partitioning: PartitioningOptions{
+			Mode:       PartitioningHash,
+			SplitBytes: 64 << 20,
+			MergeBytes: 16 << 20,
+		},
+		Transactions: TransactionOptions{
+			MaxKeys: 128,
+		},
+		CDC: CDCOptions{
+			CheckpointDir:   "data/cdc",
+			BatchSize:       100,
+			FlushInterval:   time.Second,
+			RetryBackoff:    100 * time.Millisecond,
+			MaxRetryBackoff: 30 * time.Second,
+		},
+		Backup: BackupOptions{
+			Dir:              "data/backups",
+			RestoreBatchSize: 500,
+		},
+	}
+}
+
+// Validate reports every setting that would leave the manager misbehaving
+// rather than failing outright, such as a retry policy gRPC rejects or a
+// breaker that can never close again.
+func (o Options) Validate() error {
+	var errs []error
+	if o.RPC.MaxRetries > 0 && o.RPC.RetryBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("rpc.retry_backoff must be positive when rpc.max_retries is set, got %v", o.RPC.RetryBackoff))
+	}
+	if o.Replication.WriteAcks < 0 || o.Replication.WriteAcks > ReplicationFactor {
+		errs = append(errs, fmt.Errorf("replication.write_acks must be between 1 and the replication factor %d, got %d", ReplicationFactor, o.Replication.WriteAcks))
+	}
+	if o.Breaker.Enabled {
+		if o.Breaker.HalfOpenProbes < 1 {
+			errs = append(errs, fmt.Errorf("circuit_breaker.half_open_probes must be at least 1, got %d", o.Breaker.HalfOpenProbes))
+		}
+		if o.Breaker.Window < 1 {
+			errs = append(errs, fmt.Errorf("circuit_breaker.window must be at least 1, got %d", o.Breaker.Window))
+		}
+		if o.Breaker.FailureRate <= 0 || o.Breaker.FailureRate > 1 {
+			errs = append(errs, fmt.Errorf("circuit_breaker.failure_rate must be in (0, 1], got %v", o.Breaker.FailureRate))
+		}
+	}
+	if o.RateLimit.Enabled && o.RateLimit.KeyBy != "client" && o.RateLimit.KeyBy != "prefix" {
+		errs = append(errs, fmt.Errorf("rate_limit.key_by must be \"client\" or \"prefix\", got %q", o.RateLimit.KeyBy))
+	}
+	if o.Partitioning.Mode != PartitioningHash && o.Partitioning.Mode != PartitioningRange {
+		errs = append(errs, fmt.Errorf("partitioning.mode must be %q or %q, got %q", PartitioningHash, PartitioningRange, o.Partitioning.Mode))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+		want   string
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "zero retry backoff", modify: func(o *Options) { o.RPC.RetryBackoff = 0 }, want: "rpc.retry_backoff"},
+		{name: "zero backoff without retries", modify: func(o *Options) { o.RPC.MaxRetries, o.RPC.RetryBackoff = 0, 0 }},
+		{name: "zero half-open probes", modify: func(o *Options) { o.Breaker.HalfOpenProbes = 0 }, want: "half_open_probes"},
+		{name: "breaker disabled", modify: func(o *Options) { o.Breaker.Enabled, o.Breaker.HalfOpenProbes = false, 0 }},
+		{name: "write acks over replication factor", modify: func(o *Options) { o.Replication.WriteAcks = ReplicationFactor + 1 }, want: "write_acks"},
+		{name: "write acks at replication factor", modify: func(o *Options) { o.Replication.WriteAcks = ReplicationFactor }},
+		{name: "unknown key_by", modify: func(o *Options) { o.RateLimit.Enabled, o.RateLimit.KeyBy = true, "tenant" }, want: "key_by"},
+		{name: "unknown partitioning mode", modify: func(o *Options) { o.Partitioning.Mode = "ordered" }, want: "partitioning.mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			err := opts.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("expected valid options, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error about %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package internal
+
+// Partitioner decides which servers hold a key. ConsistentHasher spreads keys
+// over a hash ring; RangePartitioner keeps them in key order between split
+// points, so that range scans only touch the servers whose ranges overlap.
+type Partitioner interface {
+	AddNode(node string)
+	RemoveNode(node string)
+	Reconcile(nodes []string)
+	GetNode(key string) (string, bool)
+	GetReplicaNodes(key string, count int) []string
+	GetNodes() []string
+	Size() int
+
+	// Position is where node sits on the hash ring, or zero when keys are not
+	// placed by hash.
+	Position(node string) uint32
+
+	// Ownership describes the keys node holds as one of their first count
+	// replicas, for pushing to the server's fence.
+	Ownership(node string, count int) (Ownership, bool)
+}
+
+// Ownership is a server's share of the keyspace: hash ranges for a
+// hash-partitioned cluster, key ranges for a range-partitioned one.
+type Ownership struct {
+	HashRanges []HashRange
+	KeyRanges  []KeyRange
+}
+
+// KeyRange covers keys in [Start, End). An empty End is unbounded.
+type KeyRange struct {
+	Start string
+	End   string
+}
+
+func (r KeyRange) Contains(key string) bool {
+	return key >= r.Start && (r.End == "" || key < r.End)
+}
+
+// Overlaps reports whether r shares any key with [start, end), where an empty
+// end is unbounded.
+func (r KeyRange) Overlaps(start, end string) bool {
+	return (end == "" || r.Start < end) && (r.End == "" || start < r.End)
+}
+
+const (
+	PartitioningHash  = "hash"
+	PartitioningRange = "range"
+)
@@ -0,0 +1,353 @@
+package internal
+
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
+// keyRangeOwner is one range of the keyspace, running from start up to the
+// next range's start, and the node that is its primary.
+type keyRangeOwner struct {
+	start string
+	owner string
+}
+
+// RangeInfo is a snapshot of one range with its replicas, primary first.
+type RangeInfo struct {
+	KeyRange
+	Replicas []string
+}
+
+// RangePartitioner keeps the keyspace in order, cut at split points held by
+// the manager. Each range has a primary, and its replicas are the nodes that
+// follow the primary in sorted node order. Ranges are spread so that no node
+// is primary for two more ranges than another.
+type RangePartitioner struct {
+	mu     sync.RWMutex
+	nodes  []string
+	ranges []keyRangeOwner
+}
+
+// NewRangePartitioner starts with the keyspace cut at splits. Without splits
+// the whole keyspace is a single range.
+func NewRangePartitioner(splits []string) *RangePartitioner {
+	p := &RangePartitioner{ranges: []keyRangeOwner{{start: ""}}}
+	sorted := append([]string(nil), splits...)
+	sort.Strings(sorted)
+	for _, s := range sorted {
+		if s != "" && s != p.ranges[len(p.ranges)-1].start {
+			p.ranges = append(p.ranges, keyRangeOwner{start: s})
+		}
+	}
+	return p
+}
+
+func (p *RangePartitioner) AddNode(node string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.addNodeLocked(node)
+}
+
+func (p *RangePartitioner) addNodeLocked(node string) {
+	idx := sort.SearchStrings(p.nodes, node)
+	if idx < len(p.nodes) && p.nodes[idx] == node {
+		return
+	}
+	p.nodes = append(p.nodes, "")
+	copy(p.nodes[idx+1:], p.nodes[idx:])
+	p.nodes[idx] = node
+
+	for i := range p.ranges {
+		if p.ranges[i].owner == "" {
+			p.ranges[i].owner = node
+		}
+	}
+
+	// Hand the new node ranges from the busiest primaries until the spread
+	// is even.
+	for {
+		counts := p.primaryCountsLocked()
+		busiest := p.busiestLocked(counts)
+		if counts[busiest]-counts[node] < 2 {
+			return
+		}
+		for i := len(p.ranges) - 1; i >= 0; i-- {
+			if p.ranges[i].owner == busiest {
+				p.ranges[i].owner = node
+				break
+			}
+		}
+	}
+}
+
+func (p *RangePartitioner) RemoveNode(node string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removeNodeLocked(node)
+}
+
+func (p *RangePartitioner) removeNodeLocked(node string) {
+	idx := sort.SearchStrings(p.nodes, node)
+	if idx == len(p.nodes) || p.nodes[idx] != node {
+		return
+	}
+	p.nodes = append(p.nodes[:idx], p.nodes[idx+1:]...)
+
+	for i := range p.ranges {
+		if p.ranges[i].owner == node {
+			p.ranges[i].owner = p.leastLoadedLocked()
+		}
+	}
+}
+
+func (p *RangePartitioner) Reconcile(nodes []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keep := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		keep[n] = struct{}{}
+	}
+	for _, n := range append([]string(nil), p.nodes...) {
+		if _, ok := keep[n]; !ok {
+			p.removeNodeLocked(n)
+		}
+	}
+	for _, n := range nodes {
+		p.addNodeLocked(n)
+	}
+}
+
+func (p *RangePartitioner) GetNode(key string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	owner := p.ranges[p.rangeIndexLocked(key)].owner
+	return owner, owner != ""
+}
+
+func (p *RangePartitioner) GetReplicaNodes(key string, count int) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.replicasLocked(p.ranges[p.rangeIndexLocked(key)].owner, count)
+}
+
+func (p *RangePartitioner) GetNodes() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]string(nil), p.nodes...)
+}
+
+func (p *RangePartitioner) Size() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.nodes)
+}
+
+func (p *RangePartitioner) Position(node string) uint32 {
+	return 0
+}
+
+// Ownership returns the ranges node replicates, with neighbouring ranges
+// joined together.
+func (p *RangePartitioner) Ownership(node string, count int) (Ownership, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	idx := sort.SearchStrings(p.nodes, node)
+	if idx == len(p.nodes) || p.nodes[idx] != node {
+		return Ownership{}, false
+	}
+
+	var owned []KeyRange
+	for i, r := range p.ranges {
+		isReplica := false
+		for _, n := range p.replicasLocked(r.owner, count) {
+			if n == node {
+				isReplica = true
+				break
+			}
+		}
+		if !isReplica {
+			continue
+		}
+
+		kr := p.keyRangeLocked(i)
+		if len(owned) > 0 && owned[len(owned)-1].End == kr.Start {
+			owned[len(owned)-1].End = kr.End
+			continue
+		}
+		owned = append(owned, kr)
+	}
+
+	return Ownership{KeyRanges: owned}, true
+}
+
+// Ranges returns every range with its first count replicas.
+func (p *RangePartitioner) Ranges(count int) []RangeInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	infos := make([]RangeInfo, len(p.ranges))
+	for i, r := range p.ranges {
+		infos[i] = RangeInfo{KeyRange: p.keyRangeLocked(i), Replicas: p.replicasLocked(r.owner, count)}
+	}
+	return infos
+}
+
+// RangesWithout returns what Ranges would return once node is removed,
+// leaving the partitioner as it is.
+func (p *RangePartitioner) RangesWithout(node string, count int) []RangeInfo {
+	p.mu.RLock()
+	c := &RangePartitioner{nodes: slices.Clone(p.nodes), ranges: slices.Clone(p.ranges)}
+	p.mu.RUnlock()
+
+	c.RemoveNode(node)
+	return c.Ranges(count)
+}
+
+// NodesForRange returns the nodes holding any key in [start, end), with an
+// empty end meaning unbounded.
+func (p *RangePartitioner) NodesForRange(start, end string, count int) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	var nodes []string
+	for i, r := range p.ranges {
+		if !p.keyRangeLocked(i).Overlaps(start, end) {
+			continue
+		}
+		for _, n := range p.replicasLocked(r.owner, count) {
+			if _, ok := seen[n]; !ok {
+				seen[n] = struct{}{}
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+// Split cuts the range containing at into two, both kept by the same primary
+// until one of them is reassigned. It fails if at is already a split point.
+func (p *RangePartitioner) Split(at string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := p.rangeIndexLocked(at)
+	if at == "" || p.ranges[idx].start == at {
+		return false
+	}
+
+	p.ranges = append(p.ranges, keyRangeOwner{})
+	copy(p.ranges[idx+2:], p.ranges[idx+1:])
+	p.ranges[idx+1] = keyRangeOwner{start: at, owner: p.ranges[idx].owner}
+	return true
+}
+
+// Merge removes the split point at start, folding that range into the one
+// before it, whose primary keeps the merged range.
+func (p *RangePartitioner) Merge(start string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := p.rangeIndexLocked(start)
+	if idx == 0 || p.ranges[idx].start != start {
+		return false
+	}
+
+	p.ranges = append(p.ranges[:idx], p.ranges[idx+1:]...)
+	return true
+}
+
+// Assign makes node the primary of the range starting at start.
+func (p *RangePartitioner) Assign(start, node string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := p.rangeIndexLocked(start)
+	if p.ranges[idx].start != start {
+		return false
+	}
+	p.ranges[idx].owner = node
+	return true
+}
+
+// ReplicasFor returns the first count replicas of a range whose primary is
+// node.
+func (p *RangePartitioner) ReplicasFor(node string, count int) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.replicasLocked(node, count)
+}
+
+// LeastLoaded returns the node that is primary for the fewest ranges.
+func (p *RangePartitioner) LeastLoaded() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.leastLoadedLocked()
+}
+
+func (p *RangePartitioner) rangeIndexLocked(key string) int {
+	return sort.Search(len(p.ranges), func(i int) bool {
+		return p.ranges[i].start > key
+	}) - 1
+}
+
+func (p *RangePartitioner) keyRangeLocked(i int) KeyRange {
+	r := KeyRange{Start: p.ranges[i].start}
+	if i+1 < len(p.ranges) {
+		r.End = p.ranges[i+1].start
+	}
+	return r
+}
+
+// replicasLocked returns owner followed by the next count-1 nodes in sorted
+// order, wrapping around.
+func (p *RangePartitioner) replicasLocked(owner string, count int) []string {
+	if owner == "" {
+		return nil
+	}
+
+	idx := sort.SearchStrings(p.nodes, owner)
+	replicas := make([]string, 0, count)
+	for i := 0; i < len(p.nodes) && len(replicas) < count; i++ {
+		replicas = append(replicas, p.nodes[(idx+i)%len(p.nodes)])
+	}
+	return replicas
+}
+
+func (p *RangePartitioner) primaryCountsLocked() map[string]int {
+	counts := make(map[string]int, len(p.nodes))
+	for _, n := range p.nodes {
+		counts[n] = 0
+	}
+	for _, r := range p.ranges {
+		if r.owner != "" {
+			counts[r.owner]++
+		}
+	}
+	return counts
+}
+
+func (p *RangePartitioner) busiestLocked(counts map[string]int) string {
+	busiest := ""
+	for _, n := range p.nodes {
+		if busiest == "" || counts[n] > counts[busiest] {
+			busiest = n
+		}
+	}
+	return busiest
+}
+
+func (p *RangePartitioner) leastLoadedLocked() string {
+	counts := p.primaryCountsLocked()
+	least := ""
+	for _, n := range p.nodes {
+		if least == "" || counts[n] < counts[least] {
+			least = n
+		}
+	}
+	return least
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRangePartitioner_SingleRange(t *testing.T) {
+	p := NewRangePartitioner(nil)
+	if _, ok := p.GetNode("a"); ok {
+		t.Fatal("expected no owner without nodes")
+	}
+
+	p.AddNode("server-1")
+	for _, key := range []string{"", "a", "zzz"} {
+		if node, ok := p.GetNode(key); !ok || node != "server-1" {
+			t.Fatalf("expected server-1 to own %q, got %q", key, node)
+		}
+	}
+}
+
+func TestRangePartitioner_SpreadsPrimaries(t *testing.T) {
+	p := NewRangePartitioner([]string{"d", "h", "m", "q", "u"})
+	for i := 0; i < 3; i++ {
+		p.AddNode(fmt.Sprintf("server-%d", i))
+	}
+
+	counts := make(map[string]int)
+	for _, r := range p.Ranges(1) {
+		counts[r.Replicas[0]]++
+	}
+	for node, n := range counts {
+		if n != 2 {
+			t.Fatalf("expected every node to own 2 of 6 ranges, %s owns %d (%v)", node, n, counts)
+		}
+	}
+
+	p.RemoveNode("server-1")
+	for _, r := range p.Ranges(1) {
+		if r.Replicas[0] == "server-1" {
+			t.Fatalf("range [%q, %q) still owned by removed node", r.Start, r.End)
+		}
+	}
+}
+
+func TestRangePartitioner_ReplicasFollowPrimary(t *testing.T) {
+	p := NewRangePartitioner(nil)
+	p.AddNode("server-b")
+	p.AddNode("server-c")
+	p.AddNode("server-a")
+
+	owner, _ := p.GetNode("k")
+	replicas := p.GetReplicaNodes("k", 2)
+	if len(replicas) != 2 || replicas[0] != owner {
+		t.Fatalf("expected 2 replicas led by %s, got %v", owner, replicas)
+	}
+	if want := p.ReplicasFor(owner, 2); !reflect.DeepEqual(replicas, want) {
+		t.Fatalf("expected replicas %v, got %v", want, replicas)
+	}
+}
+
+func TestRangePartitioner_SplitAndMerge(t *testing.T) {
+	p := NewRangePartitioner(nil)
+	p.AddNode("server-1")
+
+	if !p.Split("m") {
+		t.Fatal("expected split at m to succeed")
+	}
+	if p.Split("m") {
+		t.Fatal("expected split at an existing split point to fail")
+	}
+	if got := p.Ranges(1); len(got) != 2 || got[0].End != "m" || got[1].Start != "m" {
+		t.Fatalf("expected ranges split at m, got %+v", got)
+	}
+
+	if !p.Merge("m") {
+		t.Fatal("expected merge at m to succeed")
+	}
+	if p.Merge("") {
+		t.Fatal("expected the first range not to be mergeable")
+	}
+	if got := p.Ranges(1); len(got) != 1 {
+		t.Fatalf("expected a single range after merge, got %+v", got)
+	}
+}
+
+func TestRangePartitioner_NodesForRange(t *testing.T) {
+	p := NewRangePartitioner([]string{"m"})
+	p.AddNode("server-1")
+	p.AddNode("server-2")
+	p.Assign("", "server-1")
+	p.Assign("m", "server-2")
+
+	if got := p.NodesForRange("a", "c", 1); !reflect.DeepEqual(got, []string{"server-1"}) {
+		t.Fatalf("expected only server-1 for [a, c), got %v", got)
+	}
+	if got := p.NodesForRange("n", "", 1); !reflect.DeepEqual(got, []string{"server-2"}) {
+		t.Fatalf("expected only server-2 for [n, ...), got %v", got)
+	}
+	if got := p.NodesForRange("a", "z", 1); len(got) != 2 {
+		t.Fatalf("expected both servers for [a, z), got %v", got)
+	}
+}
+
+func TestRangePartitioner_OwnershipJoinsNeighbours(t *testing.T) {
+	p := NewRangePartitioner([]string{"g", "p"})
+	p.AddNode("server-1")
+
+	owned, ok := p.Ownership("server-1", 2)
+	if !ok {
+		t.Fatal("expected ownership for a member")
+	}
+	if want := []KeyRange{{Start: "", End: ""}}; !reflect.DeepEqual(owned.KeyRanges, want) {
+		t.Fatalf("expected one unbounded range, got %+v", owned.KeyRanges)
+	}
+	if _, ok := p.Ownership("server-9", 2); ok {
+		t.Fatal("expected no ownership for a non-member")
+	}
+}
@@ -0,0 +1,400 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"github.com/rs/zerolog/log"
+)
+
+// RebalanceRanges splits ranges that have grown past SplitBytes at their
+// median key and merges neighbours that together hold less than MergeBytes.
+// The right half of a split goes to the least loaded primary, so the load
+// spreads as the keyspace grows. It does nothing under hash partitioning.
+func (m *DBManager) RebalanceRanges() {
+	if m.ranges == nil {
+		return
+	}
+	m.splitRanges()
+	m.mergeRanges()
+}
+
+func (m *DBManager) splitRanges() {
+	for _, info := range m.ranges.Ranges(ReplicationFactor) {
+		stats, err := m.rangeStats(info)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to read stats for range [%q, %q)", info.Start, info.End)
+			continue
+		}
+		if int64(stats.Bytes) <= m.opts.Partitioning.SplitBytes || stats.MedianKey <= info.Start {
+			continue
+		}
+		if !m.ranges.Split(stats.MedianKey) {
+			continue
+		}
+		RangeChanges.WithLabelValues("split").Inc()
+		log.Info().Msgf("Split range [%q, %q) at %q (%d keys, %d bytes)", info.Start, info.End, stats.MedianKey, stats.Keys, stats.Bytes)
+
+		right := KeyRange{Start: stats.MedianKey, End: info.End}
+		if target := m.ranges.LeastLoaded(); target != info.Replicas[0] {
+			m.moveRange(right, info.Replicas, target, "range_split")
+		}
+	}
+}
+
+func (m *DBManager) mergeRanges() {
+	pinned := make(map[string]struct{}, len(m.opts.Partitioning.InitialSplits))
+	for _, s := range m.opts.Partitioning.InitialSplits {
+		pinned[s] = struct{}{}
+	}
+
+	infos := m.ranges.Ranges(ReplicationFactor)
+	sizes := make([]int64, len(infos))
+	for i, info := range infos {
+		stats, err := m.rangeStats(info)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to read stats for range [%q, %q)", info.Start, info.End)
+			sizes[i] = -1
+			continue
+		}
+		sizes[i] = int64(stats.Bytes)
+	}
+
+	for i := 1; i < len(infos); i++ {
+		left, right := infos[i-1], infos[i]
+		if _, ok := pinned[right.Start]; ok || sizes[i-1] < 0 || sizes[i] < 0 {
+			continue
+		}
+		if sizes[i-1]+sizes[i] >= m.opts.Partitioning.MergeBytes {
+			continue
+		}
+
+		// The merged range keeps the left primary, so the right range's keys
+		// have to reach the left range's replicas first.
+		if right.Replicas[0] != left.Replicas[0] && !m.moveRange(right.KeyRange, right.Replicas, left.Replicas[0], "range_merge") {
+			continue
+		}
+		if !m.ranges.Merge(right.Start) {
+			continue
+		}
+		RangeChanges.WithLabelValues("merge").Inc()
+		log.Info().Msgf("Merged range [%q, %q) into the range starting at %q", right.Start, right.End, left.Start)
+
+		// The merged range is not measured again until the next pass.
+		i++
+	}
+}
+
+// rangeStats asks the first reachable replica of a range for its size.
+func (m *DBManager) rangeStats(info RangeInfo) (*db_server.RangeStatsResponse, error) {
+	var lastErr error = fmt.Errorf("no reachable replicas")
+	for _, server := range m.serversByUUID(info.Replicas) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := server.client.RangeStats(ctx, &db_server.RangeStatsRequest{Start: info.Start, End: info.End})
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// moveRange makes target the primary of the range kr, currently held by
+// replicas. Keys are copied to the servers joining the replica set before
+// the assignment switches and the new epoch is pushed. The push fences the
+// old replicas off the range, so a second pass then carries over what
+// changed on the source while the first one ran, and only after that are
+// the keys deleted from the servers leaving the replica set. A key written
+// both on the source during the first pass and through the new primary
+// before the second can still lose the newer write.
+func (m *DBManager) moveRange(kr KeyRange, replicas []string, target, event string) bool {
+	next := m.ranges.ReplicasFor(target, ReplicationFactor)
+	joining := m.serversByUUID(difference(next, replicas))
+	leaving := m.serversByUUID(difference(replicas, next))
+
+	sources := m.serversByUUID(replicas)
+	if len(sources) == 0 {
+		log.Warn().Msgf("No reachable replica to move range [%q, %q) from", kr.Start, kr.End)
+		return false
+	}
+
+	versions := make(map[string]uint64)
+	copied, err := m.copyRange(sources[0], joining, kr, versions)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to copy range [%q, %q) to %s; keeping it on %s", kr.Start, kr.End, target, replicas[0])
+		return false
+	}
+
+	m.mu.Lock()
+	if !m.ranges.Assign(kr.Start, target) {
+		m.mu.Unlock()
+		return false
+	}
+	m.bumpEpochLocked()
+	m.mu.Unlock()
+	m.pushOwnership()
+
+	caughtUp, err := m.catchUpRange(sources[0], joining, kr, versions)
+	if err != nil {
+		// The old replicas keep their copies, so nothing is lost for good.
+		log.Warn().Err(err).Msgf("Failed to carry over late changes to range [%q, %q); keeping it on the old replicas too", kr.Start, kr.End)
+		leaving = nil
+	}
+
+	for _, server := range leaving {
+		if err := m.deleteRange(server, kr); err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete moved range [%q, %q) from server %s", kr.Start, kr.End, server.uuid)
+		}
+	}
+
+	RangeChanges.WithLabelValues("move").Inc()
+	KeysMigrated.WithLabelValues(event).Add(float64(copied + caughtUp))
+	log.Info().Msgf("Moved range [%q, %q) to %s: %d keys copied, %d caught up", kr.Start, kr.End, target, copied, caughtUp)
+	return true
+}
+
+// copyRange pages through kr on source and writes every page to targets,
+// recording the source's version of each key copied in versions. It writes
+// at epoch zero, since the targets do not own the range yet.
+func (m *DBManager) copyRange(source dbServer, targets []dbServer, kr KeyRange, versions map[string]uint64) (int, error) {
+	if len(targets) == 0 {
+		return 0, nil
+	}
+
+	copied := 0
+	err := scanRange(source, kr, func(pairs []*db_server.KeyValuePair) error {
+		if err := setOnServers(targets, pairs); err != nil {
+			return err
+		}
+		for _, p := range pairs {
+			versions[p.Key] = p.Version
+		}
+		copied += len(pairs)
+		return nil
+	})
+	return copied, err
+}
+
+// catchUpRange brings targets up to date with the changes made to kr on
+// source since copyRange recorded versions: keys set since are copied again
+// and keys deleted since are deleted.
+func (m *DBManager) catchUpRange(source dbServer, targets []dbServer, kr KeyRange, versions map[string]uint64) (int, error) {
+	if len(targets) == 0 {
+		return 0, nil
+	}
+
+	deleted := make(map[string]struct{}, len(versions))
+	for key := range versions {
+		deleted[key] = struct{}{}
+	}
+	changed := 0
+	err := scanRange(source, kr, func(pairs []*db_server.KeyValuePair) error {
+		var changes []*db_server.KeyValuePair
+		for _, p := range pairs {
+			delete(deleted, p.Key)
+			if v, ok := versions[p.Key]; !ok || v != p.Version {
+				changes = append(changes, p)
+			}
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		changed += len(changes)
+		return setOnServers(targets, changes)
+	})
+	if err != nil || len(deleted) == 0 {
+		return changed, err
+	}
+
+	keys := make([]string, 0, len(deleted))
+	for key := range deleted {
+		keys = append(keys, key)
+	}
+	for _, target := range targets {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := target.client.BatchDelete(ctx, &db_server.BatchDeleteRequest{Keys: keys})
+		cancel()
+		if err != nil {
+			return changed, fmt.Errorf("server %s: %w", target.uuid, err)
+		}
+	}
+	return changed + len(keys), nil
+}
+
+// setOnServers writes pairs to every one of servers at epoch zero.
+func setOnServers(servers []dbServer, pairs []*db_server.KeyValuePair) error {
+	for _, server := range servers {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := server.client.BatchSet(ctx, &db_server.BatchSetRequest{Pairs: pairs})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("server %s: %w", server.uuid, err)
+		}
+		for _, r := range resp.Results {
+			if !r.Success {
+				return fmt.Errorf("server %s: key %q: %s", server.uuid, r.Key, r.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// rangeMove is how one range's replicas change between two layouts of the
+// ring.
+type rangeMove struct {
+	KeyRange
+	joining []string
+	leaving []string
+	// sources are the old replicas, those staying in the replica set first.
+	sources []string
+}
+
+// rangeMoves pairs up the ranges of two layouts of the same ring and returns
+// those whose replicas differ. Node changes move ranges between nodes
+// without splitting or merging them.
+func rangeMoves(before, after []RangeInfo) []rangeMove {
+	old := make(map[KeyRange][]string, len(before))
+	for _, info := range before {
+		old[info.KeyRange] = info.Replicas
+	}
+
+	var moves []rangeMove
+	for _, info := range after {
+		replicas, ok := old[info.KeyRange]
+		if !ok {
+			continue
+		}
+		mv := rangeMove{
+			KeyRange: info.KeyRange,
+			joining:  difference(info.Replicas, replicas),
+			leaving:  difference(replicas, info.Replicas),
+		}
+		if len(mv.joining) == 0 && len(mv.leaving) == 0 {
+			continue
+		}
+		mv.sources = append(difference(replicas, mv.leaving), mv.leaving...)
+		moves = append(moves, mv)
+	}
+	return moves
+}
+
+// migrateRanges copies every moved range to the servers joining its replica
+// set, from the first old replica that can serve it, and returns the moves
+// that reached all of their joining servers.
+func (m *DBManager) migrateRanges(moves []rangeMove, event string) []rangeMove {
+	var done []rangeMove
+	migrated := 0
+	for _, mv := range moves {
+		joining := m.serversByUUID(mv.joining)
+		if len(joining) < len(mv.joining) {
+			log.Warn().Msgf("Not every server joining range [%q, %q) is reachable; keeping it on its old replicas", mv.Start, mv.End)
+			continue
+		}
+
+		var err error = fmt.Errorf("no reachable replica")
+		for _, source := range m.serversByUUID(mv.sources) {
+			var copied int
+			if copied, err = m.copyRange(source, joining, mv.KeyRange, make(map[string]uint64)); err == nil {
+				migrated += copied
+				break
+			}
+		}
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to copy range [%q, %q) to %v", mv.Start, mv.End, mv.joining)
+			continue
+		}
+		done = append(done, mv)
+	}
+
+	KeysMigrated.WithLabelValues(event).Add(float64(migrated))
+	log.Info().Msgf("Migrated %d ranges (%d keys) after a membership change", len(done), migrated)
+	return done
+}
+
+// dropRanges deletes each moved range from the servers that left its replica
+// set, once the ring no longer routes the range to them. A server the ring
+// has since made a replica of the range again keeps it, and one that is no
+// longer a member is skipped.
+func (m *DBManager) dropRanges(moves []rangeMove) {
+	for _, mv := range moves {
+		current := m.ranges.NodesForRange(mv.Start, mv.End, ReplicationFactor)
+		for _, server := range m.serversByUUID(difference(mv.leaving, current)) {
+			if err := m.deleteRange(server, mv.KeyRange); err != nil {
+				log.Warn().Err(err).Msgf("Failed to delete moved range [%q, %q) from server %s", mv.Start, mv.End, server.uuid)
+			}
+		}
+	}
+}
+
+// deleteRange removes every key in kr from server.
+func (m *DBManager) deleteRange(server dbServer, kr KeyRange) error {
+	return scanRange(server, kr, func(pairs []*db_server.KeyValuePair) error {
+		keys := make([]string, len(pairs))
+		for i, p := range pairs {
+			keys[i] = p.Key
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, err := server.client.BatchDelete(ctx, &db_server.BatchDeleteRequest{Keys: keys})
+		return err
+	})
+}
+
+// scanRange calls page with every page of keys in kr held by server.
+func scanRange(server dbServer, kr KeyRange, page func([]*db_server.KeyValuePair) error) error {
+	from := kr.Start
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := server.client.Scan(ctx, &db_server.ScanRequest{Start: from, End: kr.End, Limit: MaxScanLimit})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("scan on server %s: %w", server.uuid, err)
+		}
+		if len(resp.Pairs) == 0 {
+			return nil
+		}
+		if err := page(resp.Pairs); err != nil {
+			return err
+		}
+		if !resp.More {
+			return nil
+		}
+		from = resp.Pairs[len(resp.Pairs)-1].Key + "\x00"
+	}
+}
+
+// serversByUUID returns the registered, available servers among uuids, in
+// the same order.
+func (m *DBManager) serversByUUID(uuids []string) []dbServer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	servers := make([]dbServer, 0, len(uuids))
+	for _, uuid := range uuids {
+		if server, ok := m.servers[uuid]; ok && server.available() {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// difference returns the elements of a that are not in b.
+func difference(a, b []string) []string {
+	var out []string
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, x)
+		}
+	}
+	return out
+}
@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func rangeOptions() Options {
+	opts := DefaultOptions()
+	opts.Partitioning.Mode = PartitioningRange
+	opts.Partitioning.SplitBytes = 200
+	opts.Partitioning.MergeBytes = 100
+	return opts
+}
+
+func TestRebalanceRanges_SplitsAndKeepsKeys(t *testing.T) {
+	m, servers := newTestManager(t, rangeOptions(), 0, 0, 0)
+
+	var pairs []KeyValue
+	for i := 0; i < 40; i++ {
+		pairs = append(pairs, KeyValue{Key: fmt.Sprintf("key:%02d", i), Value: []byte("value")})
+	}
+	for _, r := range m.BatchSet(context.Background(), pairs) {
+		if r.Err != nil {
+			t.Fatalf("BatchSet failed for %s: %v", r.Key, r.Err)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		m.RebalanceRanges()
+	}
+
+	ranges := m.ranges.Ranges(ReplicationFactor)
+	if len(ranges) < 2 {
+		t.Fatalf("expected the range to split, got %+v", ranges)
+	}
+	owners := make(map[string]struct{})
+	for _, r := range ranges {
+		owners[r.Replicas[0]] = struct{}{}
+	}
+	if len(owners) < 2 {
+		t.Fatalf("expected split ranges on more than one primary, got %+v", ranges)
+	}
+
+	for _, p := range pairs {
+		val, err := m.GetKey(context.Background(), p.Key)
+		if err != nil || string(val) != string(p.Value) {
+			t.Fatalf("GetKey(%s) = %q, %v after rebalance", p.Key, val, err)
+		}
+	}
+
+	// Every server holds exactly the keys of the ranges it replicates.
+	for i, s := range servers {
+		uuid := fmt.Sprintf("server-%d", i)
+		for _, p := range pairs {
+			_, held := s.value(p.Key)
+			isReplica := false
+			for _, r := range m.partitioner.GetReplicaNodes(p.Key, ReplicationFactor) {
+				isReplica = isReplica || r == uuid
+			}
+			if held != isReplica {
+				t.Fatalf("%s holds %s = %v, replica = %v", uuid, p.Key, held, isReplica)
+			}
+		}
+	}
+}
+
+func TestRebalanceRanges_MergesSmallRanges(t *testing.T) {
+	m, _ := newTestManager(t, rangeOptions(), 0, 0, 0)
+	m.ranges.Split("g")
+	m.ranges.Split("p")
+	m.ranges.Assign("g", "server-1")
+	m.ranges.Assign("p", "server-2")
+
+	if _, err := m.SetKey(context.Background(), "q", []byte("v"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	m.RebalanceRanges()
+	m.RebalanceRanges()
+
+	if got := m.ranges.Ranges(ReplicationFactor); len(got) != 1 {
+		t.Fatalf("expected small ranges to merge into one, got %+v", got)
+	}
+	if val, err := m.GetKey(context.Background(), "q"); err != nil || string(val) != "v" {
+		t.Fatalf("GetKey(q) = %q, %v after merge", val, err)
+	}
+}
+
+func TestRebalanceRanges_KeepsInitialSplits(t *testing.T) {
+	opts := rangeOptions()
+	opts.Partitioning.InitialSplits = []string{"m"}
+	m, _ := newTestManager(t, opts, 0, 0)
+
+	m.RebalanceRanges()
+
+	if got := m.ranges.Ranges(ReplicationFactor); len(got) != 2 {
+		t.Fatalf("expected the initial split to survive, got %+v", got)
+	}
+}
+
+func TestScan_RangeModeAsksOverlappingServers(t *testing.T) {
+	opts := rangeOptions()
+	opts.Partitioning.InitialSplits = []string{"m"}
+	m, servers := newTestManager(t, opts, 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "a:1", Value: []byte("1")}, {Key: "a:2", Value: []byte("2")}, {Key: "z:1", Value: []byte("3")}})
+
+	wanted := m.ranges.NodesForRange("a:", "a;", ReplicationFactor)
+	if len(wanted) == len(servers) {
+		t.Fatalf("expected the prefix to map to fewer than %d servers, got %v", len(servers), wanted)
+	}
+
+	// Scanning servers outside the prefix's range would fail the scan.
+	for i, s := range servers {
+		uuid := fmt.Sprintf("server-%d", i)
+		skip := false
+		for _, w := range wanted {
+			skip = skip || w == uuid
+		}
+		if !skip {
+			s.grpc.Stop()
+		}
+	}
+
+	page, err := m.Scan(context.Background(), ScanOptions{Prefix: "a:"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(page.Pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %+v", page.Pairs)
+	}
+}
+
+// checkRangePlacement fails unless every server holds exactly the keys of
+// the ranges the ring makes it a replica of. uuids names servers in order.
+func checkRangePlacement(t *testing.T, m *DBManager, servers []*fakeServer, uuids []string, keys []string) {
+	t.Helper()
+	for i, s := range servers {
+		for _, key := range keys {
+			_, held := s.value(key)
+			isReplica := false
+			for _, r := range m.ranges.GetReplicaNodes(key, ReplicationFactor) {
+				isReplica = isReplica || r == uuids[i]
+			}
+			if held != isReplica {
+				t.Fatalf("%s holds %s = %v, replica = %v", uuids[i], key, held, isReplica)
+			}
+		}
+	}
+}
+
+// rangeSplits cut the keyspace into more ranges than the tests have servers,
+// so that membership changes reassign primaries too.
+var rangeSplits = []string{"b", "d", "f", "h", "k", "n", "q", "t"}
+
+// rangeTestKeys writes two keys to each of the ranges cut at rangeSplits.
+func rangeTestKeys(t *testing.T, m *DBManager) []string {
+	t.Helper()
+	var pairs []KeyValue
+	var keys []string
+	for _, prefix := range []string{"a", "c", "e", "g", "i", "l", "o", "r", "u"} {
+		for _, key := range []string{prefix + ":1", prefix + ":2"} {
+			pairs = append(pairs, KeyValue{Key: key, Value: []byte("v")})
+			keys = append(keys, key)
+		}
+	}
+	for _, r := range m.BatchSet(context.Background(), pairs) {
+		if r.Err != nil {
+			t.Fatalf("BatchSet failed for %s: %v", r.Key, r.Err)
+		}
+	}
+	m.WaitForBackgroundWrites()
+	return keys
+}
+
+func TestAddServer_RangeModeMovesRanges(t *testing.T) {
+	opts := rangeOptions()
+	opts.Partitioning.InitialSplits = rangeSplits
+	m, servers := newTestManager(t, opts, 0, 0, 0, 0)
+	keys := rangeTestKeys(t, m)
+	uuids := []string{"server-0", "server-1", "server-2", "server-3"}
+	checkRangePlacement(t, m, servers, uuids, keys)
+
+	joined := startFakeServer(t, 0)
+	if !m.AddServer("server-4", "test", joined.addr) {
+		t.Fatal("AddServer failed")
+	}
+	m.WaitForBackgroundWrites()
+
+	// The new server takes over ranges, and the servers its arrival pushed
+	// out of a range's replica set let go of the range.
+	servers, uuids = append(servers, joined), append(uuids, "server-4")
+	if _, ok := m.ranges.Ownership("server-4", ReplicationFactor); !ok {
+		t.Fatal("expected server-4 to replicate some range")
+	}
+	checkRangePlacement(t, m, servers, uuids, keys)
+	for _, key := range keys {
+		if val, err := m.GetKey(context.Background(), key); err != nil || string(val) != "v" {
+			t.Fatalf("GetKey(%s) = %q, %v after adding a server", key, val, err)
+		}
+	}
+}
+
+func TestRemoveServer_RangeModeMovesRanges(t *testing.T) {
+	opts := rangeOptions()
+	opts.Partitioning.InitialSplits = rangeSplits
+	m, servers := newTestManager(t, opts, 0, 0, 0, 0, 0)
+	keys := rangeTestKeys(t, m)
+	uuids := []string{"server-0", "server-1", "server-2", "server-3", "server-4"}
+	checkRangePlacement(t, m, servers, uuids, keys)
+
+	if !m.RemoveServer("server-2") {
+		t.Fatal("RemoveServer failed")
+	}
+	m.WaitForBackgroundWrites()
+
+	// The removed server keeps its data; every other server holds exactly
+	// the ranges it replicates on the new ring.
+	servers = append(servers[:2:2], servers[3:]...)
+	uuids = append(uuids[:2:2], uuids[3:]...)
+	checkRangePlacement(t, m, servers, uuids, keys)
+	for _, key := range keys {
+		if val, err := m.GetKey(context.Background(), key); err != nil || string(val) != "v" {
+			t.Fatalf("GetKey(%s) = %q, %v after removing a server", key, val, err)
+		}
+	}
+}
+
+func TestMoveRange_CatchesUpLateChanges(t *testing.T) {
+	m, servers := newTestManager(t, rangeOptions(), 0, 0)
+	source, target := m.serversByUUID([]string{"server-0"})[0], m.serversByUUID([]string{"server-1"})[0]
+	kr := KeyRange{Start: "a", End: "m"}
+
+	servers[0].mu.Lock()
+	servers[0].setLocked("b", "1", 0)
+	servers[0].setLocked("c", "1", 0)
+	servers[0].setLocked("d", "1", 0)
+	servers[0].mu.Unlock()
+
+	versions := make(map[string]uint64)
+	if n, err := m.copyRange(source, []dbServer{target}, kr, versions); err != nil || n != 3 {
+		t.Fatalf("copyRange = %d, %v; expected 3 keys copied", n, err)
+	}
+
+	// Writes that reach the source while the first copy runs.
+	servers[0].mu.Lock()
+	servers[0].setLocked("c", "2", 0)
+	servers[0].setLocked("e", "1", 0)
+	servers[0].deleteLocked("d")
+	servers[0].mu.Unlock()
+
+	if n, err := m.catchUpRange(source, []dbServer{target}, kr, versions); err != nil || n != 3 {
+		t.Fatalf("catchUpRange = %d, %v; expected c, e and the delete of d", n, err)
+	}
+	for key, want := range map[string]string{"b": "1", "c": "2", "e": "1"} {
+		if got, ok := servers[1].value(key); !ok || got != want {
+			t.Fatalf("expected %s=%s on the target, got %q", key, want, got)
+		}
+	}
+	if _, ok := servers[1].value("d"); ok {
+		t.Fatal("expected d deleted from the target")
+	}
+}
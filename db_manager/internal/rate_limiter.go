@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many Allow calls pass between sweeps of idle buckets.
+const sweepEvery = 1024
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket for the time elapsed since the last call and spends
+// one token. When the bucket is empty it returns how long until the next
+// token becomes available.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	if b.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// idle reports whether the bucket would be full again by now, in which case
+// dropping it is indistinguishable from keeping it.
+func (b *tokenBucket) idle(now time.Time) bool {
+	return b.rate > 0 && b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// RateLimiter keeps one token bucket per identity, where an identity is a
+// client or a key prefix depending on configuration. Identities listed in the
+// overrides get their own rate and burst.
+type RateLimiter struct {
+	mu      sync.Mutex
+	opts    RateLimitOptions
+	trusted []netip.Prefix
+	buckets map[string]*tokenBucket
+	calls   int
+	now     func() time.Time
+}
+
+// NewRateLimiter fails if a trusted proxy is neither an address nor a CIDR
+// range.
+func NewRateLimiter(opts RateLimitOptions) (*RateLimiter, error) {
+	l := &RateLimiter{
+		opts:    opts,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+	for _, proxy := range opts.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return nil, fmt.Errorf("trusted proxy %q is neither an address nor a CIDR range", proxy)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		l.trusted = append(l.trusted, prefix.Masked())
+	}
+	return l, nil
+}
+
+// TrustsPeer reports whether host, the address of a peer, is a trusted proxy
+// whose client id metadata may name the client it forwards for.
+func (l *RateLimiter) TrustsPeer(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *RateLimiter) Options() RateLimitOptions {
+	return l.opts
+}
+
+// Allow spends a token from identity's bucket. When the bucket is empty it
+// returns false and how long the caller should wait before retrying.
+func (l *RateLimiter) Allow(identity string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		for id, b := range l.buckets {
+			if b.idle(now) {
+				delete(l.buckets, id)
+			}
+		}
+	}
+
+	b, exists := l.buckets[identity]
+	if !exists {
+		limit := RateLimit{Rate: l.opts.Rate, Burst: l.opts.Burst}
+		if override, ok := l.opts.Overrides[identity]; ok {
+			limit = override
+		}
+		b = &tokenBucket{
+			rate:   limit.Rate,
+			burst:  float64(limit.Burst),
+			tokens: float64(limit.Burst),
+			last:   now,
+		}
+		l.buckets[identity] = b
+	}
+
+	ok, retryAfter := b.take(now)
+	if !ok {
+		RateLimited.Inc()
+	}
+	return ok, retryAfter
+}
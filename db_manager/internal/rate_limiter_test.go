@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestRateLimiter(t *testing.T, opts RateLimitOptions) (*RateLimiter, *time.Time) {
+	now := time.Unix(0, 0)
+	l, err := NewRateLimiter(opts)
+	if err != nil {
+		t.Fatalf("NewRateLimiter failed: %v", err)
+	}
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiter_BurstThenRefill(t *testing.T) {
+	l, now := newTestRateLimiter(t, RateLimitOptions{Rate: 10, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("client"); !ok {
+			t.Fatalf("request %d within burst was rejected", i)
+		}
+	}
+
+	ok, retryAfter := l.Allow("client")
+	if ok {
+		t.Fatal("expected request beyond burst to be rejected")
+	}
+	if retryAfter != 100*time.Millisecond {
+		t.Fatalf("expected retry after 100ms, got %v", retryAfter)
+	}
+
+	*now = now.Add(100 * time.Millisecond)
+	if ok, _ := l.Allow("client"); !ok {
+		t.Fatal("expected a token after refilling")
+	}
+}
+
+func TestRateLimiter_IdentitiesAreIsolated(t *testing.T) {
+	l, _ := newTestRateLimiter(t, RateLimitOptions{Rate: 1, Burst: 1})
+
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("expected first request from a to pass")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("expected second request from a to be rejected")
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Fatal("expected b to have its own bucket")
+	}
+}
+
+func TestRateLimiter_Overrides(t *testing.T) {
+	l, _ := newTestRateLimiter(t, RateLimitOptions{
+		Rate:      1,
+		Burst:     1,
+		Overrides: map[string]RateLimit{"batch": {Rate: 1, Burst: 5}},
+	})
+
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.Allow("batch"); !ok {
+			t.Fatalf("request %d within override burst was rejected", i)
+		}
+	}
+	if ok, _ := l.Allow("batch"); ok {
+		t.Fatal("expected request beyond override burst to be rejected")
+	}
+}
+
+func TestRateLimiter_SweepsIdleBuckets(t *testing.T) {
+	l, now := newTestRateLimiter(t, RateLimitOptions{Rate: 100, Burst: 1})
+
+	l.Allow("idle")
+	*now = now.Add(time.Second)
+	for i := 0; i < sweepEvery; i++ {
+		l.Allow("busy")
+	}
+
+	if _, exists := l.buckets["idle"]; exists {
+		t.Fatal("expected idle bucket to be swept")
+	}
+}
+
+func TestRateLimiter_TrustedProxies(t *testing.T) {
+	l, _ := newTestRateLimiter(t, RateLimitOptions{Rate: 1, Burst: 1, TrustedProxies: []string{"10.0.0.0/8", "192.168.1.5", "::1"}})
+
+	for host, want := range map[string]bool{
+		"10.1.2.3":        true,
+		"192.168.1.5":     true,
+		"::ffff:10.0.0.1": true,
+		"::1":             true,
+		"192.168.1.6":     false,
+		"11.0.0.1":        false,
+		"not-an-ip":       false,
+	} {
+		if got := l.TrustsPeer(host); got != want {
+			t.Fatalf("expected TrustsPeer(%q) = %v, got %v", host, want, got)
+		}
+	}
+
+	if _, err := NewRateLimiter(RateLimitOptions{TrustedProxies: []string{"gateway"}}); err == nil {
+		t.Fatal("expected an invalid trusted proxy to be rejected")
+	}
+}
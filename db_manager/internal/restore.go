@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RestoreProgress counts the keys a restore has handled so far. Each key is
+// restored from the server that was its primary when the backup was taken.
+// Keys that primary lacks, because its write failed or a migration was
+// still moving them, are restored from the replica copy with the highest
+// version. Every other replica copy is skipped.
+type RestoreProgress struct {
+	// Backup and Server are the backup and server snapshot being replayed.
+	Backup string
+	Server string
+
+	Restored uint64
+	Deleted  uint64
+	Skipped  uint64
+	Failed   uint64
+	// FromReplicas counts the keys, restored or deleted, taken from a
+	// replica copy.
+	FromReplicas uint64
+
+	// Failures are the keys that failed since the previous report.
+	Failures []BatchWriteResult
+}
+
+// Restore replays the backup id in dir, or the latest one when id is empty,
+// into the current cluster. dir defaults to the configured backup
+// directory; a dir from a client goes through BackupDir first. An incremental backup is replayed after the backups it builds
+// on, oldest first, deleting the keys deleted in between.
+//
+// The cluster may have any number of servers and any layout: every key is
+// written through the current ring to all of its replicas, like BatchSet.
+// The intents and locks of transactions prepared when the backup was taken
+// are dropped: Backup lets no commit through while its snapshots start, so
+// such a transaction committed after the backup, if at all. Keys that fail
+// are counted and reported to progress without stopping the restore;
+// progress is called after every batch and may return an error to stop it.
+func (m *DBManager) Restore(ctx context.Context, dir, id string, progress func(RestoreProgress) error) (RestoreProgress, error) {
+	if dir == "" {
+		dir = m.opts.Backup.Dir
+	}
+	if m.ServerCount() == 0 {
+		return RestoreProgress{}, status.Errorf(codes.Unavailable, "no servers to restore into")
+	}
+
+	chain, err := backupChain(dir, id)
+	if err != nil {
+		return RestoreProgress{}, err
+	}
+
+	r := &restore{m: m, batchSize: max(m.opts.Backup.RestoreBatchSize, 1), progress: progress}
+	for _, manifest := range chain {
+		if err := r.replay(ctx, manifest); err != nil {
+			return r.state, err
+		}
+	}
+	r.state.Backup, r.state.Server = chain[len(chain)-1].ID, ""
+	log.Info().Msgf("Restored backup %s: %d keys restored, %d deleted, %d failed, %d taken from replicas",
+		r.state.Backup, r.state.Restored, r.state.Deleted, r.state.Failed, r.state.FromReplicas)
+	return r.state, nil
+}
+
+// backupChain returns the backup id in dir, or the latest one, preceded by
+// every backup it builds on, oldest first.
+func backupChain(dir, id string) ([]*BackupManifest, error) {
+	if id == "" {
+		backups, err := ListBackups(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(backups) == 0 {
+			return nil, status.Errorf(codes.NotFound, "no backups in %s", dir)
+		}
+		id = backups[len(backups)-1].ID
+	}
+
+	var chain []*BackupManifest
+	for id != "" {
+		if id != filepath.Base(id) || id == "." || id == ".." {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid backup id %q", id)
+		}
+		manifest, err := ReadBackupManifest(filepath.Join(dir, id))
+		if os.IsNotExist(err) {
+			return nil, status.Errorf(codes.NotFound, "backup %s not found in %s", id, dir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*BackupManifest{manifest}, chain...)
+		id = manifest.Base
+	}
+	return chain, nil
+}
+
+// Partitioner rebuilds the partitioner the backup was taken under, to tell
+// which server was the primary of each key.
+func (b *BackupManifest) Partitioner() Partitioner {
+	if b.Partitioning != PartitioningRange {
+		h := NewConsistentHasher()
+		for _, s := range b.Servers {
+			h.AddNode(s.UUID)
+		}
+		return h
+	}
+
+	var splits []string
+	for _, r := range b.Ranges {
+		splits = append(splits, r.Start)
+	}
+	p := NewRangePartitioner(splits)
+	for _, s := range b.Servers {
+		p.AddNode(s.UUID)
+	}
+	for _, r := range b.Ranges {
+		if len(r.Replicas) > 0 {
+			p.Assign(r.Start, r.Replicas[0])
+		}
+	}
+	return p
+}
+
+type restore struct {
+	m         *DBManager
+	batchSize int
+	progress  func(RestoreProgress) error
+	state     RestoreProgress
+
+	sets    []KeyValue
+	deletes []string
+	// bytes is the size of the pending keys and values.
+	bytes int
+}
+
+// replay writes the keys of one backup, in two passes over its server
+// snapshots. The first writes every key its primary holds. The second
+// collects the replica copies of keys the first did not see, keeping the
+// highest version of each, and writes those. Servers hold disjoint sets of
+// primary keys, so their order does not matter, but every backup is
+// finished before the next one starts.
+func (r *restore) replay(ctx context.Context, manifest *BackupManifest) error {
+	partitioner := manifest.Partitioner()
+	isPrimary := func(server, key string) bool {
+		primary, ok := partitioner.GetNode(key)
+		return ok && primary == server
+	}
+	r.state.Backup = manifest.ID
+
+	fromPrimary := make(map[string]struct{})
+	err := r.readServers(ctx, manifest, func(server string, e db.BackupEntry) error {
+		if !isPrimary(server, e.Key) {
+			return nil
+		}
+		fromPrimary[e.Key] = struct{}{}
+		return r.add(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+
+	orphans := make(map[string]db.BackupEntry)
+	err = r.readServers(ctx, manifest, func(server string, e db.BackupEntry) error {
+		if isPrimary(server, e.Key) {
+			return nil
+		}
+		if _, ok := fromPrimary[e.Key]; ok {
+			r.state.Skipped++
+			return nil
+		}
+		if prev, ok := orphans[e.Key]; ok {
+			// Only one copy of the key is used.
+			r.state.Skipped++
+			if prev.Version >= e.Version {
+				return nil
+			}
+		}
+		orphans[e.Key] = e
+		return nil
+	})
+	if err != nil || len(orphans) == 0 {
+		return err
+	}
+
+	keys := make([]string, 0, len(orphans))
+	for key := range orphans {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	r.state.Server = ""
+	for _, key := range keys {
+		r.state.FromReplicas++
+		if err := r.add(ctx, orphans[key]); err != nil {
+			return err
+		}
+	}
+	if err := r.flush(ctx); err != nil {
+		return fmt.Errorf("restore of backup %s from replicas failed: %w", manifest.ID, err)
+	}
+	return nil
+}
+
+// readServers calls fn with every entry of every server snapshot of
+// manifest, writing what fn queued after each server.
+func (r *restore) readServers(ctx context.Context, manifest *BackupManifest, fn func(server string, e db.BackupEntry) error) error {
+	for _, part := range manifest.Servers {
+		r.state.Server = part.UUID
+		f, err := os.Open(filepath.Join(manifest.Path, part.File))
+		if err != nil {
+			return fmt.Errorf("failed to open backup of server %s: %v", part.UUID, err)
+		}
+
+		err = db.ReadBackup(f, func(e db.BackupEntry) error {
+			return fn(part.UUID, e)
+		})
+		f.Close()
+		if err == nil {
+			err = r.flush(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("restore of backup %s, server %s failed: %w", manifest.ID, part.UUID, err)
+		}
+	}
+	return nil
+}
+
+// add queues the write that restores e, flushing once a batch holds
+// batchSize keys or maxBatchBytes of keys and values.
+func (r *restore) add(ctx context.Context, e db.BackupEntry) error {
+	now := time.Now()
+	switch {
+	case e.Deleted:
+		r.deletes = append(r.deletes, e.Key)
+	case !e.ExpiresAt.IsZero() && !e.ExpiresAt.After(now):
+		// Expired since the backup; a key restored by an earlier backup
+		// must go too.
+		r.deletes = append(r.deletes, e.Key)
+	default:
+		var ttl time.Duration
+		if !e.ExpiresAt.IsZero() {
+			ttl = e.ExpiresAt.Sub(now)
+		}
+		r.sets = append(r.sets, KeyValue{Key: e.Key, Value: e.Value, TTL: ttl})
+		r.bytes += len(e.Value)
+	}
+	r.bytes += len(e.Key)
+	if len(r.sets)+len(r.deletes) >= r.batchSize || r.bytes >= maxBatchBytes {
+		return r.flush(ctx)
+	}
+	return nil
+}
+
+// flush writes the pending keys and reports progress.
+func (r *restore) flush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(r.sets) == 0 && len(r.deletes) == 0 {
+		return nil
+	}
+
+	var failures []BatchWriteResult
+	if len(r.sets) > 0 {
+		for _, res := range r.m.BatchSet(ctx, r.sets) {
+			if res.Err != nil {
+				failures = append(failures, res)
+				continue
+			}
+			r.state.Restored++
+			RestoredKeys.WithLabelValues("restored").Inc()
+		}
+	}
+	if len(r.deletes) > 0 {
+		for _, res := range r.m.BatchDelete(ctx, r.deletes) {
+			if res.Err != nil {
+				failures = append(failures, res)
+				continue
+			}
+			r.state.Deleted++
+			RestoredKeys.WithLabelValues("deleted").Inc()
+		}
+	}
+	r.state.Failed += uint64(len(failures))
+	RestoredKeys.WithLabelValues("failed").Add(float64(len(failures)))
+	r.sets, r.deletes, r.bytes = r.sets[:0], r.deletes[:0], 0
+
+	if r.progress == nil {
+		return nil
+	}
+	report := r.state
+	report.Failures = failures
+	return r.progress(report)
+}
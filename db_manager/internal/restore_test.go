@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arbhalerao/meerkat/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRestore_DifferentClusterSize(t *testing.T) {
+	source, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+
+	for i := 0; i < 20; i++ {
+		source.SetKey(context.Background(), fmt.Sprintf("key-%d", i), []byte(fmt.Sprint(i)), 0)
+	}
+	source.SetKey(context.Background(), "session", []byte("s"), time.Hour)
+	source.WaitForBackgroundWrites()
+	if _, err := source.Backup(context.Background(), dir, false); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Backup.RestoreBatchSize = 4
+	target, servers := newTestManager(t, opts, 0, 0, 0, 0, 0)
+	var reports int
+	result, err := target.Restore(context.Background(), dir, "", func(p RestoreProgress) error {
+		reports++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Restored != 21 || result.Failed != 0 || result.Skipped != 21 {
+		t.Fatalf("expected 21 keys restored from their primaries, got %+v", result)
+	}
+	if reports < 21/4 {
+		t.Fatalf("expected progress after every batch, got %d reports", reports)
+	}
+	target.WaitForBackgroundWrites()
+
+	// Every key landed on all of its replicas under the new ring.
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		for _, s := range replicasFor(t, target, servers, key) {
+			if v, ok := s.value(key); !ok || v != fmt.Sprint(i) {
+				t.Fatalf("expected replica %s to hold %s=%d, got %q", s.addr, key, i, v)
+			}
+		}
+	}
+	if ttl := replicasFor(t, target, servers, "session")[0].ttl("session"); ttl == 0 || ttl > 3600 {
+		t.Fatalf("expected session to keep its TTL, got %d", ttl)
+	}
+}
+
+func TestRestore_IncrementalChain(t *testing.T) {
+	source, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+
+	source.SetKey(context.Background(), "kept", []byte("1"), 0)
+	source.SetKey(context.Background(), "changed", []byte("1"), 0)
+	source.SetKey(context.Background(), "deleted", []byte("1"), 0)
+	source.WaitForBackgroundWrites()
+	if _, err := source.Backup(context.Background(), dir, true); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	source.SetKey(context.Background(), "changed", []byte("2"), 0)
+	source.DeleteKey(context.Background(), "deleted")
+	source.WaitForBackgroundWrites()
+	incr, err := source.Backup(context.Background(), dir, true)
+	if err != nil {
+		t.Fatalf("incremental Backup failed: %v", err)
+	}
+
+	target, _ := newTestManager(t, DefaultOptions(), 0, 0)
+	result, err := target.Restore(context.Background(), dir, incr.ID, nil)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Backup != incr.ID || result.Deleted != 1 || result.Failed != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	target.WaitForBackgroundWrites()
+
+	for key, want := range map[string]string{"kept": "1", "changed": "2"} {
+		if v, err := target.GetKey(context.Background(), key); err != nil || string(v) != want {
+			t.Fatalf("expected %s=%s, got %q, %v", key, want, v, err)
+		}
+	}
+	if _, err := target.GetKey(context.Background(), "deleted"); err == nil {
+		t.Fatal("expected deleted to stay deleted")
+	}
+}
+
+func TestRestore_FlushesLargeBatches(t *testing.T) {
+	source, _ := newTestManager(t, DefaultOptions(), 0)
+	dir := t.TempDir()
+	for i := 0; i < 8; i++ {
+		source.SetKey(context.Background(), fmt.Sprintf("key-%d", i), make([]byte, 1<<20), 0)
+	}
+	source.WaitForBackgroundWrites()
+	if _, err := source.Backup(context.Background(), dir, false); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Far fewer keys than a batch holds, but far more bytes.
+	target, _ := newTestManager(t, DefaultOptions(), 0)
+	var reports int
+	result, err := target.Restore(context.Background(), dir, "", func(p RestoreProgress) error {
+		reports++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Restored != 8 || result.Failed != 0 {
+		t.Fatalf("expected 8 keys restored, got %+v", result)
+	}
+	if reports < 3 {
+		t.Fatalf("expected the keys to be written in batches of at most 3 MB, got %d batches", reports)
+	}
+}
+
+func TestRestore_ReportsFailures(t *testing.T) {
+	source, _ := newTestManager(t, DefaultOptions(), 0, 0)
+	dir := t.TempDir()
+	source.SetKey(context.Background(), "a", []byte("1"), 0)
+	source.WaitForBackgroundWrites()
+	if _, err := source.Backup(context.Background(), dir, false); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	target, servers := newTestManager(t, DefaultOptions(), 0)
+	servers[0].grpc.Stop()
+
+	var failures []BatchWriteResult
+	result, err := target.Restore(context.Background(), dir, "", func(p RestoreProgress) error {
+		failures = append(failures, p.Failures...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Failed != 1 || len(failures) != 1 || failures[0].Key != "a" || failures[0].Err == nil {
+		t.Fatalf("expected a to be reported as failed, got %+v and %+v", result, failures)
+	}
+}
+
+func TestRestore_KeyOnlyOnReplica(t *testing.T) {
+	source, sourceServers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+	source.SetKey(context.Background(), "a", []byte("1"), 0)
+	source.WaitForBackgroundWrites()
+
+	// The primary's write of orphan failed; only a replica took it, first
+	// at an older value.
+	replica := replicasFor(t, source, sourceServers, "orphan")[1]
+	replica.mu.Lock()
+	replica.setLocked("orphan", "old", 0)
+	replica.setLocked("orphan", "new", 0)
+	replica.mu.Unlock()
+
+	if _, err := source.Backup(context.Background(), dir, false); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	target, servers := newTestManager(t, DefaultOptions(), 0, 0)
+	result, err := target.Restore(context.Background(), dir, "", nil)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Restored != 2 || result.FromReplicas != 1 || result.Failed != 0 {
+		t.Fatalf("expected a and orphan restored, orphan from a replica, got %+v", result)
+	}
+	target.WaitForBackgroundWrites()
+	for _, s := range replicasFor(t, target, servers, "orphan") {
+		if v, ok := s.value("orphan"); !ok || v != "new" {
+			t.Fatalf("expected replica %s to hold orphan=new, got %q", s.addr, v)
+		}
+	}
+}
+
+func TestRestore_DropsPreparedIntents(t *testing.T) {
+	source, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	dir := t.TempDir()
+	source.SetKey(context.Background(), "a", []byte("1"), 0)
+	source.WaitForBackgroundWrites()
+	manifest, err := source.Backup(context.Background(), dir, false)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// A db_server's snapshot holds the records of transactions it had
+	// prepared, under the reserved prefix.
+	f, err := os.OpenFile(filepath.Join(manifest.Path, manifest.Servers[0].File), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open backup file: %v", err)
+	}
+	err = db.WriteBackup(f, []db.BackupEntry{
+		{Key: "\x00meerkat/txn/intent/txn-1", Value: []byte(`[{"key":"b","value":"MQ=="}]`), Version: 90},
+		{Key: "\x00meerkat/txn/lock/b", Value: []byte("txn-1"), Version: 90},
+	})
+	f.Close()
+	if err != nil {
+		t.Fatalf("WriteBackup failed: %v", err)
+	}
+
+	target, servers := newTestManager(t, DefaultOptions(), 0, 0)
+	result, err := target.Restore(context.Background(), dir, "", nil)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Restored != 1 || result.Failed != 0 {
+		t.Fatalf("expected only a restored, got %+v", result)
+	}
+	target.WaitForBackgroundWrites()
+	for _, s := range servers {
+		s.mu.Lock()
+		for key := range s.data {
+			if db.IsReservedKey(key) {
+				t.Errorf("expected no transaction records on %s, got %q", s.addr, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestRestore_NotFound(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0)
+	if _, err := m.Restore(context.Background(), t.TempDir(), "", nil); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NOT_FOUND without backups, got %v", err)
+	}
+	if _, err := m.Restore(context.Background(), t.TempDir(), "missing", nil); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NOT_FOUND for a missing backup, got %v", err)
+	}
+	if _, err := m.Restore(context.Background(), t.TempDir(), "../elsewhere", nil); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for a backup id naming a path, got %v", err)
+	}
+}
+
+func TestBackupManifest_Partitioner_Range(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Partitioning.Mode = PartitioningRange
+	opts.Partitioning.InitialSplits = []string{"g", "n", "t"}
+	m, _ := newTestManager(t, opts, 0, 0, 0)
+
+	manifest, _ := m.backupLayout()
+	rebuilt := manifest.Partitioner()
+	for _, key := range []string{"", "a", "g", "m", "n", "s", "z"} {
+		want, _ := m.partitioner.GetNode(key)
+		if got, _ := rebuilt.GetNode(key); got != want {
+			t.Fatalf("expected %q on %s as in the backed up ring, got %s", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultScanLimit = 100
+	MaxScanLimit     = 1000
+
+	// maxScanBytes bounds the keys and values of one page. A value that is
+	// valid UTF-8 may be sent twice, in Value and in ValueBytes, so a page
+	// stays under about 3 MB, inside gRPC's default 4 MB message limit.
+	maxScanBytes = (3 << 20) / 2
+
+	// scanCursorPrefix versions the continuation token format.
+	scanCursorPrefix = "k1:"
+)
+
+type ScanOptions struct {
+	Prefix string
+	Start  string
+	End    string
+	Limit  int
+	Cursor string
+}
+
+type ScanPage struct {
+	Pairs      []KeyValue
+	NextCursor string
+}
+
+// Scan returns one page of the keys matching opts across the cluster, in key
+// order. Every server that may hold matching keys is asked for its first
+// Limit matches past the cursor; the answers are merged, replicas of the same
+// key collapsed, and the first Limit keys kept. That is enough because any
+// key in the global first Limit is also in the first Limit of every server
+// holding it. A server may also stop short of Limit to keep its page under
+// its byte limit, so merged keys past the last key of any such page are left
+// for the next page, which asks that server again from there. The merged page
+// is itself cut at maxScanBytes.
+//
+// The cursor is the last key returned, so it stays valid across membership
+// changes. Up to ReplicationFactor-1 servers may fail without failing the
+// scan, since every key still has a replica among the others.
+func (m *DBManager) Scan(ctx context.Context, opts ScanOptions) (ScanPage, error) {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("scan").Observe(time.Since(start).Seconds())
+	}()
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultScanLimit
+	}
+	if limit > MaxScanLimit {
+		limit = MaxScanLimit
+	}
+
+	from := opts.Start
+	if opts.Cursor != "" {
+		last, err := decodeScanCursor(opts.Cursor)
+		if err != nil {
+			RequestsTotal.WithLabelValues("scan", "error").Inc()
+			return ScanPage{}, err
+		}
+		// The smallest key greater than last.
+		if after := last + "\x00"; after > from {
+			from = after
+		}
+	}
+
+	servers := m.scanServers(opts.Prefix, from, opts.End)
+
+	if len(servers) == 0 {
+		RequestsTotal.WithLabelValues("scan", "error").Inc()
+		return ScanPage{}, fmt.Errorf("no available database servers")
+	}
+
+	req := &db_server.ScanRequest{Prefix: opts.Prefix, Start: from, End: opts.End, Limit: uint32(limit)}
+	responses := make([]*db_server.ScanResponse, len(servers))
+	errs := make([]error, len(servers))
+
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server dbServer) {
+			defer wg.Done()
+			if !server.available() {
+				errs[i] = fmt.Errorf("server %s is unavailable", server.uuid)
+				return
+			}
+			callCtx, cancel := m.callContext(ctx)
+			defer cancel()
+			responses[i], errs[i] = server.client.Scan(callCtx, req)
+		}(i, server)
+	}
+	wg.Wait()
+
+	failed := 0
+	var lastErr error
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if failed >= min(ReplicationFactor, len(servers)) {
+		RequestsTotal.WithLabelValues("scan", "error").Inc()
+		return ScanPage{}, fmt.Errorf("scan failed on %d of %d servers: %w", failed, len(servers), lastErr)
+	}
+
+	// Replicas of a key collapse into the primary's copy when the primary
+	// answered, so the version is the one Get and Watch report.
+	merged := make(map[string]KeyValue)
+	fromPrimary := make(map[string]bool)
+	more := false
+	// cutoff is the smallest last key of a page that stopped early; only
+	// keys up to it are known not to be missing a smaller key.
+	cutoff, cut := "", false
+	for i, resp := range responses {
+		if resp == nil {
+			continue
+		}
+		if resp.More && len(resp.Pairs) > 0 {
+			more = true
+			if last := resp.Pairs[len(resp.Pairs)-1].Key; !cut || last < cutoff {
+				cutoff, cut = last, true
+			}
+		}
+		for _, p := range resp.Pairs {
+			if fromPrimary[p.Key] {
+				continue
+			}
+			primary, _ := m.partitioner.GetNode(p.Key)
+			if _, seen := merged[p.Key]; seen && primary != servers[i].uuid {
+				continue
+			}
+			merged[p.Key] = KeyValue{
+				Key:     p.Key,
+				Value:   storedValue(p.ValueBytes, p.Value),
+				TTL:     time.Duration(p.TtlSeconds) * time.Second,
+				Version: p.Version,
+			}
+			fromPrimary[p.Key] = primary == servers[i].uuid
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		if !cut || key <= cutoff {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+		more = true
+	}
+	size := 0
+	for i, key := range keys {
+		size += len(key) + len(merged[key].Value)
+		if i > 0 && size > maxScanBytes {
+			keys = keys[:i]
+			more = true
+			break
+		}
+	}
+
+	page := ScanPage{Pairs: make([]KeyValue, len(keys))}
+	for i, key := range keys {
+		page.Pairs[i] = merged[key]
+	}
+	if more && len(keys) > 0 {
+		page.NextCursor = encodeScanCursor(keys[len(keys)-1])
+	}
+
+	RequestsTotal.WithLabelValues("scan", "success").Inc()
+	return page, nil
+}
+
+// scanServers returns the servers a scan has to ask. Under hash partitioning
+// that is every server; under range partitioning only the replicas of the
+// ranges that overlap the scanned keys.
+func (m *DBManager) scanServers(prefix, from, end string) []dbServer {
+	var uuids []string
+	if m.ranges != nil {
+		start, stop := scanBounds(prefix, from, end)
+		uuids = m.ranges.NodesForRange(start, stop, ReplicationFactor)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ranges == nil {
+		servers := make([]dbServer, 0, len(m.servers))
+		for _, server := range m.servers {
+			servers = append(servers, server)
+		}
+		return servers
+	}
+
+	servers := make([]dbServer, 0, len(uuids))
+	for _, uuid := range uuids {
+		if server, ok := m.servers[uuid]; ok {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// scanBounds narrows [from, end) to the keys that can start with prefix. An
+// empty stop is unbounded.
+func scanBounds(prefix, from, end string) (start, stop string) {
+	start, stop = from, end
+	if prefix > start {
+		start = prefix
+	}
+	if prefixEnd := prefixSuccessor(prefix); prefixEnd != "" && (stop == "" || prefixEnd < stop) {
+		stop = prefixEnd
+	}
+	return start, stop
+}
+
+// prefixSuccessor returns the smallest key greater than every key starting
+// with prefix, or "" if there is none.
+func prefixSuccessor(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	return ""
+}
+
+func encodeScanCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(scanCursorPrefix + lastKey))
+}
+
+func decodeScanCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), scanCursorPrefix) {
+		return "", status.Errorf(codes.InvalidArgument, "malformed scan cursor %q", cursor)
+	}
+	return strings.TrimPrefix(string(raw), scanCursorPrefix), nil
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestScan_PagesInKeyOrder(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0, 0)
+
+	var pairs []KeyValue
+	for i := 0; i < 25; i++ {
+		pairs = append(pairs, KeyValue{Key: fmt.Sprintf("user:%02d", i), Value: []byte(fmt.Sprintf("v%d", i))})
+	}
+	pairs = append(pairs, KeyValue{Key: "order:1", Value: []byte("x")})
+	m.BatchSet(context.Background(), pairs)
+
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("scan did not terminate")
+		}
+		page, err := m.Scan(context.Background(), ScanOptions{Prefix: "user:", Limit: 10, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		if len(page.Pairs) > 10 {
+			t.Fatalf("expected at most 10 pairs per page, got %d", len(page.Pairs))
+		}
+		for _, p := range page.Pairs {
+			got = append(got, p.Key)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(got) != 25 {
+		t.Fatalf("expected 25 keys, got %d: %v", len(got), got)
+	}
+	for i, key := range got {
+		if want := fmt.Sprintf("user:%02d", i); key != want {
+			t.Fatalf("key %d: expected %s, got %s", i, want, key)
+		}
+	}
+}
+
+func TestScan_PagesBySize(t *testing.T) {
+	tests := []struct {
+		name      string
+		scanBytes int
+		value     int
+		perPage   int
+	}{
+		// Servers stop their pages early, at different keys.
+		{name: "server pages", scanBytes: 250, value: 100, perPage: 100},
+		// The servers' pages merge into more than the manager's own limit.
+		{name: "merged page", scanBytes: maxScanBytes, value: 600 << 10, perPage: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0, 0)
+			for _, s := range servers {
+				s.scanBytes = tt.scanBytes
+			}
+
+			pairs := make([]KeyValue, 12)
+			for i := range pairs {
+				pairs[i] = KeyValue{Key: fmt.Sprintf("key-%02d", i), Value: make([]byte, tt.value)}
+			}
+			for _, r := range m.BatchSet(context.Background(), pairs) {
+				if r.Err != nil {
+					t.Fatalf("BatchSet failed for %s: %v", r.Key, r.Err)
+				}
+			}
+
+			var got []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > len(pairs) {
+					t.Fatal("scan did not terminate")
+				}
+				page, err := m.Scan(context.Background(), ScanOptions{Limit: 100, Cursor: cursor})
+				if err != nil {
+					t.Fatalf("Scan failed: %v", err)
+				}
+				if len(page.Pairs) > tt.perPage {
+					t.Fatalf("expected at most %d pairs per page, got %d", tt.perPage, len(page.Pairs))
+				}
+				for _, p := range page.Pairs {
+					got = append(got, p.Key)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			if len(got) != len(pairs) {
+				t.Fatalf("expected %d keys, got %d: %v", len(pairs), len(got), got)
+			}
+			for i, key := range got {
+				if key != pairs[i].Key {
+					t.Fatalf("key %d: expected %s, got %s", i, pairs[i].Key, key)
+				}
+			}
+		})
+	}
+}
+
+func TestScan_Range(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}, {Key: "c", Value: []byte("3")}, {Key: "d", Value: []byte("4")}})
+
+	page, err := m.Scan(context.Background(), ScanOptions{Start: "b", End: "d"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(page.Pairs) != 2 || page.Pairs[0].Key != "b" || page.Pairs[1].Key != "c" || page.NextCursor != "" {
+		t.Fatalf("expected [b c] with no cursor, got %+v", page)
+	}
+}
+
+func TestScan_ReportsPrimaryVersion(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	// Writing the key twice puts its replicas at different versions.
+	m.SetKey(context.Background(), "other", []byte("1"), 0)
+	m.SetKey(context.Background(), "a", []byte("1"), 0)
+	m.SetKey(context.Background(), "a", []byte("2"), 0)
+	m.WaitForBackgroundWrites()
+	_, version, err := m.GetKeyVersion(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("GetKeyVersion failed: %v", err)
+	}
+
+	page, err := m.Scan(context.Background(), ScanOptions{Prefix: "a"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(page.Pairs) != 1 || page.Pairs[0].Version != version {
+		t.Fatalf("expected a at the primary's version %d, got %+v", version, page.Pairs)
+	}
+}
+
+func TestScan_ToleratesServerFailure(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.BatchSet(context.Background(), []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}})
+	servers[0].grpc.Stop()
+
+	page, err := m.Scan(context.Background(), ScanOptions{})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(page.Pairs) != 2 {
+		t.Fatalf("expected both keys from the surviving replicas, got %+v", page.Pairs)
+	}
+}
+
+func TestScan_MalformedCursor(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	_, err := m.Scan(context.Background(), ScanOptions{Cursor: "not a cursor"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT, got %v", err)
+	}
+}
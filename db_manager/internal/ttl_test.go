@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSetKey_ReplicatesTTL(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "session:1", []byte("token"), 90*time.Second); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	for _, r := range replicasFor(t, m, servers, "session:1") {
+		if ttl := r.ttl("session:1"); ttl != 90 {
+			t.Fatalf("expected replica %s to hold a 90s TTL, got %d", r.addr, ttl)
+		}
+	}
+
+	ttl, ok, err := m.TTL(context.Background(), "session:1")
+	if err != nil {
+		t.Fatalf("TTL failed: %v", err)
+	}
+	if !ok || ttl != 90*time.Second {
+		t.Fatalf("expected 90s TTL, got %v (has TTL %v)", ttl, ok)
+	}
+}
+
+func TestTTL_NoExpiry(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	if _, err := m.SetKey(context.Background(), "user:1", []byte("Alice"), 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+
+	if _, ok, err := m.TTL(context.Background(), "user:1"); err != nil || ok {
+		t.Fatalf("expected no TTL, got has TTL %v, err %v", ok, err)
+	}
+}
+
+func TestMigration_PreservesTTL(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	for i := 0; i < 50; i++ {
+		if _, err := m.SetKey(context.Background(), fmt.Sprintf("key-%d", i), []byte("value"), time.Hour); err != nil {
+			t.Fatalf("SetKey failed: %v", err)
+		}
+	}
+	m.WaitForBackgroundWrites()
+
+	joined := startFakeServer(t, 0)
+	m.AddServer("server-joined", "test", joined.addr)
+	m.WaitForBackgroundWrites()
+
+	migrated := 0
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if _, ok := joined.value(key); !ok {
+			continue
+		}
+		migrated++
+		if ttl := joined.ttl(key); ttl == 0 || ttl > 3600 {
+			t.Fatalf("expected migrated key %s to keep its TTL, got %d", key, ttl)
+		}
+	}
+	if migrated == 0 {
+		t.Fatal("expected some keys to migrate to the joined server")
+	}
+}
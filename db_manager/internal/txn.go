@@ -0,0 +1,360 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Transactions whose keys all have the same primary are applied in a single
+// Badger transaction on the primary and then copied to the other replicas,
+// like any other write through the primary. Transactions spanning several
+// primaries use two-phase commit with the manager as coordinator: every
+// replica of every key prepares its part, which records an intent and locks
+// the keys on that db_server, and the outcome is logged before any
+// participant is told to commit or abort. A transaction touching a key whose
+// primary's breaker is open fails with UNAVAILABLE before any server is
+// involved.
+
+// TxnOp is one write of a transaction: a set of Value, expiring after TTL if
+// positive, or a delete when Delete is true.
+type TxnOp struct {
+	Key    string
+	Value  []byte
+	TTL    time.Duration
+	Delete bool
+}
+
+// OpenTxnLog makes the coordinator log durable in the file at path, picking
+// up any transactions a previous run left unfinished. It must be called
+// before the manager serves requests; ResolveTransactions then finishes them.
+func (m *DBManager) OpenTxnLog(path string) error {
+	l, err := openTxnLog(path)
+	if err != nil {
+		return err
+	}
+	m.txns = l
+	return nil
+}
+
+// Transact applies ops atomically. It returns the two-phase commit's id when
+// the transaction spanned several primaries, and an ABORTED error when it
+// lost a conflict with another transaction on the same keys.
+//
+// A transaction on a single primary that the primary applied but too few
+// other replicas acknowledged fails with DATA_LOSS rather than ABORTED: it is
+// committed and visible, the missing copies may still land in the background,
+// but it is held by fewer replicas than write_acks asks for. Retrying it
+// applies it again.
+func (m *DBManager) Transact(ctx context.Context, ops []TxnOp) (string, error) {
+	start := time.Now()
+	defer func() {
+		RequestDuration.WithLabelValues("transact").Observe(time.Since(start).Seconds())
+	}()
+
+	if err := m.checkTxn(ops); err != nil {
+		RequestsTotal.WithLabelValues("transact", "error").Inc()
+		return "", err
+	}
+
+	keys := make([]string, len(ops))
+	for i, op := range ops {
+		keys[i] = op.Key
+	}
+	sets, errs, epoch := m.resolveBatch(keys)
+
+	// Every replica of a key takes part, with the ops for the keys it holds.
+	// The copies from the primary need as many acks as the key needing the
+	// most.
+	batches := make(map[string]*serverBatch)
+	primaries := make(map[string]bool)
+	var servers []dbServer
+	var primary dbServer
+	acks := 1
+	for i := range ops {
+		if errs[i] == nil {
+			primary, errs[i] = sets[i].primaryServer(keys[i])
+		}
+		if errs[i] == nil && len(sets[i].servers) < m.writeAcks(sets[i]) {
+			errs[i] = status.Errorf(codes.Unavailable, "%d replicas reachable, %d must accept the write", len(sets[i].servers), m.writeAcks(sets[i]))
+		}
+		if errs[i] != nil {
+			RequestsTotal.WithLabelValues("transact", "error").Inc()
+			return "", fmt.Errorf("cannot route key %q: %w", keys[i], errs[i])
+		}
+		acks = max(acks, m.writeAcks(sets[i]))
+		primaries[primary.uuid] = true
+		for _, server := range sets[i].servers {
+			b, ok := batches[server.uuid]
+			if !ok {
+				b = &serverBatch{server: server}
+				batches[server.uuid] = b
+				servers = append(servers, server)
+			}
+			b.indexes = append(b.indexes, i)
+		}
+	}
+	if err := m.admit(servers); err != nil {
+		RequestsTotal.WithLabelValues("transact", "shed").Inc()
+		return "", err
+	}
+
+	if len(primaries) == 1 {
+		err := m.applyTxn(ctx, ops, primary, batches, acks, epoch)
+		m.countTxn("transact", "single_shard", err)
+		return "", err
+	}
+
+	id, err := m.twoPhaseCommit(ctx, ops, batches, epoch)
+	m.countTxn("transact", "two_phase", err)
+	return id, err
+}
+
+func (m *DBManager) countTxn(op, mode string, err error) {
+	if status.Code(err) == codes.DataLoss {
+		// Committed, though short of its acks.
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		Transactions.WithLabelValues(mode, "committed").Inc()
+		return
+	}
+	if err != nil {
+		RequestsTotal.WithLabelValues(op, "error").Inc()
+		Transactions.WithLabelValues(mode, "aborted").Inc()
+		return
+	}
+	RequestsTotal.WithLabelValues(op, "success").Inc()
+	Transactions.WithLabelValues(mode, "committed").Inc()
+}
+
+// checkTxn rejects transactions that could never commit before any server
+// is involved.
+func (m *DBManager) checkTxn(ops []TxnOp) error {
+	if len(ops) == 0 {
+		return status.Error(codes.InvalidArgument, "a transaction needs at least one op")
+	}
+	if limit := m.opts.Transactions.MaxKeys; limit > 0 && len(ops) > limit {
+		return status.Errorf(codes.InvalidArgument, "transaction writes %d keys, over the limit of %d", len(ops), limit)
+	}
+
+	seen := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		if seen[op.Key] {
+			return status.Errorf(codes.InvalidArgument, "key %q appears more than once in the transaction", op.Key)
+		}
+		seen[op.Key] = true
+		if !op.Delete {
+			if err := m.checkValueSize(op.Key, op.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyTxn runs a transaction whose keys share primary as one ApplyTxn there,
+// then copies each other replica its part, waiting for acks replicas in all.
+func (m *DBManager) applyTxn(ctx context.Context, ops []TxnOp, primary dbServer, batches map[string]*serverBatch, acks int, epoch uint64) error {
+	callCtx, cancel := m.callContext(ctx)
+	_, err := primary.client.ApplyTxn(callCtx, &db_server.ApplyTxnRequest{Ops: txnRequestOps(ops, batches[primary.uuid].indexes), Epoch: epoch})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("primary %s rejected transaction: %w", primary.uuid, err)
+	}
+
+	var secondaries []dbServer
+	for uuid, b := range batches {
+		if uuid != primary.uuid {
+			secondaries = append(secondaries, b.server)
+		}
+	}
+	acked, err := m.replicateFromPrimary(ctx, secondaries, acks, func(ctx context.Context, server dbServer) error {
+		_, err := server.client.ApplyTxn(ctx, &db_server.ApplyTxnRequest{Ops: txnRequestOps(ops, batches[server.uuid].indexes), Epoch: epoch})
+		if err != nil {
+			ReplicationWrites.WithLabelValues("failure").Inc()
+			return err
+		}
+		ReplicationWrites.WithLabelValues("success").Inc()
+		return nil
+	})
+	if err != nil {
+		return status.Errorf(codes.DataLoss, "transaction committed on the primary but acknowledged by only %d other replicas: %v", acked, err)
+	}
+	return nil
+}
+
+// twoPhaseCommit prepares the transaction on every participant and commits
+// it only if all of them prepared. The decision is logged before it is sent,
+// so a participant that misses it can be told later by ResolveTransactions.
+func (m *DBManager) twoPhaseCommit(ctx context.Context, ops []TxnOp, batches map[string]*serverBatch, epoch uint64) (string, error) {
+	id := uuid.NewString()
+	participants := make([]string, 0, len(batches))
+	for uuid := range batches {
+		participants = append(participants, uuid)
+	}
+	sort.Strings(participants)
+
+	if err := m.txns.begin(id, participants); err != nil {
+		return "", fmt.Errorf("failed to log transaction %s: %v", id, err)
+	}
+	defer m.txns.setActive(id, false)
+
+	m.runBatches(ctx, slices.Collect(maps.Values(batches)), func(ctx context.Context, b *serverBatch) {
+		_, b.err = b.server.client.PrepareTxn(ctx, &db_server.PrepareTxnRequest{TxnId: id, Ops: txnRequestOps(ops, b.indexes), Epoch: epoch})
+	})
+
+	var prepareErr error
+	for _, uuid := range participants {
+		b := batches[uuid]
+		if b.err == nil {
+			continue
+		}
+		// A lock conflict is the error worth reporting, since the client can
+		// retry it.
+		if prepareErr == nil || status.Code(b.err) == codes.Aborted {
+			prepareErr = fmt.Errorf("server %s failed to prepare: %w", uuid, b.err)
+		}
+	}
+
+	decision := txnCommit
+	if prepareErr != nil {
+		decision = txnAbort
+	}
+	if err := m.txns.append(txnRecord{ID: id, State: decision}); err != nil {
+		// Nothing has been committed yet, so an undecided transaction can
+		// still be aborted; recovery does so if this attempt fails too.
+		if decision == txnCommit {
+			prepareErr = fmt.Errorf("failed to log commit: %v", err)
+			decision = txnAbort
+		}
+		if err := m.txns.append(txnRecord{ID: id, State: txnAbort}); err != nil {
+			return "", fmt.Errorf("transaction %s aborted: %v", id, prepareErr)
+		}
+	}
+
+	// The outcome is decided; finish it even if the client goes away.
+	m.finishTxn(context.WithoutCancel(ctx), id, decision, batches)
+
+	if decision == txnAbort {
+		if status.Code(prepareErr) == codes.Aborted {
+			return "", status.Errorf(codes.Aborted, "transaction %s aborted: %v", id, prepareErr)
+		}
+		return "", fmt.Errorf("transaction %s aborted: %w", id, prepareErr)
+	}
+	return id, nil
+}
+
+// finishTxn sends the decision to every participant and, once all of them
+// have acknowledged it, logs the transaction as done. It reports whether
+// that happened.
+func (m *DBManager) finishTxn(ctx context.Context, id, decision string, batches map[string]*serverBatch) bool {
+	if decision == txnCommit {
+		m.commits.RLock()
+		defer m.commits.RUnlock()
+	}
+	m.runBatches(ctx, slices.Collect(maps.Values(batches)), func(ctx context.Context, b *serverBatch) {
+		if decision == txnCommit {
+			_, b.err = b.server.client.CommitTxn(ctx, &db_server.CommitTxnRequest{TxnId: id})
+			return
+		}
+		_, b.err = b.server.client.AbortTxn(ctx, &db_server.AbortTxnRequest{TxnId: id})
+	})
+
+	for _, b := range batches {
+		if b.err != nil {
+			log.Warn().Err(b.err).Msgf("Server %s did not acknowledge %s of transaction %s; it will be retried", b.server.uuid, decision, id)
+			return false
+		}
+	}
+
+	if err := m.txns.append(txnRecord{ID: id, State: txnDone}); err != nil {
+		log.Warn().Err(err).Msgf("Failed to log transaction %s as done", id)
+		return false
+	}
+	return true
+}
+
+// ResolveTransactions finishes two-phase commits their coordinator left
+// behind: decided ones are resent to participants that have not acknowledged
+// them, undecided ones are aborted, and intents on db_servers that the log
+// does not know are presumed aborted. Participants that have left the cluster
+// are skipped, since their keys have moved elsewhere without the intents.
+func (m *DBManager) ResolveTransactions() {
+	for _, rec := range m.txns.unfinished() {
+		decision := rec.State
+		if decision == txnBegin {
+			if err := m.txns.append(txnRecord{ID: rec.ID, State: txnAbort}); err != nil {
+				log.Warn().Err(err).Msgf("Failed to log abort of transaction %s", rec.ID)
+				continue
+			}
+			decision = txnAbort
+		}
+
+		batches := make(map[string]*serverBatch)
+		m.mu.Lock()
+		for _, uuid := range rec.Participants {
+			if server, ok := m.servers[uuid]; ok {
+				batches[uuid] = &serverBatch{server: server}
+			}
+		}
+		m.mu.Unlock()
+
+		if m.finishTxn(context.Background(), rec.ID, decision, batches) {
+			log.Info().Msgf("Recovered transaction %s: %s", rec.ID, decision)
+			TransactionsRecovered.WithLabelValues(decision).Inc()
+		}
+	}
+
+	m.mu.Lock()
+	servers := make([]dbServer, 0, len(m.servers))
+	for _, server := range m.servers {
+		servers = append(servers, server)
+	}
+	m.mu.Unlock()
+
+	for _, server := range servers {
+		ctx, cancel := m.callContext(context.Background())
+		resp, err := server.client.ListPreparedTxns(ctx, &db_server.ListPreparedTxnsRequest{})
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		for _, id := range resp.TxnIds {
+			if m.txns.known(id) {
+				continue
+			}
+			ctx, cancel := m.callContext(context.Background())
+			_, err := server.client.AbortTxn(ctx, &db_server.AbortTxnRequest{TxnId: id})
+			cancel()
+			if err != nil {
+				log.Warn().Err(err).Msgf("Failed to abort orphaned transaction %s on server %s", id, server.uuid)
+				continue
+			}
+			log.Info().Msgf("Aborted orphaned transaction %s on server %s", id, server.uuid)
+			TransactionsRecovered.WithLabelValues(txnAbort).Inc()
+		}
+	}
+}
+
+func txnRequestOps(ops []TxnOp, indexes []int) []*db_server.TxnOp {
+	reqOps := make([]*db_server.TxnOp, len(indexes))
+	for j, i := range indexes {
+		op := ops[i]
+		reqOps[j] = &db_server.TxnOp{
+			Key:        op.Key,
+			Value:      op.Value,
+			TtlSeconds: uint64((op.TTL + time.Second - 1) / time.Second),
+			Delete:     op.Delete,
+		}
+	}
+	return reqOps
+}
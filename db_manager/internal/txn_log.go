@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Coordinator states of a two-phase commit, in the order they are logged.
+// Once a commit or abort record is durable the outcome is decided; done means
+// every participant has acknowledged it and the transaction can be forgotten.
+const (
+	txnBegin  = "begin"
+	txnCommit = "commit"
+	txnAbort  = "abort"
+	txnDone   = "done"
+)
+
+// txnRecord is one line of the coordinator log. Participants are the uuids
+// of the db_servers holding a prepared part of the transaction.
+type txnRecord struct {
+	ID           string   `json:"id"`
+	State        string   `json:"state"`
+	Participants []string `json:"participants,omitempty"`
+}
+
+// txnLog is the coordinator log of two-phase commits. Without a file it only
+// lives in memory, and in-doubt transactions are lost with the manager; with
+// one, every record is fsynced before the coordinator acts on it, so a
+// restarted manager can finish what it decided.
+type txnLog struct {
+	mu   sync.Mutex
+	file *os.File
+	txns map[string]*txnEntry
+}
+
+type txnEntry struct {
+	state        string
+	participants []string
+	// active is set while Transact is still driving the transaction, so
+	// recovery leaves it alone.
+	active bool
+}
+
+func newTxnLog() *txnLog {
+	return &txnLog{txns: make(map[string]*txnEntry)}
+}
+
+// openTxnLog replays the log at path and rewrites it with only the
+// unfinished transactions before appending to it.
+func openTxnLog(path string) (*txnLog, error) {
+	l := newTxnLog()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create transaction log directory: %v", err)
+	}
+
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec txnRecord
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				// A torn last line from a crash mid-write; the record was
+				// never acted on.
+				break
+			}
+			l.apply(rec)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transaction log %s: %v", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to open transaction log %s: %v", path, err)
+	}
+
+	if err := l.compact(path); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// compact writes the unfinished transactions to a fresh file and swaps it in
+// for the log at path.
+func (l *txnLog) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create transaction log %s: %v", tmp, err)
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for id, e := range l.txns {
+		if err := enc.Encode(txnRecord{ID: id, State: e.state, Participants: e.participants}); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write transaction log %s: %v", tmp, err)
+		}
+	}
+	if err := w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write transaction log %s: %v", tmp, err)
+	}
+	f.Close()
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace transaction log %s: %v", path, err)
+	}
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	l.file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction log %s: %v", path, err)
+	}
+	return nil
+}
+
+// append makes rec durable, then applies it.
+func (l *txnLog) append(rec txnRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.writeLocked(rec)
+}
+
+// begin logs the start of a transaction that the caller is driving; it stays
+// active until setActive clears it or a done record ends it.
+func (l *txnLog) begin(id string, participants []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.writeLocked(txnRecord{ID: id, State: txnBegin, Participants: participants}); err != nil {
+		return err
+	}
+	l.txns[id].active = true
+	return nil
+}
+
+// writeLocked makes rec durable, then applies it. The caller must hold l.mu.
+func (l *txnLog) writeLocked(rec txnRecord) error {
+	if l.file != nil {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write transaction log: %v", err)
+		}
+		if err := l.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync transaction log: %v", err)
+		}
+	}
+
+	l.apply(rec)
+	return nil
+}
+
+// apply updates the in-memory state for rec. The caller must hold l.mu or
+// own l exclusively.
+func (l *txnLog) apply(rec txnRecord) {
+	if rec.State == txnDone {
+		delete(l.txns, rec.ID)
+		return
+	}
+	e, ok := l.txns[rec.ID]
+	if !ok {
+		e = &txnEntry{}
+		l.txns[rec.ID] = e
+	}
+	e.state = rec.State
+	if len(rec.Participants) > 0 {
+		e.participants = rec.Participants
+	}
+}
+
+func (l *txnLog) setActive(id string, active bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.txns[id]; ok {
+		e.active = active
+	}
+}
+
+// known reports whether transaction id is in the log and not yet done.
+func (l *txnLog) known(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.txns[id]
+	return ok
+}
+
+// unfinished returns the transactions no Transact call is driving any more.
+func (l *txnLog) unfinished() []txnRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var recs []txnRecord
+	for id, e := range l.txns {
+		if !e.active {
+			recs = append(recs, txnRecord{ID: id, State: e.state, Participants: e.participants})
+		}
+	}
+	return recs
+}
+
+func (l *txnLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
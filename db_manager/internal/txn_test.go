@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// txnKeys returns two keys whose primaries are the same server if samePrimary
+// is set, and different servers otherwise.
+func txnKeys(t *testing.T, m *DBManager, samePrimary bool) (string, string) {
+	t.Helper()
+
+	first, _, err := m.getReplicaServers("txn:0")
+	if err != nil {
+		t.Fatalf("getReplicaServers failed: %v", err)
+	}
+	for i := 1; i < 1000; i++ {
+		key := fmt.Sprintf("txn:%d", i)
+		replicas, _, _ := m.getReplicaServers(key)
+		if (replicas[0].uuid == first[0].uuid) == samePrimary {
+			return "txn:0", key
+		}
+	}
+	t.Fatal("no suitable pair of keys found")
+	return "", ""
+}
+
+func TestTransact_SingleShard(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	a, b := txnKeys(t, m, true)
+
+	id, err := m.Transact(context.Background(), []TxnOp{
+		{Key: a, Value: []byte("1")},
+		{Key: b, Value: []byte("2")},
+	})
+	if err != nil {
+		t.Fatalf("Transact failed: %v", err)
+	}
+	if id != "" {
+		t.Fatalf("expected no two-phase commit id for a single-shard transaction, got %q", id)
+	}
+	m.WaitForBackgroundWrites()
+
+	if n := replicasFor(t, m, servers, a)[0].txnCallCount("apply"); n != 1 {
+		t.Fatalf("expected one ApplyTxn on the primary, got %d", n)
+	}
+	for _, s := range servers {
+		if n := s.txnCallCount("prepare"); n != 0 {
+			t.Fatalf("expected no PrepareTxn calls, got %d on %s", n, s.addr)
+		}
+	}
+	for key, want := range map[string]string{a: "1", b: "2"} {
+		for _, r := range replicasFor(t, m, servers, key) {
+			if v, _ := r.value(key); v != want {
+				t.Fatalf("expected replica %s to hold %s=%s, got %q", r.addr, key, want, v)
+			}
+		}
+	}
+}
+
+func TestTransact_SingleShardShortOfAcks(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Replication.WriteAcks = 2
+	m, servers := newTestManager(t, opts, 0, 0, 0)
+	a, b := txnKeys(t, m, true)
+
+	replicas := replicasFor(t, m, servers, a)
+	for _, r := range replicas[1:] {
+		r.grpc.Stop()
+	}
+
+	_, err := m.Transact(context.Background(), []TxnOp{
+		{Key: a, Value: []byte("1")},
+		{Key: b, Value: []byte("2")},
+	})
+	if status.Code(err) != codes.DataLoss {
+		t.Fatalf("expected DATA_LOSS for a transaction short of its acks, got %v", err)
+	}
+	if v, _ := replicas[0].value(a); v != "1" {
+		t.Fatalf("expected the primary to hold the committed write, got %q", v)
+	}
+}
+
+func TestTransact_TwoPhaseCommit(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	a, b := txnKeys(t, m, false)
+	m.SetKey(context.Background(), b, []byte("old"), 0)
+	m.WaitForBackgroundWrites()
+
+	id, err := m.Transact(context.Background(), []TxnOp{
+		{Key: a, Value: []byte("1")},
+		{Key: b, Delete: true},
+	})
+	if err != nil {
+		t.Fatalf("Transact failed: %v", err)
+	}
+	if id == "" {
+		t.Fatal("expected a two-phase commit id")
+	}
+
+	for _, r := range replicasFor(t, m, servers, a) {
+		if v, _ := r.value(a); v != "1" {
+			t.Fatalf("expected replica %s to hold %s=1, got %q", r.addr, a, v)
+		}
+	}
+	for _, r := range replicasFor(t, m, servers, b) {
+		if _, ok := r.value(b); ok {
+			t.Fatalf("expected %s to be deleted on replica %s", b, r.addr)
+		}
+	}
+	for _, s := range servers {
+		if n := s.preparedTxns(); n != 0 {
+			t.Fatalf("expected no prepared transactions left on %s, got %d", s.addr, n)
+		}
+	}
+	if m.txns.known(id) {
+		t.Fatalf("expected transaction %s to be logged as done", id)
+	}
+}
+
+func TestTransact_ConflictAborts(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	a, b := txnKeys(t, m, false)
+
+	// Another transaction holds b on its primary.
+	locked := replicasFor(t, m, servers, b)[0]
+	locked.PrepareTxn(context.Background(), &db_server.PrepareTxnRequest{
+		TxnId: "other",
+		Ops:   []*db_server.TxnOp{{Key: b, Value: []byte("other")}},
+	})
+
+	_, err := m.Transact(context.Background(), []TxnOp{
+		{Key: a, Value: []byte("1")},
+		{Key: b, Value: []byte("2")},
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("expected ABORTED, got %v", err)
+	}
+
+	for _, s := range servers {
+		if _, ok := s.value(a); ok {
+			t.Fatalf("expected %s to stay unwritten on %s", a, s.addr)
+		}
+		want := 0
+		if s == locked {
+			want = 1
+		}
+		if n := s.preparedTxns(); n != want {
+			t.Fatalf("expected %d prepared transactions on %s, got %d", want, s.addr, n)
+		}
+	}
+}
+
+func TestTransact_Invalid(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	_, err := m.Transact(context.Background(), []TxnOp{{Key: "a"}, {Key: "a", Delete: true}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for a repeated key, got %v", err)
+	}
+	_, err = m.Transact(context.Background(), nil)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for an empty transaction, got %v", err)
+	}
+}
+
+func TestResolveTransactions_FromLog(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	a, b := txnKeys(t, m, false)
+
+	// A coordinator decided to commit "decided" and crashed before telling
+	// anyone; "undecided" was prepared but never decided; "orphan" is
+	// unknown to the log.
+	participants := []string{"server-0", "server-1", "server-2"}
+	for _, s := range servers {
+		s.PrepareTxn(context.Background(), &db_server.PrepareTxnRequest{TxnId: "decided", Ops: []*db_server.TxnOp{{Key: a, Value: []byte("1")}}})
+		s.PrepareTxn(context.Background(), &db_server.PrepareTxnRequest{TxnId: "undecided", Ops: []*db_server.TxnOp{{Key: b, Value: []byte("2")}}})
+	}
+	servers[0].PrepareTxn(context.Background(), &db_server.PrepareTxnRequest{TxnId: "orphan", Ops: []*db_server.TxnOp{{Key: "c", Value: []byte("3")}}})
+
+	path := filepath.Join(t.TempDir(), "txn.log")
+	var log []byte
+	for _, rec := range []txnRecord{
+		{ID: "decided", State: txnBegin, Participants: participants},
+		{ID: "undecided", State: txnBegin, Participants: participants},
+		{ID: "finished", State: txnBegin, Participants: participants},
+		{ID: "decided", State: txnCommit},
+		{ID: "finished", State: txnAbort},
+		{ID: "finished", State: txnDone},
+	} {
+		line, _ := json.Marshal(rec)
+		log = append(append(log, line...), '\n')
+	}
+	if err := os.WriteFile(path, log, 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	if err := m.OpenTxnLog(path); err != nil {
+		t.Fatalf("OpenTxnLog failed: %v", err)
+	}
+	if m.txns.known("finished") {
+		t.Fatal("expected the finished transaction to be compacted away")
+	}
+
+	m.ResolveTransactions()
+
+	for _, s := range servers {
+		if v, _ := s.value(a); v != "1" {
+			t.Fatalf("expected the decided commit on %s, got %s=%q", s.addr, a, v)
+		}
+		if _, ok := s.value(b); ok {
+			t.Fatalf("expected the undecided transaction to be aborted on %s", s.addr)
+		}
+		if n := s.preparedTxns(); n != 0 {
+			t.Fatalf("expected no prepared transactions left on %s, got %d", s.addr, n)
+		}
+	}
+
+	m.txns.close()
+	reopened, err := openTxnLog(path)
+	if err != nil {
+		t.Fatalf("openTxnLog failed: %v", err)
+	}
+	defer reopened.close()
+	if n := len(reopened.unfinished()); n != 0 {
+		t.Fatalf("expected an empty log after recovery, got %d transactions", n)
+	}
+}
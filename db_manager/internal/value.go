@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkValueSize rejects values over the configured limit before they are
+// sent to any replica.
+func (m *DBManager) checkValueSize(key string, value []byte) error {
+	if limit := m.opts.Limits.MaxValueSize; limit > 0 && len(value) > limit {
+		return status.Errorf(codes.InvalidArgument, "value for key %q is %d bytes, over the %d-byte limit", key, len(value), limit)
+	}
+	return nil
+}
+
+// storedValue returns the value in a db_server response, falling back to the
+// deprecated string field for servers that do not fill the bytes field yet.
+func storedValue(b []byte, legacy string) []byte {
+	if len(b) > 0 {
+		return b
+	}
+	return []byte(legacy)
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSetKey_BinaryValue(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	binary := []byte{0x00, 0xff, 0xc3, 0x28, 0x00}
+	if _, err := m.SetKey(context.Background(), "bin", binary, 0); err != nil {
+		t.Fatalf("SetKey failed: %v", err)
+	}
+	m.WaitForBackgroundWrites()
+
+	val, err := m.GetKey(context.Background(), "bin")
+	if err != nil {
+		t.Fatalf("GetKey failed: %v", err)
+	}
+	if !bytes.Equal(val, binary) {
+		t.Fatalf("expected %x, got %x", binary, val)
+	}
+}
+
+func TestSetKey_ValueTooLarge(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Limits.MaxValueSize = 8
+	m, servers := newTestManager(t, opts, 0, 0)
+
+	_, err := m.SetKey(context.Background(), "big", make([]byte, 9), 0)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an oversized value, got %v", err)
+	}
+	for _, s := range servers {
+		if _, ok := s.value("big"); ok {
+			t.Fatalf("oversized value reached server %s", s.addr)
+		}
+	}
+
+	if _, err := m.SetKey(context.Background(), "small", make([]byte, 8), 0); err != nil {
+		t.Fatalf("SetKey at the limit failed: %v", err)
+	}
+}
+
+func TestBatchSet_ValueTooLarge(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Limits.MaxValueSize = 8
+	m, _ := newTestManager(t, opts, 0, 0)
+
+	results := m.BatchSet(context.Background(), []KeyValue{{Key: "big", Value: make([]byte, 9)}, {Key: "small", Value: []byte("ok")}})
+	if status.Code(results[0].Err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for the oversized value, got %v", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Fatalf("expected the small value to be written, got %v", results[1].Err)
+	}
+}
@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// watchTokenPrefix versions the resume token format. Tokens of the
+	// first format keyed positions by server UUID, which a server loses when
+	// it restarts; they are still accepted.
+	watchTokenPrefix   = "w2:"
+	watchTokenPrefixV1 = "w1:"
+
+	// watchDedupKeys bounds how many keys a watch remembers the last change
+	// of for deduplication.
+	watchDedupKeys = 10000
+)
+
+// WatchOptions selects what a watch follows: a single Key, or every key
+// starting with Prefix. FromVersion, a version of Key on its primary, starts
+// a key watch after that version; ResumeToken resumes any watch after the
+// event it came with.
+type WatchOptions struct {
+	Key         string
+	Prefix      string
+	FromVersion uint64
+	ResumeToken string
+}
+
+// WatchEvent is a set of Value, or a delete when Deleted is true. Version is
+// the change's version on Server, the primary it was reported by, and Time is
+// when that server committed it, or zero for a change reported by catch-up.
+// A Gap event carries no change: Server's catch-up that follows may leave out
+// changes it made after Version.
+type WatchEvent struct {
+	Key         string
+	Value       []byte
+	Deleted     bool
+	Version     uint64
+	TTL         time.Duration
+	Time        time.Time
+	Server      string
+	ResumeToken string
+	Gap         bool
+}
+
+// lastChange is what a watch remembers of the last change it reported for a
+// key, to drop the same change reported again by a different server.
+type lastChange struct {
+	server  string
+	deleted bool
+	sum     [sha256.Size]byte
+}
+
+// Watch follows the change feeds of every replica that can hold matching
+// keys and calls fn with each change until ctx is done, fn fails, a feed
+// fails or the topology changes. Replicas report every write they apply, so
+// a change is passed on only from the key's current primary, and a change
+// the new primary reports again after a failover or migration is dropped.
+//
+// Each event carries a resume token holding the last version seen from every
+// server, by address, which unlike the UUID survives a server restart;
+// versions are local to each server, so a token is the only way to resume a
+// prefix watch. A server replays the changes made since its position while
+// it still holds them; past that it catches up through its storage, which
+// reports only the latest change of each key and forgets deletes, and a Gap
+// event says so first.
+func (m *DBManager) Watch(ctx context.Context, opts WatchOptions, fn func(WatchEvent) error) error {
+	if opts.Key != "" && opts.Prefix != "" {
+		return status.Error(codes.InvalidArgument, "a watch follows either a key or a prefix")
+	}
+	if opts.Key == "" && opts.FromVersion > 0 {
+		return status.Error(codes.InvalidArgument, "from_version applies to key watches; resume prefix watches with a resume token")
+	}
+
+	positions := make(map[string]uint64)
+	if opts.ResumeToken != "" {
+		var err error
+		if positions, err = m.decodeWatchToken(opts.ResumeToken); err != nil {
+			return err
+		}
+	}
+
+	prefix := opts.Prefix
+	epoch := m.Epoch()
+	var servers []dbServer
+	if opts.Key != "" {
+		prefix = opts.Key
+		replicas, replicaEpoch, err := m.getReplicaServers(opts.Key)
+		if err != nil {
+			return err
+		}
+		servers, epoch = replicas, replicaEpoch
+		if _, ok := positions[servers[0].addr]; !ok && opts.FromVersion > 0 {
+			positions[servers[0].addr] = opts.FromVersion
+		}
+	} else {
+		servers = m.scanServers(prefix, "", "")
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("no available database servers")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type change struct {
+		server dbServer
+		event  *db_server.WatchEvent
+	}
+	changes := make(chan change)
+	failures := make(chan error, len(servers))
+	for _, server := range servers {
+		// A server the token or from_version has a position for catches up
+		// from it, even from zero; any other starts with live changes.
+		from, catchUp := positions[server.addr]
+		go func(server dbServer) {
+			stream, err := server.client.Watch(ctx, &db_server.WatchRequest{Prefix: prefix, FromVersion: from, CatchUp: catchUp})
+			if err != nil {
+				failures <- fmt.Errorf("server %s: %w", server.uuid, err)
+				return
+			}
+			for {
+				event, err := stream.Recv()
+				if err != nil {
+					failures <- fmt.Errorf("server %s: %w", server.uuid, err)
+					return
+				}
+				select {
+				case changes <- change{server: server, event: event}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(server)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	last := make(map[string]lastChange)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-failures:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("change feed failed: %w", err)
+		case <-ticker.C:
+			if m.Epoch() != epoch {
+				return status.Error(codes.Unavailable, "cluster topology changed; resume the watch with the last resume token")
+			}
+		case c := <-changes:
+			e := c.event
+			if pos, ok := positions[c.server.addr]; !ok || e.Version > pos {
+				positions[c.server.addr] = e.Version
+			}
+			if e.Gap {
+				// Any replica's gap counts, since its catch-up may hold the
+				// only report of a change whose primary has since moved.
+				if err := fn(WatchEvent{Version: e.Version, Server: c.server.uuid, ResumeToken: encodeWatchToken(positions), Gap: true}); err != nil {
+					return err
+				}
+				continue
+			}
+			if e.Progress || opts.Key != "" && e.Key != opts.Key {
+				continue
+			}
+			if !m.isPrimary(c.server.uuid, e.Key) {
+				continue
+			}
+
+			seen := lastChange{server: c.server.uuid, deleted: e.Deleted, sum: sha256.Sum256(e.Value)}
+			if prev, ok := last[e.Key]; ok && prev.server != c.server.uuid && prev.deleted == seen.deleted && prev.sum == seen.sum {
+				last[e.Key] = seen
+				continue
+			}
+			if len(last) >= watchDedupKeys {
+				last = make(map[string]lastChange)
+			}
+			last[e.Key] = seen
+
+			event := WatchEvent{
+				Key:         e.Key,
+				Value:       e.Value,
+				Deleted:     e.Deleted,
+				Version:     e.Version,
+				TTL:         time.Duration(e.TtlSeconds) * time.Second,
+				Server:      c.server.uuid,
+				ResumeToken: encodeWatchToken(positions),
+			}
+			if e.CommitUnixNanos != 0 {
+				event.Time = time.Unix(0, e.CommitUnixNanos).UTC()
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// isPrimary reports whether uuid is the first reachable replica of key.
+func (m *DBManager) isPrimary(uuid, key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, err := m.replicaSetLocked(key)
+	return err == nil && set.servers[0].uuid == uuid
+}
+
+func encodeWatchToken(positions map[string]uint64) string {
+	raw, _ := json.Marshal(positions)
+	return base64.RawURLEncoding.EncodeToString(append([]byte(watchTokenPrefix), raw...))
+}
+
+// decodeWatchToken returns the positions in token by server address. A token
+// of the first format is translated through the current membership; servers
+// that restarted since have new UUIDs, and start with live changes.
+func (m *DBManager) decodeWatchToken(token string) (map[string]uint64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < len(watchTokenPrefix) {
+		return nil, status.Errorf(codes.InvalidArgument, "malformed resume token %q", token)
+	}
+
+	prefix := string(raw[:len(watchTokenPrefix)])
+	if prefix != watchTokenPrefix && prefix != watchTokenPrefixV1 {
+		return nil, status.Errorf(codes.InvalidArgument, "malformed resume token %q", token)
+	}
+	positions := make(map[string]uint64)
+	if err := json.Unmarshal(raw[len(watchTokenPrefix):], &positions); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "malformed resume token %q", token)
+	}
+	if prefix == watchTokenPrefix {
+		return positions, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byAddr := make(map[string]uint64, len(positions))
+	for uuid, pos := range positions {
+		if server, ok := m.servers[uuid]; ok {
+			byAddr[server.addr] = pos
+		}
+	}
+	return byAddr, nil
+}
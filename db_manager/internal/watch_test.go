@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startWatch runs Watch in the background until the returned stop is called
+// or the test ends.
+func startWatch(t *testing.T, m *DBManager, opts WatchOptions) (<-chan WatchEvent, func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan WatchEvent, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Watch(ctx, opts, func(e WatchEvent) error {
+			events <- e
+			return nil
+		})
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+
+	// Let the feeds from the servers open before the test writes.
+	time.Sleep(100 * time.Millisecond)
+	return events, stop
+}
+
+func nextWatchEvent(t *testing.T, events <-chan WatchEvent) WatchEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a watch event")
+		return WatchEvent{}
+	}
+}
+
+func expectNoWatchEvent(t *testing.T, events <-chan WatchEvent) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Fatalf("expected no further events, got %+v", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatch_KeyReportsEachChangeOnce(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	events, _ := startWatch(t, m, WatchOptions{Key: "config"})
+
+	m.SetKey(context.Background(), "config-other", []byte("ignored"), 0)
+	m.SetKey(context.Background(), "config", []byte("v1"), 0)
+	m.WaitForBackgroundWrites()
+
+	if e := nextWatchEvent(t, events); e.Key != "config" || string(e.Value) != "v1" || e.Deleted {
+		t.Fatalf("expected config=v1, got %+v", e)
+	}
+	expectNoWatchEvent(t, events)
+
+	m.DeleteKey(context.Background(), "config")
+	m.WaitForBackgroundWrites()
+	if e := nextWatchEvent(t, events); e.Key != "config" || !e.Deleted {
+		t.Fatalf("expected config to be deleted, got %+v", e)
+	}
+	expectNoWatchEvent(t, events)
+}
+
+func TestWatch_FromVersion(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+
+	m.SetKey(context.Background(), "config", []byte("v1"), 0)
+	m.WaitForBackgroundWrites()
+	_, version, err := m.GetKeyVersion(context.Background(), "config")
+	if err != nil {
+		t.Fatalf("GetKeyVersion failed: %v", err)
+	}
+	m.SetKey(context.Background(), "config", []byte("v2"), 0)
+	m.WaitForBackgroundWrites()
+
+	events, _ := startWatch(t, m, WatchOptions{Key: "config", FromVersion: version})
+	if e := nextWatchEvent(t, events); string(e.Value) != "v2" || e.Version <= version {
+		t.Fatalf("expected the change after version %d to v2, got %+v", version, e)
+	}
+	expectNoWatchEvent(t, events)
+}
+
+func TestWatch_ResumeToken(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	events, stop := startWatch(t, m, WatchOptions{Prefix: "config:"})
+
+	m.SetKey(context.Background(), "config:a", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+	first := nextWatchEvent(t, events)
+	if first.Key != "config:a" || first.ResumeToken == "" {
+		t.Fatalf("expected config:a with a resume token, got %+v", first)
+	}
+	stop()
+
+	// Changes made while nobody watches are picked up on resume.
+	m.SetKey(context.Background(), "config:b", []byte("1"), 0)
+	m.SetKey(context.Background(), "other", []byte("1"), 0)
+	m.WaitForBackgroundWrites()
+
+	events, _ = startWatch(t, m, WatchOptions{Prefix: "config:", ResumeToken: first.ResumeToken})
+	if e := nextWatchEvent(t, events); e.Key != "config:b" {
+		t.Fatalf("expected config:b after resuming, got %+v", e)
+	}
+	expectNoWatchEvent(t, events)
+}
+
+func TestWatch_ResumeAfterServerRestart(t *testing.T) {
+	m, servers := newTestManager(t, DefaultOptions(), 0)
+	events, stop := startWatch(t, m, WatchOptions{Prefix: "config:"})
+
+	m.SetKey(context.Background(), "config:a", []byte("1"), 0)
+	first := nextWatchEvent(t, events)
+	stop()
+
+	// The restarted server registers again under a new UUID.
+	m.RemoveServer("server-0")
+	m.AddServer("server-0-restarted", "test", servers[0].addr)
+	m.WaitForBackgroundWrites()
+	m.SetKey(context.Background(), "config:b", []byte("1"), 0)
+
+	events, _ = startWatch(t, m, WatchOptions{Prefix: "config:", ResumeToken: first.ResumeToken})
+	if e := nextWatchEvent(t, events); e.Key != "config:b" {
+		t.Fatalf("expected config:b after resuming, got %+v", e)
+	}
+	expectNoWatchEvent(t, events)
+}
+
+func TestWatch_Invalid(t *testing.T) {
+	m, _ := newTestManager(t, DefaultOptions(), 0, 0, 0)
+	noop := func(WatchEvent) error { return nil }
+
+	err := m.Watch(context.Background(), WatchOptions{Key: "a", Prefix: "a"}, noop)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for key and prefix, got %v", err)
+	}
+	err = m.Watch(context.Background(), WatchOptions{Prefix: "a", ResumeToken: "bogus"}, noop)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected INVALID_ARGUMENT for a malformed token, got %v", err)
+	}
+}
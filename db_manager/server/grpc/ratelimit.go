@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arbhalerao/meerkat/db_manager/internal"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// clientIDHeader lets a trusted proxy name the client it forwards for. It is
+// ignored from other peers, which are limited by their address.
+const clientIDHeader = "x-client-id"
+
+// rateLimitExempt lists the DBManager methods the limiter never rejects.
+// Topology is what smart clients call to find their way around the manager,
+// so throttling it would only push more traffic through the proxy path.
+var rateLimitExempt = map[string]struct{}{
+	"/db_manager.DBManager/Topology": {},
+}
+
+// rateLimitInterceptor rejects requests whose identity has run out of tokens
+// with RESOURCE_EXHAUSTED. The status carries a RetryInfo detail and the
+// response a retry-after trailer, both saying how long to back off.
+func rateLimitInterceptor(limiter *internal.RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, exempt := rateLimitExempt[info.FullMethod]; exempt {
+			return handler(ctx, req)
+		}
+		setTrailer := func(md metadata.MD) { grpc.SetTrailer(ctx, md) }
+		if err := rateLimit(ctx, req, limiter, setTrailer); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreamInterceptor limits streams like rateLimitInterceptor does
+// unary calls, charging one token per stream.
+func rateLimitStreamInterceptor(limiter *internal.RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if _, exempt := rateLimitExempt[info.FullMethod]; exempt {
+			return handler(srv, ss)
+		}
+		return handler(srv, &rateLimitedStream{ServerStream: ss, limiter: limiter})
+	}
+}
+
+// rateLimitedStream charges its stream when the first request arrives, so
+// that in prefix mode a Watch is keyed by its request like a unary call.
+type rateLimitedStream struct {
+	grpc.ServerStream
+	limiter *internal.RateLimiter
+	charged bool
+}
+
+func (s *rateLimitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil || s.charged {
+		return err
+	}
+	s.charged = true
+	return rateLimit(s.Context(), m, s.limiter, s.SetTrailer)
+}
+
+// rateLimit spends a token for req, or returns the RESOURCE_EXHAUSTED status
+// to reject it with after setting the retry-after trailer.
+func rateLimit(ctx context.Context, req any, limiter *internal.RateLimiter, setTrailer func(metadata.MD)) error {
+	identity := rateLimitIdentity(ctx, req, limiter)
+	ok, retryAfter := limiter.Allow(identity)
+	if ok {
+		return nil
+	}
+
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	setTrailer(metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+
+	st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for %q, retry after %s", identity, retryAfter.Round(time.Millisecond))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// rateLimitIdentity picks the bucket a request is charged to: the key prefix
+// in prefix mode when the key has one, otherwise the client.
+func rateLimitIdentity(ctx context.Context, req any, limiter *internal.RateLimiter) string {
+	opts := limiter.Options()
+	if opts.KeyBy == "prefix" && opts.PrefixDelimiter != "" {
+		if keyed, ok := req.(interface{ GetKey() string }); ok {
+			key := keyed.GetKey()
+			if i := strings.Index(key, opts.PrefixDelimiter); i >= 0 {
+				return key[:i]
+			}
+		}
+	}
+	return clientIdentity(ctx, limiter)
+}
+
+// clientIdentity is the peer's host, or the client id a trusted proxy sent
+// on behalf of the client behind it.
+func clientIdentity(ctx context.Context, limiter *internal.RateLimiter) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if limiter.TrustsPeer(host) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(clientIDHeader); len(ids) > 0 && ids[0] != "" {
+				return ids[0]
+			}
+		}
+	}
+	return host
+}
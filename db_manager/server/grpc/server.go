@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/arbhalerao/meerkat/db_manager/internal"
 	"github.com/arbhalerao/meerkat/pb/db_manager"
 	"github.com/arbhalerao/meerkat/utils"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
@@ -19,8 +22,18 @@ type Server struct {
 	manager *internal.DBManager
 }
 
-func NewServer(addr string, manager *internal.DBManager) *Server {
-	grpcServer := grpc.NewServer()
+// NewServer builds the manager's gRPC front end. A nil limiter turns rate
+// limiting off.
+func NewServer(addr string, manager *internal.DBManager, limiter *internal.RateLimiter) *Server {
+	var opts []grpc.ServerOption
+	if limiter != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(rateLimitInterceptor(limiter)),
+			grpc.ChainStreamInterceptor(rateLimitStreamInterceptor(limiter)),
+		)
+	}
+
+	grpcServer := grpc.NewServer(opts...)
 	s := &Server{
 		grpc:    grpcServer,
 		addr:    addr,
@@ -52,15 +65,28 @@ func (s *Server) Stop() {
 }
 
 func (s *Server) Get(ctx context.Context, req *db_manager.GetRequest) (*db_manager.GetResponse, error) {
-	val, err := s.manager.GetKey(req.Key)
+	legacy := legacyValues(ctx)
+	if req.WithVersion {
+		val, version, err := s.manager.GetKeyVersion(ctx, req.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get key %q: %w", req.Key, err)
+		}
+		return &db_manager.GetResponse{Value: legacy(val), ValueBytes: val, Version: version}, nil
+	}
+
+	val, err := s.manager.GetKey(ctx, req.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get key %q: %w", req.Key, err)
 	}
-	return &db_manager.GetResponse{Value: val}, nil
+	return &db_manager.GetResponse{Value: legacy(val), ValueBytes: val}, nil
 }
 
 func (s *Server) Set(ctx context.Context, req *db_manager.SetRequest) (*db_manager.SetResponse, error) {
-	success, err := s.manager.SetKey(req.Key, req.Value)
+	ttl, err := requestTTL(req.Key, req.TtlSeconds)
+	if err != nil {
+		return nil, err
+	}
+	success, err := s.manager.SetKey(ctx, req.Key, requestValue(req.ValueBytes, req.Value), ttl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set key %q: %w", req.Key, err)
 	}
@@ -71,7 +97,7 @@ func (s *Server) Set(ctx context.Context, req *db_manager.SetRequest) (*db_manag
 }
 
 func (s *Server) Delete(ctx context.Context, req *db_manager.DeleteRequest) (*db_manager.DeleteResponse, error) {
-	success, err := s.manager.DeleteKey(req.Key)
+	success, err := s.manager.DeleteKey(ctx, req.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete key %q: %w", req.Key, err)
 	}
@@ -80,3 +106,258 @@ func (s *Server) Delete(ctx context.Context, req *db_manager.DeleteRequest) (*db
 	}
 	return &db_manager.DeleteResponse{Success: success}, nil
 }
+
+func (s *Server) ConditionalSet(ctx context.Context, req *db_manager.ConditionalSetRequest) (*db_manager.ConditionalSetResponse, error) {
+	ttl, err := requestTTL(req.Key, req.TtlSeconds)
+	if err != nil {
+		return nil, err
+	}
+	value := requestValue(req.ValueBytes, req.Value)
+
+	var success bool
+	switch cond := req.Condition.(type) {
+	case *db_manager.ConditionalSetRequest_IfAbsent:
+		if !cond.IfAbsent {
+			return nil, status.Error(codes.InvalidArgument, "if_absent must be true when set")
+		}
+		success, err = s.manager.SetKeyIfAbsent(ctx, req.Key, value, ttl)
+	case *db_manager.ConditionalSetRequest_IfVersion:
+		success, err = s.manager.SetKeyIfVersion(ctx, req.Key, value, cond.IfVersion, ttl)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "a condition is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to conditionally set key %q: %w", req.Key, err)
+	}
+	return &db_manager.ConditionalSetResponse{Success: success}, nil
+}
+
+func (s *Server) ConditionalDelete(ctx context.Context, req *db_manager.ConditionalDeleteRequest) (*db_manager.ConditionalDeleteResponse, error) {
+	success, err := s.manager.DeleteKeyIfValue(ctx, req.Key, requestValue(req.IfValueBytes, req.IfValue))
+	if err != nil {
+		return nil, fmt.Errorf("failed to conditionally delete key %q: %w", req.Key, err)
+	}
+	return &db_manager.ConditionalDeleteResponse{Success: success}, nil
+}
+
+func (s *Server) Increment(ctx context.Context, req *db_manager.IncrementRequest) (*db_manager.IncrementResponse, error) {
+	val, err := s.manager.Increment(ctx, req.Key, req.Delta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to increment key %q: %w", req.Key, err)
+	}
+	return &db_manager.IncrementResponse{Value: val}, nil
+}
+
+func (s *Server) BatchGet(ctx context.Context, req *db_manager.BatchGetRequest) (*db_manager.BatchGetResponse, error) {
+	results := s.manager.BatchGet(ctx, req.Keys)
+
+	legacy := legacyValues(ctx)
+	resp := &db_manager.BatchGetResponse{Results: make([]*db_manager.BatchGetResult, len(results))}
+	for i, r := range results {
+		resp.Results[i] = &db_manager.BatchGetResult{Key: r.Key, Found: r.Found, Value: legacy(r.Value), ValueBytes: r.Value}
+		if r.Err != nil {
+			resp.Results[i].Error = r.Err.Error()
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) BatchSet(ctx context.Context, req *db_manager.BatchSetRequest) (*db_manager.BatchSetResponse, error) {
+	pairs := make([]internal.KeyValue, len(req.Pairs))
+	for i, p := range req.Pairs {
+		ttl, err := requestTTL(p.Key, p.TtlSeconds)
+		if err != nil {
+			return nil, err
+		}
+		pairs[i] = internal.KeyValue{Key: p.Key, Value: requestValue(p.ValueBytes, p.Value), TTL: ttl}
+	}
+
+	return &db_manager.BatchSetResponse{Results: keyResults(s.manager.BatchSet(ctx, pairs))}, nil
+}
+
+func (s *Server) BatchDelete(ctx context.Context, req *db_manager.BatchDeleteRequest) (*db_manager.BatchDeleteResponse, error) {
+	return &db_manager.BatchDeleteResponse{Results: keyResults(s.manager.BatchDelete(ctx, req.Keys))}, nil
+}
+
+func (s *Server) Scan(ctx context.Context, req *db_manager.ScanRequest) (*db_manager.ScanResponse, error) {
+	page, err := s.manager.Scan(ctx, internal.ScanOptions{
+		Prefix: req.Prefix,
+		Start:  req.Start,
+		End:    req.End,
+		Limit:  int(req.Limit),
+		Cursor: req.Cursor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	legacy := legacyValues(ctx)
+	resp := &db_manager.ScanResponse{Pairs: make([]*db_manager.KeyValuePair, len(page.Pairs)), NextCursor: page.NextCursor}
+	for i, p := range page.Pairs {
+		resp.Pairs[i] = &db_manager.KeyValuePair{Key: p.Key, Value: legacy(p.Value), ValueBytes: p.Value, TtlSeconds: ttlSeconds(p.TTL), Version: p.Version}
+	}
+	return resp, nil
+}
+
+func (s *Server) Transact(ctx context.Context, req *db_manager.TransactRequest) (*db_manager.TransactResponse, error) {
+	ops := make([]internal.TxnOp, len(req.Ops))
+	for i, op := range req.Ops {
+		ttl, err := requestTTL(op.Key, op.TtlSeconds)
+		if err != nil {
+			return nil, err
+		}
+		ops[i] = internal.TxnOp{Key: op.Key, Value: op.Value, TTL: ttl, Delete: op.Delete}
+	}
+
+	id, err := s.manager.Transact(ctx, ops)
+	if err != nil {
+		return nil, fmt.Errorf("transaction failed: %w", err)
+	}
+	return &db_manager.TransactResponse{Committed: true, TxnId: id}, nil
+}
+
+func (s *Server) Watch(req *db_manager.WatchRequest, stream grpc.ServerStreamingServer[db_manager.WatchEvent]) error {
+	opts := internal.WatchOptions{
+		Key:         req.Key,
+		Prefix:      req.Prefix,
+		FromVersion: req.FromVersion,
+		ResumeToken: req.ResumeToken,
+	}
+	err := s.manager.Watch(stream.Context(), opts, func(e internal.WatchEvent) error {
+		return stream.Send(&db_manager.WatchEvent{
+			Key:         e.Key,
+			Value:       e.Value,
+			Deleted:     e.Deleted,
+			Version:     e.Version,
+			TtlSeconds:  ttlSeconds(e.TTL),
+			ResumeToken: e.ResumeToken,
+			Gap:         e.Gap,
+			Server:      e.Server,
+		})
+	})
+	if err != nil && stream.Context().Err() == nil {
+		return fmt.Errorf("watch failed: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) Backup(ctx context.Context, req *db_manager.BackupRequest) (*db_manager.BackupResponse, error) {
+	dir, err := s.manager.BackupDir(req.Dir)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := s.manager.Backup(ctx, dir, req.Incremental)
+	if err != nil {
+		return nil, fmt.Errorf("backup failed: %w", err)
+	}
+
+	resp := &db_manager.BackupResponse{
+		Id:          manifest.ID,
+		Path:        manifest.Path,
+		Incremental: manifest.Kind == internal.BackupIncremental,
+		Base:        manifest.Base,
+		Servers:     uint32(len(manifest.Servers)),
+	}
+	for _, part := range manifest.Servers {
+		resp.Bytes += part.Bytes
+	}
+	return resp, nil
+}
+
+func (s *Server) Restore(req *db_manager.RestoreRequest, stream grpc.ServerStreamingServer[db_manager.RestoreProgress]) error {
+	send := func(p internal.RestoreProgress, done bool) error {
+		return stream.Send(&db_manager.RestoreProgress{
+			BackupId: p.Backup,
+			Server:   p.Server,
+			Restored: p.Restored,
+			Deleted:  p.Deleted,
+			Skipped:  p.Skipped,
+			Failed:   p.Failed,
+			Failures: keyResults(p.Failures),
+			Done:     done,
+
+			FromReplicas: p.FromReplicas,
+		})
+	}
+
+	dir, err := s.manager.BackupDir(req.Dir)
+	if err != nil {
+		return err
+	}
+	result, err := s.manager.Restore(stream.Context(), dir, req.BackupId, func(p internal.RestoreProgress) error {
+		return send(p, false)
+	})
+	if err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	return send(result, true)
+}
+
+func keyResults(results []internal.BatchWriteResult) []*db_manager.KeyResult {
+	pb := make([]*db_manager.KeyResult, len(results))
+	for i, r := range results {
+		pb[i] = &db_manager.KeyResult{Key: r.Key, Success: r.Err == nil}
+		if r.Err != nil {
+			pb[i].Error = r.Err.Error()
+		}
+	}
+	return pb
+}
+
+func (s *Server) TTL(ctx context.Context, req *db_manager.TTLRequest) (*db_manager.TTLResponse, error) {
+	ttl, ok, err := s.manager.TTL(ctx, req.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TTL of key %q: %w", req.Key, err)
+	}
+	return &db_manager.TTLResponse{HasTtl: ok, TtlSeconds: ttlSeconds(ttl)}, nil
+}
+
+// maxTTLSeconds is the longest TTL a request may set, about 100 years. Far
+// longer ones would overflow a time.Duration.
+const maxTTLSeconds = 100 * 365 * 24 * 60 * 60
+
+// requestTTL converts the TTL a request sets for key, rejecting one over
+// maxTTLSeconds with INVALID_ARGUMENT.
+func requestTTL(key string, seconds uint64) (time.Duration, error) {
+	if seconds > maxTTLSeconds {
+		return 0, status.Errorf(codes.InvalidArgument, "TTL of %d seconds for key %q is over the %d-second limit", seconds, key, maxTTLSeconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ttlSeconds rounds a remaining lifetime up to whole seconds, like the
+// db_servers do, so a key about to expire is never reported as one that
+// never does.
+func ttlSeconds(ttl time.Duration) uint64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return uint64((ttl + time.Second - 1) / time.Second)
+}
+
+func (s *Server) Topology(ctx context.Context, req *db_manager.TopologyRequest) (*db_manager.TopologyResponse, error) {
+	servers, epoch := s.manager.GetClusterStatus()
+
+	nodes := make([]*db_manager.Node, len(servers))
+	for i, server := range servers {
+		nodes[i] = &db_manager.Node{
+			Uuid:     server.UUID,
+			Region:   server.Region,
+			Addr:     server.Addr,
+			Position: server.Position,
+		}
+	}
+
+	partitioning := db_manager.Partitioning_HASH
+	if s.manager.Partitioning() == internal.PartitioningRange {
+		partitioning = db_manager.Partitioning_RANGE
+	}
+
+	return &db_manager.TopologyResponse{
+		Epoch:             epoch,
+		ReplicationFactor: internal.ReplicationFactor,
+		Nodes:             nodes,
+		Partitioning:      partitioning,
+		WriteAcks:         uint32(s.manager.WriteAcks()),
+	}, nil
+}
@@ -0,0 +1,41 @@
+package grpc
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// valueBytesHeader is the request metadata a client sends to say it reads
+// values from the bytes fields only.
+const valueBytesHeader = "x-value-bytes"
+
+// requestValue returns the value a request carries: the bytes field, or the
+// deprecated string field when an older client left the bytes field empty.
+func requestValue(b []byte, legacy string) []byte {
+	if len(b) > 0 {
+		return b
+	}
+	return []byte(legacy)
+}
+
+// legacyValue fills the deprecated string field of a response for older
+// clients. A proto string must be valid UTF-8, so other values are left out
+// and are only returned in the bytes field.
+func legacyValue(b []byte) string {
+	if !utf8.Valid(b) {
+		return ""
+	}
+	return string(b)
+}
+
+// legacyValues returns how to fill the deprecated string field of responses
+// to the request in ctx: with legacyValue, or not at all for a client that
+// sent valueBytesHeader, so that values are not sent twice.
+func legacyValues(ctx context.Context) func([]byte) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(valueBytesHeader)) > 0 {
+		return func([]byte) string { return "" }
+	}
+	return legacyValue
+}
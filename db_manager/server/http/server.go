@@ -122,11 +122,12 @@ func (s *Server) clusterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	servers := s.manager.GetClusterStatus()
+	servers, epoch := s.manager.GetClusterStatus()
 
 	response := map[string]interface{}{
 		"status":             "healthy",
 		"server_count":       len(servers),
+		"epoch":              epoch,
 		"replication_factor": internal.ReplicationFactor,
 		"servers":            servers,
 		"time":               time.Now().Format(time.RFC3339),
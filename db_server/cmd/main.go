@@ -6,11 +6,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/arbhalerao/meerkat/db"
 	"github.com/arbhalerao/meerkat/db_server/db_manager_client"
+	"github.com/arbhalerao/meerkat/db_server/metrics"
 	grpc_server "github.com/arbhalerao/meerkat/db_server/server/grpc"
 	http_server "github.com/arbhalerao/meerkat/db_server/server/http"
 	"github.com/arbhalerao/meerkat/utils"
@@ -22,7 +25,33 @@ type Config struct {
 		GRPC_Addr    string `toml:"grpc_addr"`
 		HTTP_Addr    string `toml:"http_addr"`
 		MANAGER_Addr string `toml:"manager_addr"`
+		// MaxValueSize bounds stored values in bytes; zero uses the default.
+		MaxValueSize int `toml:"max_value_size"`
 	} `toml:"server"`
+	Storage struct {
+		// Engine is badger, memory or bolt; empty selects badger.
+		Engine string `toml:"engine"`
+		// MaxMemory bounds the memory engine in bytes; zero means no
+		// limit. Eviction is lru, lfu or none, which rejects writes
+		// once the limit is reached.
+		MaxMemory int64  `toml:"max_memory"`
+		Eviction  string `toml:"eviction"`
+		// DataDir holds the badger and bolt data. A relative path is
+		// taken from the config file's directory.
+		DataDir string `toml:"data_dir"`
+		// Badger tuning; zero values keep Badger's defaults.
+		SyncWrites       bool   `toml:"sync_writes"`
+		ValueLogFileSize int64  `toml:"value_log_file_size"`
+		Compression      string `toml:"compression"`
+		BlockCacheSize   int64  `toml:"block_cache_size"`
+		IndexCacheSize   int64  `toml:"index_cache_size"`
+		ValueThreshold   int64  `toml:"value_threshold"`
+		// GCInterval is how often Badger's value log is garbage
+		// collected, 10m when zero and never when negative.
+		// GCDiscardRatio is how stale a file must be to be rewritten.
+		GCInterval     time.Duration `toml:"gc_interval"`
+		GCDiscardRatio float64       `toml:"gc_discard_ratio"`
+	} `toml:"storage"`
 }
 
 func main() {
@@ -44,12 +73,28 @@ func main() {
 	grpcAddr := config.Server.GRPC_Addr
 	managerAddr := config.Server.MANAGER_Addr
 
-	dbPath := fmt.Sprintf("../../data/db_%s", region)
-	database, err := db.NewDatabase(dbPath)
+	dbOptions := storageOptions(&config)
+	if err := dbOptions.Validate(); err != nil {
+		utils.Logger.Fatal().Err(err).Msg("Invalid storage config")
+		return
+	}
+	dbPath, err := dataDir(&config, *configPath)
+	if err != nil {
+		utils.Logger.Fatal().Err(err).Msg("Invalid storage config")
+		return
+	}
+
+	database, err := db.Open(dbPath, dbOptions)
 	if err != nil {
 		utils.Logger.Fatal().Err(err).Msg("Failed to initialize database")
 		return
 	}
+	if dbPath != "" {
+		utils.Logger.Info().Msgf("Using the %s storage engine in %s", engineName(config.Storage.Engine), dbPath)
+	} else {
+		utils.Logger.Info().Msgf("Using the %s storage engine", engineName(config.Storage.Engine))
+	}
+	metrics.Register(database)
 	defer func() {
 		if err := database.Close(); err != nil {
 			utils.Logger.Error().Err(err).Msg("Failed to close database")
@@ -58,7 +103,12 @@ func main() {
 
 	httpAddr := config.Server.HTTP_Addr
 
-	grpcService := grpc_server.NewServer(database, grpcAddr)
+	maxValueSize := config.Server.MaxValueSize
+	if maxValueSize <= 0 {
+		maxValueSize = grpc_server.DefaultMaxValueSize
+	}
+
+	grpcService := grpc_server.NewServer(database, grpcAddr, maxValueSize)
 
 	ready := make(chan bool)
 	if *register {
@@ -87,7 +137,7 @@ func main() {
 
 	var httpService *http_server.Server
 	if httpAddr != "" {
-		httpService = http_server.NewServer(database, httpAddr)
+		httpService = http_server.NewServer(database, httpAddr, maxValueSize)
 		httpService.RegisterHandlers()
 
 		wg.Add(1)
@@ -111,3 +161,48 @@ func main() {
 
 	utils.Logger.Info().Msg("Servers stopped successfully.")
 }
+
+func engineName(engine string) string {
+	if engine == "" {
+		return db.EngineBadger
+	}
+	return engine
+}
+
+func storageOptions(config *Config) db.Options {
+	s := config.Storage
+	return db.Options{
+		Engine:    s.Engine,
+		MaxMemory: s.MaxMemory,
+		Eviction:  s.Eviction,
+		Badger: db.BadgerOptions{
+			SyncWrites:       s.SyncWrites,
+			ValueLogFileSize: s.ValueLogFileSize,
+			Compression:      s.Compression,
+			BlockCacheSize:   s.BlockCacheSize,
+			IndexCacheSize:   s.IndexCacheSize,
+			ValueThreshold:   s.ValueThreshold,
+			GCInterval:       s.GCInterval,
+			GCDiscardRatio:   s.GCDiscardRatio,
+		},
+	}
+}
+
+// dataDir resolves the configured data directory against the config file's
+// directory and makes sure it can be created. The memory engine needs none.
+func dataDir(config *Config, configPath string) (string, error) {
+	dir := config.Storage.DataDir
+	if config.Storage.Engine == db.EngineMemory {
+		return "", nil
+	}
+	if dir == "" {
+		return "", fmt.Errorf("storage.data_dir must be set for the %s engine", engineName(config.Storage.Engine))
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(configPath), dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return dir, nil
+}
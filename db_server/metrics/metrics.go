@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Register exports the storage metrics of database. They are read from the
+// database on every scrape.
+func Register(database *db.Database) {
+	if _, ok := database.MemoryStats(); ok {
+		registerMemory(database)
+	}
+	if _, _, ok := database.DiskUsage(); ok {
+		registerDisk(database)
+	}
+}
+
+func registerDisk(database *db.Database) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "lsm_bytes",
+		Help:      "Bytes Badger's LSM tree takes on disk, as Badger last measured",
+	}, func() float64 {
+		lsm, _, _ := database.DiskUsage()
+		return float64(lsm)
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "vlog_bytes",
+		Help:      "Bytes Badger's value log takes on disk, as Badger last measured",
+	}, func() float64 {
+		_, vlog, _ := database.DiskUsage()
+		return float64(vlog)
+	})
+}
+
+func registerMemory(database *db.Database) {
+	stat := func(get func(db.MemoryStats) float64) func() float64 {
+		return func() float64 {
+			stats, _ := database.MemoryStats()
+			return get(stats)
+		}
+	}
+
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "evictions_total",
+		Help:      "Keys the memory engine evicted to stay within its memory limit",
+	}, stat(func(s db.MemoryStats) float64 { return float64(s.Evictions) }))
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "memory_bytes",
+		Help:      "Bytes the memory engine holds, counted against its limit",
+	}, stat(func(s db.MemoryStats) float64 { return float64(s.Bytes) }))
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "memory_limit_bytes",
+		Help:      "Memory limit of the memory engine, zero when unlimited",
+	}, stat(func(s db.MemoryStats) float64 { return float64(s.MaxBytes) }))
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "meerkat",
+		Subsystem: "storage",
+		Name:      "memory_keys",
+		Help:      "Keys the memory engine holds, including tombstones of recent deletes",
+	}, stat(func(s db.MemoryStats) float64 { return float64(s.Keys) }))
+}
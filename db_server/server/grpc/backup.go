@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// backupChunkSize is how much backup data each BackupChunk carries.
+const backupChunkSize = 1 << 20
+
+// chunkWriter sends everything written to it as backup chunks of at most
+// backupChunkSize, keeping them well under gRPC's message size limit.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[db_server.BackupChunk]
+}
+
+func (w chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n := min(len(p)-written, backupChunkSize)
+		if err := w.stream.Send(&db_server.BackupChunk{Data: p[written : written+n]}); err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+// Backup streams a snapshot of the whole database. Like Watch it is not
+// fenced: the manager backs up every server and records which keys each
+// owned in the backup's manifest.
+func (s *Server) Backup(req *db_server.BackupRequest, stream grpc.ServerStreamingServer[db_server.BackupChunk]) error {
+	w := bufio.NewWriterSize(chunkWriter{stream: stream}, backupChunkSize)
+	version, err := s.db.Backup(w, req.Since)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		if stream.Context().Err() != nil {
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+		return fmt.Errorf("backup since version %d failed: %v", req.Since, err)
+	}
+	return stream.Send(&db_server.BackupChunk{Version: version, Last: true})
+}
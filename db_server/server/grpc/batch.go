@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+)
+
+func (s *Server) BatchGet(ctx context.Context, req *db_server.BatchGetRequest) (*db_server.BatchGetResponse, error) {
+	results := make([]*db_server.BatchGetResult, len(req.Keys))
+	keys := make([]string, 0, len(req.Keys))
+	for i, key := range req.Keys {
+		results[i] = &db_server.BatchGetResult{Key: key}
+		if err := s.checkKey(req.Epoch, key); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	values, err := s.db.GetKeys(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d keys: %v", len(keys), err)
+	}
+
+	for _, r := range results {
+		if val, ok := values[r.Key]; ok {
+			r.Found = true
+			r.Value = legacyValue(val)
+			r.ValueBytes = val
+		}
+	}
+
+	return &db_server.BatchGetResponse{Results: results}, nil
+}
+
+func (s *Server) BatchSet(ctx context.Context, req *db_server.BatchSetRequest) (*db_server.BatchSetResponse, error) {
+	results := make([]*db_server.KeyResult, len(req.Pairs))
+	pairs := make([]db.KeyValuePair, 0, len(req.Pairs))
+	var accepted []*db_server.KeyResult
+	for i, p := range req.Pairs {
+		results[i] = &db_server.KeyResult{Key: p.Key}
+		value := requestValue(p.ValueBytes, p.Value)
+		if err := s.checkKey(req.Epoch, p.Key); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := s.checkValueSize(p.Key, value); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		ttl, err := requestTTL(p.Key, p.TtlSeconds)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		pairs = append(pairs, db.KeyValuePair{
+			Key:   p.Key,
+			Value: value,
+			TTL:   ttl,
+		})
+		accepted = append(accepted, results[i])
+	}
+
+	applyBatch(accepted, s.db.SetKeys(pairs))
+	return &db_server.BatchSetResponse{Results: results}, nil
+}
+
+func (s *Server) BatchDelete(ctx context.Context, req *db_server.BatchDeleteRequest) (*db_server.BatchDeleteResponse, error) {
+	results := make([]*db_server.KeyResult, len(req.Keys))
+	keys := make([]string, 0, len(req.Keys))
+	var accepted []*db_server.KeyResult
+	for i, key := range req.Keys {
+		results[i] = &db_server.KeyResult{Key: key}
+		if err := s.checkKey(req.Epoch, key); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		keys = append(keys, key)
+		accepted = append(accepted, results[i])
+	}
+
+	applyBatch(accepted, s.db.DeleteKeys(keys))
+	return &db_server.BatchDeleteResponse{Results: results}, nil
+}
+
+// applyBatch records the outcome of each key of a batch: errs holds one
+// error per key that was part of it. Keys succeed or fail on their own.
+func applyBatch(results []*db_server.KeyResult, errs []error) {
+	for i, r := range results {
+		if errs[i] != nil {
+			r.Error = errs[i].Error()
+			continue
+		}
+		r.Success = true
+	}
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sync"
+
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fence holds the ranges the manager last assigned to this server and the
+// ring epoch they belong to. Requests routed on an older epoch, or for keys
+// outside the owned ranges, are rejected before they reach the database.
+// A hash-partitioned cluster assigns hash ranges and a range-partitioned one
+// assigns key ranges.
+type fence struct {
+	mu        sync.RWMutex
+	epoch     uint64
+	ranges    []*db_server.HashRange
+	keyRanges []*db_server.KeyRange
+	assigned  bool
+}
+
+// update installs a new ownership assignment. Assignments older than the
+// current one are ignored so that out-of-order pushes cannot roll it back.
+func (f *fence) update(epoch uint64, ranges []*db_server.HashRange, keyRanges []*db_server.KeyRange) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if epoch < f.epoch {
+		return false
+	}
+
+	f.epoch = epoch
+	f.ranges = ranges
+	f.keyRanges = keyRanges
+	f.assigned = true
+	return true
+}
+
+// check admits a request for key routed at epoch. Epoch zero is used by
+// callers that do not route on the ring, such as key migration, and is always
+// allowed. Requests from a newer epoch than the last assignment are admitted
+// too: the manager pushes the matching ranges right after the ring changes.
+func (f *fence) check(epoch uint64, key string) error {
+	if epoch == 0 {
+		return nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if epoch < f.epoch {
+		return fencingError(db_server.FencingReason_STALE_EPOCH, f.epoch,
+			fmt.Sprintf("stale epoch %d: server is at epoch %d", epoch, f.epoch))
+	}
+	if epoch > f.epoch || !f.assigned {
+		return nil
+	}
+
+	if len(f.keyRanges) > 0 {
+		for _, r := range f.keyRanges {
+			if key >= r.Start && (r.End == "" || key < r.End) {
+				return nil
+			}
+		}
+	} else {
+		hash := crc32.ChecksumIEEE([]byte(key))
+		for _, r := range f.ranges {
+			if rangeContains(r, hash) {
+				return nil
+			}
+		}
+	}
+
+	return fencingError(db_server.FencingReason_WRONG_OWNER, f.epoch,
+		fmt.Sprintf("key %q is not owned by this server at epoch %d", key, f.epoch))
+}
+
+func rangeContains(r *db_server.HashRange, hash uint32) bool {
+	switch {
+	case r.Start < r.End:
+		return hash > r.Start && hash <= r.End
+	case r.Start > r.End:
+		return hash > r.Start || hash <= r.End
+	default:
+		return true
+	}
+}
+
+func fencingError(reason db_server.FencingReason, serverEpoch uint64, msg string) error {
+	st := status.New(codes.FailedPrecondition, msg)
+	detailed, err := st.WithDetails(&db_server.FencingError{Reason: reason, ServerEpoch: serverEpoch})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RunGC and Compact let an operator reclaim disk space without waiting for
+// the background GC. Like Backup they are not fenced: they work on the
+// whole database, not on keys the server owns.
+
+func (s *Server) RunGC(ctx context.Context, req *db_server.RunGCRequest) (*db_server.RunGCResponse, error) {
+	if req.DiscardRatio < 0 || req.DiscardRatio >= 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "discard_ratio must be at least 0 and below 1, got %g", req.DiscardRatio)
+	}
+	rewritten, err := s.db.RunValueLogGC(req.DiscardRatio)
+	if err != nil {
+		return nil, maintenanceError("value log GC", err)
+	}
+	lsm, vlog, _ := s.db.DiskUsage()
+	return &db_server.RunGCResponse{FilesRewritten: int32(rewritten), LsmBytes: lsm, VlogBytes: vlog}, nil
+}
+
+func (s *Server) Compact(ctx context.Context, req *db_server.CompactRequest) (*db_server.CompactResponse, error) {
+	if req.Workers < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "workers must not be negative, got %d", req.Workers)
+	}
+	if err := s.db.Flatten(int(req.Workers)); err != nil {
+		return nil, maintenanceError("compaction", err)
+	}
+	lsm, vlog, _ := s.db.DiskUsage()
+	return &db_server.CompactResponse{LsmBytes: lsm, VlogBytes: vlog}, nil
+}
+
+func maintenanceError(op string, err error) error {
+	switch {
+	case errors.Is(err, db.ErrNotSupported):
+		return status.Errorf(codes.FailedPrecondition, "%s is %v", op, err)
+	case errors.Is(err, db.ErrGCRunning):
+		return status.Errorf(codes.Aborted, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "%s failed: %v", op, err)
+	}
+}
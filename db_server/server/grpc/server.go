@@ -2,29 +2,44 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/arbhalerao/meerkat/db"
 	"github.com/arbhalerao/meerkat/pb/db_server"
 	"github.com/arbhalerao/meerkat/utils"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	db_server.UnimplementedDBServerServer
-	db   *db.Database
-	grpc *grpc.Server
-	addr string
+	db    *db.Database
+	grpc  *grpc.Server
+	addr  string
+	fence fence
+
+	maxValueSize int
 }
 
-func NewServer(db *db.Database, addr string) *Server {
-	grpcServer := grpc.NewServer()
+func NewServer(db *db.Database, addr string, maxValueSize int) *Server {
+	// The manager pings idle pooled connections; allow that instead of
+	// answering with GOAWAY "too_many_pings".
+	grpcServer := grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             10 * time.Second,
+		PermitWithoutStream: true,
+	}))
 	s := &Server{
 		db:   db,
 		grpc: grpcServer,
 		addr: addr,
+
+		maxValueSize: maxValueSize,
 	}
 	db_server.RegisterDBServerServer(grpcServer, s)
 	return s
@@ -57,32 +72,146 @@ func (s *Server) HealthCheck(ctx context.Context, req *db_server.HealthCheckRequ
 }
 
 func (s *Server) Get(ctx context.Context, req *db_server.GetRequest) (*db_server.GetResponse, error) {
-	val, err := s.db.GetKey(req.Key)
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	val, version, err := s.db.GetKeyVersion(req.Key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get key '%s': %v", req.Key, err)
 	}
 
-	return &db_server.GetResponse{Value: string(val)}, nil
+	return &db_server.GetResponse{Value: legacyValue(val), ValueBytes: val, Version: version}, nil
 }
 
 func (s *Server) Set(ctx context.Context, req *db_server.SetRequest) (*db_server.SetResponse, error) {
-	err := s.db.SetKey(req.Key, req.Value)
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	value := requestValue(req.ValueBytes, req.Value)
+	if err := s.checkValueSize(req.Key, value); err != nil {
+		return nil, err
+	}
+
+	ttl, err := requestTTL(req.Key, req.TtlSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if req.PrimaryOrder != 0 {
+		err = s.db.SetKeyFromPrimary(req.Key, value, ttl, db.PrimaryVersion{Epoch: req.Epoch, Order: req.PrimaryOrder})
+	} else {
+		err = s.db.SetKey(req.Key, value, ttl)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to set key '%s' with value '%s': %v", req.Key, req.Value, err)
+		return nil, writeError(err, "failed to set key '%s': %v", req.Key)
 	}
 
 	return &db_server.SetResponse{Success: true}, nil
 }
 
 func (s *Server) Delete(ctx context.Context, req *db_server.DeleteRequest) (*db_server.DeleteResponse, error) {
-	err := s.db.DeleteKey(req.Key)
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if req.PrimaryOrder != 0 {
+		err = s.db.DeleteKeyFromPrimary(req.Key, db.PrimaryVersion{Epoch: req.Epoch, Order: req.PrimaryOrder})
+	} else {
+		err = s.db.DeleteKey(req.Key)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete key '%s': %v", req.Key, err)
+		return nil, writeError(err, "failed to delete key '%s': %v", req.Key)
 	}
 
 	return &db_server.DeleteResponse{Success: true}, nil
 }
 
+func (s *Server) ConditionalSet(ctx context.Context, req *db_server.ConditionalSetRequest) (*db_server.ConditionalSetResponse, error) {
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	value := requestValue(req.ValueBytes, req.Value)
+	if err := s.checkValueSize(req.Key, value); err != nil {
+		return nil, err
+	}
+
+	ttl, err := requestTTL(req.Key, req.TtlSeconds)
+	if err != nil {
+		return nil, err
+	}
+	var order uint64
+	switch cond := req.Condition.(type) {
+	case *db_server.ConditionalSetRequest_IfAbsent:
+		if !cond.IfAbsent {
+			return nil, status.Error(codes.InvalidArgument, "if_absent must be true when set")
+		}
+		order, err = s.db.SetKeyIfAbsent(req.Key, value, ttl)
+	case *db_server.ConditionalSetRequest_IfVersion:
+		order, err = s.db.SetKeyIfVersion(req.Key, value, cond.IfVersion, ttl)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "a condition is required")
+	}
+	if err != nil {
+		return nil, writeError(err, "failed to conditionally set key '%s': %v", req.Key)
+	}
+
+	return &db_server.ConditionalSetResponse{Success: true, Order: order}, nil
+}
+
+func (s *Server) ConditionalDelete(ctx context.Context, req *db_server.ConditionalDeleteRequest) (*db_server.ConditionalDeleteResponse, error) {
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	order, err := s.db.DeleteKeyIfValue(req.Key, requestValue(req.IfValueBytes, req.IfValue))
+	if err != nil {
+		return nil, writeError(err, "failed to conditionally delete key '%s': %v", req.Key)
+	}
+
+	return &db_server.ConditionalDeleteResponse{Success: true, Order: order}, nil
+}
+
+func (s *Server) Increment(ctx context.Context, req *db_server.IncrementRequest) (*db_server.IncrementResponse, error) {
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	val, ttl, order, err := s.db.IncrementKey(req.Key, req.Delta)
+	switch {
+	case errors.Is(err, db.ErrNotInteger):
+		return nil, status.Errorf(codes.InvalidArgument, "failed to increment key '%s': %v", req.Key, err)
+	case errors.Is(err, db.ErrOverflow):
+		return nil, status.Errorf(codes.OutOfRange, "failed to increment key '%s': %v", req.Key, err)
+	case err != nil:
+		return nil, writeError(err, "failed to increment key '%s': %v", req.Key)
+	}
+
+	return &db_server.IncrementResponse{Value: val, TtlSeconds: ttlSeconds(ttl), Order: order}, nil
+}
+
+// writeError answers a failed condition, or a key locked by a prepared
+// transaction, with ABORTED so callers can tell it apart from a storage
+// error.
+func writeError(err error, format string, key string) error {
+	if errors.Is(err, db.ErrConditionFailed) || errors.Is(err, db.ErrLocked) {
+		return status.Errorf(codes.Aborted, format, key, err)
+	}
+	return fmt.Errorf(format, key, err)
+}
+
+// checkKey rejects keys reserved for the database's transaction records,
+// which clients may not read or write, and keys this server does not own at
+// epoch.
+func (s *Server) checkKey(epoch uint64, key string) error {
+	if db.IsReservedKey(key) {
+		return status.Errorf(codes.InvalidArgument, "key %q is reserved", key)
+	}
+	return s.fence.check(epoch, key)
+}
+
 func (s *Server) ListKeys(ctx context.Context, req *db_server.ListKeysRequest) (*db_server.ListKeysResponse, error) {
 	pairs, err := s.db.GetAllKeys()
 	if err != nil {
@@ -92,10 +221,95 @@ func (s *Server) ListKeys(ctx context.Context, req *db_server.ListKeysRequest) (
 	pbPairs := make([]*db_server.KeyValuePair, len(pairs))
 	for i, p := range pairs {
 		pbPairs[i] = &db_server.KeyValuePair{
-			Key:   p.Key,
-			Value: p.Value,
+			Key:        p.Key,
+			Value:      legacyValue(p.Value),
+			ValueBytes: p.Value,
+			TtlSeconds: ttlSeconds(p.TTL),
+			Version:    p.Version,
 		}
 	}
 
 	return &db_server.ListKeysResponse{Pairs: pbPairs}, nil
 }
+
+func (s *Server) TTL(ctx context.Context, req *db_server.TTLRequest) (*db_server.TTLResponse, error) {
+	if err := s.checkKey(req.Epoch, req.Key); err != nil {
+		return nil, err
+	}
+
+	ttl, err := s.db.GetTTL(req.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TTL of key '%s': %v", req.Key, err)
+	}
+
+	return &db_server.TTLResponse{HasTtl: ttl > 0, TtlSeconds: ttlSeconds(ttl)}, nil
+}
+
+// maxTTLSeconds is the longest TTL a request may set, about 100 years. Far
+// longer ones would overflow a time.Duration.
+const maxTTLSeconds = 100 * 365 * 24 * 60 * 60
+
+// requestTTL converts the TTL a request sets for key, rejecting one over
+// maxTTLSeconds with INVALID_ARGUMENT.
+func requestTTL(key string, seconds uint64) (time.Duration, error) {
+	if seconds > maxTTLSeconds {
+		return 0, status.Errorf(codes.InvalidArgument, "TTL of %d seconds for key '%s' is over the %d-second limit", seconds, key, maxTTLSeconds)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ttlSeconds rounds a remaining lifetime up to whole seconds, so a key that
+// is about to expire is never reported, or migrated, as one that never does.
+func ttlSeconds(ttl time.Duration) uint64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return uint64((ttl + time.Second - 1) / time.Second)
+}
+
+// maxScanBytes bounds the keys and values read for one Scan page. A value
+// that is valid UTF-8 is sent twice, in Value and in ValueBytes, so a page
+// stays under about 3 MB, inside gRPC's default 4 MB message limit.
+const maxScanBytes = (3 << 20) / 2
+
+func (s *Server) Scan(ctx context.Context, req *db_server.ScanRequest) (*db_server.ScanResponse, error) {
+	pairs, more, err := s.db.ScanKeys(req.Prefix, req.Start, req.End, int(req.Limit), maxScanBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan keys: %v", err)
+	}
+
+	pbPairs := make([]*db_server.KeyValuePair, len(pairs))
+	for i, p := range pairs {
+		pbPairs[i] = &db_server.KeyValuePair{
+			Key:        p.Key,
+			Value:      legacyValue(p.Value),
+			ValueBytes: p.Value,
+			TtlSeconds: ttlSeconds(p.TTL),
+			Version:    p.Version,
+		}
+	}
+
+	return &db_server.ScanResponse{Pairs: pbPairs, More: more}, nil
+}
+
+func (s *Server) RangeStats(ctx context.Context, req *db_server.RangeStatsRequest) (*db_server.RangeStatsResponse, error) {
+	keys, bytes, median, err := s.db.RangeStats(req.Start, req.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats for range [%q, %q): %v", req.Start, req.End, err)
+	}
+
+	return &db_server.RangeStatsResponse{Keys: uint64(keys), Bytes: uint64(bytes), MedianKey: median}, nil
+}
+
+func (s *Server) UpdateOwnership(ctx context.Context, req *db_server.UpdateOwnershipRequest) (*db_server.UpdateOwnershipResponse, error) {
+	accepted := s.fence.update(req.Epoch, req.Ranges, req.KeyRanges)
+	if accepted {
+		if len(req.KeyRanges) > 0 {
+			utils.Logger.Info().Msgf("Owning %d key range(s) at epoch %d", len(req.KeyRanges), req.Epoch)
+		} else {
+			utils.Logger.Info().Msgf("Owning %d hash range(s) at epoch %d", len(req.Ranges), req.Epoch)
+		}
+	}
+
+	return &db_server.UpdateOwnershipResponse{Accepted: accepted}, nil
+}
@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) ApplyTxn(ctx context.Context, req *db_server.ApplyTxnRequest) (*db_server.ApplyTxnResponse, error) {
+	ops, err := s.txnOps(req.Epoch, req.Ops)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.ApplyTxn(ops); err != nil {
+		return nil, txnError(err, "failed to apply transaction: %v")
+	}
+
+	return &db_server.ApplyTxnResponse{}, nil
+}
+
+func (s *Server) PrepareTxn(ctx context.Context, req *db_server.PrepareTxnRequest) (*db_server.PrepareTxnResponse, error) {
+	if req.TxnId == "" {
+		return nil, status.Error(codes.InvalidArgument, "txn_id is required")
+	}
+	ops, err := s.txnOps(req.Epoch, req.Ops)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.PrepareTxn(req.TxnId, ops); err != nil {
+		return nil, txnError(err, "failed to prepare transaction: %v")
+	}
+
+	return &db_server.PrepareTxnResponse{}, nil
+}
+
+func (s *Server) CommitTxn(ctx context.Context, req *db_server.CommitTxnRequest) (*db_server.CommitTxnResponse, error) {
+	if err := s.db.CommitTxn(req.TxnId); err != nil {
+		return nil, err
+	}
+	return &db_server.CommitTxnResponse{}, nil
+}
+
+func (s *Server) AbortTxn(ctx context.Context, req *db_server.AbortTxnRequest) (*db_server.AbortTxnResponse, error) {
+	if err := s.db.AbortTxn(req.TxnId); err != nil {
+		return nil, err
+	}
+	return &db_server.AbortTxnResponse{}, nil
+}
+
+func (s *Server) ListPreparedTxns(ctx context.Context, req *db_server.ListPreparedTxnsRequest) (*db_server.ListPreparedTxnsResponse, error) {
+	ids, err := s.db.PreparedTxns()
+	if err != nil {
+		return nil, err
+	}
+	return &db_server.ListPreparedTxnsResponse{TxnIds: ids}, nil
+}
+
+// txnOps fences and validates every op of a transaction. Unlike batches, a
+// transaction fails as a whole if any key is rejected.
+func (s *Server) txnOps(epoch uint64, reqOps []*db_server.TxnOp) ([]db.TxnOp, error) {
+	if len(reqOps) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "a transaction needs at least one op")
+	}
+
+	ops := make([]db.TxnOp, len(reqOps))
+	for i, op := range reqOps {
+		if err := s.checkKey(epoch, op.Key); err != nil {
+			return nil, err
+		}
+		if !op.Delete {
+			if err := s.checkValueSize(op.Key, op.Value); err != nil {
+				return nil, err
+			}
+		}
+		ttl, err := requestTTL(op.Key, op.TtlSeconds)
+		if err != nil {
+			return nil, err
+		}
+		ops[i] = db.TxnOp{
+			Key:    op.Key,
+			Value:  op.Value,
+			TTL:    ttl,
+			Delete: op.Delete,
+		}
+	}
+	return ops, nil
+}
+
+// txnError answers a lock conflict with ABORTED so the coordinator can tell
+// it apart from a storage error.
+func txnError(err error, format string) error {
+	if errors.Is(err, db.ErrLocked) {
+		return status.Errorf(codes.Aborted, format, err)
+	}
+	return fmt.Errorf(format, err)
+}
@@ -0,0 +1,37 @@
+package grpc
+
+import (
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMaxValueSize bounds values when no max_value_size is configured.
+const DefaultMaxValueSize = 1 << 20
+
+// requestValue returns the value a request carries: the bytes field, or the
+// deprecated string field when an older client left the bytes field empty.
+func requestValue(b []byte, legacy string) []byte {
+	if len(b) > 0 {
+		return b
+	}
+	return []byte(legacy)
+}
+
+// legacyValue fills the deprecated string field of a response for older
+// clients. A proto string must be valid UTF-8, so other values are left out
+// and are only returned in the bytes field.
+func legacyValue(b []byte) string {
+	if !utf8.Valid(b) {
+		return ""
+	}
+	return string(b)
+}
+
+func (s *Server) checkValueSize(key string, value []byte) error {
+	if s.maxValueSize > 0 && len(value) > s.maxValueSize {
+		return status.Errorf(codes.InvalidArgument, "value for key '%s' is %d bytes, over the %d-byte limit", key, len(value), s.maxValueSize)
+	}
+	return nil
+}
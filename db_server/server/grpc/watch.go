@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arbhalerao/meerkat/db"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Watch streams the server's change feed. It is not fenced: the manager
+// watches every replica and decides which copy of a change to pass on.
+func (s *Server) Watch(req *db_server.WatchRequest, stream grpc.ServerStreamingServer[db_server.WatchEvent]) error {
+	err := s.db.Watch(stream.Context(), req.Prefix, req.FromVersion, req.CatchUp, func(e db.Event) error {
+		event := &db_server.WatchEvent{
+			Key:        e.Key,
+			Value:      e.Value,
+			Deleted:    e.Deleted,
+			Version:    e.Version,
+			TtlSeconds: ttlSeconds(e.TTL),
+			Progress:   e.Progress,
+			Gap:        e.Gap,
+		}
+		if !e.Time.IsZero() {
+			event.CommitUnixNanos = e.Time.UnixNano()
+		}
+		return stream.Send(event)
+	})
+	switch {
+	case errors.Is(err, db.ErrWatchLagging):
+		return status.Errorf(codes.Aborted, "%v", err)
+	case stream.Context().Err() != nil:
+		return status.FromContextError(stream.Context().Err()).Err()
+	case err != nil:
+		return fmt.Errorf("watch on prefix '%s' failed: %v", req.Prefix, err)
+	}
+	return nil
+}
@@ -1,13 +1,23 @@
 package http
 
 import (
+	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
 
+	"github.com/arbhalerao/meerkat/db"
 	"github.com/arbhalerao/meerkat/utils"
 	"github.com/dgraph-io/badger"
 )
 
+// valueJSON is a key and its value as JSON. encoding/json carries []byte as
+// standard base64, so values may be arbitrary bytes.
+type valueJSON struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
 func (s *Server) GetHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -19,6 +29,9 @@ func (s *Server) GetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	key := r.Form.Get("key")
+	if rejectReserved(w, "GET", key) {
+		return
+	}
 
 	value, err := s.db.GetKey(key)
 	if err != nil {
@@ -34,8 +47,7 @@ func (s *Server) GetHandler(w http.ResponseWriter, r *http.Request) {
 
 	utils.Logger.Info().Msgf("[GET] Successfully retrieved key %s", key)
 
-	response := fmt.Sprintf(`{"key": %q, "value": %q}`, key, string(value))
-	_, err = w.Write([]byte(response))
+	err = json.NewEncoder(w).Encode(valueJSON{Key: key, Value: value})
 	if err != nil {
 		utils.Logger.Error().Msgf("Error writing response for key '%s': %v", key, err)
 		return
@@ -45,17 +57,23 @@ func (s *Server) GetHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Server) SetHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	err := r.ParseForm()
+	key, value, err := s.parseSetRequest(w, r)
 	if err != nil {
-		utils.Logger.Error().Msgf("[SET] Error parsing form data")
-		http.Error(w, fmt.Sprintf(`{"error": "Failed to forward request: %s"}`, err.Error()), http.StatusInternalServerError)
+		utils.Logger.Error().Msgf("[SET] Error parsing request: %v", err)
+		http.Error(w, fmt.Sprintf(`{"error": "Failed to parse request: %s"}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	if rejectReserved(w, "SET", key) {
 		return
 	}
 
-	key := r.Form.Get("key")
-	value := r.Form.Get("value")
+	if s.maxValueSize > 0 && len(value) > s.maxValueSize {
+		utils.Logger.Error().Msgf("[SET] Value for key %s is %d bytes, over the %d-byte limit", key, len(value), s.maxValueSize)
+		http.Error(w, fmt.Sprintf(`{"error": "Value for key '%s' is over the %d-byte limit"}`, key, s.maxValueSize), http.StatusRequestEntityTooLarge)
+		return
+	}
 
-	err = s.db.SetKey(key, value)
+	err = s.db.SetKey(key, value, 0)
 	if err != nil {
 		utils.Logger.Error().Msgf("[SET] Error setting key %s: %v", key, err)
 		http.Error(w, fmt.Sprintf(`{"error": "Failed to set key '%s': %v"}`, key, err), http.StatusInternalServerError)
@@ -72,6 +90,29 @@ func (s *Server) SetHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// parseSetRequest reads the key and value of a set. A JSON body carries the
+// value base64-encoded, as GetHandler returns it; form data carries it as
+// plain text.
+func (s *Server) parseSetRequest(w http.ResponseWriter, r *http.Request) (string, []byte, error) {
+	if s.maxValueSize > 0 {
+		// Room for the base64-encoded value and the rest of the body.
+		r.Body = http.MaxBytesReader(w, r.Body, int64(s.maxValueSize)*4/3+4096)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var req valueJSON
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return "", nil, err
+		}
+		return req.Key, req.Value, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return "", nil, err
+	}
+	return r.Form.Get("key"), []byte(r.Form.Get("value")), nil
+}
+
 func (s *Server) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -83,6 +124,9 @@ func (s *Server) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	key := r.Form.Get("key")
+	if rejectReserved(w, "DELETE", key) {
+		return
+	}
 
 	err = s.db.DeleteKey(key)
 	if err != nil {
@@ -105,3 +149,14 @@ func (s *Server) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// rejectReserved answers requests for keys reserved for the database's
+// transaction records with 400.
+func rejectReserved(w http.ResponseWriter, op, key string) bool {
+	if !db.IsReservedKey(key) {
+		return false
+	}
+	utils.Logger.Error().Msgf("[%s] Key %q is reserved", op, key)
+	http.Error(w, `{"error": "Key is reserved"}`, http.StatusBadRequest)
+	return true
+}
@@ -7,18 +7,22 @@ import (
 
 	"github.com/arbhalerao/meerkat/db"
 	"github.com/arbhalerao/meerkat/utils"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Server struct {
 	db     *db.Database
 	addr   string
 	server *http.Server
+
+	maxValueSize int
 }
 
-func NewServer(db *db.Database, addr string) *Server {
+func NewServer(db *db.Database, addr string, maxValueSize int) *Server {
 	return &Server{
-		db:   db,
-		addr: addr,
+		db:           db,
+		addr:         addr,
+		maxValueSize: maxValueSize,
 		server: &http.Server{
 			Addr: addr,
 		},
@@ -30,6 +34,7 @@ func (s *Server) RegisterHandlers() {
 	mux.HandleFunc("/get", s.GetHandler)
 	mux.HandleFunc("/set", s.SetHandler)
 	mux.HandleFunc("/delete", s.DeleteHandler)
+	mux.Handle("/metrics", promhttp.Handler())
 	s.server.Handler = mux
 }
 
@@ -21,10 +21,64 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Partitioning is how keys are placed on servers. Under RANGE, keys are
+// placed by manager-kept split points rather than by hash, and clients should
+// send requests through the manager.
+type Partitioning int32
+
+const (
+	Partitioning_HASH  Partitioning = 0
+	Partitioning_RANGE Partitioning = 1
+)
+
+// Enum value maps for Partitioning.
+var (
+	Partitioning_name = map[int32]string{
+		0: "HASH",
+		1: "RANGE",
+	}
+	Partitioning_value = map[string]int32{
+		"HASH":  0,
+		"RANGE": 1,
+	}
+)
+
+func (x Partitioning) Enum() *Partitioning {
+	p := new(Partitioning)
+	*p = x
+	return p
+}
+
+func (x Partitioning) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Partitioning) Descriptor() protoreflect.EnumDescriptor {
+	return file_db_manager_proto_enumTypes[0].Descriptor()
+}
+
+func (Partitioning) Type() protoreflect.EnumType {
+	return &file_db_manager_proto_enumTypes[0]
+}
+
+func (x Partitioning) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Partitioning.Descriptor instead.
+func (Partitioning) EnumDescriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{0}
+}
+
+// ttl_seconds makes the key expire that long after the write; zero keeps it
+// until it is deleted.
 type SetRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	Value         string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,4,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,6 +120,7 @@ func (x *SetRequest) GetKey() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in db_manager.proto.
 func (x *SetRequest) GetValue() string {
 	if x != nil {
 		return x.Value
@@ -73,6 +128,20 @@ func (x *SetRequest) GetValue() string {
 	return ""
 }
 
+func (x *SetRequest) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *SetRequest) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
 type SetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -117,9 +186,12 @@ func (x *SetResponse) GetSuccess() bool {
 	return false
 }
 
+// with_version reads from the key's primary and returns its version, for use
+// in a later ConditionalSet with if_version. Such reads are not hedged.
 type GetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	WithVersion   bool                   `protobuf:"varint,2,opt,name=with_version,json=withVersion,proto3" json:"with_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -161,9 +233,19 @@ func (x *GetRequest) GetKey() string {
 	return ""
 }
 
+func (x *GetRequest) GetWithVersion() bool {
+	if x != nil {
+		return x.WithVersion
+	}
+	return false
+}
+
 type GetResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	Value         string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Version       uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,3,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -198,6 +280,7 @@ func (*GetResponse) Descriptor() ([]byte, []int) {
 	return file_db_manager_proto_rawDescGZIP(), []int{3}
 }
 
+// Deprecated: Marked as deprecated in db_manager.proto.
 func (x *GetResponse) GetValue() string {
 	if x != nil {
 		return x.Value
@@ -205,6 +288,20 @@ func (x *GetResponse) GetValue() string {
 	return ""
 }
 
+func (x *GetResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetResponse) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
 type DeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -293,84 +390,2252 @@ func (x *DeleteResponse) GetSuccess() bool {
 	return false
 }
 
-var File_db_manager_proto protoreflect.FileDescriptor
+// ConditionalSetRequest writes the key only if the condition holds on the
+// key's primary; the result is then copied to the other replicas. A failed
+// condition is answered with ABORTED.
+type ConditionalSetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	Value      string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds uint64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// Types that are valid to be assigned to Condition:
+	//
+	//	*ConditionalSetRequest_IfAbsent
+	//	*ConditionalSetRequest_IfVersion
+	Condition     isConditionalSetRequest_Condition `protobuf_oneof:"condition"`
+	ValueBytes    []byte                            `protobuf:"bytes,6,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_db_manager_proto_rawDesc = "" +
-	"\n" +
-	"\x10db_manager.proto\x12\n" +
-	"db_manager\"4\n" +
-	"\n" +
-	"SetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"'\n" +
-	"\vSetResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x1e\n" +
-	"\n" +
-	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"#\n" +
-	"\vGetResponse\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\tR\x05value\"!\n" +
-	"\rDeleteRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"*\n" +
-	"\x0eDeleteResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess2\xbc\x01\n" +
-	"\tDBManager\x126\n" +
-	"\x03Set\x12\x16.db_manager.SetRequest\x1a\x17.db_manager.SetResponse\x126\n" +
-	"\x03Get\x12\x16.db_manager.GetRequest\x1a\x17.db_manager.GetResponse\x12?\n" +
-	"\x06Delete\x12\x19.db_manager.DeleteRequest\x1a\x1a.db_manager.DeleteResponseB.Z,github.com/arbhalerao/meerkat/pb/db_managerb\x06proto3"
+func (x *ConditionalSetRequest) Reset() {
+	*x = ConditionalSetRequest{}
+	mi := &file_db_manager_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-var (
-	file_db_manager_proto_rawDescOnce sync.Once
-	file_db_manager_proto_rawDescData []byte
-)
+func (x *ConditionalSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-func file_db_manager_proto_rawDescGZIP() []byte {
-	file_db_manager_proto_rawDescOnce.Do(func() {
-		file_db_manager_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_db_manager_proto_rawDesc), len(file_db_manager_proto_rawDesc)))
-	})
-	return file_db_manager_proto_rawDescData
+func (*ConditionalSetRequest) ProtoMessage() {}
+
+func (x *ConditionalSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var file_db_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
-var file_db_manager_proto_goTypes = []any{
-	(*SetRequest)(nil),     // 0: db_manager.SetRequest
-	(*SetResponse)(nil),    // 1: db_manager.SetResponse
-	(*GetRequest)(nil),     // 2: db_manager.GetRequest
-	(*GetResponse)(nil),    // 3: db_manager.GetResponse
-	(*DeleteRequest)(nil),  // 4: db_manager.DeleteRequest
-	(*DeleteResponse)(nil), // 5: db_manager.DeleteResponse
+// Deprecated: Use ConditionalSetRequest.ProtoReflect.Descriptor instead.
+func (*ConditionalSetRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{6}
 }
-var file_db_manager_proto_depIdxs = []int32{
-	0, // 0: db_manager.DBManager.Set:input_type -> db_manager.SetRequest
-	2, // 1: db_manager.DBManager.Get:input_type -> db_manager.GetRequest
-	4, // 2: db_manager.DBManager.Delete:input_type -> db_manager.DeleteRequest
-	1, // 3: db_manager.DBManager.Set:output_type -> db_manager.SetResponse
-	3, // 4: db_manager.DBManager.Get:output_type -> db_manager.GetResponse
-	5, // 5: db_manager.DBManager.Delete:output_type -> db_manager.DeleteResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+
+func (x *ConditionalSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
 }
 
-func init() { file_db_manager_proto_init() }
-func file_db_manager_proto_init() {
-	if File_db_manager_proto != nil {
-		return
+// Deprecated: Marked as deprecated in db_manager.proto.
+func (x *ConditionalSetRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ConditionalSetRequest) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *ConditionalSetRequest) GetCondition() isConditionalSetRequest_Condition {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+func (x *ConditionalSetRequest) GetIfAbsent() bool {
+	if x != nil {
+		if x, ok := x.Condition.(*ConditionalSetRequest_IfAbsent); ok {
+			return x.IfAbsent
+		}
+	}
+	return false
+}
+
+func (x *ConditionalSetRequest) GetIfVersion() uint64 {
+	if x != nil {
+		if x, ok := x.Condition.(*ConditionalSetRequest_IfVersion); ok {
+			return x.IfVersion
+		}
+	}
+	return 0
+}
+
+func (x *ConditionalSetRequest) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+type isConditionalSetRequest_Condition interface {
+	isConditionalSetRequest_Condition()
+}
+
+type ConditionalSetRequest_IfAbsent struct {
+	// if_absent requires the key not to exist.
+	IfAbsent bool `protobuf:"varint,4,opt,name=if_absent,json=ifAbsent,proto3,oneof"`
+}
+
+type ConditionalSetRequest_IfVersion struct {
+	// if_version requires the key to be at this version, as returned by
+	// a Get with with_version.
+	IfVersion uint64 `protobuf:"varint,5,opt,name=if_version,json=ifVersion,proto3,oneof"`
+}
+
+func (*ConditionalSetRequest_IfAbsent) isConditionalSetRequest_Condition() {}
+
+func (*ConditionalSetRequest_IfVersion) isConditionalSetRequest_Condition() {}
+
+type ConditionalSetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConditionalSetResponse) Reset() {
+	*x = ConditionalSetResponse{}
+	mi := &file_db_manager_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConditionalSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConditionalSetResponse) ProtoMessage() {}
+
+func (x *ConditionalSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConditionalSetResponse.ProtoReflect.Descriptor instead.
+func (*ConditionalSetResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ConditionalSetResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// ConditionalDeleteRequest deletes the key only if it holds if_value. A
+// failed condition is answered with ABORTED.
+type ConditionalDeleteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	IfValue       string `protobuf:"bytes,2,opt,name=if_value,json=ifValue,proto3" json:"if_value,omitempty"`
+	IfValueBytes  []byte `protobuf:"bytes,3,opt,name=if_value_bytes,json=ifValueBytes,proto3" json:"if_value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConditionalDeleteRequest) Reset() {
+	*x = ConditionalDeleteRequest{}
+	mi := &file_db_manager_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConditionalDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConditionalDeleteRequest) ProtoMessage() {}
+
+func (x *ConditionalDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConditionalDeleteRequest.ProtoReflect.Descriptor instead.
+func (*ConditionalDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ConditionalDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in db_manager.proto.
+func (x *ConditionalDeleteRequest) GetIfValue() string {
+	if x != nil {
+		return x.IfValue
+	}
+	return ""
+}
+
+func (x *ConditionalDeleteRequest) GetIfValueBytes() []byte {
+	if x != nil {
+		return x.IfValueBytes
+	}
+	return nil
+}
+
+type ConditionalDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConditionalDeleteResponse) Reset() {
+	*x = ConditionalDeleteResponse{}
+	mi := &file_db_manager_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConditionalDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConditionalDeleteResponse) ProtoMessage() {}
+
+func (x *ConditionalDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConditionalDeleteResponse.ProtoReflect.Descriptor instead.
+func (*ConditionalDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ConditionalDeleteResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// IncrementRequest atomically adds delta, which may be negative, to the
+// integer stored under key on the key's primary, treating a missing key as
+// zero. The new value is then copied to the other replicas.
+type IncrementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementRequest) Reset() {
+	*x = IncrementRequest{}
+	mi := &file_db_manager_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementRequest) ProtoMessage() {}
+
+func (x *IncrementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementRequest.ProtoReflect.Descriptor instead.
+func (*IncrementRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IncrementRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *IncrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+type IncrementResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         int64                  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementResponse) Reset() {
+	*x = IncrementResponse{}
+	mi := &file_db_manager_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementResponse) ProtoMessage() {}
+
+func (x *IncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementResponse.ProtoReflect.Descriptor instead.
+func (*IncrementResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IncrementResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type BatchGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_db_manager_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchGetRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// BatchGetResult reports one key of a BatchGet. A key that does not exist on
+// any replica has found unset and no error.
+type BatchGetResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Found bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	Value         string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,5,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResult) Reset() {
+	*x = BatchGetResult{}
+	mi := &file_db_manager_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResult) ProtoMessage() {}
+
+func (x *BatchGetResult) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResult.ProtoReflect.Descriptor instead.
+func (*BatchGetResult) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchGetResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+// Deprecated: Marked as deprecated in db_manager.proto.
+func (x *BatchGetResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+type BatchGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchGetResult      `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_db_manager_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchGetResponse) GetResults() []*BatchGetResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// ttl_seconds makes the key expire that long after the write; zero keeps it
+// until it is deleted. In Scan responses it is the remaining lifetime, and
+// version is the key's version on its primary; writes ignore version.
+type KeyValuePair struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_manager.proto.
+	Value         string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,4,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	Version       uint64 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValuePair) Reset() {
+	*x = KeyValuePair{}
+	mi := &file_db_manager_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValuePair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValuePair) ProtoMessage() {}
+
+func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValuePair.ProtoReflect.Descriptor instead.
+func (*KeyValuePair) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *KeyValuePair) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in db_manager.proto.
+func (x *KeyValuePair) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValuePair) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *KeyValuePair) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+func (x *KeyValuePair) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type BatchSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSetRequest) Reset() {
+	*x = BatchSetRequest{}
+	mi := &file_db_manager_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSetRequest) ProtoMessage() {}
+
+func (x *BatchSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSetRequest.ProtoReflect.Descriptor instead.
+func (*BatchSetRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchSetRequest) GetPairs() []*KeyValuePair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+// KeyResult reports the outcome of a batched write for one key. success
+// means the write reached the configured number of replicas.
+type KeyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyResult) Reset() {
+	*x = KeyResult{}
+	mi := &file_db_manager_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyResult) ProtoMessage() {}
+
+func (x *KeyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyResult.ProtoReflect.Descriptor instead.
+func (*KeyResult) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *KeyResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KeyResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BatchSetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*KeyResult           `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSetResponse) Reset() {
+	*x = BatchSetResponse{}
+	mi := &file_db_manager_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSetResponse) ProtoMessage() {}
+
+func (x *BatchSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSetResponse.ProtoReflect.Descriptor instead.
+func (*BatchSetResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchSetResponse) GetResults() []*KeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchDeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteRequest) Reset() {
+	*x = BatchDeleteRequest{}
+	mi := &file_db_manager_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteRequest) ProtoMessage() {}
+
+func (x *BatchDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteRequest.ProtoReflect.Descriptor instead.
+func (*BatchDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchDeleteRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type BatchDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*KeyResult           `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteResponse) Reset() {
+	*x = BatchDeleteResponse{}
+	mi := &file_db_manager_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteResponse) ProtoMessage() {}
+
+func (x *BatchDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteResponse.ProtoReflect.Descriptor instead.
+func (*BatchDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BatchDeleteResponse) GetResults() []*KeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// ScanRequest pages through keys that start with prefix, are >= start and,
+// if end is set, < end, across the whole cluster in key order. limit caps the
+// page size. To continue, send the same request with cursor set to the
+// previous response's next_cursor.
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Start         string                 `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	Limit         uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_db_manager_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ScanRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ScanRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+// next_cursor is empty on the last page.
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_db_manager_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ScanResponse) GetPairs() []*KeyValuePair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// TxnOp is one write of a transaction: a set of value, or a delete when
+// delete is true.
+type TxnOp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Delete        bool                   `protobuf:"varint,4,opt,name=delete,proto3" json:"delete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOp) Reset() {
+	*x = TxnOp{}
+	mi := &file_db_manager_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOp) ProtoMessage() {}
+
+func (x *TxnOp) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOp.ProtoReflect.Descriptor instead.
+func (*TxnOp) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *TxnOp) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnOp) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *TxnOp) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *TxnOp) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+// TransactRequest applies ops atomically: either every op takes effect or
+// none does. Each key may appear once. A transaction that conflicts with
+// another one in flight on the same keys is answered with ABORTED and can be
+// retried.
+type TransactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ops           []*TxnOp               `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactRequest) Reset() {
+	*x = TransactRequest{}
+	mi := &file_db_manager_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactRequest) ProtoMessage() {}
+
+func (x *TransactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactRequest.ProtoReflect.Descriptor instead.
+func (*TransactRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *TransactRequest) GetOps() []*TxnOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+// txn_id is set when the transaction spanned several servers and went
+// through two-phase commit.
+type TransactResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Committed     bool                   `protobuf:"varint,1,opt,name=committed,proto3" json:"committed,omitempty"`
+	TxnId         string                 `protobuf:"bytes,2,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactResponse) Reset() {
+	*x = TransactResponse{}
+	mi := &file_db_manager_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactResponse) ProtoMessage() {}
+
+func (x *TransactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactResponse.ProtoReflect.Descriptor instead.
+func (*TransactResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TransactResponse) GetCommitted() bool {
+	if x != nil {
+		return x.Committed
+	}
+	return false
+}
+
+func (x *TransactResponse) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+// BackupRequest snapshots every server into a new backup under dir, a
+// directory on the manager's host inside its configured backup directory,
+// or that directory itself when empty. A relative dir is taken from the
+// configured directory and one outside it fails with INVALID_ARGUMENT. An incremental backup holds only the changes since
+// the latest backup in that directory.
+type BackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dir           string                 `protobuf:"bytes,1,opt,name=dir,proto3" json:"dir,omitempty"`
+	Incremental   bool                   `protobuf:"varint,2,opt,name=incremental,proto3" json:"incremental,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_db_manager_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BackupRequest) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *BackupRequest) GetIncremental() bool {
+	if x != nil {
+		return x.Incremental
+	}
+	return false
+}
+
+// BackupResponse names the backup written to path and, for an incremental
+// backup, the backup it builds on.
+type BackupResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Incremental   bool                   `protobuf:"varint,3,opt,name=incremental,proto3" json:"incremental,omitempty"`
+	Base          string                 `protobuf:"bytes,4,opt,name=base,proto3" json:"base,omitempty"`
+	Servers       uint32                 `protobuf:"varint,5,opt,name=servers,proto3" json:"servers,omitempty"`
+	Bytes         uint64                 `protobuf:"varint,6,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupResponse) Reset() {
+	*x = BackupResponse{}
+	mi := &file_db_manager_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupResponse) ProtoMessage() {}
+
+func (x *BackupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupResponse.ProtoReflect.Descriptor instead.
+func (*BackupResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *BackupResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BackupResponse) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *BackupResponse) GetIncremental() bool {
+	if x != nil {
+		return x.Incremental
+	}
+	return false
+}
+
+func (x *BackupResponse) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *BackupResponse) GetServers() uint32 {
+	if x != nil {
+		return x.Servers
+	}
+	return 0
+}
+
+func (x *BackupResponse) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+// RestoreRequest replays backup_id from dir, confined like
+// BackupRequest.dir, or the latest backup when backup_id is empty, into the
+// current cluster, writing every key to its replicas under the current ring. An incremental backup is replayed after
+// the backups it builds on.
+type RestoreRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dir           string                 `protobuf:"bytes,1,opt,name=dir,proto3" json:"dir,omitempty"`
+	BackupId      string                 `protobuf:"bytes,2,opt,name=backup_id,json=backupId,proto3" json:"backup_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreRequest) Reset() {
+	*x = RestoreRequest{}
+	mi := &file_db_manager_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreRequest) ProtoMessage() {}
+
+func (x *RestoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreRequest.ProtoReflect.Descriptor instead.
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RestoreRequest) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetBackupId() string {
+	if x != nil {
+		return x.BackupId
+	}
+	return ""
+}
+
+// RestoreProgress is sent after every batch with running totals; failures
+// lists the keys that failed since the previous message. The last message
+// has done set. from_replicas counts the keys restored from a replica copy
+// because their primary's snapshot lacked them.
+type RestoreProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BackupId      string                 `protobuf:"bytes,1,opt,name=backup_id,json=backupId,proto3" json:"backup_id,omitempty"`
+	Server        string                 `protobuf:"bytes,2,opt,name=server,proto3" json:"server,omitempty"`
+	Restored      uint64                 `protobuf:"varint,3,opt,name=restored,proto3" json:"restored,omitempty"`
+	Deleted       uint64                 `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Skipped       uint64                 `protobuf:"varint,5,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed        uint64                 `protobuf:"varint,6,opt,name=failed,proto3" json:"failed,omitempty"`
+	Failures      []*KeyResult           `protobuf:"bytes,7,rep,name=failures,proto3" json:"failures,omitempty"`
+	Done          bool                   `protobuf:"varint,8,opt,name=done,proto3" json:"done,omitempty"`
+	FromReplicas  uint64                 `protobuf:"varint,9,opt,name=from_replicas,json=fromReplicas,proto3" json:"from_replicas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreProgress) Reset() {
+	*x = RestoreProgress{}
+	mi := &file_db_manager_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreProgress) ProtoMessage() {}
+
+func (x *RestoreProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreProgress.ProtoReflect.Descriptor instead.
+func (*RestoreProgress) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RestoreProgress) GetBackupId() string {
+	if x != nil {
+		return x.BackupId
+	}
+	return ""
+}
+
+func (x *RestoreProgress) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *RestoreProgress) GetRestored() uint64 {
+	if x != nil {
+		return x.Restored
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetDeleted() uint64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetSkipped() uint64 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetFailed() uint64 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetFailures() []*KeyResult {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+func (x *RestoreProgress) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *RestoreProgress) GetFromReplicas() uint64 {
+	if x != nil {
+		return x.FromReplicas
+	}
+	return 0
+}
+
+// WatchRequest follows changes to key, or to every key starting with prefix.
+// A key watch can start after from_version, a version returned by Get with
+// with_version. Any watch resumes where it left off when resume_token is set
+// to the token of the last event received. The stream is ended with
+// UNAVAILABLE when the cluster topology changes, and should then be resumed.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	FromVersion   uint64                 `protobuf:"varint,3,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,4,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_db_manager_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetFromVersion() uint64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+// WatchEvent is a set of value, or a delete when deleted is true. Each change
+// is reported once, from the key's primary, whose version it carries. A gap
+// event carries no change: it reports that the resumed watch may have missed
+// changes made after version on server, the UUID of a db_server.
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Deleted       bool                   `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Version       uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	ResumeToken   string                 `protobuf:"bytes,6,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	Gap           bool                   `protobuf:"varint,7,opt,name=gap,proto3" json:"gap,omitempty"`
+	Server        string                 `protobuf:"bytes,8,opt,name=server,proto3" json:"server,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_db_manager_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WatchEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *WatchEvent) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetGap() bool {
+	if x != nil {
+		return x.Gap
+	}
+	return false
+}
+
+func (x *WatchEvent) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+type TTLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TTLRequest) Reset() {
+	*x = TTLRequest{}
+	mi := &file_db_manager_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLRequest) ProtoMessage() {}
+
+func (x *TTLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLRequest.ProtoReflect.Descriptor instead.
+func (*TTLRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *TTLRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// has_ttl is false for keys that never expire. ttl_seconds is the remaining
+// lifetime, rounded up.
+type TTLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HasTtl        bool                   `protobuf:"varint,1,opt,name=has_ttl,json=hasTtl,proto3" json:"has_ttl,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TTLResponse) Reset() {
+	*x = TTLResponse{}
+	mi := &file_db_manager_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLResponse) ProtoMessage() {}
+
+func (x *TTLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLResponse.ProtoReflect.Descriptor instead.
+func (*TTLResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *TTLResponse) GetHasTtl() bool {
+	if x != nil {
+		return x.HasTtl
+	}
+	return false
+}
+
+func (x *TTLResponse) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type TopologyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopologyRequest) Reset() {
+	*x = TopologyRequest{}
+	mi := &file_db_manager_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopologyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopologyRequest) ProtoMessage() {}
+
+func (x *TopologyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopologyRequest.ProtoReflect.Descriptor instead.
+func (*TopologyRequest) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{34}
+}
+
+// Node is a ring member. position is the node's hash on the ring; keys are
+// placed on the first node whose position is >= crc32(key), wrapping around.
+type Node struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Region        string                 `protobuf:"bytes,2,opt,name=region,proto3" json:"region,omitempty"`
+	Addr          string                 `protobuf:"bytes,3,opt,name=addr,proto3" json:"addr,omitempty"`
+	Position      uint32                 `protobuf:"varint,4,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Node) Reset() {
+	*x = Node{}
+	mi := &file_db_manager_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Node) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Node) ProtoMessage() {}
+
+func (x *Node) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Node.ProtoReflect.Descriptor instead.
+func (*Node) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Node) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Node) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Node) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Node) GetPosition() uint32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+// write_acks is how many replicas must accept a write before it succeeds,
+// capped at the replicas a key has. Clients writing to replicas directly must
+// honor it; zero, from managers that predate it, means one.
+type TopologyResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Epoch             uint64                 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	ReplicationFactor uint32                 `protobuf:"varint,2,opt,name=replication_factor,json=replicationFactor,proto3" json:"replication_factor,omitempty"`
+	Nodes             []*Node                `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Partitioning      Partitioning           `protobuf:"varint,4,opt,name=partitioning,proto3,enum=db_manager.Partitioning" json:"partitioning,omitempty"`
+	WriteAcks         uint32                 `protobuf:"varint,5,opt,name=write_acks,json=writeAcks,proto3" json:"write_acks,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *TopologyResponse) Reset() {
+	*x = TopologyResponse{}
+	mi := &file_db_manager_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopologyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopologyResponse) ProtoMessage() {}
+
+func (x *TopologyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_manager_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopologyResponse.ProtoReflect.Descriptor instead.
+func (*TopologyResponse) Descriptor() ([]byte, []int) {
+	return file_db_manager_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *TopologyResponse) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *TopologyResponse) GetReplicationFactor() uint32 {
+	if x != nil {
+		return x.ReplicationFactor
+	}
+	return 0
+}
+
+func (x *TopologyResponse) GetNodes() []*Node {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *TopologyResponse) GetPartitioning() Partitioning {
+	if x != nil {
+		return x.Partitioning
+	}
+	return Partitioning_HASH
+}
+
+func (x *TopologyResponse) GetWriteAcks() uint32 {
+	if x != nil {
+		return x.WriteAcks
+	}
+	return 0
+}
+
+var File_db_manager_proto protoreflect.FileDescriptor
+
+const file_db_manager_proto_rawDesc = "" +
+	"\n" +
+	"\x10db_manager.proto\x12\n" +
+	"db_manager\"z\n" +
+	"\n" +
+	"SetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vvalue_bytes\x18\x04 \x01(\fR\n" +
+	"valueBytes\"'\n" +
+	"\vSetResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"A\n" +
+	"\n" +
+	"GetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12!\n" +
+	"\fwith_version\x18\x02 \x01(\bR\vwithVersion\"b\n" +
+	"\vGetResponse\x12\x18\n" +
+	"\x05value\x18\x01 \x01(\tB\x02\x18\x01R\x05value\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x1f\n" +
+	"\vvalue_bytes\x18\x03 \x01(\fR\n" +
+	"valueBytes\"!\n" +
+	"\rDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"*\n" +
+	"\x0eDeleteResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xd2\x01\n" +
+	"\x15ConditionalSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1d\n" +
+	"\tif_absent\x18\x04 \x01(\bH\x00R\bifAbsent\x12\x1f\n" +
+	"\n" +
+	"if_version\x18\x05 \x01(\x04H\x00R\tifVersion\x12\x1f\n" +
+	"\vvalue_bytes\x18\x06 \x01(\fR\n" +
+	"valueBytesB\v\n" +
+	"\tcondition\"2\n" +
+	"\x16ConditionalSetResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"q\n" +
+	"\x18ConditionalDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1d\n" +
+	"\bif_value\x18\x02 \x01(\tB\x02\x18\x01R\aifValue\x12$\n" +
+	"\x0eif_value_bytes\x18\x03 \x01(\fR\fifValueBytes\"5\n" +
+	"\x19ConditionalDeleteResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\":\n" +
+	"\x10IncrementRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\")\n" +
+	"\x11IncrementResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x03R\x05value\"%\n" +
+	"\x0fBatchGetRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\"\x89\x01\n" +
+	"\x0eBatchGetResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x18\n" +
+	"\x05value\x18\x03 \x01(\tB\x02\x18\x01R\x05value\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1f\n" +
+	"\vvalue_bytes\x18\x05 \x01(\fR\n" +
+	"valueBytes\"H\n" +
+	"\x10BatchGetResponse\x124\n" +
+	"\aresults\x18\x01 \x03(\v2\x1a.db_manager.BatchGetResultR\aresults\"\x96\x01\n" +
+	"\fKeyValuePair\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vvalue_bytes\x18\x04 \x01(\fR\n" +
+	"valueBytes\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\"A\n" +
+	"\x0fBatchSetRequest\x12.\n" +
+	"\x05pairs\x18\x01 \x03(\v2\x18.db_manager.KeyValuePairR\x05pairs\"M\n" +
+	"\tKeyResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"C\n" +
+	"\x10BatchSetResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.db_manager.KeyResultR\aresults\"(\n" +
+	"\x12BatchDeleteRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\"F\n" +
+	"\x13BatchDeleteResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.db_manager.KeyResultR\aresults\"{\n" +
+	"\vScanRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\tR\x03end\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x05 \x01(\tR\x06cursor\"_\n" +
+	"\fScanResponse\x12.\n" +
+	"\x05pairs\x18\x01 \x03(\v2\x18.db_manager.KeyValuePairR\x05pairs\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"h\n" +
+	"\x05TxnOp\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x16\n" +
+	"\x06delete\x18\x04 \x01(\bR\x06delete\"6\n" +
+	"\x0fTransactRequest\x12#\n" +
+	"\x03ops\x18\x01 \x03(\v2\x11.db_manager.TxnOpR\x03ops\"G\n" +
+	"\x10TransactResponse\x12\x1c\n" +
+	"\tcommitted\x18\x01 \x01(\bR\tcommitted\x12\x15\n" +
+	"\x06txn_id\x18\x02 \x01(\tR\x05txnId\"C\n" +
+	"\rBackupRequest\x12\x10\n" +
+	"\x03dir\x18\x01 \x01(\tR\x03dir\x12 \n" +
+	"\vincremental\x18\x02 \x01(\bR\vincremental\"\x9a\x01\n" +
+	"\x0eBackupResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12 \n" +
+	"\vincremental\x18\x03 \x01(\bR\vincremental\x12\x12\n" +
+	"\x04base\x18\x04 \x01(\tR\x04base\x12\x18\n" +
+	"\aservers\x18\x05 \x01(\rR\aservers\x12\x14\n" +
+	"\x05bytes\x18\x06 \x01(\x04R\x05bytes\"?\n" +
+	"\x0eRestoreRequest\x12\x10\n" +
+	"\x03dir\x18\x01 \x01(\tR\x03dir\x12\x1b\n" +
+	"\tbackup_id\x18\x02 \x01(\tR\bbackupId\"\x9a\x02\n" +
+	"\x0fRestoreProgress\x12\x1b\n" +
+	"\tbackup_id\x18\x01 \x01(\tR\bbackupId\x12\x16\n" +
+	"\x06server\x18\x02 \x01(\tR\x06server\x12\x1a\n" +
+	"\brestored\x18\x03 \x01(\x04R\brestored\x12\x18\n" +
+	"\adeleted\x18\x04 \x01(\x04R\adeleted\x12\x18\n" +
+	"\askipped\x18\x05 \x01(\x04R\askipped\x12\x16\n" +
+	"\x06failed\x18\x06 \x01(\x04R\x06failed\x121\n" +
+	"\bfailures\x18\a \x03(\v2\x15.db_manager.KeyResultR\bfailures\x12\x12\n" +
+	"\x04done\x18\b \x01(\bR\x04done\x12#\n" +
+	"\rfrom_replicas\x18\t \x01(\x04R\ffromReplicas\"~\n" +
+	"\fWatchRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12!\n" +
+	"\ffrom_version\x18\x03 \x01(\x04R\vfromVersion\x12!\n" +
+	"\fresume_token\x18\x04 \x01(\tR\vresumeToken\"\xd6\x01\n" +
+	"\n" +
+	"WatchEvent\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x18\n" +
+	"\adeleted\x18\x03 \x01(\bR\adeleted\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x04R\n" +
+	"ttlSeconds\x12!\n" +
+	"\fresume_token\x18\x06 \x01(\tR\vresumeToken\x12\x10\n" +
+	"\x03gap\x18\a \x01(\bR\x03gap\x12\x16\n" +
+	"\x06server\x18\b \x01(\tR\x06server\"\x1e\n" +
+	"\n" +
+	"TTLRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"G\n" +
+	"\vTTLResponse\x12\x17\n" +
+	"\ahas_ttl\x18\x01 \x01(\bR\x06hasTtl\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x04R\n" +
+	"ttlSeconds\"\x11\n" +
+	"\x0fTopologyRequest\"b\n" +
+	"\x04Node\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x16\n" +
+	"\x06region\x18\x02 \x01(\tR\x06region\x12\x12\n" +
+	"\x04addr\x18\x03 \x01(\tR\x04addr\x12\x1a\n" +
+	"\bposition\x18\x04 \x01(\rR\bposition\"\xdc\x01\n" +
+	"\x10TopologyResponse\x12\x14\n" +
+	"\x05epoch\x18\x01 \x01(\x04R\x05epoch\x12-\n" +
+	"\x12replication_factor\x18\x02 \x01(\rR\x11replicationFactor\x12&\n" +
+	"\x05nodes\x18\x03 \x03(\v2\x10.db_manager.NodeR\x05nodes\x12<\n" +
+	"\fpartitioning\x18\x04 \x01(\x0e2\x18.db_manager.PartitioningR\fpartitioning\x12\x1d\n" +
+	"\n" +
+	"write_acks\x18\x05 \x01(\rR\twriteAcks*#\n" +
+	"\fPartitioning\x12\b\n" +
+	"\x04HASH\x10\x00\x12\t\n" +
+	"\x05RANGE\x10\x012\xe4\b\n" +
+	"\tDBManager\x126\n" +
+	"\x03Set\x12\x16.db_manager.SetRequest\x1a\x17.db_manager.SetResponse\x126\n" +
+	"\x03Get\x12\x16.db_manager.GetRequest\x1a\x17.db_manager.GetResponse\x12?\n" +
+	"\x06Delete\x12\x19.db_manager.DeleteRequest\x1a\x1a.db_manager.DeleteResponse\x12E\n" +
+	"\bTopology\x12\x1b.db_manager.TopologyRequest\x1a\x1c.db_manager.TopologyResponse\x126\n" +
+	"\x03TTL\x12\x16.db_manager.TTLRequest\x1a\x17.db_manager.TTLResponse\x12W\n" +
+	"\x0eConditionalSet\x12!.db_manager.ConditionalSetRequest\x1a\".db_manager.ConditionalSetResponse\x12`\n" +
+	"\x11ConditionalDelete\x12$.db_manager.ConditionalDeleteRequest\x1a%.db_manager.ConditionalDeleteResponse\x12H\n" +
+	"\tIncrement\x12\x1c.db_manager.IncrementRequest\x1a\x1d.db_manager.IncrementResponse\x12E\n" +
+	"\bBatchGet\x12\x1b.db_manager.BatchGetRequest\x1a\x1c.db_manager.BatchGetResponse\x12E\n" +
+	"\bBatchSet\x12\x1b.db_manager.BatchSetRequest\x1a\x1c.db_manager.BatchSetResponse\x12N\n" +
+	"\vBatchDelete\x12\x1e.db_manager.BatchDeleteRequest\x1a\x1f.db_manager.BatchDeleteResponse\x129\n" +
+	"\x04Scan\x12\x17.db_manager.ScanRequest\x1a\x18.db_manager.ScanResponse\x12E\n" +
+	"\bTransact\x12\x1b.db_manager.TransactRequest\x1a\x1c.db_manager.TransactResponse\x12;\n" +
+	"\x05Watch\x12\x18.db_manager.WatchRequest\x1a\x16.db_manager.WatchEvent0\x01\x12?\n" +
+	"\x06Backup\x12\x19.db_manager.BackupRequest\x1a\x1a.db_manager.BackupResponse\x12D\n" +
+	"\aRestore\x12\x1a.db_manager.RestoreRequest\x1a\x1b.db_manager.RestoreProgress0\x01B-Z+github.com/arbhalerao/meerkat/pb/db_managerb\x06proto3"
+
+var (
+	file_db_manager_proto_rawDescOnce sync.Once
+	file_db_manager_proto_rawDescData []byte
+)
+
+func file_db_manager_proto_rawDescGZIP() []byte {
+	file_db_manager_proto_rawDescOnce.Do(func() {
+		file_db_manager_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_db_manager_proto_rawDesc), len(file_db_manager_proto_rawDesc)))
+	})
+	return file_db_manager_proto_rawDescData
+}
+
+var file_db_manager_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_db_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
+var file_db_manager_proto_goTypes = []any{
+	(Partitioning)(0),                 // 0: db_manager.Partitioning
+	(*SetRequest)(nil),                // 1: db_manager.SetRequest
+	(*SetResponse)(nil),               // 2: db_manager.SetResponse
+	(*GetRequest)(nil),                // 3: db_manager.GetRequest
+	(*GetResponse)(nil),               // 4: db_manager.GetResponse
+	(*DeleteRequest)(nil),             // 5: db_manager.DeleteRequest
+	(*DeleteResponse)(nil),            // 6: db_manager.DeleteResponse
+	(*ConditionalSetRequest)(nil),     // 7: db_manager.ConditionalSetRequest
+	(*ConditionalSetResponse)(nil),    // 8: db_manager.ConditionalSetResponse
+	(*ConditionalDeleteRequest)(nil),  // 9: db_manager.ConditionalDeleteRequest
+	(*ConditionalDeleteResponse)(nil), // 10: db_manager.ConditionalDeleteResponse
+	(*IncrementRequest)(nil),          // 11: db_manager.IncrementRequest
+	(*IncrementResponse)(nil),         // 12: db_manager.IncrementResponse
+	(*BatchGetRequest)(nil),           // 13: db_manager.BatchGetRequest
+	(*BatchGetResult)(nil),            // 14: db_manager.BatchGetResult
+	(*BatchGetResponse)(nil),          // 15: db_manager.BatchGetResponse
+	(*KeyValuePair)(nil),              // 16: db_manager.KeyValuePair
+	(*BatchSetRequest)(nil),           // 17: db_manager.BatchSetRequest
+	(*KeyResult)(nil),                 // 18: db_manager.KeyResult
+	(*BatchSetResponse)(nil),          // 19: db_manager.BatchSetResponse
+	(*BatchDeleteRequest)(nil),        // 20: db_manager.BatchDeleteRequest
+	(*BatchDeleteResponse)(nil),       // 21: db_manager.BatchDeleteResponse
+	(*ScanRequest)(nil),               // 22: db_manager.ScanRequest
+	(*ScanResponse)(nil),              // 23: db_manager.ScanResponse
+	(*TxnOp)(nil),                     // 24: db_manager.TxnOp
+	(*TransactRequest)(nil),           // 25: db_manager.TransactRequest
+	(*TransactResponse)(nil),          // 26: db_manager.TransactResponse
+	(*BackupRequest)(nil),             // 27: db_manager.BackupRequest
+	(*BackupResponse)(nil),            // 28: db_manager.BackupResponse
+	(*RestoreRequest)(nil),            // 29: db_manager.RestoreRequest
+	(*RestoreProgress)(nil),           // 30: db_manager.RestoreProgress
+	(*WatchRequest)(nil),              // 31: db_manager.WatchRequest
+	(*WatchEvent)(nil),                // 32: db_manager.WatchEvent
+	(*TTLRequest)(nil),                // 33: db_manager.TTLRequest
+	(*TTLResponse)(nil),               // 34: db_manager.TTLResponse
+	(*TopologyRequest)(nil),           // 35: db_manager.TopologyRequest
+	(*Node)(nil),                      // 36: db_manager.Node
+	(*TopologyResponse)(nil),          // 37: db_manager.TopologyResponse
+}
+var file_db_manager_proto_depIdxs = []int32{
+	14, // 0: db_manager.BatchGetResponse.results:type_name -> db_manager.BatchGetResult
+	16, // 1: db_manager.BatchSetRequest.pairs:type_name -> db_manager.KeyValuePair
+	18, // 2: db_manager.BatchSetResponse.results:type_name -> db_manager.KeyResult
+	18, // 3: db_manager.BatchDeleteResponse.results:type_name -> db_manager.KeyResult
+	16, // 4: db_manager.ScanResponse.pairs:type_name -> db_manager.KeyValuePair
+	24, // 5: db_manager.TransactRequest.ops:type_name -> db_manager.TxnOp
+	18, // 6: db_manager.RestoreProgress.failures:type_name -> db_manager.KeyResult
+	36, // 7: db_manager.TopologyResponse.nodes:type_name -> db_manager.Node
+	0,  // 8: db_manager.TopologyResponse.partitioning:type_name -> db_manager.Partitioning
+	1,  // 9: db_manager.DBManager.Set:input_type -> db_manager.SetRequest
+	3,  // 10: db_manager.DBManager.Get:input_type -> db_manager.GetRequest
+	5,  // 11: db_manager.DBManager.Delete:input_type -> db_manager.DeleteRequest
+	35, // 12: db_manager.DBManager.Topology:input_type -> db_manager.TopologyRequest
+	33, // 13: db_manager.DBManager.TTL:input_type -> db_manager.TTLRequest
+	7,  // 14: db_manager.DBManager.ConditionalSet:input_type -> db_manager.ConditionalSetRequest
+	9,  // 15: db_manager.DBManager.ConditionalDelete:input_type -> db_manager.ConditionalDeleteRequest
+	11, // 16: db_manager.DBManager.Increment:input_type -> db_manager.IncrementRequest
+	13, // 17: db_manager.DBManager.BatchGet:input_type -> db_manager.BatchGetRequest
+	17, // 18: db_manager.DBManager.BatchSet:input_type -> db_manager.BatchSetRequest
+	20, // 19: db_manager.DBManager.BatchDelete:input_type -> db_manager.BatchDeleteRequest
+	22, // 20: db_manager.DBManager.Scan:input_type -> db_manager.ScanRequest
+	25, // 21: db_manager.DBManager.Transact:input_type -> db_manager.TransactRequest
+	31, // 22: db_manager.DBManager.Watch:input_type -> db_manager.WatchRequest
+	27, // 23: db_manager.DBManager.Backup:input_type -> db_manager.BackupRequest
+	29, // 24: db_manager.DBManager.Restore:input_type -> db_manager.RestoreRequest
+	2,  // 25: db_manager.DBManager.Set:output_type -> db_manager.SetResponse
+	4,  // 26: db_manager.DBManager.Get:output_type -> db_manager.GetResponse
+	6,  // 27: db_manager.DBManager.Delete:output_type -> db_manager.DeleteResponse
+	37, // 28: db_manager.DBManager.Topology:output_type -> db_manager.TopologyResponse
+	34, // 29: db_manager.DBManager.TTL:output_type -> db_manager.TTLResponse
+	8,  // 30: db_manager.DBManager.ConditionalSet:output_type -> db_manager.ConditionalSetResponse
+	10, // 31: db_manager.DBManager.ConditionalDelete:output_type -> db_manager.ConditionalDeleteResponse
+	12, // 32: db_manager.DBManager.Increment:output_type -> db_manager.IncrementResponse
+	15, // 33: db_manager.DBManager.BatchGet:output_type -> db_manager.BatchGetResponse
+	19, // 34: db_manager.DBManager.BatchSet:output_type -> db_manager.BatchSetResponse
+	21, // 35: db_manager.DBManager.BatchDelete:output_type -> db_manager.BatchDeleteResponse
+	23, // 36: db_manager.DBManager.Scan:output_type -> db_manager.ScanResponse
+	26, // 37: db_manager.DBManager.Transact:output_type -> db_manager.TransactResponse
+	32, // 38: db_manager.DBManager.Watch:output_type -> db_manager.WatchEvent
+	28, // 39: db_manager.DBManager.Backup:output_type -> db_manager.BackupResponse
+	30, // 40: db_manager.DBManager.Restore:output_type -> db_manager.RestoreProgress
+	25, // [25:41] is the sub-list for method output_type
+	9,  // [9:25] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_db_manager_proto_init() }
+func file_db_manager_proto_init() {
+	if File_db_manager_proto != nil {
+		return
+	}
+	file_db_manager_proto_msgTypes[6].OneofWrappers = []any{
+		(*ConditionalSetRequest_IfAbsent)(nil),
+		(*ConditionalSetRequest_IfVersion)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_db_manager_proto_rawDesc), len(file_db_manager_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      1,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_db_manager_proto_goTypes,
 		DependencyIndexes: file_db_manager_proto_depIdxs,
+		EnumInfos:         file_db_manager_proto_enumTypes,
 		MessageInfos:      file_db_manager_proto_msgTypes,
 	}.Build()
 	File_db_manager_proto = out.File
@@ -21,10 +21,75 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type FencingReason int32
+
+const (
+	FencingReason_FENCING_REASON_UNSPECIFIED FencingReason = 0
+	FencingReason_STALE_EPOCH                FencingReason = 1
+	FencingReason_WRONG_OWNER                FencingReason = 2
+)
+
+// Enum value maps for FencingReason.
+var (
+	FencingReason_name = map[int32]string{
+		0: "FENCING_REASON_UNSPECIFIED",
+		1: "STALE_EPOCH",
+		2: "WRONG_OWNER",
+	}
+	FencingReason_value = map[string]int32{
+		"FENCING_REASON_UNSPECIFIED": 0,
+		"STALE_EPOCH":                1,
+		"WRONG_OWNER":                2,
+	}
+)
+
+func (x FencingReason) Enum() *FencingReason {
+	p := new(FencingReason)
+	*p = x
+	return p
+}
+
+func (x FencingReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FencingReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_db_server_proto_enumTypes[0].Descriptor()
+}
+
+func (FencingReason) Type() protoreflect.EnumType {
+	return &file_db_server_proto_enumTypes[0]
+}
+
+func (x FencingReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FencingReason.Descriptor instead.
+func (FencingReason) EnumDescriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{0}
+}
+
+// epoch is the ring epoch the caller routed the request on. Zero means the
+// caller is not routing on the ring (key migration, local tools) and skips
+// fencing.
+//
+// ttl_seconds makes the key expire that long after the write; zero keeps it
+// until it is deleted.
+//
+// A non-zero primary_order marks the write as a copy of one the key's
+// primary made, with the order the primary returned for it. The copy is
+// dropped, successfully, if the server already applied a copy of a later
+// write, ordered by epoch and then primary_order.
 type SetRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	Value         string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Epoch         uint64 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	TtlSeconds    uint64 `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,5,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	PrimaryOrder  uint64 `protobuf:"varint,6,opt,name=primary_order,json=primaryOrder,proto3" json:"primary_order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -66,6 +131,7 @@ func (x *SetRequest) GetKey() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in db_server.proto.
 func (x *SetRequest) GetValue() string {
 	if x != nil {
 		return x.Value
@@ -73,6 +139,34 @@ func (x *SetRequest) GetValue() string {
 	return ""
 }
 
+func (x *SetRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *SetRequest) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *SetRequest) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+func (x *SetRequest) GetPrimaryOrder() uint64 {
+	if x != nil {
+		return x.PrimaryOrder
+	}
+	return 0
+}
+
 type SetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -120,6 +214,7 @@ func (x *SetResponse) GetSuccess() bool {
 type GetRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -161,9 +256,21 @@ func (x *GetRequest) GetKey() string {
 	return ""
 }
 
+func (x *GetRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// version changes on every write to the key. Versions are local to each
+// server, so only the server that issued one can check it.
 type GetResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	Value         string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Version       uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,3,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -198,6 +305,7 @@ func (*GetResponse) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{3}
 }
 
+// Deprecated: Marked as deprecated in db_server.proto.
 func (x *GetResponse) GetValue() string {
 	if x != nil {
 		return x.Value
@@ -205,9 +313,26 @@ func (x *GetResponse) GetValue() string {
 	return ""
 }
 
+func (x *GetResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetResponse) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+// primary_order is as in SetRequest.
 type DeleteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	PrimaryOrder  uint64                 `protobuf:"varint,3,opt,name=primary_order,json=primaryOrder,proto3" json:"primary_order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -249,6 +374,20 @@ func (x *DeleteRequest) GetKey() string {
 	return ""
 }
 
+func (x *DeleteRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *DeleteRequest) GetPrimaryOrder() uint64 {
+	if x != nil {
+		return x.PrimaryOrder
+	}
+	return 0
+}
+
 type DeleteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -293,26 +432,39 @@ func (x *DeleteResponse) GetSuccess() bool {
 	return false
 }
 
-type HealthCheckRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+// ConditionalSetRequest writes the key only if the condition holds. A failed
+// condition is answered with ABORTED.
+type ConditionalSetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	Value      string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Epoch      uint64 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	TtlSeconds uint64 `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// Types that are valid to be assigned to Condition:
+	//
+	//	*ConditionalSetRequest_IfAbsent
+	//	*ConditionalSetRequest_IfVersion
+	Condition     isConditionalSetRequest_Condition `protobuf_oneof:"condition"`
+	ValueBytes    []byte                            `protobuf:"bytes,7,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
+func (x *ConditionalSetRequest) Reset() {
+	*x = ConditionalSetRequest{}
 	mi := &file_db_server_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *ConditionalSetRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*ConditionalSetRequest) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+func (x *ConditionalSetRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_db_server_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -324,32 +476,115 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConditionalSetRequest.ProtoReflect.Descriptor instead.
+func (*ConditionalSetRequest) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{6}
 }
 
-type HealthCheckResponse struct {
+func (x *ConditionalSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in db_server.proto.
+func (x *ConditionalSetRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ConditionalSetRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *ConditionalSetRequest) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *ConditionalSetRequest) GetCondition() isConditionalSetRequest_Condition {
+	if x != nil {
+		return x.Condition
+	}
+	return nil
+}
+
+func (x *ConditionalSetRequest) GetIfAbsent() bool {
+	if x != nil {
+		if x, ok := x.Condition.(*ConditionalSetRequest_IfAbsent); ok {
+			return x.IfAbsent
+		}
+	}
+	return false
+}
+
+func (x *ConditionalSetRequest) GetIfVersion() uint64 {
+	if x != nil {
+		if x, ok := x.Condition.(*ConditionalSetRequest_IfVersion); ok {
+			return x.IfVersion
+		}
+	}
+	return 0
+}
+
+func (x *ConditionalSetRequest) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+type isConditionalSetRequest_Condition interface {
+	isConditionalSetRequest_Condition()
+}
+
+type ConditionalSetRequest_IfAbsent struct {
+	// if_absent requires the key not to exist.
+	IfAbsent bool `protobuf:"varint,5,opt,name=if_absent,json=ifAbsent,proto3,oneof"`
+}
+
+type ConditionalSetRequest_IfVersion struct {
+	// if_version requires the key to be at this version.
+	IfVersion uint64 `protobuf:"varint,6,opt,name=if_version,json=ifVersion,proto3,oneof"`
+}
+
+func (*ConditionalSetRequest_IfAbsent) isConditionalSetRequest_Condition() {}
+
+func (*ConditionalSetRequest_IfVersion) isConditionalSetRequest_Condition() {}
+
+// order places the write among the writes this server checks the key
+// for; a later one has a higher order. Replicas are sent it as
+// primary_order with the copy of the write.
+type ConditionalSetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Order         uint64                 `protobuf:"varint,2,opt,name=order,proto3" json:"order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
+func (x *ConditionalSetResponse) Reset() {
+	*x = ConditionalSetResponse{}
 	mi := &file_db_server_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *ConditionalSetResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*ConditionalSetResponse) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+func (x *ConditionalSetResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_db_server_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -361,38 +596,52 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConditionalSetResponse.ProtoReflect.Descriptor instead.
+func (*ConditionalSetResponse) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *HealthCheckResponse) GetHealthy() bool {
+func (x *ConditionalSetResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Healthy
+		return x.Success
 	}
 	return false
 }
 
-type ListKeysRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *ConditionalSetResponse) GetOrder() uint64 {
+	if x != nil {
+		return x.Order
+	}
+	return 0
+}
+
+// ConditionalDeleteRequest deletes the key only if it holds if_value. A
+// failed condition is answered with ABORTED.
+type ConditionalDeleteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Epoch uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	IfValue       string `protobuf:"bytes,3,opt,name=if_value,json=ifValue,proto3" json:"if_value,omitempty"`
+	IfValueBytes  []byte `protobuf:"bytes,4,opt,name=if_value_bytes,json=ifValueBytes,proto3" json:"if_value_bytes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListKeysRequest) Reset() {
-	*x = ListKeysRequest{}
+func (x *ConditionalDeleteRequest) Reset() {
+	*x = ConditionalDeleteRequest{}
 	mi := &file_db_server_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListKeysRequest) String() string {
+func (x *ConditionalDeleteRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListKeysRequest) ProtoMessage() {}
+func (*ConditionalDeleteRequest) ProtoMessage() {}
 
-func (x *ListKeysRequest) ProtoReflect() protoreflect.Message {
+func (x *ConditionalDeleteRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_db_server_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -404,33 +653,63 @@ func (x *ListKeysRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListKeysRequest.ProtoReflect.Descriptor instead.
-func (*ListKeysRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConditionalDeleteRequest.ProtoReflect.Descriptor instead.
+func (*ConditionalDeleteRequest) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{8}
 }
 
-type KeyValuePair struct {
+func (x *ConditionalDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ConditionalDeleteRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in db_server.proto.
+func (x *ConditionalDeleteRequest) GetIfValue() string {
+	if x != nil {
+		return x.IfValue
+	}
+	return ""
+}
+
+func (x *ConditionalDeleteRequest) GetIfValueBytes() []byte {
+	if x != nil {
+		return x.IfValueBytes
+	}
+	return nil
+}
+
+// order is as in ConditionalSetResponse.
+type ConditionalDeleteResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Order         uint64                 `protobuf:"varint,2,opt,name=order,proto3" json:"order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *KeyValuePair) Reset() {
-	*x = KeyValuePair{}
+func (x *ConditionalDeleteResponse) Reset() {
+	*x = ConditionalDeleteResponse{}
 	mi := &file_db_server_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *KeyValuePair) String() string {
+func (x *ConditionalDeleteResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*KeyValuePair) ProtoMessage() {}
+func (*ConditionalDeleteResponse) ProtoMessage() {}
 
-func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
+func (x *ConditionalDeleteResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_db_server_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -442,46 +721,51 @@ func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use KeyValuePair.ProtoReflect.Descriptor instead.
-func (*KeyValuePair) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConditionalDeleteResponse.ProtoReflect.Descriptor instead.
+func (*ConditionalDeleteResponse) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *KeyValuePair) GetKey() string {
+func (x *ConditionalDeleteResponse) GetSuccess() bool {
 	if x != nil {
-		return x.Key
+		return x.Success
 	}
-	return ""
+	return false
 }
 
-func (x *KeyValuePair) GetValue() string {
+func (x *ConditionalDeleteResponse) GetOrder() uint64 {
 	if x != nil {
-		return x.Value
+		return x.Order
 	}
-	return ""
+	return 0
 }
 
-type ListKeysResponse struct {
+// IncrementRequest adds delta to the integer stored under key, treating a
+// missing key as zero. A non-integer value is answered with INVALID_ARGUMENT
+// and an overflowing result with OUT_OF_RANGE.
+type IncrementRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListKeysResponse) Reset() {
-	*x = ListKeysResponse{}
+func (x *IncrementRequest) Reset() {
+	*x = IncrementRequest{}
 	mi := &file_db_server_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListKeysResponse) String() string {
+func (x *IncrementRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListKeysResponse) ProtoMessage() {}
+func (*IncrementRequest) ProtoMessage() {}
 
-func (x *ListKeysResponse) ProtoReflect() protoreflect.Message {
+func (x *IncrementRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_db_server_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -493,116 +777,2767 @@ func (x *ListKeysResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListKeysResponse.ProtoReflect.Descriptor instead.
-func (*ListKeysResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use IncrementRequest.ProtoReflect.Descriptor instead.
+func (*IncrementRequest) Descriptor() ([]byte, []int) {
 	return file_db_server_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *ListKeysResponse) GetPairs() []*KeyValuePair {
+func (x *IncrementRequest) GetKey() string {
 	if x != nil {
-		return x.Pairs
+		return x.Key
 	}
-	return nil
+	return ""
 }
 
-var File_db_server_proto protoreflect.FileDescriptor
+func (x *IncrementRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
 
-const file_db_server_proto_rawDesc = "" +
-	"\n" +
-	"\x0fdb_server.proto\x12\tdb_server\"4\n" +
-	"\n" +
-	"SetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"'\n" +
-	"\vSetResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x1e\n" +
-	"\n" +
-	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"#\n" +
-	"\vGetResponse\x12\x14\n" +
-	"\x05value\x18\x01 \x01(\tR\x05value\"!\n" +
-	"\rDeleteRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"*\n" +
-	"\x0eDeleteResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x14\n" +
-	"\x12HealthCheckRequest\"/\n" +
-	"\x13HealthCheckResponse\x12\x18\n" +
-	"\ahealthy\x18\x01 \x01(\bR\ahealthy\"\x11\n" +
-	"\x0fListKeysRequest\"6\n" +
-	"\fKeyValuePair\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\"A\n" +
-	"\x10ListKeysResponse\x12-\n" +
-	"\x05pairs\x18\x01 \x03(\v2\x17.db_server.KeyValuePairR\x05pairs2\xc8\x02\n" +
-	"\bDBServer\x124\n" +
-	"\x03Set\x12\x15.db_server.SetRequest\x1a\x16.db_server.SetResponse\x124\n" +
-	"\x03Get\x12\x15.db_server.GetRequest\x1a\x16.db_server.GetResponse\x12=\n" +
-	"\x06Delete\x12\x18.db_server.DeleteRequest\x1a\x19.db_server.DeleteResponse\x12L\n" +
-	"\vHealthCheck\x12\x1d.db_server.HealthCheckRequest\x1a\x1e.db_server.HealthCheckResponse\x12C\n" +
-	"\bListKeys\x12\x1a.db_server.ListKeysRequest\x1a\x1b.db_server.ListKeysResponseB-Z+github.com/arbhalerao/meerkat/pb/db_serverb\x06proto3"
+func (x *IncrementRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
 
-var (
-	file_db_server_proto_rawDescOnce sync.Once
-	file_db_server_proto_rawDescData []byte
-)
+// ttl_seconds is the key's remaining lifetime, rounded up, or zero if it
+// does not expire, so the new value can be copied to replicas with it.
+// order is as in ConditionalSetResponse.
+type IncrementResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         int64                  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Order         uint64                 `protobuf:"varint,3,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-func file_db_server_proto_rawDescGZIP() []byte {
-	file_db_server_proto_rawDescOnce.Do(func() {
-		file_db_server_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_db_server_proto_rawDesc), len(file_db_server_proto_rawDesc)))
-	})
-	return file_db_server_proto_rawDescData
+func (x *IncrementResponse) Reset() {
+	*x = IncrementResponse{}
+	mi := &file_db_server_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-var file_db_server_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
-var file_db_server_proto_goTypes = []any{
-	(*SetRequest)(nil),          // 0: db_server.SetRequest
-	(*SetResponse)(nil),         // 1: db_server.SetResponse
-	(*GetRequest)(nil),          // 2: db_server.GetRequest
-	(*GetResponse)(nil),         // 3: db_server.GetResponse
-	(*DeleteRequest)(nil),       // 4: db_server.DeleteRequest
-	(*DeleteResponse)(nil),      // 5: db_server.DeleteResponse
-	(*HealthCheckRequest)(nil),  // 6: db_server.HealthCheckRequest
-	(*HealthCheckResponse)(nil), // 7: db_server.HealthCheckResponse
-	(*ListKeysRequest)(nil),     // 8: db_server.ListKeysRequest
-	(*KeyValuePair)(nil),        // 9: db_server.KeyValuePair
-	(*ListKeysResponse)(nil),    // 10: db_server.ListKeysResponse
+func (x *IncrementResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-var file_db_server_proto_depIdxs = []int32{
-	9,  // 0: db_server.ListKeysResponse.pairs:type_name -> db_server.KeyValuePair
-	0,  // 1: db_server.DBServer.Set:input_type -> db_server.SetRequest
-	2,  // 2: db_server.DBServer.Get:input_type -> db_server.GetRequest
-	4,  // 3: db_server.DBServer.Delete:input_type -> db_server.DeleteRequest
-	6,  // 4: db_server.DBServer.HealthCheck:input_type -> db_server.HealthCheckRequest
-	8,  // 5: db_server.DBServer.ListKeys:input_type -> db_server.ListKeysRequest
-	1,  // 6: db_server.DBServer.Set:output_type -> db_server.SetResponse
-	3,  // 7: db_server.DBServer.Get:output_type -> db_server.GetResponse
-	5,  // 8: db_server.DBServer.Delete:output_type -> db_server.DeleteResponse
-	7,  // 9: db_server.DBServer.HealthCheck:output_type -> db_server.HealthCheckResponse
-	10, // 10: db_server.DBServer.ListKeys:output_type -> db_server.ListKeysResponse
-	6,  // [6:11] is the sub-list for method output_type
-	1,  // [1:6] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+
+func (*IncrementResponse) ProtoMessage() {}
+
+func (x *IncrementResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func init() { file_db_server_proto_init() }
-func file_db_server_proto_init() {
+// Deprecated: Use IncrementResponse.ProtoReflect.Descriptor instead.
+func (*IncrementResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IncrementResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *IncrementResponse) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *IncrementResponse) GetOrder() uint64 {
+	if x != nil {
+		return x.Order
+	}
+	return 0
+}
+
+type BatchGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetRequest) Reset() {
+	*x = BatchGetRequest{}
+	mi := &file_db_server_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetRequest) ProtoMessage() {}
+
+func (x *BatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BatchGetRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *BatchGetRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// BatchGetResult reports one key of a BatchGet. A key that does not exist has
+// found unset and no error.
+type BatchGetResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Found bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	Value         string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Error         string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,5,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResult) Reset() {
+	*x = BatchGetResult{}
+	mi := &file_db_server_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResult) ProtoMessage() {}
+
+func (x *BatchGetResult) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResult.ProtoReflect.Descriptor instead.
+func (*BatchGetResult) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *BatchGetResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+// Deprecated: Marked as deprecated in db_server.proto.
+func (x *BatchGetResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BatchGetResult) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+type BatchGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchGetResult      `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetResponse) Reset() {
+	*x = BatchGetResponse{}
+	mi := &file_db_server_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetResponse) ProtoMessage() {}
+
+func (x *BatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchGetResponse) GetResults() []*BatchGetResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSetRequest) Reset() {
+	*x = BatchSetRequest{}
+	mi := &file_db_server_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSetRequest) ProtoMessage() {}
+
+func (x *BatchSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSetRequest.ProtoReflect.Descriptor instead.
+func (*BatchSetRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BatchSetRequest) GetPairs() []*KeyValuePair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *BatchSetRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// KeyResult reports the outcome of a batched write for one key.
+type KeyResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyResult) Reset() {
+	*x = KeyResult{}
+	mi := &file_db_server_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyResult) ProtoMessage() {}
+
+func (x *KeyResult) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyResult.ProtoReflect.Descriptor instead.
+func (*KeyResult) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *KeyResult) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *KeyResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type BatchSetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*KeyResult           `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSetResponse) Reset() {
+	*x = BatchSetResponse{}
+	mi := &file_db_server_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSetResponse) ProtoMessage() {}
+
+func (x *BatchSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSetResponse.ProtoReflect.Descriptor instead.
+func (*BatchSetResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchSetResponse) GetResults() []*KeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchDeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteRequest) Reset() {
+	*x = BatchDeleteRequest{}
+	mi := &file_db_server_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteRequest) ProtoMessage() {}
+
+func (x *BatchDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteRequest.ProtoReflect.Descriptor instead.
+func (*BatchDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchDeleteRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *BatchDeleteRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+type BatchDeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*KeyResult           `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchDeleteResponse) Reset() {
+	*x = BatchDeleteResponse{}
+	mi := &file_db_server_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchDeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchDeleteResponse) ProtoMessage() {}
+
+func (x *BatchDeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchDeleteResponse.ProtoReflect.Descriptor instead.
+func (*BatchDeleteResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchDeleteResponse) GetResults() []*KeyResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type HealthCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_db_server_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{20}
+}
+
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_db_server_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *HealthCheckResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+type ListKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListKeysRequest) Reset() {
+	*x = ListKeysRequest{}
+	mi := &file_db_server_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListKeysRequest) ProtoMessage() {}
+
+func (x *ListKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListKeysRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{22}
+}
+
+// ttl_seconds is the key's remaining lifetime, rounded up, or zero if it
+// does not expire. version is set in Scan and ListKeys responses and ignored
+// in writes.
+type KeyValuePair struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Deprecated: Marked as deprecated in db_server.proto.
+	Value         string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	ValueBytes    []byte `protobuf:"bytes,4,opt,name=value_bytes,json=valueBytes,proto3" json:"value_bytes,omitempty"`
+	Version       uint64 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValuePair) Reset() {
+	*x = KeyValuePair{}
+	mi := &file_db_server_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValuePair) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValuePair) ProtoMessage() {}
+
+func (x *KeyValuePair) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValuePair.ProtoReflect.Descriptor instead.
+func (*KeyValuePair) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *KeyValuePair) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in db_server.proto.
+func (x *KeyValuePair) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValuePair) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *KeyValuePair) GetValueBytes() []byte {
+	if x != nil {
+		return x.ValueBytes
+	}
+	return nil
+}
+
+func (x *KeyValuePair) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListKeysResponse) Reset() {
+	*x = ListKeysResponse{}
+	mi := &file_db_server_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListKeysResponse) ProtoMessage() {}
+
+func (x *ListKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListKeysResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListKeysResponse) GetPairs() []*KeyValuePair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+type TTLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TTLRequest) Reset() {
+	*x = TTLRequest{}
+	mi := &file_db_server_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLRequest) ProtoMessage() {}
+
+func (x *TTLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLRequest.ProtoReflect.Descriptor instead.
+func (*TTLRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TTLRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TTLRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+// has_ttl is false for keys that never expire. ttl_seconds is the remaining
+// lifetime, rounded up.
+type TTLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HasTtl        bool                   `protobuf:"varint,1,opt,name=has_ttl,json=hasTtl,proto3" json:"has_ttl,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TTLResponse) Reset() {
+	*x = TTLResponse{}
+	mi := &file_db_server_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TTLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TTLResponse) ProtoMessage() {}
+
+func (x *TTLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TTLResponse.ProtoReflect.Descriptor instead.
+func (*TTLResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TTLResponse) GetHasTtl() bool {
+	if x != nil {
+		return x.HasTtl
+	}
+	return false
+}
+
+func (x *TTLResponse) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+// HashRange covers ring hashes in (start, end], wrapping past zero when
+// start > end. A range with start == end covers the whole ring.
+// ScanRequest asks for keys that start with prefix, are >= start and, if end
+// is set, < end, in key order. A limit of zero returns every match.
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Start         string                 `protobuf:"bytes,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,3,opt,name=end,proto3" json:"end,omitempty"`
+	Limit         uint32                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_db_server_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ScanRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// more is set when further matching keys exist beyond the returned ones.
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pairs         []*KeyValuePair        `protobuf:"bytes,1,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	More          bool                   `protobuf:"varint,2,opt,name=more,proto3" json:"more,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_db_server_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ScanResponse) GetPairs() []*KeyValuePair {
+	if x != nil {
+		return x.Pairs
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+// RangeStatsRequest asks for the size of the keys in [start, end). An empty
+// end is unbounded.
+type RangeStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         string                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeStatsRequest) Reset() {
+	*x = RangeStatsRequest{}
+	mi := &file_db_server_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeStatsRequest) ProtoMessage() {}
+
+func (x *RangeStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeStatsRequest.ProtoReflect.Descriptor instead.
+func (*RangeStatsRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RangeStatsRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *RangeStatsRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+// bytes approximates the stored size of keys and values. median_key splits
+// the range into two halves with about the same number of keys.
+type RangeStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          uint64                 `protobuf:"varint,1,opt,name=keys,proto3" json:"keys,omitempty"`
+	Bytes         uint64                 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	MedianKey     string                 `protobuf:"bytes,3,opt,name=median_key,json=medianKey,proto3" json:"median_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeStatsResponse) Reset() {
+	*x = RangeStatsResponse{}
+	mi := &file_db_server_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeStatsResponse) ProtoMessage() {}
+
+func (x *RangeStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeStatsResponse.ProtoReflect.Descriptor instead.
+func (*RangeStatsResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *RangeStatsResponse) GetKeys() uint64 {
+	if x != nil {
+		return x.Keys
+	}
+	return 0
+}
+
+func (x *RangeStatsResponse) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *RangeStatsResponse) GetMedianKey() string {
+	if x != nil {
+		return x.MedianKey
+	}
+	return ""
+}
+
+// TxnOp is one write of a transaction: a set of value, or a delete when
+// delete is true.
+type TxnOp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds    uint64                 `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Delete        bool                   `protobuf:"varint,4,opt,name=delete,proto3" json:"delete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TxnOp) Reset() {
+	*x = TxnOp{}
+	mi := &file_db_server_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TxnOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxnOp) ProtoMessage() {}
+
+func (x *TxnOp) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxnOp.ProtoReflect.Descriptor instead.
+func (*TxnOp) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *TxnOp) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *TxnOp) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *TxnOp) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *TxnOp) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+// ApplyTxnRequest writes ops atomically. It is fenced as a whole: if the
+// server does not own every key at epoch, nothing is written. A key locked by
+// a prepared transaction is answered with ABORTED.
+type ApplyTxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ops           []*TxnOp               `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyTxnRequest) Reset() {
+	*x = ApplyTxnRequest{}
+	mi := &file_db_server_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyTxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyTxnRequest) ProtoMessage() {}
+
+func (x *ApplyTxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyTxnRequest.ProtoReflect.Descriptor instead.
+func (*ApplyTxnRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ApplyTxnRequest) GetOps() []*TxnOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *ApplyTxnRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+type ApplyTxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApplyTxnResponse) Reset() {
+	*x = ApplyTxnResponse{}
+	mi := &file_db_server_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyTxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyTxnResponse) ProtoMessage() {}
+
+func (x *ApplyTxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyTxnResponse.ProtoReflect.Descriptor instead.
+func (*ApplyTxnResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{33}
+}
+
+// PrepareTxnRequest is the first phase of a two-phase commit: the server
+// records ops under txn_id and locks their keys until the transaction is
+// committed or aborted. Keys locked by another transaction are answered
+// with ABORTED.
+type PrepareTxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxnId         string                 `protobuf:"bytes,1,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	Ops           []*TxnOp               `protobuf:"bytes,2,rep,name=ops,proto3" json:"ops,omitempty"`
+	Epoch         uint64                 `protobuf:"varint,3,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrepareTxnRequest) Reset() {
+	*x = PrepareTxnRequest{}
+	mi := &file_db_server_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrepareTxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrepareTxnRequest) ProtoMessage() {}
+
+func (x *PrepareTxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrepareTxnRequest.ProtoReflect.Descriptor instead.
+func (*PrepareTxnRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *PrepareTxnRequest) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+func (x *PrepareTxnRequest) GetOps() []*TxnOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *PrepareTxnRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+type PrepareTxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrepareTxnResponse) Reset() {
+	*x = PrepareTxnResponse{}
+	mi := &file_db_server_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrepareTxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrepareTxnResponse) ProtoMessage() {}
+
+func (x *PrepareTxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrepareTxnResponse.ProtoReflect.Descriptor instead.
+func (*PrepareTxnResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{35}
+}
+
+// CommitTxnRequest and AbortTxnRequest finish a prepared transaction. Both
+// succeed for transactions the server does not know, so the coordinator can
+// repeat them.
+type CommitTxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxnId         string                 `protobuf:"bytes,1,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitTxnRequest) Reset() {
+	*x = CommitTxnRequest{}
+	mi := &file_db_server_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitTxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitTxnRequest) ProtoMessage() {}
+
+func (x *CommitTxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitTxnRequest.ProtoReflect.Descriptor instead.
+func (*CommitTxnRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *CommitTxnRequest) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+type CommitTxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitTxnResponse) Reset() {
+	*x = CommitTxnResponse{}
+	mi := &file_db_server_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitTxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitTxnResponse) ProtoMessage() {}
+
+func (x *CommitTxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitTxnResponse.ProtoReflect.Descriptor instead.
+func (*CommitTxnResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{37}
+}
+
+type AbortTxnRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxnId         string                 `protobuf:"bytes,1,opt,name=txn_id,json=txnId,proto3" json:"txn_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortTxnRequest) Reset() {
+	*x = AbortTxnRequest{}
+	mi := &file_db_server_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortTxnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortTxnRequest) ProtoMessage() {}
+
+func (x *AbortTxnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortTxnRequest.ProtoReflect.Descriptor instead.
+func (*AbortTxnRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *AbortTxnRequest) GetTxnId() string {
+	if x != nil {
+		return x.TxnId
+	}
+	return ""
+}
+
+type AbortTxnResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortTxnResponse) Reset() {
+	*x = AbortTxnResponse{}
+	mi := &file_db_server_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortTxnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortTxnResponse) ProtoMessage() {}
+
+func (x *AbortTxnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortTxnResponse.ProtoReflect.Descriptor instead.
+func (*AbortTxnResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{39}
+}
+
+type ListPreparedTxnsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPreparedTxnsRequest) Reset() {
+	*x = ListPreparedTxnsRequest{}
+	mi := &file_db_server_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPreparedTxnsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPreparedTxnsRequest) ProtoMessage() {}
+
+func (x *ListPreparedTxnsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPreparedTxnsRequest.ProtoReflect.Descriptor instead.
+func (*ListPreparedTxnsRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{40}
+}
+
+type ListPreparedTxnsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxnIds        []string               `protobuf:"bytes,1,rep,name=txn_ids,json=txnIds,proto3" json:"txn_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPreparedTxnsResponse) Reset() {
+	*x = ListPreparedTxnsResponse{}
+	mi := &file_db_server_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPreparedTxnsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPreparedTxnsResponse) ProtoMessage() {}
+
+func (x *ListPreparedTxnsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPreparedTxnsResponse.ProtoReflect.Descriptor instead.
+func (*ListPreparedTxnsResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListPreparedTxnsResponse) GetTxnIds() []string {
+	if x != nil {
+		return x.TxnIds
+	}
+	return nil
+}
+
+// WatchRequest streams changes to keys starting with prefix. With catch_up
+// set, the latest change of each key after from_version comes first;
+// otherwise only changes made from now on are sent. A watcher that falls
+// behind is ended with ABORTED and should watch again from the last version
+// it received.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	FromVersion   uint64                 `protobuf:"varint,2,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	CatchUp       bool                   `protobuf:"varint,3,opt,name=catch_up,json=catchUp,proto3" json:"catch_up,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_db_server_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetFromVersion() uint64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetCatchUp() bool {
+	if x != nil {
+		return x.CatchUp
+	}
+	return false
+}
+
+// WatchEvent is a set of value, or a delete when deleted is true. version is
+// the server's commit timestamp of the change, as returned by Get. Once
+// caught up, the server sends a progress event, which carries no change and
+// only the version the live feed continues from. commit_unix_nanos is when
+// the server committed the change; it is zero for changes sent by a catch-up,
+// whose commit time the server no longer knows. A gap event carries no change
+// either: it comes before a catch-up that may leave out changes made after
+// its version, because the server's recent changes no longer reach back that
+// far and its storage keeps only the latest change of each key.
+type WatchEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value           []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Deleted         bool                   `protobuf:"varint,3,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Version         uint64                 `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	TtlSeconds      uint64                 `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Progress        bool                   `protobuf:"varint,6,opt,name=progress,proto3" json:"progress,omitempty"`
+	CommitUnixNanos int64                  `protobuf:"varint,7,opt,name=commit_unix_nanos,json=commitUnixNanos,proto3" json:"commit_unix_nanos,omitempty"`
+	Gap             bool                   `protobuf:"varint,8,opt,name=gap,proto3" json:"gap,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_db_server_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *WatchEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *WatchEvent) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetTtlSeconds() uint64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetProgress() bool {
+	if x != nil {
+		return x.Progress
+	}
+	return false
+}
+
+func (x *WatchEvent) GetCommitUnixNanos() int64 {
+	if x != nil {
+		return x.CommitUnixNanos
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetGap() bool {
+	if x != nil {
+		return x.Gap
+	}
+	return false
+}
+
+// BackupRequest streams a snapshot of the server's data in Badger's backup
+// format: every entry written after since, or all of them when since is zero.
+type BackupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Since         uint64                 `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupRequest) Reset() {
+	*x = BackupRequest{}
+	mi := &file_db_server_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupRequest) ProtoMessage() {}
+
+func (x *BackupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupRequest.ProtoReflect.Descriptor instead.
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *BackupRequest) GetSince() uint64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+// BackupChunk is the next piece of the backup. The last chunk carries the
+// highest version in the backup, to pass as since for the next incremental
+// one; it is zero when nothing changed after since.
+type BackupChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Version       uint64                 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Last          bool                   `protobuf:"varint,3,opt,name=last,proto3" json:"last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackupChunk) Reset() {
+	*x = BackupChunk{}
+	mi := &file_db_server_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackupChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackupChunk) ProtoMessage() {}
+
+func (x *BackupChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackupChunk.ProtoReflect.Descriptor instead.
+func (*BackupChunk) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *BackupChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BackupChunk) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *BackupChunk) GetLast() bool {
+	if x != nil {
+		return x.Last
+	}
+	return false
+}
+
+// RunGCRequest collects stale data from the Badger value log now, rewriting
+// files in which at least discard_ratio of the data is stale. Zero uses the
+// server's configured ratio. Servers on other engines answer
+// FAILED_PRECONDITION, and ABORTED while a GC is already running.
+type RunGCRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DiscardRatio  float64                `protobuf:"fixed64,1,opt,name=discard_ratio,json=discardRatio,proto3" json:"discard_ratio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunGCRequest) Reset() {
+	*x = RunGCRequest{}
+	mi := &file_db_server_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunGCRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunGCRequest) ProtoMessage() {}
+
+func (x *RunGCRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunGCRequest.ProtoReflect.Descriptor instead.
+func (*RunGCRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *RunGCRequest) GetDiscardRatio() float64 {
+	if x != nil {
+		return x.DiscardRatio
+	}
+	return 0
+}
+
+// RunGCResponse reports how many value log files were rewritten and the
+// disk usage Badger last measured.
+type RunGCResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FilesRewritten int32                  `protobuf:"varint,1,opt,name=files_rewritten,json=filesRewritten,proto3" json:"files_rewritten,omitempty"`
+	LsmBytes       int64                  `protobuf:"varint,2,opt,name=lsm_bytes,json=lsmBytes,proto3" json:"lsm_bytes,omitempty"`
+	VlogBytes      int64                  `protobuf:"varint,3,opt,name=vlog_bytes,json=vlogBytes,proto3" json:"vlog_bytes,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunGCResponse) Reset() {
+	*x = RunGCResponse{}
+	mi := &file_db_server_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunGCResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunGCResponse) ProtoMessage() {}
+
+func (x *RunGCResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunGCResponse.ProtoReflect.Descriptor instead.
+func (*RunGCResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *RunGCResponse) GetFilesRewritten() int32 {
+	if x != nil {
+		return x.FilesRewritten
+	}
+	return 0
+}
+
+func (x *RunGCResponse) GetLsmBytes() int64 {
+	if x != nil {
+		return x.LsmBytes
+	}
+	return 0
+}
+
+func (x *RunGCResponse) GetVlogBytes() int64 {
+	if x != nil {
+		return x.VlogBytes
+	}
+	return 0
+}
+
+// CompactRequest flattens the Badger LSM tree into a single level using
+// workers concurrent compactions, one per CPU when zero.
+type CompactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workers       int32                  `protobuf:"varint,1,opt,name=workers,proto3" json:"workers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactRequest) Reset() {
+	*x = CompactRequest{}
+	mi := &file_db_server_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactRequest) ProtoMessage() {}
+
+func (x *CompactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactRequest.ProtoReflect.Descriptor instead.
+func (*CompactRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *CompactRequest) GetWorkers() int32 {
+	if x != nil {
+		return x.Workers
+	}
+	return 0
+}
+
+type CompactResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LsmBytes      int64                  `protobuf:"varint,1,opt,name=lsm_bytes,json=lsmBytes,proto3" json:"lsm_bytes,omitempty"`
+	VlogBytes     int64                  `protobuf:"varint,2,opt,name=vlog_bytes,json=vlogBytes,proto3" json:"vlog_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactResponse) Reset() {
+	*x = CompactResponse{}
+	mi := &file_db_server_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactResponse) ProtoMessage() {}
+
+func (x *CompactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactResponse.ProtoReflect.Descriptor instead.
+func (*CompactResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *CompactResponse) GetLsmBytes() int64 {
+	if x != nil {
+		return x.LsmBytes
+	}
+	return 0
+}
+
+func (x *CompactResponse) GetVlogBytes() int64 {
+	if x != nil {
+		return x.VlogBytes
+	}
+	return 0
+}
+
+type HashRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         uint32                 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           uint32                 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashRange) Reset() {
+	*x = HashRange{}
+	mi := &file_db_server_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashRange) ProtoMessage() {}
+
+func (x *HashRange) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashRange.ProtoReflect.Descriptor instead.
+func (*HashRange) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *HashRange) GetStart() uint32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *HashRange) GetEnd() uint32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// KeyRange covers keys in [start, end). An empty end is unbounded.
+type KeyRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         string                 `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           string                 `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyRange) Reset() {
+	*x = KeyRange{}
+	mi := &file_db_server_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRange) ProtoMessage() {}
+
+func (x *KeyRange) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRange.ProtoReflect.Descriptor instead.
+func (*KeyRange) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *KeyRange) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *KeyRange) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+// UpdateOwnershipRequest carries hash ranges when the cluster is
+// hash-partitioned and key_ranges when it is range-partitioned.
+type UpdateOwnershipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Epoch         uint64                 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Ranges        []*HashRange           `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	KeyRanges     []*KeyRange            `protobuf:"bytes,3,rep,name=key_ranges,json=keyRanges,proto3" json:"key_ranges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOwnershipRequest) Reset() {
+	*x = UpdateOwnershipRequest{}
+	mi := &file_db_server_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOwnershipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOwnershipRequest) ProtoMessage() {}
+
+func (x *UpdateOwnershipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOwnershipRequest.ProtoReflect.Descriptor instead.
+func (*UpdateOwnershipRequest) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *UpdateOwnershipRequest) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *UpdateOwnershipRequest) GetRanges() []*HashRange {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *UpdateOwnershipRequest) GetKeyRanges() []*KeyRange {
+	if x != nil {
+		return x.KeyRanges
+	}
+	return nil
+}
+
+type UpdateOwnershipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOwnershipResponse) Reset() {
+	*x = UpdateOwnershipResponse{}
+	mi := &file_db_server_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOwnershipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOwnershipResponse) ProtoMessage() {}
+
+func (x *UpdateOwnershipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOwnershipResponse.ProtoReflect.Descriptor instead.
+func (*UpdateOwnershipResponse) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *UpdateOwnershipResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+// FencingError is attached as a status detail to FAILED_PRECONDITION errors
+// returned for requests routed on stale topology.
+type FencingError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        FencingReason          `protobuf:"varint,1,opt,name=reason,proto3,enum=db_server.FencingReason" json:"reason,omitempty"`
+	ServerEpoch   uint64                 `protobuf:"varint,2,opt,name=server_epoch,json=serverEpoch,proto3" json:"server_epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FencingError) Reset() {
+	*x = FencingError{}
+	mi := &file_db_server_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FencingError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FencingError) ProtoMessage() {}
+
+func (x *FencingError) ProtoReflect() protoreflect.Message {
+	mi := &file_db_server_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FencingError.ProtoReflect.Descriptor instead.
+func (*FencingError) Descriptor() ([]byte, []int) {
+	return file_db_server_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *FencingError) GetReason() FencingReason {
+	if x != nil {
+		return x.Reason
+	}
+	return FencingReason_FENCING_REASON_UNSPECIFIED
+}
+
+func (x *FencingError) GetServerEpoch() uint64 {
+	if x != nil {
+		return x.ServerEpoch
+	}
+	return 0
+}
+
+var File_db_server_proto protoreflect.FileDescriptor
+
+const file_db_server_proto_rawDesc = "" +
+	"\n" +
+	"\x0fdb_server.proto\x12\tdb_server\"\xb5\x01\n" +
+	"\n" +
+	"SetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vvalue_bytes\x18\x05 \x01(\fR\n" +
+	"valueBytes\x12#\n" +
+	"\rprimary_order\x18\x06 \x01(\x04R\fprimaryOrder\"'\n" +
+	"\vSetResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"4\n" +
+	"\n" +
+	"GetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"b\n" +
+	"\vGetResponse\x12\x18\n" +
+	"\x05value\x18\x01 \x01(\tB\x02\x18\x01R\x05value\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x1f\n" +
+	"\vvalue_bytes\x18\x03 \x01(\fR\n" +
+	"valueBytes\"\\\n" +
+	"\rDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\x12#\n" +
+	"\rprimary_order\x18\x03 \x01(\x04R\fprimaryOrder\"*\n" +
+	"\x0eDeleteResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xe8\x01\n" +
+	"\x15ConditionalSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1d\n" +
+	"\tif_absent\x18\x05 \x01(\bH\x00R\bifAbsent\x12\x1f\n" +
+	"\n" +
+	"if_version\x18\x06 \x01(\x04H\x00R\tifVersion\x12\x1f\n" +
+	"\vvalue_bytes\x18\a \x01(\fR\n" +
+	"valueBytesB\v\n" +
+	"\tcondition\"H\n" +
+	"\x16ConditionalSetResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05order\x18\x02 \x01(\x04R\x05order\"\x87\x01\n" +
+	"\x18ConditionalDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\x12\x1d\n" +
+	"\bif_value\x18\x03 \x01(\tB\x02\x18\x01R\aifValue\x12$\n" +
+	"\x0eif_value_bytes\x18\x04 \x01(\fR\fifValueBytes\"K\n" +
+	"\x19ConditionalDeleteResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05order\x18\x02 \x01(\x04R\x05order\"P\n" +
+	"\x10IncrementRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\"`\n" +
+	"\x11IncrementResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x03R\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x04R\n" +
+	"ttlSeconds\x12\x14\n" +
+	"\x05order\x18\x03 \x01(\x04R\x05order\";\n" +
+	"\x0fBatchGetRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"\x89\x01\n" +
+	"\x0eBatchGetResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x18\n" +
+	"\x05value\x18\x03 \x01(\tB\x02\x18\x01R\x05value\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1f\n" +
+	"\vvalue_bytes\x18\x05 \x01(\fR\n" +
+	"valueBytes\"G\n" +
+	"\x10BatchGetResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.db_server.BatchGetResultR\aresults\"V\n" +
+	"\x0fBatchSetRequest\x12-\n" +
+	"\x05pairs\x18\x01 \x03(\v2\x17.db_server.KeyValuePairR\x05pairs\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"M\n" +
+	"\tKeyResult\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"B\n" +
+	"\x10BatchSetResponse\x12.\n" +
+	"\aresults\x18\x01 \x03(\v2\x14.db_server.KeyResultR\aresults\">\n" +
+	"\x12BatchDeleteRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"E\n" +
+	"\x13BatchDeleteResponse\x12.\n" +
+	"\aresults\x18\x01 \x03(\v2\x14.db_server.KeyResultR\aresults\"\x14\n" +
+	"\x12HealthCheckRequest\"/\n" +
+	"\x13HealthCheckResponse\x12\x18\n" +
+	"\ahealthy\x18\x01 \x01(\bR\ahealthy\"\x11\n" +
+	"\x0fListKeysRequest\"\x96\x01\n" +
+	"\fKeyValuePair\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\x05value\x18\x02 \x01(\tB\x02\x18\x01R\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vvalue_bytes\x18\x04 \x01(\fR\n" +
+	"valueBytes\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x04R\aversion\"A\n" +
+	"\x10ListKeysResponse\x12-\n" +
+	"\x05pairs\x18\x01 \x03(\v2\x17.db_server.KeyValuePairR\x05pairs\"4\n" +
+	"\n" +
+	"TTLRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"G\n" +
+	"\vTTLResponse\x12\x17\n" +
+	"\ahas_ttl\x18\x01 \x01(\bR\x06hasTtl\x12\x1f\n" +
+	"\vttl_seconds\x18\x02 \x01(\x04R\n" +
+	"ttlSeconds\"c\n" +
+	"\vScanRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\tR\x03end\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\"Q\n" +
+	"\fScanResponse\x12-\n" +
+	"\x05pairs\x18\x01 \x03(\v2\x17.db_server.KeyValuePairR\x05pairs\x12\x12\n" +
+	"\x04more\x18\x02 \x01(\bR\x04more\";\n" +
+	"\x11RangeStatsRequest\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\"]\n" +
+	"\x12RangeStatsResponse\x12\x12\n" +
+	"\x04keys\x18\x01 \x01(\x04R\x04keys\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x04R\x05bytes\x12\x1d\n" +
+	"\n" +
+	"median_key\x18\x03 \x01(\tR\tmedianKey\"h\n" +
+	"\x05TxnOp\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x04R\n" +
+	"ttlSeconds\x12\x16\n" +
+	"\x06delete\x18\x04 \x01(\bR\x06delete\"K\n" +
+	"\x0fApplyTxnRequest\x12\"\n" +
+	"\x03ops\x18\x01 \x03(\v2\x10.db_server.TxnOpR\x03ops\x12\x14\n" +
+	"\x05epoch\x18\x02 \x01(\x04R\x05epoch\"\x12\n" +
+	"\x10ApplyTxnResponse\"d\n" +
+	"\x11PrepareTxnRequest\x12\x15\n" +
+	"\x06txn_id\x18\x01 \x01(\tR\x05txnId\x12\"\n" +
+	"\x03ops\x18\x02 \x03(\v2\x10.db_server.TxnOpR\x03ops\x12\x14\n" +
+	"\x05epoch\x18\x03 \x01(\x04R\x05epoch\"\x14\n" +
+	"\x12PrepareTxnResponse\")\n" +
+	"\x10CommitTxnRequest\x12\x15\n" +
+	"\x06txn_id\x18\x01 \x01(\tR\x05txnId\"\x13\n" +
+	"\x11CommitTxnResponse\"(\n" +
+	"\x0fAbortTxnRequest\x12\x15\n" +
+	"\x06txn_id\x18\x01 \x01(\tR\x05txnId\"\x12\n" +
+	"\x10AbortTxnResponse\"\x19\n" +
+	"\x17ListPreparedTxnsRequest\"3\n" +
+	"\x18ListPreparedTxnsResponse\x12\x17\n" +
+	"\atxn_ids\x18\x01 \x03(\tR\x06txnIds\"d\n" +
+	"\fWatchRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12!\n" +
+	"\ffrom_version\x18\x02 \x01(\x04R\vfromVersion\x12\x19\n" +
+	"\bcatch_up\x18\x03 \x01(\bR\acatchUp\"\xe3\x01\n" +
+	"\n" +
+	"WatchEvent\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x18\n" +
+	"\adeleted\x18\x03 \x01(\bR\adeleted\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x04R\aversion\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x04R\n" +
+	"ttlSeconds\x12\x1a\n" +
+	"\bprogress\x18\x06 \x01(\bR\bprogress\x12*\n" +
+	"\x11commit_unix_nanos\x18\a \x01(\x03R\x0fcommitUnixNanos\x12\x10\n" +
+	"\x03gap\x18\b \x01(\bR\x03gap\"%\n" +
+	"\rBackupRequest\x12\x14\n" +
+	"\x05since\x18\x01 \x01(\x04R\x05since\"O\n" +
+	"\vBackupChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x04R\aversion\x12\x12\n" +
+	"\x04last\x18\x03 \x01(\bR\x04last\"3\n" +
+	"\fRunGCRequest\x12#\n" +
+	"\rdiscard_ratio\x18\x01 \x01(\x01R\fdiscardRatio\"t\n" +
+	"\rRunGCResponse\x12'\n" +
+	"\x0ffiles_rewritten\x18\x01 \x01(\x05R\x0efilesRewritten\x12\x1b\n" +
+	"\tlsm_bytes\x18\x02 \x01(\x03R\blsmBytes\x12\x1d\n" +
+	"\n" +
+	"vlog_bytes\x18\x03 \x01(\x03R\tvlogBytes\"*\n" +
+	"\x0eCompactRequest\x12\x18\n" +
+	"\aworkers\x18\x01 \x01(\x05R\aworkers\"M\n" +
+	"\x0fCompactResponse\x12\x1b\n" +
+	"\tlsm_bytes\x18\x01 \x01(\x03R\blsmBytes\x12\x1d\n" +
+	"\n" +
+	"vlog_bytes\x18\x02 \x01(\x03R\tvlogBytes\"3\n" +
+	"\tHashRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\rR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\rR\x03end\"2\n" +
+	"\bKeyRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\tR\x03end\"\x90\x01\n" +
+	"\x16UpdateOwnershipRequest\x12\x14\n" +
+	"\x05epoch\x18\x01 \x01(\x04R\x05epoch\x12,\n" +
+	"\x06ranges\x18\x02 \x03(\v2\x14.db_server.HashRangeR\x06ranges\x122\n" +
+	"\n" +
+	"key_ranges\x18\x03 \x03(\v2\x13.db_server.KeyRangeR\tkeyRanges\"5\n" +
+	"\x17UpdateOwnershipResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\"c\n" +
+	"\fFencingError\x120\n" +
+	"\x06reason\x18\x01 \x01(\x0e2\x18.db_server.FencingReasonR\x06reason\x12!\n" +
+	"\fserver_epoch\x18\x02 \x01(\x04R\vserverEpoch*Q\n" +
+	"\rFencingReason\x12\x1e\n" +
+	"\x1aFENCING_REASON_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vSTALE_EPOCH\x10\x01\x12\x0f\n" +
+	"\vWRONG_OWNER\x10\x022\xa4\r\n" +
+	"\bDBServer\x124\n" +
+	"\x03Set\x12\x15.db_server.SetRequest\x1a\x16.db_server.SetResponse\x124\n" +
+	"\x03Get\x12\x15.db_server.GetRequest\x1a\x16.db_server.GetResponse\x12=\n" +
+	"\x06Delete\x12\x18.db_server.DeleteRequest\x1a\x19.db_server.DeleteResponse\x12L\n" +
+	"\vHealthCheck\x12\x1d.db_server.HealthCheckRequest\x1a\x1e.db_server.HealthCheckResponse\x12C\n" +
+	"\bListKeys\x12\x1a.db_server.ListKeysRequest\x1a\x1b.db_server.ListKeysResponse\x12X\n" +
+	"\x0fUpdateOwnership\x12!.db_server.UpdateOwnershipRequest\x1a\".db_server.UpdateOwnershipResponse\x124\n" +
+	"\x03TTL\x12\x15.db_server.TTLRequest\x1a\x16.db_server.TTLResponse\x12U\n" +
+	"\x0eConditionalSet\x12 .db_server.ConditionalSetRequest\x1a!.db_server.ConditionalSetResponse\x12^\n" +
+	"\x11ConditionalDelete\x12#.db_server.ConditionalDeleteRequest\x1a$.db_server.ConditionalDeleteResponse\x12F\n" +
+	"\tIncrement\x12\x1b.db_server.IncrementRequest\x1a\x1c.db_server.IncrementResponse\x12C\n" +
+	"\bBatchGet\x12\x1a.db_server.BatchGetRequest\x1a\x1b.db_server.BatchGetResponse\x12C\n" +
+	"\bBatchSet\x12\x1a.db_server.BatchSetRequest\x1a\x1b.db_server.BatchSetResponse\x12L\n" +
+	"\vBatchDelete\x12\x1d.db_server.BatchDeleteRequest\x1a\x1e.db_server.BatchDeleteResponse\x127\n" +
+	"\x04Scan\x12\x16.db_server.ScanRequest\x1a\x17.db_server.ScanResponse\x12I\n" +
+	"\n" +
+	"RangeStats\x12\x1c.db_server.RangeStatsRequest\x1a\x1d.db_server.RangeStatsResponse\x12C\n" +
+	"\bApplyTxn\x12\x1a.db_server.ApplyTxnRequest\x1a\x1b.db_server.ApplyTxnResponse\x12I\n" +
+	"\n" +
+	"PrepareTxn\x12\x1c.db_server.PrepareTxnRequest\x1a\x1d.db_server.PrepareTxnResponse\x12F\n" +
+	"\tCommitTxn\x12\x1b.db_server.CommitTxnRequest\x1a\x1c.db_server.CommitTxnResponse\x12C\n" +
+	"\bAbortTxn\x12\x1a.db_server.AbortTxnRequest\x1a\x1b.db_server.AbortTxnResponse\x12[\n" +
+	"\x10ListPreparedTxns\x12\".db_server.ListPreparedTxnsRequest\x1a#.db_server.ListPreparedTxnsResponse\x129\n" +
+	"\x05Watch\x12\x17.db_server.WatchRequest\x1a\x15.db_server.WatchEvent0\x01\x12<\n" +
+	"\x06Backup\x12\x18.db_server.BackupRequest\x1a\x16.db_server.BackupChunk0\x01\x12:\n" +
+	"\x05RunGC\x12\x17.db_server.RunGCRequest\x1a\x18.db_server.RunGCResponse\x12@\n" +
+	"\aCompact\x12\x19.db_server.CompactRequest\x1a\x1a.db_server.CompactResponseB,Z*github.com/arbhalerao/meerkat/pb/db_serverb\x06proto3"
+
+var (
+	file_db_server_proto_rawDescOnce sync.Once
+	file_db_server_proto_rawDescData []byte
+)
+
+func file_db_server_proto_rawDescGZIP() []byte {
+	file_db_server_proto_rawDescOnce.Do(func() {
+		file_db_server_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_db_server_proto_rawDesc), len(file_db_server_proto_rawDesc)))
+	})
+	return file_db_server_proto_rawDescData
+}
+
+var file_db_server_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_db_server_proto_msgTypes = make([]protoimpl.MessageInfo, 55)
+var file_db_server_proto_goTypes = []any{
+	(FencingReason)(0),                // 0: db_server.FencingReason
+	(*SetRequest)(nil),                // 1: db_server.SetRequest
+	(*SetResponse)(nil),               // 2: db_server.SetResponse
+	(*GetRequest)(nil),                // 3: db_server.GetRequest
+	(*GetResponse)(nil),               // 4: db_server.GetResponse
+	(*DeleteRequest)(nil),             // 5: db_server.DeleteRequest
+	(*DeleteResponse)(nil),            // 6: db_server.DeleteResponse
+	(*ConditionalSetRequest)(nil),     // 7: db_server.ConditionalSetRequest
+	(*ConditionalSetResponse)(nil),    // 8: db_server.ConditionalSetResponse
+	(*ConditionalDeleteRequest)(nil),  // 9: db_server.ConditionalDeleteRequest
+	(*ConditionalDeleteResponse)(nil), // 10: db_server.ConditionalDeleteResponse
+	(*IncrementRequest)(nil),          // 11: db_server.IncrementRequest
+	(*IncrementResponse)(nil),         // 12: db_server.IncrementResponse
+	(*BatchGetRequest)(nil),           // 13: db_server.BatchGetRequest
+	(*BatchGetResult)(nil),            // 14: db_server.BatchGetResult
+	(*BatchGetResponse)(nil),          // 15: db_server.BatchGetResponse
+	(*BatchSetRequest)(nil),           // 16: db_server.BatchSetRequest
+	(*KeyResult)(nil),                 // 17: db_server.KeyResult
+	(*BatchSetResponse)(nil),          // 18: db_server.BatchSetResponse
+	(*BatchDeleteRequest)(nil),        // 19: db_server.BatchDeleteRequest
+	(*BatchDeleteResponse)(nil),       // 20: db_server.BatchDeleteResponse
+	(*HealthCheckRequest)(nil),        // 21: db_server.HealthCheckRequest
+	(*HealthCheckResponse)(nil),       // 22: db_server.HealthCheckResponse
+	(*ListKeysRequest)(nil),           // 23: db_server.ListKeysRequest
+	(*KeyValuePair)(nil),              // 24: db_server.KeyValuePair
+	(*ListKeysResponse)(nil),          // 25: db_server.ListKeysResponse
+	(*TTLRequest)(nil),                // 26: db_server.TTLRequest
+	(*TTLResponse)(nil),               // 27: db_server.TTLResponse
+	(*ScanRequest)(nil),               // 28: db_server.ScanRequest
+	(*ScanResponse)(nil),              // 29: db_server.ScanResponse
+	(*RangeStatsRequest)(nil),         // 30: db_server.RangeStatsRequest
+	(*RangeStatsResponse)(nil),        // 31: db_server.RangeStatsResponse
+	(*TxnOp)(nil),                     // 32: db_server.TxnOp
+	(*ApplyTxnRequest)(nil),           // 33: db_server.ApplyTxnRequest
+	(*ApplyTxnResponse)(nil),          // 34: db_server.ApplyTxnResponse
+	(*PrepareTxnRequest)(nil),         // 35: db_server.PrepareTxnRequest
+	(*PrepareTxnResponse)(nil),        // 36: db_server.PrepareTxnResponse
+	(*CommitTxnRequest)(nil),          // 37: db_server.CommitTxnRequest
+	(*CommitTxnResponse)(nil),         // 38: db_server.CommitTxnResponse
+	(*AbortTxnRequest)(nil),           // 39: db_server.AbortTxnRequest
+	(*AbortTxnResponse)(nil),          // 40: db_server.AbortTxnResponse
+	(*ListPreparedTxnsRequest)(nil),   // 41: db_server.ListPreparedTxnsRequest
+	(*ListPreparedTxnsResponse)(nil),  // 42: db_server.ListPreparedTxnsResponse
+	(*WatchRequest)(nil),              // 43: db_server.WatchRequest
+	(*WatchEvent)(nil),                // 44: db_server.WatchEvent
+	(*BackupRequest)(nil),             // 45: db_server.BackupRequest
+	(*BackupChunk)(nil),               // 46: db_server.BackupChunk
+	(*RunGCRequest)(nil),              // 47: db_server.RunGCRequest
+	(*RunGCResponse)(nil),             // 48: db_server.RunGCResponse
+	(*CompactRequest)(nil),            // 49: db_server.CompactRequest
+	(*CompactResponse)(nil),           // 50: db_server.CompactResponse
+	(*HashRange)(nil),                 // 51: db_server.HashRange
+	(*KeyRange)(nil),                  // 52: db_server.KeyRange
+	(*UpdateOwnershipRequest)(nil),    // 53: db_server.UpdateOwnershipRequest
+	(*UpdateOwnershipResponse)(nil),   // 54: db_server.UpdateOwnershipResponse
+	(*FencingError)(nil),              // 55: db_server.FencingError
+}
+var file_db_server_proto_depIdxs = []int32{
+	14, // 0: db_server.BatchGetResponse.results:type_name -> db_server.BatchGetResult
+	24, // 1: db_server.BatchSetRequest.pairs:type_name -> db_server.KeyValuePair
+	17, // 2: db_server.BatchSetResponse.results:type_name -> db_server.KeyResult
+	17, // 3: db_server.BatchDeleteResponse.results:type_name -> db_server.KeyResult
+	24, // 4: db_server.ListKeysResponse.pairs:type_name -> db_server.KeyValuePair
+	24, // 5: db_server.ScanResponse.pairs:type_name -> db_server.KeyValuePair
+	32, // 6: db_server.ApplyTxnRequest.ops:type_name -> db_server.TxnOp
+	32, // 7: db_server.PrepareTxnRequest.ops:type_name -> db_server.TxnOp
+	51, // 8: db_server.UpdateOwnershipRequest.ranges:type_name -> db_server.HashRange
+	52, // 9: db_server.UpdateOwnershipRequest.key_ranges:type_name -> db_server.KeyRange
+	0,  // 10: db_server.FencingError.reason:type_name -> db_server.FencingReason
+	1,  // 11: db_server.DBServer.Set:input_type -> db_server.SetRequest
+	3,  // 12: db_server.DBServer.Get:input_type -> db_server.GetRequest
+	5,  // 13: db_server.DBServer.Delete:input_type -> db_server.DeleteRequest
+	21, // 14: db_server.DBServer.HealthCheck:input_type -> db_server.HealthCheckRequest
+	23, // 15: db_server.DBServer.ListKeys:input_type -> db_server.ListKeysRequest
+	53, // 16: db_server.DBServer.UpdateOwnership:input_type -> db_server.UpdateOwnershipRequest
+	26, // 17: db_server.DBServer.TTL:input_type -> db_server.TTLRequest
+	7,  // 18: db_server.DBServer.ConditionalSet:input_type -> db_server.ConditionalSetRequest
+	9,  // 19: db_server.DBServer.ConditionalDelete:input_type -> db_server.ConditionalDeleteRequest
+	11, // 20: db_server.DBServer.Increment:input_type -> db_server.IncrementRequest
+	13, // 21: db_server.DBServer.BatchGet:input_type -> db_server.BatchGetRequest
+	16, // 22: db_server.DBServer.BatchSet:input_type -> db_server.BatchSetRequest
+	19, // 23: db_server.DBServer.BatchDelete:input_type -> db_server.BatchDeleteRequest
+	28, // 24: db_server.DBServer.Scan:input_type -> db_server.ScanRequest
+	30, // 25: db_server.DBServer.RangeStats:input_type -> db_server.RangeStatsRequest
+	33, // 26: db_server.DBServer.ApplyTxn:input_type -> db_server.ApplyTxnRequest
+	35, // 27: db_server.DBServer.PrepareTxn:input_type -> db_server.PrepareTxnRequest
+	37, // 28: db_server.DBServer.CommitTxn:input_type -> db_server.CommitTxnRequest
+	39, // 29: db_server.DBServer.AbortTxn:input_type -> db_server.AbortTxnRequest
+	41, // 30: db_server.DBServer.ListPreparedTxns:input_type -> db_server.ListPreparedTxnsRequest
+	43, // 31: db_server.DBServer.Watch:input_type -> db_server.WatchRequest
+	45, // 32: db_server.DBServer.Backup:input_type -> db_server.BackupRequest
+	47, // 33: db_server.DBServer.RunGC:input_type -> db_server.RunGCRequest
+	49, // 34: db_server.DBServer.Compact:input_type -> db_server.CompactRequest
+	2,  // 35: db_server.DBServer.Set:output_type -> db_server.SetResponse
+	4,  // 36: db_server.DBServer.Get:output_type -> db_server.GetResponse
+	6,  // 37: db_server.DBServer.Delete:output_type -> db_server.DeleteResponse
+	22, // 38: db_server.DBServer.HealthCheck:output_type -> db_server.HealthCheckResponse
+	25, // 39: db_server.DBServer.ListKeys:output_type -> db_server.ListKeysResponse
+	54, // 40: db_server.DBServer.UpdateOwnership:output_type -> db_server.UpdateOwnershipResponse
+	27, // 41: db_server.DBServer.TTL:output_type -> db_server.TTLResponse
+	8,  // 42: db_server.DBServer.ConditionalSet:output_type -> db_server.ConditionalSetResponse
+	10, // 43: db_server.DBServer.ConditionalDelete:output_type -> db_server.ConditionalDeleteResponse
+	12, // 44: db_server.DBServer.Increment:output_type -> db_server.IncrementResponse
+	15, // 45: db_server.DBServer.BatchGet:output_type -> db_server.BatchGetResponse
+	18, // 46: db_server.DBServer.BatchSet:output_type -> db_server.BatchSetResponse
+	20, // 47: db_server.DBServer.BatchDelete:output_type -> db_server.BatchDeleteResponse
+	29, // 48: db_server.DBServer.Scan:output_type -> db_server.ScanResponse
+	31, // 49: db_server.DBServer.RangeStats:output_type -> db_server.RangeStatsResponse
+	34, // 50: db_server.DBServer.ApplyTxn:output_type -> db_server.ApplyTxnResponse
+	36, // 51: db_server.DBServer.PrepareTxn:output_type -> db_server.PrepareTxnResponse
+	38, // 52: db_server.DBServer.CommitTxn:output_type -> db_server.CommitTxnResponse
+	40, // 53: db_server.DBServer.AbortTxn:output_type -> db_server.AbortTxnResponse
+	42, // 54: db_server.DBServer.ListPreparedTxns:output_type -> db_server.ListPreparedTxnsResponse
+	44, // 55: db_server.DBServer.Watch:output_type -> db_server.WatchEvent
+	46, // 56: db_server.DBServer.Backup:output_type -> db_server.BackupChunk
+	48, // 57: db_server.DBServer.RunGC:output_type -> db_server.RunGCResponse
+	50, // 58: db_server.DBServer.Compact:output_type -> db_server.CompactResponse
+	35, // [35:59] is the sub-list for method output_type
+	11, // [11:35] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_db_server_proto_init() }
+func file_db_server_proto_init() {
 	if File_db_server_proto != nil {
 		return
 	}
+	file_db_server_proto_msgTypes[6].OneofWrappers = []any{
+		(*ConditionalSetRequest_IfAbsent)(nil),
+		(*ConditionalSetRequest_IfVersion)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_db_server_proto_rawDesc), len(file_db_server_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   55,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_db_server_proto_goTypes,
 		DependencyIndexes: file_db_server_proto_depIdxs,
+		EnumInfos:         file_db_server_proto_enumTypes,
 		MessageInfos:      file_db_server_proto_msgTypes,
 	}.Build()
 	File_db_server_proto = out.File
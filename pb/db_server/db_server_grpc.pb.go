@@ -19,11 +19,30 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DBServer_Set_FullMethodName         = "/db_server.DBServer/Set"
-	DBServer_Get_FullMethodName         = "/db_server.DBServer/Get"
-	DBServer_Delete_FullMethodName      = "/db_server.DBServer/Delete"
-	DBServer_HealthCheck_FullMethodName = "/db_server.DBServer/HealthCheck"
-	DBServer_ListKeys_FullMethodName    = "/db_server.DBServer/ListKeys"
+	DBServer_Set_FullMethodName               = "/db_server.DBServer/Set"
+	DBServer_Get_FullMethodName               = "/db_server.DBServer/Get"
+	DBServer_Delete_FullMethodName            = "/db_server.DBServer/Delete"
+	DBServer_HealthCheck_FullMethodName       = "/db_server.DBServer/HealthCheck"
+	DBServer_ListKeys_FullMethodName          = "/db_server.DBServer/ListKeys"
+	DBServer_UpdateOwnership_FullMethodName   = "/db_server.DBServer/UpdateOwnership"
+	DBServer_TTL_FullMethodName               = "/db_server.DBServer/TTL"
+	DBServer_ConditionalSet_FullMethodName    = "/db_server.DBServer/ConditionalSet"
+	DBServer_ConditionalDelete_FullMethodName = "/db_server.DBServer/ConditionalDelete"
+	DBServer_Increment_FullMethodName         = "/db_server.DBServer/Increment"
+	DBServer_BatchGet_FullMethodName          = "/db_server.DBServer/BatchGet"
+	DBServer_BatchSet_FullMethodName          = "/db_server.DBServer/BatchSet"
+	DBServer_BatchDelete_FullMethodName       = "/db_server.DBServer/BatchDelete"
+	DBServer_Scan_FullMethodName              = "/db_server.DBServer/Scan"
+	DBServer_RangeStats_FullMethodName        = "/db_server.DBServer/RangeStats"
+	DBServer_ApplyTxn_FullMethodName          = "/db_server.DBServer/ApplyTxn"
+	DBServer_PrepareTxn_FullMethodName        = "/db_server.DBServer/PrepareTxn"
+	DBServer_CommitTxn_FullMethodName         = "/db_server.DBServer/CommitTxn"
+	DBServer_AbortTxn_FullMethodName          = "/db_server.DBServer/AbortTxn"
+	DBServer_ListPreparedTxns_FullMethodName  = "/db_server.DBServer/ListPreparedTxns"
+	DBServer_Watch_FullMethodName             = "/db_server.DBServer/Watch"
+	DBServer_Backup_FullMethodName            = "/db_server.DBServer/Backup"
+	DBServer_RunGC_FullMethodName             = "/db_server.DBServer/RunGC"
+	DBServer_Compact_FullMethodName           = "/db_server.DBServer/Compact"
 )
 
 // DBServerClient is the client API for DBServer service.
@@ -35,6 +54,25 @@ type DBServerClient interface {
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
 	ListKeys(ctx context.Context, in *ListKeysRequest, opts ...grpc.CallOption) (*ListKeysResponse, error)
+	UpdateOwnership(ctx context.Context, in *UpdateOwnershipRequest, opts ...grpc.CallOption) (*UpdateOwnershipResponse, error)
+	TTL(ctx context.Context, in *TTLRequest, opts ...grpc.CallOption) (*TTLResponse, error)
+	ConditionalSet(ctx context.Context, in *ConditionalSetRequest, opts ...grpc.CallOption) (*ConditionalSetResponse, error)
+	ConditionalDelete(ctx context.Context, in *ConditionalDeleteRequest, opts ...grpc.CallOption) (*ConditionalDeleteResponse, error)
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error)
+	BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error)
+	BatchSet(ctx context.Context, in *BatchSetRequest, opts ...grpc.CallOption) (*BatchSetResponse, error)
+	BatchDelete(ctx context.Context, in *BatchDeleteRequest, opts ...grpc.CallOption) (*BatchDeleteResponse, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	RangeStats(ctx context.Context, in *RangeStatsRequest, opts ...grpc.CallOption) (*RangeStatsResponse, error)
+	ApplyTxn(ctx context.Context, in *ApplyTxnRequest, opts ...grpc.CallOption) (*ApplyTxnResponse, error)
+	PrepareTxn(ctx context.Context, in *PrepareTxnRequest, opts ...grpc.CallOption) (*PrepareTxnResponse, error)
+	CommitTxn(ctx context.Context, in *CommitTxnRequest, opts ...grpc.CallOption) (*CommitTxnResponse, error)
+	AbortTxn(ctx context.Context, in *AbortTxnRequest, opts ...grpc.CallOption) (*AbortTxnResponse, error)
+	ListPreparedTxns(ctx context.Context, in *ListPreparedTxnsRequest, opts ...grpc.CallOption) (*ListPreparedTxnsResponse, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error)
+	RunGC(ctx context.Context, in *RunGCRequest, opts ...grpc.CallOption) (*RunGCResponse, error)
+	Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*CompactResponse, error)
 }
 
 type dBServerClient struct {
@@ -95,6 +133,214 @@ func (c *dBServerClient) ListKeys(ctx context.Context, in *ListKeysRequest, opts
 	return out, nil
 }
 
+func (c *dBServerClient) UpdateOwnership(ctx context.Context, in *UpdateOwnershipRequest, opts ...grpc.CallOption) (*UpdateOwnershipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateOwnershipResponse)
+	err := c.cc.Invoke(ctx, DBServer_UpdateOwnership_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) TTL(ctx context.Context, in *TTLRequest, opts ...grpc.CallOption) (*TTLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TTLResponse)
+	err := c.cc.Invoke(ctx, DBServer_TTL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) ConditionalSet(ctx context.Context, in *ConditionalSetRequest, opts ...grpc.CallOption) (*ConditionalSetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConditionalSetResponse)
+	err := c.cc.Invoke(ctx, DBServer_ConditionalSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) ConditionalDelete(ctx context.Context, in *ConditionalDeleteRequest, opts ...grpc.CallOption) (*ConditionalDeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConditionalDeleteResponse)
+	err := c.cc.Invoke(ctx, DBServer_ConditionalDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*IncrementResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrementResponse)
+	err := c.cc.Invoke(ctx, DBServer_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) BatchGet(ctx context.Context, in *BatchGetRequest, opts ...grpc.CallOption) (*BatchGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetResponse)
+	err := c.cc.Invoke(ctx, DBServer_BatchGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) BatchSet(ctx context.Context, in *BatchSetRequest, opts ...grpc.CallOption) (*BatchSetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchSetResponse)
+	err := c.cc.Invoke(ctx, DBServer_BatchSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) BatchDelete(ctx context.Context, in *BatchDeleteRequest, opts ...grpc.CallOption) (*BatchDeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchDeleteResponse)
+	err := c.cc.Invoke(ctx, DBServer_BatchDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, DBServer_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) RangeStats(ctx context.Context, in *RangeStatsRequest, opts ...grpc.CallOption) (*RangeStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RangeStatsResponse)
+	err := c.cc.Invoke(ctx, DBServer_RangeStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) ApplyTxn(ctx context.Context, in *ApplyTxnRequest, opts ...grpc.CallOption) (*ApplyTxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApplyTxnResponse)
+	err := c.cc.Invoke(ctx, DBServer_ApplyTxn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) PrepareTxn(ctx context.Context, in *PrepareTxnRequest, opts ...grpc.CallOption) (*PrepareTxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PrepareTxnResponse)
+	err := c.cc.Invoke(ctx, DBServer_PrepareTxn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) CommitTxn(ctx context.Context, in *CommitTxnRequest, opts ...grpc.CallOption) (*CommitTxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitTxnResponse)
+	err := c.cc.Invoke(ctx, DBServer_CommitTxn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) AbortTxn(ctx context.Context, in *AbortTxnRequest, opts ...grpc.CallOption) (*AbortTxnResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AbortTxnResponse)
+	err := c.cc.Invoke(ctx, DBServer_AbortTxn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) ListPreparedTxns(ctx context.Context, in *ListPreparedTxnsRequest, opts ...grpc.CallOption) (*ListPreparedTxnsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPreparedTxnsResponse)
+	err := c.cc.Invoke(ctx, DBServer_ListPreparedTxns_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DBServer_ServiceDesc.Streams[0], DBServer_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DBServer_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *dBServerClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DBServer_ServiceDesc.Streams[1], DBServer_Backup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BackupRequest, BackupChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DBServer_BackupClient = grpc.ServerStreamingClient[BackupChunk]
+
+func (c *dBServerClient) RunGC(ctx context.Context, in *RunGCRequest, opts ...grpc.CallOption) (*RunGCResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunGCResponse)
+	err := c.cc.Invoke(ctx, DBServer_RunGC_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dBServerClient) Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*CompactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompactResponse)
+	err := c.cc.Invoke(ctx, DBServer_Compact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DBServerServer is the server API for DBServer service.
 // All implementations must embed UnimplementedDBServerServer
 // for forward compatibility.
@@ -104,6 +350,25 @@ type DBServerServer interface {
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 	ListKeys(context.Context, *ListKeysRequest) (*ListKeysResponse, error)
+	UpdateOwnership(context.Context, *UpdateOwnershipRequest) (*UpdateOwnershipResponse, error)
+	TTL(context.Context, *TTLRequest) (*TTLResponse, error)
+	ConditionalSet(context.Context, *ConditionalSetRequest) (*ConditionalSetResponse, error)
+	ConditionalDelete(context.Context, *ConditionalDeleteRequest) (*ConditionalDeleteResponse, error)
+	Increment(context.Context, *IncrementRequest) (*IncrementResponse, error)
+	BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error)
+	BatchSet(context.Context, *BatchSetRequest) (*BatchSetResponse, error)
+	BatchDelete(context.Context, *BatchDeleteRequest) (*BatchDeleteResponse, error)
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	RangeStats(context.Context, *RangeStatsRequest) (*RangeStatsResponse, error)
+	ApplyTxn(context.Context, *ApplyTxnRequest) (*ApplyTxnResponse, error)
+	PrepareTxn(context.Context, *PrepareTxnRequest) (*PrepareTxnResponse, error)
+	CommitTxn(context.Context, *CommitTxnRequest) (*CommitTxnResponse, error)
+	AbortTxn(context.Context, *AbortTxnRequest) (*AbortTxnResponse, error)
+	ListPreparedTxns(context.Context, *ListPreparedTxnsRequest) (*ListPreparedTxnsResponse, error)
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	Backup(*BackupRequest, grpc.ServerStreamingServer[BackupChunk]) error
+	RunGC(context.Context, *RunGCRequest) (*RunGCResponse, error)
+	Compact(context.Context, *CompactRequest) (*CompactResponse, error)
 	mustEmbedUnimplementedDBServerServer()
 }
 
@@ -129,6 +394,63 @@ func (UnimplementedDBServerServer) HealthCheck(context.Context, *HealthCheckRequ
 func (UnimplementedDBServerServer) ListKeys(context.Context, *ListKeysRequest) (*ListKeysResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListKeys not implemented")
 }
+func (UnimplementedDBServerServer) UpdateOwnership(context.Context, *UpdateOwnershipRequest) (*UpdateOwnershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateOwnership not implemented")
+}
+func (UnimplementedDBServerServer) TTL(context.Context, *TTLRequest) (*TTLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TTL not implemented")
+}
+func (UnimplementedDBServerServer) ConditionalSet(context.Context, *ConditionalSetRequest) (*ConditionalSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConditionalSet not implemented")
+}
+func (UnimplementedDBServerServer) ConditionalDelete(context.Context, *ConditionalDeleteRequest) (*ConditionalDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConditionalDelete not implemented")
+}
+func (UnimplementedDBServerServer) Increment(context.Context, *IncrementRequest) (*IncrementResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedDBServerServer) BatchGet(context.Context, *BatchGetRequest) (*BatchGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGet not implemented")
+}
+func (UnimplementedDBServerServer) BatchSet(context.Context, *BatchSetRequest) (*BatchSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchSet not implemented")
+}
+func (UnimplementedDBServerServer) BatchDelete(context.Context, *BatchDeleteRequest) (*BatchDeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDelete not implemented")
+}
+func (UnimplementedDBServerServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedDBServerServer) RangeStats(context.Context, *RangeStatsRequest) (*RangeStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RangeStats not implemented")
+}
+func (UnimplementedDBServerServer) ApplyTxn(context.Context, *ApplyTxnRequest) (*ApplyTxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyTxn not implemented")
+}
+func (UnimplementedDBServerServer) PrepareTxn(context.Context, *PrepareTxnRequest) (*PrepareTxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareTxn not implemented")
+}
+func (UnimplementedDBServerServer) CommitTxn(context.Context, *CommitTxnRequest) (*CommitTxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitTxn not implemented")
+}
+func (UnimplementedDBServerServer) AbortTxn(context.Context, *AbortTxnRequest) (*AbortTxnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortTxn not implemented")
+}
+func (UnimplementedDBServerServer) ListPreparedTxns(context.Context, *ListPreparedTxnsRequest) (*ListPreparedTxnsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPreparedTxns not implemented")
+}
+func (UnimplementedDBServerServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDBServerServer) Backup(*BackupRequest, grpc.ServerStreamingServer[BackupChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedDBServerServer) RunGC(context.Context, *RunGCRequest) (*RunGCResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunGC not implemented")
+}
+func (UnimplementedDBServerServer) Compact(context.Context, *CompactRequest) (*CompactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compact not implemented")
+}
 func (UnimplementedDBServerServer) mustEmbedUnimplementedDBServerServer() {}
 func (UnimplementedDBServerServer) testEmbeddedByValue()                  {}
 
@@ -240,6 +562,334 @@ func _DBServer_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DBServer_UpdateOwnership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateOwnershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).UpdateOwnership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_UpdateOwnership_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).UpdateOwnership(ctx, req.(*UpdateOwnershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_TTL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TTLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).TTL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_TTL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).TTL(ctx, req.(*TTLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_ConditionalSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConditionalSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).ConditionalSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_ConditionalSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).ConditionalSet(ctx, req.(*ConditionalSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_ConditionalDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConditionalDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).ConditionalDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_ConditionalDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).ConditionalDelete(ctx, req.(*ConditionalDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_BatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).BatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_BatchGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).BatchGet(ctx, req.(*BatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_BatchSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).BatchSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_BatchSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).BatchSet(ctx, req.(*BatchSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_BatchDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).BatchDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_BatchDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).BatchDelete(ctx, req.(*BatchDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_RangeStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).RangeStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_RangeStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).RangeStats(ctx, req.(*RangeStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_ApplyTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyTxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).ApplyTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_ApplyTxn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).ApplyTxn(ctx, req.(*ApplyTxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_PrepareTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrepareTxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).PrepareTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_PrepareTxn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).PrepareTxn(ctx, req.(*PrepareTxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_CommitTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitTxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).CommitTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_CommitTxn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).CommitTxn(ctx, req.(*CommitTxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_AbortTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortTxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).AbortTxn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_AbortTxn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).AbortTxn(ctx, req.(*AbortTxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_ListPreparedTxns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPreparedTxnsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).ListPreparedTxns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_ListPreparedTxns_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).ListPreparedTxns(ctx, req.(*ListPreparedTxnsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServerServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DBServer_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _DBServer_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DBServerServer).Backup(m, &grpc.GenericServerStream[BackupRequest, BackupChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DBServer_BackupServer = grpc.ServerStreamingServer[BackupChunk]
+
+func _DBServer_RunGC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunGCRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).RunGC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_RunGC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).RunGC(ctx, req.(*RunGCRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DBServer_Compact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DBServerServer).Compact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DBServer_Compact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DBServerServer).Compact(ctx, req.(*CompactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DBServer_ServiceDesc is the grpc.ServiceDesc for DBServer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,7 +917,86 @@ var DBServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListKeys",
 			Handler:    _DBServer_ListKeys_Handler,
 		},
+		{
+			MethodName: "UpdateOwnership",
+			Handler:    _DBServer_UpdateOwnership_Handler,
+		},
+		{
+			MethodName: "TTL",
+			Handler:    _DBServer_TTL_Handler,
+		},
+		{
+			MethodName: "ConditionalSet",
+			Handler:    _DBServer_ConditionalSet_Handler,
+		},
+		{
+			MethodName: "ConditionalDelete",
+			Handler:    _DBServer_ConditionalDelete_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _DBServer_Increment_Handler,
+		},
+		{
+			MethodName: "BatchGet",
+			Handler:    _DBServer_BatchGet_Handler,
+		},
+		{
+			MethodName: "BatchSet",
+			Handler:    _DBServer_BatchSet_Handler,
+		},
+		{
+			MethodName: "BatchDelete",
+			Handler:    _DBServer_BatchDelete_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _DBServer_Scan_Handler,
+		},
+		{
+			MethodName: "RangeStats",
+			Handler:    _DBServer_RangeStats_Handler,
+		},
+		{
+			MethodName: "ApplyTxn",
+			Handler:    _DBServer_ApplyTxn_Handler,
+		},
+		{
+			MethodName: "PrepareTxn",
+			Handler:    _DBServer_PrepareTxn_Handler,
+		},
+		{
+			MethodName: "CommitTxn",
+			Handler:    _DBServer_CommitTxn_Handler,
+		},
+		{
+			MethodName: "AbortTxn",
+			Handler:    _DBServer_AbortTxn_Handler,
+		},
+		{
+			MethodName: "ListPreparedTxns",
+			Handler:    _DBServer_ListPreparedTxns_Handler,
+		},
+		{
+			MethodName: "RunGC",
+			Handler:    _DBServer_RunGC_Handler,
+		},
+		{
+			MethodName: "Compact",
+			Handler:    _DBServer_Compact_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _DBServer_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Backup",
+			Handler:       _DBServer_Backup_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "db_server.proto",
 }
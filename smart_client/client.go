@@ -0,0 +1,368 @@
+package smart_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var errNoRoute = errors.New("no topology available to route the request")
+
+type Options struct {
+	// DialOptions are used for the manager connection and every db_server
+	// connection. Insecure transport credentials are used when empty.
+	DialOptions []grpc.DialOption
+	// DisableFallback stops the client from proxying a request through the
+	// manager when it cannot be served directly.
+	DisableFallback bool
+}
+
+// Client routes key operations straight to the db_server replicas that own
+// them, using a cached copy of the manager's hash ring. The ring is refreshed
+// whenever a server reports that the request was routed on stale topology,
+// and requests fall back to the manager when no replica can be reached.
+type Client struct {
+	opts        Options
+	managerConn *grpc.ClientConn
+	manager     db_manager.DBManagerClient
+
+	mu      sync.RWMutex
+	ring    *ring
+	servers map[string]*serverConn
+}
+
+type serverConn struct {
+	conn   *grpc.ClientConn
+	client db_server.DBServerClient
+}
+
+// New connects to the manager at managerAddr and loads the initial topology.
+// If the topology cannot be loaded and fallback is enabled, the client still
+// works by proxying through the manager until a later Refresh succeeds.
+func New(ctx context.Context, managerAddr string, opts Options) (*Client, error) {
+	if len(opts.DialOptions) == 0 {
+		opts.DialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	// Values are read from the bytes fields only, so the manager need not
+	// send them twice.
+	managerOpts := append(slices.Clip(opts.DialOptions), grpc.WithChainUnaryInterceptor(valueBytesOnly))
+	conn, err := grpc.NewClient(managerAddr, managerOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to manager %s: %w", managerAddr, err)
+	}
+
+	c := &Client{
+		opts:        opts,
+		managerConn: conn,
+		manager:     db_manager.NewDBManagerClient(conn),
+		servers:     make(map[string]*serverConn),
+	}
+
+	if err := c.Refresh(ctx); err != nil && opts.DisableFallback {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Refresh reloads the ring and membership from the manager, dialing new
+// servers and closing connections to servers that left.
+func (c *Client) Refresh(ctx context.Context) error {
+	topology, err := c.manager.Topology(ctx, &db_manager.TopologyRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch topology: %w", err)
+	}
+
+	next := newRing(topology)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ring != nil && next.epoch < c.ring.epoch {
+		return nil
+	}
+
+	live := make(map[string]struct{}, len(topology.Nodes))
+	for _, node := range topology.Nodes {
+		live[node.Addr] = struct{}{}
+		if _, exists := c.servers[node.Addr]; exists {
+			continue
+		}
+
+		conn, err := grpc.NewClient(node.Addr, c.opts.DialOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to connect to server %s: %w", node.Addr, err)
+		}
+		c.servers[node.Addr] = &serverConn{conn: conn, client: db_server.NewDBServerClient(conn)}
+	}
+
+	for addr, server := range c.servers {
+		if _, ok := live[addr]; !ok {
+			server.conn.Close()
+			delete(c.servers, addr)
+		}
+	}
+
+	c.ring = next
+	return nil
+}
+
+// Epoch returns the ring epoch of the cached topology, or zero if none has
+// been loaded.
+func (c *Client) Epoch() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ring == nil {
+		return 0
+	}
+	return c.ring.epoch
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for addr, server := range c.servers {
+		server.conn.Close()
+		delete(c.servers, addr)
+	}
+	return c.managerConn.Close()
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := c.withRefresh(ctx, func() error {
+		replicas, epoch, _ := c.route(key)
+		if len(replicas) == 0 {
+			return errNoRoute
+		}
+
+		var lastErr error
+		for _, server := range replicas {
+			resp, err := server.Get(ctx, &db_server.GetRequest{Key: key, Epoch: epoch})
+			if err == nil {
+				value = responseValue(resp.ValueBytes, resp.Value)
+				return nil
+			}
+			if isStaleTopology(err) {
+				return err
+			}
+			lastErr = err
+		}
+		return lastErr
+	})
+	if err == nil || !c.shouldFallback(err) {
+		return value, err
+	}
+
+	resp, err := c.manager.Get(ctx, &db_manager.GetRequest{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return responseValue(resp.ValueBytes, resp.Value), nil
+}
+
+func (c *Client) Set(ctx context.Context, key string, value []byte) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL writes key so that it expires ttl after the write. The TTL is
+// rounded up to whole seconds; zero means the key never expires.
+func (c *Client) SetWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ttlSeconds := uint64((ttl + time.Second - 1) / time.Second)
+	err := c.withRefresh(ctx, func() error {
+		return c.writeReplicas(key, func(server db_server.DBServerClient, epoch uint64) error {
+			_, err := server.Set(ctx, &db_server.SetRequest{Key: key, ValueBytes: value, Epoch: epoch, TtlSeconds: ttlSeconds})
+			return err
+		})
+	})
+	if err == nil || !c.shouldFallback(err) {
+		return err
+	}
+
+	_, err = c.manager.Set(ctx, &db_manager.SetRequest{Key: key, ValueBytes: value, TtlSeconds: ttlSeconds})
+	return err
+}
+
+// TTL returns the remaining lifetime of key. ok is false for keys that never
+// expire.
+func (c *Client) TTL(ctx context.Context, key string) (ttl time.Duration, ok bool, err error) {
+	var resp *db_server.TTLResponse
+	err = c.withRefresh(ctx, func() error {
+		replicas, epoch, _ := c.route(key)
+		if len(replicas) == 0 {
+			return errNoRoute
+		}
+
+		var lastErr error
+		for _, server := range replicas {
+			r, err := server.TTL(ctx, &db_server.TTLRequest{Key: key, Epoch: epoch})
+			if err == nil {
+				resp = r
+				return nil
+			}
+			if isStaleTopology(err) {
+				return err
+			}
+			lastErr = err
+		}
+		return lastErr
+	})
+	if err == nil {
+		return time.Duration(resp.TtlSeconds) * time.Second, resp.HasTtl, nil
+	}
+	if !c.shouldFallback(err) {
+		return 0, false, err
+	}
+
+	managerResp, err := c.manager.TTL(ctx, &db_manager.TTLRequest{Key: key})
+	if err != nil {
+		return 0, false, err
+	}
+	return time.Duration(managerResp.TtlSeconds) * time.Second, managerResp.HasTtl, nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	err := c.withRefresh(ctx, func() error {
+		return c.writeReplicas(key, func(server db_server.DBServerClient, epoch uint64) error {
+			_, err := server.Delete(ctx, &db_server.DeleteRequest{Key: key, Epoch: epoch})
+			return err
+		})
+	})
+	if err == nil || !c.shouldFallback(err) {
+		return err
+	}
+
+	_, err = c.manager.Delete(ctx, &db_manager.DeleteRequest{Key: key})
+	return err
+}
+
+// route returns clients for the replicas of key on the cached ring, the
+// epoch the lookup was made at, and how many of the replicas must accept a
+// write. Replicas without a connection are left out but still count towards
+// the acks, which are capped at the replicas the key has on the ring.
+func (c *Client) route(key string) ([]db_server.DBServerClient, uint64, int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ring == nil {
+		return nil, 0, 0
+	}
+
+	nodes := c.ring.replicas(key)
+	var clients []db_server.DBServerClient
+	for _, node := range nodes {
+		if server, ok := c.servers[node.Addr]; ok {
+			clients = append(clients, server.client)
+		}
+	}
+	return clients, c.ring.epoch, min(c.ring.writeAcks, len(nodes))
+}
+
+// writeReplicas applies write to every replica of key at once and, like the
+// manager, succeeds once the cluster's configured number of replicas accepted
+// it. A write that cannot reach that many replicas fails with UNAVAILABLE
+// without being sent, and any fencing error is returned so the caller can
+// refresh the ring and retry.
+func (c *Client) writeReplicas(key string, write func(db_server.DBServerClient, uint64) error) error {
+	replicas, epoch, acks := c.route(key)
+	if len(replicas) == 0 {
+		return errNoRoute
+	}
+	if len(replicas) < acks {
+		return status.Errorf(codes.Unavailable, "%d replicas of key %q reachable, %d must accept the write", len(replicas), key, acks)
+	}
+
+	errs := make([]error, len(replicas))
+	var wg sync.WaitGroup
+	for i, server := range replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = write(server, epoch)
+		}()
+	}
+	wg.Wait()
+
+	acked := 0
+	var lastErr error
+	for _, err := range errs {
+		if err == nil {
+			acked++
+			continue
+		}
+		if isStaleTopology(err) {
+			return err
+		}
+		lastErr = err
+	}
+
+	if acked < acks {
+		return fmt.Errorf("write to key %q accepted by %d of %d required replicas: %w", key, acked, acks, lastErr)
+	}
+	return nil
+}
+
+// withRefresh runs op and, if it was rejected for stale topology, reloads the
+// ring and runs it once more.
+func (c *Client) withRefresh(ctx context.Context, op func() error) error {
+	err := op()
+	if !isStaleTopology(err) {
+		return err
+	}
+
+	if rerr := c.Refresh(ctx); rerr != nil {
+		return err
+	}
+	return op()
+}
+
+func (c *Client) shouldFallback(err error) bool {
+	if c.opts.DisableFallback {
+		return false
+	}
+	return errors.Is(err, errNoRoute) || isStaleTopology(err) || status.Code(err) == codes.Unavailable
+}
+
+func isStaleTopology(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		return false
+	}
+
+	for _, detail := range st.Details() {
+		if _, ok := detail.(*db_server.FencingError); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// valueBytesOnly tells the manager that the client reads values from the
+// bytes fields only.
+func valueBytesOnly(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, "x-value-bytes", "1"), method, req, reply, cc, opts...)
+}
+
+// responseValue returns the value in a response, falling back to the
+// deprecated string field for servers that do not fill the bytes field yet.
+func responseValue(b []byte, legacy string) []byte {
+	if len(b) > 0 {
+		return b
+	}
+	return []byte(legacy)
+}
@@ -0,0 +1,226 @@
+package smart_client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+	"github.com/arbhalerao/meerkat/pb/db_server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeManager serves a settable topology and records the writes proxied
+// through it.
+type fakeManager struct {
+	db_manager.UnimplementedDBManagerServer
+
+	mu            sync.Mutex
+	topology      *db_manager.TopologyResponse
+	topologyCalls int
+	data          map[string][]byte
+}
+
+func (m *fakeManager) Topology(ctx context.Context, req *db_manager.TopologyRequest) (*db_manager.TopologyResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.topologyCalls++
+	return m.topology, nil
+}
+
+func (m *fakeManager) Get(ctx context.Context, req *db_manager.GetRequest) (*db_manager.GetResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[req.Key]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "key not found")
+	}
+	return &db_manager.GetResponse{ValueBytes: value}, nil
+}
+
+func (m *fakeManager) Set(ctx context.Context, req *db_manager.SetRequest) (*db_manager.SetResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[req.Key] = req.ValueBytes
+	return &db_manager.SetResponse{}, nil
+}
+
+func (m *fakeManager) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.topologyCalls
+}
+
+func (m *fakeManager) value(key string) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data[key]
+}
+
+func (m *fakeManager) setTopology(topology *db_manager.TopologyResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.topology = topology
+}
+
+// fakeServer fences requests routed at an older epoch than its own and fails
+// every request with err when set.
+type fakeServer struct {
+	db_server.UnimplementedDBServerServer
+
+	mu    sync.Mutex
+	epoch uint64
+	err   error
+	data  map[string][]byte
+}
+
+func (s *fakeServer) check(epoch uint64) error {
+	if s.err != nil {
+		return s.err
+	}
+	if epoch < s.epoch {
+		st, _ := status.New(codes.FailedPrecondition, "stale epoch").WithDetails(&db_server.FencingError{Reason: db_server.FencingReason_STALE_EPOCH, ServerEpoch: s.epoch})
+		return st.Err()
+	}
+	return nil
+}
+
+func (s *fakeServer) Get(ctx context.Context, req *db_server.GetRequest) (*db_server.GetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(req.Epoch); err != nil {
+		return nil, err
+	}
+	value, ok := s.data[req.Key]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "key not found")
+	}
+	return &db_server.GetResponse{ValueBytes: value}, nil
+}
+
+func (s *fakeServer) Set(ctx context.Context, req *db_server.SetRequest) (*db_server.SetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(req.Epoch); err != nil {
+		return nil, err
+	}
+	s.data[req.Key] = req.ValueBytes
+	return &db_server.SetResponse{}, nil
+}
+
+func (s *fakeServer) set(epoch uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch, s.err = epoch, err
+}
+
+func (s *fakeServer) value(key string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key]
+}
+
+func serve(t *testing.T, register func(*grpc.Server)) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// newTestCluster starts a manager and two servers holding every key at
+// epoch, with both replicas required to accept a write.
+func newTestCluster(t *testing.T, epoch uint64) (*fakeManager, []*fakeServer, string) {
+	t.Helper()
+	manager := &fakeManager{data: make(map[string][]byte)}
+	servers := make([]*fakeServer, 2)
+	nodes := make([]*db_manager.Node, 2)
+	for i := range servers {
+		servers[i] = &fakeServer{epoch: epoch, data: make(map[string][]byte)}
+		addr := serve(t, func(srv *grpc.Server) { db_server.RegisterDBServerServer(srv, servers[i]) })
+		nodes[i] = &db_manager.Node{Uuid: addr, Addr: addr, Position: uint32(i) << 31}
+	}
+	manager.topology = &db_manager.TopologyResponse{Epoch: epoch, ReplicationFactor: 2, WriteAcks: 2, Nodes: nodes}
+	addr := serve(t, func(srv *grpc.Server) { db_manager.RegisterDBManagerServer(srv, manager) })
+	return manager, servers, addr
+}
+
+func newTestClient(t *testing.T, addr string, opts Options) *Client {
+	t.Helper()
+	c, err := New(context.Background(), addr, opts)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_RefreshesOnStaleEpoch(t *testing.T) {
+	manager, servers, addr := newTestCluster(t, 1)
+	c := newTestClient(t, addr, Options{DisableFallback: true})
+
+	// The ring moves on after the client loaded it.
+	topology := manager.topology
+	manager.setTopology(&db_manager.TopologyResponse{Epoch: 2, ReplicationFactor: 2, WriteAcks: 2, Nodes: topology.Nodes})
+	for _, server := range servers {
+		server.set(2, nil)
+	}
+
+	if err := c.Set(context.Background(), "key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if c.Epoch() != 2 {
+		t.Fatalf("expected the client to refresh to epoch 2, got %d", c.Epoch())
+	}
+	if calls := manager.calls(); calls != 2 {
+		t.Fatalf("expected one refresh after the initial load, got %d topology calls", calls)
+	}
+	for i, server := range servers {
+		if string(server.value("key")) != "value" {
+			t.Fatalf("expected replica %d to hold the retried write, got %q", i, server.value("key"))
+		}
+	}
+
+	value, err := c.Get(context.Background(), "key")
+	if err != nil || string(value) != "value" {
+		t.Fatalf("expected value, got %q, %v", value, err)
+	}
+}
+
+func TestClient_FallsBackToManager(t *testing.T) {
+	manager, servers, addr := newTestCluster(t, 1)
+	c := newTestClient(t, addr, Options{})
+
+	servers[1].set(1, status.Error(codes.Unavailable, "down"))
+	if err := c.Set(context.Background(), "key", []byte("value")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if string(manager.value("key")) != "value" {
+		t.Fatal("expected a write short of its acks to be proxied through the manager")
+	}
+
+	servers[0].set(1, status.Error(codes.Unavailable, "down"))
+	value, err := c.Get(context.Background(), "key")
+	if err != nil || string(value) != "value" {
+		t.Fatalf("expected the read to fall back to the manager, got %q, %v", value, err)
+	}
+}
+
+func TestClient_WriteHonorsAcks(t *testing.T) {
+	_, servers, addr := newTestCluster(t, 1)
+	c := newTestClient(t, addr, Options{DisableFallback: true})
+
+	servers[1].set(1, status.Error(codes.Unavailable, "down"))
+	err := c.Set(context.Background(), "key", []byte("value"))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected UNAVAILABLE with one of two required replicas down, got %v", err)
+	}
+}
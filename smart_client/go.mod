@@ -0,0 +1,18 @@
+module github.com/arbhalerao/meerkat/smart_client
+
+go 1.23.0
+
+replace github.com/arbhalerao/meerkat/pb => ../pb
+
+require (
+	github.com/arbhalerao/meerkat/pb v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.70.0
+)
+
+require (
+	golang.org/x/net v0.32.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
+	google.golang.org/protobuf v1.36.5 // indirect
+)
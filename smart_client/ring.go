@@ -0,0 +1,72 @@
+package smart_client
+
+import (
+	"hash/crc32"
+	"sort"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+)
+
+// ring is an immutable snapshot of the manager's hash ring. It places keys
+// exactly like the manager's ConsistentHasher: a key belongs to the first node
+// whose position is >= crc32(key), and its replicas are the next distinct
+// nodes clockwise.
+type ring struct {
+	epoch             uint64
+	replicationFactor int
+	writeAcks         int
+	positions         []uint32
+	nodes             map[uint32]*db_manager.Node
+}
+
+func newRing(topology *db_manager.TopologyResponse) *ring {
+	r := &ring{
+		epoch:             topology.Epoch,
+		replicationFactor: int(topology.ReplicationFactor),
+		writeAcks:         max(int(topology.WriteAcks), 1),
+		positions:         make([]uint32, 0, len(topology.Nodes)),
+		nodes:             make(map[uint32]*db_manager.Node, len(topology.Nodes)),
+	}
+
+	// Range-partitioned clusters place keys by split points the client does
+	// not track; an empty ring sends every request through the manager.
+	if topology.Partitioning == db_manager.Partitioning_RANGE {
+		return r
+	}
+
+	for _, node := range topology.Nodes {
+		if _, exists := r.nodes[node.Position]; exists {
+			continue
+		}
+		r.nodes[node.Position] = node
+		r.positions = append(r.positions, node.Position)
+	}
+
+	sort.Slice(r.positions, func(i, j int) bool { return r.positions[i] < r.positions[j] })
+	return r
+}
+
+func (r *ring) replicas(key string) []*db_manager.Node {
+	if len(r.positions) == 0 {
+		return nil
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	idx := sort.Search(len(r.positions), func(i int) bool {
+		return r.positions[i] >= hash
+	})
+	if idx == len(r.positions) {
+		idx = 0
+	}
+
+	count := r.replicationFactor
+	if count > len(r.positions) {
+		count = len(r.positions)
+	}
+
+	nodes := make([]*db_manager.Node, 0, count)
+	for i := 0; i < count; i++ {
+		nodes = append(nodes, r.nodes[r.positions[(idx+i)%len(r.positions)]])
+	}
+	return nodes
+}
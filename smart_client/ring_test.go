@@ -0,0 +1,74 @@
+package smart_client
+
+import (
+	"fmt"
+	"hash/crc32"
+	"testing"
+
+	"github.com/arbhalerao/meerkat/pb/db_manager"
+)
+
+func testTopology() *db_manager.TopologyResponse {
+	return &db_manager.TopologyResponse{
+		Epoch:             7,
+		ReplicationFactor: 2,
+		Nodes: []*db_manager.Node{
+			{Uuid: "c", Addr: "c:1", Position: 3000},
+			{Uuid: "a", Addr: "a:1", Position: 1000},
+			{Uuid: "b", Addr: "b:1", Position: 2000},
+		},
+	}
+}
+
+func TestRing_Empty(t *testing.T) {
+	r := newRing(&db_manager.TopologyResponse{ReplicationFactor: 2})
+	if nodes := r.replicas("key"); len(nodes) != 0 {
+		t.Fatalf("expected no replicas on empty ring, got %d", len(nodes))
+	}
+}
+
+func TestRing_ReplicasFollowPositions(t *testing.T) {
+	r := newRing(testTopology())
+	if r.epoch != 7 {
+		t.Fatalf("expected epoch 7, got %d", r.epoch)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		hash := crc32.ChecksumIEEE([]byte(key))
+
+		var want []string
+		switch {
+		case hash <= 1000 || hash > 3000:
+			want = []string{"a", "b"}
+		case hash <= 2000:
+			want = []string{"b", "c"}
+		default:
+			want = []string{"c", "a"}
+		}
+
+		got := r.replicas(key)
+		if len(got) != 2 || got[0].Uuid != want[0] || got[1].Uuid != want[1] {
+			t.Fatalf("key %q (hash %d): expected %v, got %v", key, hash, want, got)
+		}
+	}
+}
+
+func TestRing_ReplicationFactorCappedAtClusterSize(t *testing.T) {
+	topology := testTopology()
+	topology.ReplicationFactor = 5
+
+	r := newRing(topology)
+	nodes := r.replicas("key")
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 replicas, got %d", len(nodes))
+	}
+
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		if seen[n.Uuid] {
+			t.Fatalf("duplicate replica %s", n.Uuid)
+		}
+		seen[n.Uuid] = true
+	}
+}